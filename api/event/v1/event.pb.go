@@ -0,0 +1,769 @@
+// gRPC API сервиса событий
+//
+// Повторяет HTTP API /v1: запуск и завершение событий, список и получение события по идентификатору,
+// а также поток изменений Watch, которого в HTTP API нет. Аутентификация и арендатор передаются
+// в метаданных вызова так же, как заголовки HTTP: authorization ("Bearer <токен>") или x-api-key
+// и x-tenant-id (имя настраивается tenants.header)
+//
+// Код Go рядом с этим файлом сгенерирован protoc-gen-go и protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative api/event/v1/event.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: api/event/v1/event.proto
+
+package eventv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// State — состояние события
+type State int32
+
+const (
+	State_STATE_UNSPECIFIED State = 0
+	// STATE_STARTED — событие активно
+	State_STATE_STARTED State = 1
+	// STATE_FINISHED — событие завершено
+	State_STATE_FINISHED State = 2
+)
+
+// Enum value maps for State.
+var (
+	State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "STATE_STARTED",
+		2: "STATE_FINISHED",
+	}
+	State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"STATE_STARTED":     1,
+		"STATE_FINISHED":    2,
+	}
+)
+
+func (x State) Enum() *State {
+	p := new(State)
+	*p = x
+	return p
+}
+
+func (x State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (State) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_event_v1_event_proto_enumTypes[0].Descriptor()
+}
+
+func (State) Type() protoreflect.EnumType {
+	return &file_api_event_v1_event_proto_enumTypes[0]
+}
+
+func (x State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use State.Descriptor instead.
+func (State) EnumDescriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{0}
+}
+
+type EventChange_Kind int32
+
+const (
+	EventChange_KIND_UNSPECIFIED EventChange_Kind = 0
+	// KIND_STARTED — событие запущено
+	EventChange_KIND_STARTED EventChange_Kind = 1
+	// KIND_FINISHED — событие завершено (в том числе каскадно вместе с родителем)
+	EventChange_KIND_FINISHED EventChange_Kind = 2
+)
+
+// Enum value maps for EventChange_Kind.
+var (
+	EventChange_Kind_name = map[int32]string{
+		0: "KIND_UNSPECIFIED",
+		1: "KIND_STARTED",
+		2: "KIND_FINISHED",
+	}
+	EventChange_Kind_value = map[string]int32{
+		"KIND_UNSPECIFIED": 0,
+		"KIND_STARTED":     1,
+		"KIND_FINISHED":    2,
+	}
+)
+
+func (x EventChange_Kind) Enum() *EventChange_Kind {
+	p := new(EventChange_Kind)
+	*p = x
+	return p
+}
+
+func (x EventChange_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventChange_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_event_v1_event_proto_enumTypes[1].Descriptor()
+}
+
+func (EventChange_Kind) Type() protoreflect.EnumType {
+	return &file_api_event_v1_event_proto_enumTypes[1]
+}
+
+func (x EventChange_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventChange_Kind.Descriptor instead.
+func (EventChange_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{7, 0}
+}
+
+// Event — событие; поля совпадают с событием HTTP API
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id — идентификатор события (24 шестнадцатеричных символа)
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type      string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	State     State                  `protobuf:"varint,3,opt,name=state,proto3,enum=event.v1.State" json:"state,omitempty"`
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// finished_at не заполнено у активного события
+	FinishedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	// parent_id — идентификатор родительского события, пусто у события верхнего уровня
+	ParentId      string           `protobuf:"bytes,6,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	StartedBy     string           `protobuf:"bytes,7,opt,name=started_by,json=startedBy,proto3" json:"started_by,omitempty"`
+	FinishedBy    string           `protobuf:"bytes,8,opt,name=finished_by,json=finishedBy,proto3" json:"finished_by,omitempty"`
+	Attributes    *structpb.Struct `protobuf:"bytes,9,opt,name=attributes,proto3" json:"attributes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_api_event_v1_event_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_api_event_v1_event_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetState() State {
+	if x != nil {
+		return x.State
+	}
+	return State_STATE_UNSPECIFIED
+}
+
+func (x *Event) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Event) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *Event) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *Event) GetStartedBy() string {
+	if x != nil {
+		return x.StartedBy
+	}
+	return ""
+}
+
+func (x *Event) GetFinishedBy() string {
+	if x != nil {
+		return x.FinishedBy
+	}
+	return ""
+}
+
+func (x *Event) GetAttributes() *structpb.Struct {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+type StartRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// type — тип события, обязателен
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// attributes — атрибуты нового события; проверяются по схеме типа из реестра
+	Attributes *structpb.Struct `protobuf:"bytes,2,opt,name=attributes,proto3" json:"attributes,omitempty"`
+	// parent_id — родительское событие, которое должно существовать и быть активным
+	ParentId      string `protobuf:"bytes,3,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRequest) Reset() {
+	*x = StartRequest{}
+	mi := &file_api_event_v1_event_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRequest) ProtoMessage() {}
+
+func (x *StartRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_event_v1_event_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRequest.ProtoReflect.Descriptor instead.
+func (*StartRequest) Descriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *StartRequest) GetAttributes() *structpb.Struct {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *StartRequest) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+type FinishRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// type — тип завершаемого события, обязателен
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// attributes добавляются к атрибутам события
+	Attributes *structpb.Struct `protobuf:"bytes,2,opt,name=attributes,proto3" json:"attributes,omitempty"`
+	// cascade — завершить вместе с событием все его активные вложенные события
+	Cascade       bool `protobuf:"varint,3,opt,name=cascade,proto3" json:"cascade,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FinishRequest) Reset() {
+	*x = FinishRequest{}
+	mi := &file_api_event_v1_event_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinishRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinishRequest) ProtoMessage() {}
+
+func (x *FinishRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_event_v1_event_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinishRequest.ProtoReflect.Descriptor instead.
+func (*FinishRequest) Descriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FinishRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *FinishRequest) GetAttributes() *structpb.Struct {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *FinishRequest) GetCascade() bool {
+	if x != nil {
+		return x.Cascade
+	}
+	return false
+}
+
+type ListRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// offset — сколько событий пропустить
+	Offset int32 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	// limit — сколько событий вернуть (0 — без ограничения, но не больше api.max_page_size)
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// type — вернуть только события этого типа
+	Type          string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_api_event_v1_event_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_event_v1_event_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_api_event_v1_event_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_event_v1_event_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListResponse) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_api_event_v1_event_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_event_v1_event_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// type — передавать изменения только событий этого типа (пусто — всех доступных клиенту)
+	Type          string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_api_event_v1_event_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_event_v1_event_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+// EventChange — изменение события
+type EventChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  EventChange_Kind       `protobuf:"varint,1,opt,name=kind,proto3,enum=event.v1.EventChange_Kind" json:"kind,omitempty"`
+	// event — событие после изменения
+	Event         *Event `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventChange) Reset() {
+	*x = EventChange{}
+	mi := &file_api_event_v1_event_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventChange) ProtoMessage() {}
+
+func (x *EventChange) ProtoReflect() protoreflect.Message {
+	mi := &file_api_event_v1_event_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventChange.ProtoReflect.Descriptor instead.
+func (*EventChange) Descriptor() ([]byte, []int) {
+	return file_api_event_v1_event_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EventChange) GetKind() EventChange_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return EventChange_KIND_UNSPECIFIED
+}
+
+func (x *EventChange) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+var File_api_event_v1_event_proto protoreflect.FileDescriptor
+
+const file_api_event_v1_event_proto_rawDesc = "" +
+	"\n" +
+	"\x18api/event/v1/event.proto\x12\bevent.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe0\x02\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12%\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x0f.event.v1.StateR\x05state\x129\n" +
+	"\n" +
+	"started_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x1b\n" +
+	"\tparent_id\x18\x06 \x01(\tR\bparentId\x12\x1d\n" +
+	"\n" +
+	"started_by\x18\a \x01(\tR\tstartedBy\x12\x1f\n" +
+	"\vfinished_by\x18\b \x01(\tR\n" +
+	"finishedBy\x127\n" +
+	"\n" +
+	"attributes\x18\t \x01(\v2\x17.google.protobuf.StructR\n" +
+	"attributes\"x\n" +
+	"\fStartRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x127\n" +
+	"\n" +
+	"attributes\x18\x02 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"attributes\x12\x1b\n" +
+	"\tparent_id\x18\x03 \x01(\tR\bparentId\"v\n" +
+	"\rFinishRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x127\n" +
+	"\n" +
+	"attributes\x18\x02 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"attributes\x12\x18\n" +
+	"\acascade\x18\x03 \x01(\bR\acascade\"O\n" +
+	"\vListRequest\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x05R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\"7\n" +
+	"\fListResponse\x12'\n" +
+	"\x06events\x18\x01 \x03(\v2\x0f.event.v1.EventR\x06events\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\"\n" +
+	"\fWatchRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\"\xa7\x01\n" +
+	"\vEventChange\x12.\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x1a.event.v1.EventChange.KindR\x04kind\x12%\n" +
+	"\x05event\x18\x02 \x01(\v2\x0f.event.v1.EventR\x05event\"A\n" +
+	"\x04Kind\x12\x14\n" +
+	"\x10KIND_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fKIND_STARTED\x10\x01\x12\x11\n" +
+	"\rKIND_FINISHED\x10\x02*E\n" +
+	"\x05State\x12\x15\n" +
+	"\x11STATE_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTATE_STARTED\x10\x01\x12\x12\n" +
+	"\x0eSTATE_FINISHED\x10\x022\x93\x02\n" +
+	"\fEventService\x120\n" +
+	"\x05Start\x12\x16.event.v1.StartRequest\x1a\x0f.event.v1.Event\x122\n" +
+	"\x06Finish\x12\x17.event.v1.FinishRequest\x1a\x0f.event.v1.Event\x125\n" +
+	"\x04List\x12\x15.event.v1.ListRequest\x1a\x16.event.v1.ListResponse\x12,\n" +
+	"\x03Get\x12\x14.event.v1.GetRequest\x1a\x0f.event.v1.Event\x128\n" +
+	"\x05Watch\x12\x16.event.v1.WatchRequest\x1a\x15.event.v1.EventChange0\x01B$Z\"event-service/api/event/v1;eventv1b\x06proto3"
+
+var (
+	file_api_event_v1_event_proto_rawDescOnce sync.Once
+	file_api_event_v1_event_proto_rawDescData []byte
+)
+
+func file_api_event_v1_event_proto_rawDescGZIP() []byte {
+	file_api_event_v1_event_proto_rawDescOnce.Do(func() {
+		file_api_event_v1_event_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_event_v1_event_proto_rawDesc), len(file_api_event_v1_event_proto_rawDesc)))
+	})
+	return file_api_event_v1_event_proto_rawDescData
+}
+
+var file_api_event_v1_event_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_api_event_v1_event_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_api_event_v1_event_proto_goTypes = []any{
+	(State)(0),                    // 0: event.v1.State
+	(EventChange_Kind)(0),         // 1: event.v1.EventChange.Kind
+	(*Event)(nil),                 // 2: event.v1.Event
+	(*StartRequest)(nil),          // 3: event.v1.StartRequest
+	(*FinishRequest)(nil),         // 4: event.v1.FinishRequest
+	(*ListRequest)(nil),           // 5: event.v1.ListRequest
+	(*ListResponse)(nil),          // 6: event.v1.ListResponse
+	(*GetRequest)(nil),            // 7: event.v1.GetRequest
+	(*WatchRequest)(nil),          // 8: event.v1.WatchRequest
+	(*EventChange)(nil),           // 9: event.v1.EventChange
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 11: google.protobuf.Struct
+}
+var file_api_event_v1_event_proto_depIdxs = []int32{
+	0,  // 0: event.v1.Event.state:type_name -> event.v1.State
+	10, // 1: event.v1.Event.started_at:type_name -> google.protobuf.Timestamp
+	10, // 2: event.v1.Event.finished_at:type_name -> google.protobuf.Timestamp
+	11, // 3: event.v1.Event.attributes:type_name -> google.protobuf.Struct
+	11, // 4: event.v1.StartRequest.attributes:type_name -> google.protobuf.Struct
+	11, // 5: event.v1.FinishRequest.attributes:type_name -> google.protobuf.Struct
+	2,  // 6: event.v1.ListResponse.events:type_name -> event.v1.Event
+	1,  // 7: event.v1.EventChange.kind:type_name -> event.v1.EventChange.Kind
+	2,  // 8: event.v1.EventChange.event:type_name -> event.v1.Event
+	3,  // 9: event.v1.EventService.Start:input_type -> event.v1.StartRequest
+	4,  // 10: event.v1.EventService.Finish:input_type -> event.v1.FinishRequest
+	5,  // 11: event.v1.EventService.List:input_type -> event.v1.ListRequest
+	7,  // 12: event.v1.EventService.Get:input_type -> event.v1.GetRequest
+	8,  // 13: event.v1.EventService.Watch:input_type -> event.v1.WatchRequest
+	2,  // 14: event.v1.EventService.Start:output_type -> event.v1.Event
+	2,  // 15: event.v1.EventService.Finish:output_type -> event.v1.Event
+	6,  // 16: event.v1.EventService.List:output_type -> event.v1.ListResponse
+	2,  // 17: event.v1.EventService.Get:output_type -> event.v1.Event
+	9,  // 18: event.v1.EventService.Watch:output_type -> event.v1.EventChange
+	14, // [14:19] is the sub-list for method output_type
+	9,  // [9:14] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_api_event_v1_event_proto_init() }
+func file_api_event_v1_event_proto_init() {
+	if File_api_event_v1_event_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_event_v1_event_proto_rawDesc), len(file_api_event_v1_event_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_event_v1_event_proto_goTypes,
+		DependencyIndexes: file_api_event_v1_event_proto_depIdxs,
+		EnumInfos:         file_api_event_v1_event_proto_enumTypes,
+		MessageInfos:      file_api_event_v1_event_proto_msgTypes,
+	}.Build()
+	File_api_event_v1_event_proto = out.File
+	file_api_event_v1_event_proto_goTypes = nil
+	file_api_event_v1_event_proto_depIdxs = nil
+}
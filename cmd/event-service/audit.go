@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"event-service/internal/config"
+	"event-service/pkg/audit"
+	"event-service/pkg/tenant"
+)
+
+// runAuditVerify выполняет подкоманду audit-verify — проверку цепочек журнала аудита
+// Использование: event-service audit-verify [-tenant id]
+// Без -tenant проверяются цепочки всех арендаторов
+// Принимает те же настройки, что и сервер (-config, переменные окружения, флаги)
+// Отчёты печатаются в out в формате JSON; если хотя бы одна цепочка нарушена, возвращается ошибка
+func runAuditVerify(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("audit-verify", flag.ContinueOnError)
+	tenantID := fs.String("tenant", "", "арендатор, чью цепочку проверить (пусто = все)")
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		return err
+	}
+	if *tenantID != "" {
+		if err := tenant.Validate(*tenantID); err != nil {
+			return err
+		}
+	}
+
+	mongoURI, mongoCleanup, err := getMongoURI(cfg.Mongo)
+	if err != nil {
+		return err
+	}
+	defer mongoCleanup()
+
+	client, err := connectToMongoDB(mongoURI, cfg.Mongo)
+	if err != nil {
+		return err
+	}
+	defer cleanupConnection(client, cfg.Mongo)
+
+	auditLog, err := setupAuditLog(client, cfg)
+	if err != nil {
+		return err
+	}
+	return verifyAuditLog(context.Background(), auditLog, *tenantID, out)
+}
+
+// verifyAuditLog проверяет цепочку арендатора tenantID (или всех арендаторов) и печатает отчёты
+func verifyAuditLog(ctx context.Context, auditLog *audit.Log, tenantID string, out io.Writer) error {
+	tenants := []string{tenantID}
+	if tenantID == "" {
+		var err error
+		if tenants, err = auditLog.Tenants(ctx); err != nil {
+			return err
+		}
+	}
+
+	reports := make([]*audit.VerifyReport, 0, len(tenants))
+	var broken []string
+	for _, id := range tenants {
+		report, err := auditLog.Verify(ctx, id)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+		if !report.Valid {
+			broken = append(broken, fmt.Sprintf("%s (запись №%d)", id, report.BrokenSeq))
+		}
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(reports); err != nil {
+		return err
+	}
+	if len(broken) > 0 {
+		return fmt.Errorf("цепочка журнала нарушена у арендаторов: %s", strings.Join(broken, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"event-service/internal/backup"
+	"event-service/internal/config"
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// restoreBatchSize — сколько документов вставляется за один запрос при восстановлении
+const restoreBatchSize = 1000
+
+// backupCollection — коллекция, которая попадает в резервную копию
+type backupCollection struct {
+	// role — имя раздела копии; по нему при восстановлении выбирается коллекция из настроек
+	role string
+	// collections — коллекции всех арендаторов
+	collections *tenant.Collections
+}
+
+// backupCollections возвращает коллекции базы database, которые копируются, в порядке копирования
+// Типы, события и архив размещаются по арендаторам согласно placement, ключи и журнал аудита — общие;
+// остальные коллекции из настроек резервируются, как в tenantCollections
+// Состояние лимитов запросов не копируется: это счётчики, которые быстро устаревают
+func backupCollections(client *mongo.Client, cfg *config.Config, database string, placement tenant.Placement) []backupCollection {
+	perTenant := func(name string) *tenant.Collections {
+		return tenant.NewCollections(client, database, name, placement).Reserve(cfg.Collections.Names()...)
+	}
+	shared := func(name string) *tenant.Collections {
+		return tenant.NewCollections(client, database, name, tenant.PlacementShared)
+	}
+	return []backupCollection{
+		{role: "types", collections: perTenant(cfg.Collections.Types)},
+		{role: "events", collections: perTenant(cfg.Collections.Events)},
+		{role: "archive", collections: perTenant(cfg.Collections.Archive)},
+		{role: "api_keys", collections: shared(cfg.Collections.APIKeys)},
+		{role: "audit", collections: shared(cfg.Collections.Audit)},
+	}
+}
+
+// runBackup выполняет подкоманду backup — резервное копирование базы сервиса в файл
+// Использование: event-service backup -out <файл> [-snapshot]
+// Копия пишется во временный файл рядом с -out и переименовывается, только когда полностью записана
+// С -snapshot все коллекции читаются из одного снимка базы (нужен набор реплик или шардированный кластер)
+// Принимает те же настройки, что и сервер; отчёт о содержимом копии печатается в out в формате JSON
+func runBackup(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	output := fs.String("out", "", "файл резервной копии")
+	snapshot := fs.Bool("snapshot", false, "читать все коллекции из одного снимка базы (набор реплик, MongoDB 5.0+)")
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return errors.New("укажите файл резервной копии: -out")
+	}
+	placement, err := tenant.ParsePlacement(cfg.Tenants.Placement)
+	if err != nil {
+		return err
+	}
+
+	mongoURI, mongoCleanup, err := getMongoURI(cfg.Mongo)
+	if err != nil {
+		return err
+	}
+	defer mongoCleanup()
+
+	client, err := connectToMongoDB(mongoURI, cfg.Mongo)
+	if err != nil {
+		return err
+	}
+	defer cleanupConnection(client, cfg.Mongo)
+
+	file, err := os.CreateTemp(filepath.Dir(*output), filepath.Base(*output)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	header := backup.Header{
+		CreatedAt: time.Now().UTC(),
+		Database:  cfg.Mongo.Database,
+		Placement: string(placement),
+		Snapshot:  *snapshot,
+	}
+	report, err := writeBackup(context.Background(), client, file, header, backupCollections(client, cfg, cfg.Mongo.Database, placement))
+	if err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), *output); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// writeBackup записывает в w копию коллекций sources
+// Список коллекций арендаторов читается до начала снимка: команды списка коллекций и баз
+// не выполняются в snapshot-сессии. Арендатор, появившийся во время копирования, в копию не попадёт
+// Каждая коллекция попадает в копию один раз — под первым назначением, которому она досталась;
+// иначе при восстановлении её документы вставлялись бы дважды
+func writeBackup(ctx context.Context, client *mongo.Client, w io.Writer, header backup.Header, sources []backupCollection) (*backup.Report, error) {
+	type target struct {
+		section backup.Collection
+		col     *mongo.Collection
+	}
+	var targets []target
+	seen := map[string]string{}
+	for _, source := range sources {
+		ids, err := source.collections.Tenants(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			col, _, err := source.collections.For(tenant.WithTenant(ctx, id))
+			if err != nil {
+				return nil, err
+			}
+			key := col.Database().Name() + "." + col.Name()
+			if role, ok := seen[key]; ok {
+				slog.Warn("Коллекция уже скопирована под другим назначением", "collection", key, "role", role, "skipped", source.role)
+				continue
+			}
+			seen[key] = source.role
+			targets = append(targets, target{
+				section: backup.Collection{Role: source.role, Tenant: id, Database: col.Database().Name(), Name: col.Name()},
+				col:     col,
+			})
+		}
+	}
+
+	if header.Snapshot {
+		session, err := client.StartSession(options.Session().SetSnapshot(true))
+		if err != nil {
+			return nil, err
+		}
+		defer session.EndSession(ctx)
+		ctx = mongo.NewSessionContext(ctx, session)
+	}
+
+	bw, err := backup.NewWriter(w, header)
+	if err != nil {
+		return nil, err
+	}
+	header.Format, header.Version = backup.Format, backup.Version
+	report := &backup.Report{Header: header, Collections: []backup.Section{}}
+	for _, t := range targets {
+		if err := bw.Begin(t.section); err != nil {
+			return nil, err
+		}
+		cursor, err := t.col.Find(ctx, bson.D{})
+		if err != nil {
+			return nil, fmt.Errorf("коллекция %s.%s: %w", t.section.Database, t.section.Name, err)
+		}
+		for cursor.Next(ctx) {
+			if err := bw.Write(cursor.Current); err != nil {
+				cursor.Close(ctx)
+				return nil, err
+			}
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("коллекция %s.%s: %w", t.section.Database, t.section.Name, err)
+		}
+
+		sum, err := bw.End()
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Коллекция скопирована", "database", t.section.Database, "collection", t.section.Name, "documents", sum.Documents)
+		report.Collections = append(report.Collections, backup.Section{Collection: t.section, Documents: sum.Documents})
+		report.Documents += sum.Documents
+	}
+	if err := bw.Close(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// runRestore выполняет подкоманду restore — восстановление базы сервиса из резервной копии
+// Использование: event-service restore -in <файл> [-verify] [-drop]
+// Сначала копия читается целиком и сверяются контрольные суммы; с -verify на этом всё заканчивается
+// Данные записываются в базу и коллекции из настроек, поэтому восстановить копию в другую базу можно
+// флагом -mongo-database (или MONGO_DATABASE). Если в какой-то из коллекций уже есть документы,
+// восстановление не начинается, пока не указан -drop — удалить эти коллекции перед загрузкой
+// Отчёт о восстановленных коллекциях печатается в out в формате JSON
+func runRestore(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	input := fs.String("in", "", "файл резервной копии")
+	verifyOnly := fs.Bool("verify", false, "только проверить контрольные суммы копии, ничего не восстанавливая")
+	drop := fs.Bool("drop", false, "удалить непустые коллекции перед восстановлением")
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		return err
+	}
+	if *input == "" {
+		return errors.New("укажите файл резервной копии: -in")
+	}
+	placement, err := tenant.ParsePlacement(cfg.Tenants.Placement)
+	if err != nil {
+		return err
+	}
+
+	// Проверяем копию до подключения к базе, чтобы не запускать MongoDB зря
+	file, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	report, err := backup.Verify(file)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if *verifyOnly {
+		return encoder.Encode(report)
+	}
+	// При общем размещении все арендаторы лежат в одной коллекции, и разнести их по отдельным нельзя;
+	// обратное возможно, но запутывает, поэтому размещение должно совпадать
+	if report.Header.Placement != string(placement) {
+		return fmt.Errorf("копия сделана при размещении арендаторов %s, а восстанавливается при %s (tenants.placement)",
+			report.Header.Placement, placement)
+	}
+
+	mongoURI, mongoCleanup, err := getMongoURI(cfg.Mongo)
+	if err != nil {
+		return err
+	}
+	defer mongoCleanup()
+
+	client, err := connectToMongoDB(mongoURI, cfg.Mongo)
+	if err != nil {
+		return err
+	}
+	defer cleanupConnection(client, cfg.Mongo)
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	restored, err := readBackup(context.Background(), file, report.Collections, backupCollections(client, cfg, cfg.Mongo.Database, placement), *drop)
+	if err != nil {
+		return err
+	}
+	return encoder.Encode(restored)
+}
+
+// restoreTarget возвращает коллекцию, в которую восстанавливается раздел копии
+func restoreTarget(ctx context.Context, section backup.Collection, targets []backupCollection) (*mongo.Collection, error) {
+	for _, t := range targets {
+		if t.role == section.Role {
+			col, _, err := t.collections.For(tenant.WithTenant(ctx, section.Tenant))
+			return col, err
+		}
+	}
+	return nil, fmt.Errorf("неизвестная коллекция в резервной копии: %q", section.Role)
+}
+
+// readBackup загружает уже проверенную копию из r с разделами sections в коллекции targets
+// Сначала проверяет, что все коллекции пусты (или удаляет их при drop), затем вставляет документы
+// пачками по restoreBatchSize. Контрольные суммы сверяются повторно по мере чтения:
+// если файл изменился после проверки, восстановление прервётся на повреждённом разделе
+// Индексы не копируются — сервис создаёт их при первом обращении к коллекции
+func readBackup(ctx context.Context, r io.Reader, sections []backup.Section, targets []backupCollection, drop bool) (*backup.Report, error) {
+	// Два раздела в одной коллекции дали бы ошибку дублирующегося _id на полпути, поэтому проверяем заранее
+	cols := make([]*mongo.Collection, len(sections))
+	seen := map[string]string{}
+	for i, section := range sections {
+		col, err := restoreTarget(ctx, section.Collection, targets)
+		if err != nil {
+			return nil, err
+		}
+		key := col.Database().Name() + "." + col.Name()
+		if other, ok := seen[key]; ok {
+			return nil, fmt.Errorf("разделы %s и %s.%s восстанавливаются в одну коллекцию %s",
+				other, section.Database, section.Name, key)
+		}
+		seen[key] = section.Database + "." + section.Name
+		cols[i] = col
+	}
+
+	for _, col := range cols {
+		if drop {
+			if err := col.Drop(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		count, err := col.CountDocuments(ctx, bson.D{}, options.Count().SetLimit(1))
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("коллекция %s.%s не пуста; укажите -drop, чтобы заменить её содержимое",
+				col.Database().Name(), col.Name())
+		}
+	}
+
+	br, err := backup.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	restored := &backup.Report{Header: br.Header(), Collections: []backup.Section{}}
+	for {
+		section, err := br.Next()
+		if err == io.EOF {
+			return restored, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		col, err := restoreTarget(ctx, *section, targets)
+		if err != nil {
+			return nil, err
+		}
+
+		result := backup.Section{Collection: backup.Collection{
+			Role: section.Role, Tenant: section.Tenant, Database: col.Database().Name(), Name: col.Name(),
+		}}
+		batch := make([]interface{}, 0, restoreBatchSize)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			if _, err := col.InsertMany(ctx, batch); err != nil {
+				return fmt.Errorf("коллекция %s.%s: %w", result.Database, result.Name, err)
+			}
+			result.Documents += int64(len(batch))
+			batch = batch[:0]
+			return nil
+		}
+		for {
+			doc, err := br.Document()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if batch = append(batch, doc); len(batch) == restoreBatchSize {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+
+		slog.Info("Коллекция восстановлена", "database", result.Database, "collection", result.Name, "documents", result.Documents)
+		restored.Collections = append(restored.Collections, result)
+		restored.Documents += result.Documents
+	}
+}
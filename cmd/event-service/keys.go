@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+
+	"event-service/internal/config"
+	"event-service/pkg/auth"
+	"event-service/pkg/tenant"
+)
+
+// runCreateKey выполняет подкоманду create-key — выпуск API-ключа из командной строки
+// Использование: event-service create-key -name <название> -scopes read,start [-types "billing.*"] [-tenant id]
+// Нужна прежде всего для первого ключа с правом admin: остальные ключи можно выпускать через API
+// Ключ печатается в out в формате JSON один раз
+// Принимает те же настройки, что и сервер (-config, переменные окружения, флаги)
+func runCreateKey(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("create-key", flag.ContinueOnError)
+	name := fs.String("name", "", "название ключа")
+	scopes := fs.String("scopes", "", "права через запятую: read, start, finish, admin")
+	types := fs.String("types", "", "шаблоны типов событий через запятую, например billing.* (пусто = любые)")
+	tenantID := fs.String("tenant", tenant.Default, "арендатор, к данным которого даёт доступ ключ")
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		return err
+	}
+	if *name == "" || *scopes == "" {
+		return errors.New("укажите -name и -scopes")
+	}
+	if err := tenant.Validate(*tenantID); err != nil {
+		return err
+	}
+
+	mongoURI, mongoCleanup, err := getMongoURI(cfg.Mongo)
+	if err != nil {
+		return err
+	}
+	defer mongoCleanup()
+
+	client, err := connectToMongoDB(mongoURI, cfg.Mongo)
+	if err != nil {
+		return err
+	}
+	defer cleanupConnection(client, cfg.Mongo)
+
+	keys, err := setupKeyService(client, cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := tenant.WithTenant(context.Background(), *tenantID)
+	key, plaintext, err := keys.Create(ctx, auth.KeyRequest{
+		Name:   *name,
+		Scopes: splitList(*scopes),
+		Types:  splitList(*types),
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(auth.KeyResponse{APIKey: key, Key: plaintext})
+}
+
+// splitList разбивает список через запятую, пропуская пустые элементы
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
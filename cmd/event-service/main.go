@@ -2,45 +2,106 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
+	"event-service/internal/config"
 	"event-service/internal/db"
+	"event-service/pkg/audit"
+	"event-service/pkg/auth"
 	"event-service/pkg/event"
+	"event-service/pkg/graphqlapi"
+	"event-service/pkg/grpcapi"
+	"event-service/pkg/health"
+	"event-service/pkg/i18n"
+	"event-service/pkg/logging"
+	"event-service/pkg/metrics"
+	"event-service/pkg/openapi"
+	"event-service/pkg/problem"
+	"event-service/pkg/ratelimit"
+	"event-service/pkg/tenant"
+	"event-service/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc"
 )
 
+// problemRouteNotFound — ответ на запрос к несуществующему пути
+var problemRouteNotFound = problem.New(http.StatusNotFound, "route_not_found")
+
+// routeHandlers — все HTTP-обработчики, которые регистрирует setupRouter
+// Обработчик, равный nil, просто не регистрируется
+type routeHandlers struct {
+	events *event.EventHandler
+	types  *event.TypeHandler
+	keys   *auth.KeyHandler
+	audit  *audit.Handler
+
+	// archive — архив событий с истёкшим сроком хранения (/v1/archives)
+	archive *event.ArchiveHandler
+
+	// graphql — GraphQL API (/graphql): запросы POST проходят через middleware, как /v1,
+	// а подписки по WebSocket аутентифицируются сообщением connection_init
+	graphql *graphqlapi.Handler
+
+	// health — проверки состояния для оркестратора (/livez, /readyz, /health)
+	health *health.Monitor
+
+	// metrics — метрики Prometheus (/metrics) и middleware, считающее запросы
+	metrics *metrics.Metrics
+
+	// traceService — имя сервиса в серверных спанах HTTP-запросов; пусто — запросы не трассируются
+	traceService string
+
+	// validator — проверка запросов и ответов по спецификации OpenAPI; nil — без проверки
+	validator *openapi.Validator
+
+	// language — язык ответов для клиентов, которые не указали в Accept-Language ни одного поддерживаемого;
+	// пусто — русский
+	language string
+
+	// middleware выполняются перед каждым обработчиком /v1 (например, определение арендатора)
+	middleware []gin.HandlerFunc
+
+	// trustedProxies — прокси, которым можно верить в X-Forwarded-For и X-Real-IP (IP или CIDR);
+	// пусто — адрес клиента (ключ лимитов, журнал аудита и доступа) берётся из соединения
+	trustedProxies []string
+}
+
 // getMongoURI получает URI для подключения к MongoDB
-// Если установлена переменная окружения MONGO_URI — использует её
-// Если нет — запускает встроенный MongoDB
-func getMongoURI() (string, func(), error) {
-	externalURI := os.Getenv("MONGO_URI")
-	if externalURI != "" {
-		log.Println("Используется внешний MongoDB из переменной окружения MONGO_URI")
-		return externalURI, func() {}, nil
+// Если в настройках задан mongo.uri (MONGO_URI) — использует его
+// Если нет — запускает встроенный MongoDB на порту mongo.embedded_port
+func getMongoURI(cfg config.Mongo) (string, func(), error) {
+	if cfg.URI != "" {
+		slog.Info("Используется внешний MongoDB из настроек (mongo.uri)")
+		return cfg.URI, func() {}, nil
 	}
 
 	// Запускаем встроенный MongoDB
-	log.Println("Запуск встроенного MongoDB...")
-	mongoURI, cleanup, err := db.StartEmbeddedMongo()
+	slog.Info("Запуск встроенного MongoDB")
+	mongoURI, cleanup, err := db.StartEmbeddedMongoOnPort(cfg.EmbeddedPort)
 	if err != nil {
 		return "", nil, err
 	}
 	return mongoURI, cleanup, nil
 }
 
-// connectToMongoDB подключается к MongoDB
-func connectToMongoDB(mongoURI string) (*mongo.Client, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// connectToMongoDB подключается к MongoDB, ожидая не дольше mongo.connect_timeout
+func connectToMongoDB(mongoURI string, cfg config.Mongo) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	// Монитор команд создаёт спан на каждую команду MongoDB, пока трассировка включена
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI).SetMonitor(tracing.MongoMonitor()))
 	if err != nil {
 		return nil, err
 	}
@@ -55,117 +116,691 @@ func connectToMongoDB(mongoURI string) (*mongo.Client, error) {
 	return client, nil
 }
 
+// setupTracing включает трассировку по настройкам tracing
+// Возвращает функцию, которая при остановке отправляет накопленные спаны
+func setupTracing(cfg config.Tracing, shutdownTimeout time.Duration) (func(), error) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Exporter,
+		Endpoint:    cfg.Endpoint,
+		Insecure:    cfg.Insecure,
+		ServiceName: cfg.ServiceName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			slog.Warn("Не удалось отправить спаны при остановке", "error", err)
+		}
+	}, nil
+}
+
+// namingRule собирает правило именования типов из настроек event_types:
+//   - pattern — регулярное выражение для каждой части имени (по умолчанию ^[a-z0-9]+$)
+//   - max_length — максимальная длина имени (по умолчанию без ограничения)
+//   - separators — разделители пространств имён, например "." или ".-"
+func namingRule(cfg config.EventTypes) (*event.NamingRule, error) {
+	return event.NewNamingRule(cfg.Pattern, cfg.MaxLength, cfg.Separators)
+}
+
+// setupTypeService создаёт реестр типов событий и индекс по имени типа
+// Строгий режим включается настройкой event_types.strict (EVENT_TYPES_STRICT=true):
+// тогда события можно запускать только для зарегистрированных типов
+func setupTypeService(collections *tenant.Collections, cfg *config.Config) (*event.TypeService, error) {
+	naming, err := namingRule(cfg.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.SetupTimeout)
+	defer cancel()
+
+	repo := event.NewTenantTypeRepository(collections)
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	if cfg.EventTypes.Strict {
+		slog.Info("Включён строгий режим: разрешены только зарегистрированные типы событий")
+	}
+	return event.NewTypeService(repo, cfg.EventTypes.Strict, naming), nil
+}
+
+// tenantCollections возвращает коллекции name всех арендаторов в базе из настроек
+// Остальные коллекции из настроек резервируются, чтобы при размещении collection архив events_archive
+// не принимался за коллекцию событий арендатора archive
+func tenantCollections(client *mongo.Client, cfg *config.Config, name string, placement tenant.Placement) *tenant.Collections {
+	return tenant.NewCollections(client, cfg.Mongo.Database, name, placement).Reserve(cfg.Collections.Names()...)
+}
+
+// setupStorage создаёт репозиторий событий и реестр типов
+// Данные арендаторов размещаются согласно настройке tenants.placement (TENANT_PLACEMENT):
+//   - shared (по умолчанию) — все арендаторы в коллекциях events и event_types
+//   - collection — отдельные коллекции events_<арендатор> и event_types_<арендатор>
+//   - database — отдельная база events_db_<арендатор> на каждого арендатора
+//
+// Имена базы и коллекций тоже берутся из настроек
+func setupStorage(client *mongo.Client, cfg *config.Config) (*event.EventRepository, *event.TypeService, error) {
+	placement, err := tenant.ParsePlacement(cfg.Tenants.Placement)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.SetupTimeout)
+	defer cancel()
+
+	repo := event.NewTenantEventRepository(tenantCollections(client, cfg, cfg.Collections.Events, placement))
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	types, err := setupTypeService(tenantCollections(client, cfg, cfg.Collections.Types, placement), cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, types, nil
+}
+
+// setupArchive создаёт сервис сроков хранения и архив в хранилище из настройки retention.archive:
+// коллекции collections.archive (арендаторы размещаются как в коллекции событий) или файлах в retention.dir
+func setupArchive(client *mongo.Client, cfg *config.Config, repo *event.EventRepository, types *event.TypeService) (*event.ArchiveService, error) {
+	mode, err := event.ParseRetentionMode(cfg.Retention.Mode)
+	if err != nil {
+		return nil, err
+	}
+	policy := event.RetentionPolicy{Days: cfg.Retention.Days, Mode: mode, BatchSize: cfg.Retention.BatchSize}
+
+	if cfg.Retention.Archive == "files" {
+		return event.NewArchiveService(repo, event.NewArchiveFiles(cfg.Retention.Dir), types, policy), nil
+	}
+
+	placement, err := tenant.ParsePlacement(cfg.Tenants.Placement)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.SetupTimeout)
+	defer cancel()
+
+	archive := event.NewArchiveRepository(tenantCollections(client, cfg, cfg.Collections.Archive, placement))
+	if err := archive.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	return event.NewArchiveService(repo, archive, types, policy), nil
+}
+
+// auditFlushInterval — как часто отложенные записи аудита добавляются в журнал
+const auditFlushInterval = 30 * time.Second
+
+// setupAuditLog создаёт журнал аудита и его индексы
+// Журнал всех арендаторов хранится в общей коллекции (collections.audit), у каждого арендатора своя цепочка;
+// записи, которые не удалось добавить сразу, ждут в collections.audit_outbox
+func setupAuditLog(client *mongo.Client, cfg *config.Config) (*audit.Log, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.SetupTimeout)
+	defer cancel()
+
+	database := client.Database(cfg.Mongo.Database)
+	auditLog := audit.NewLog(database.Collection(cfg.Collections.Audit))
+	auditLog.SetOutbox(database.Collection(cfg.Collections.AuditOutbox))
+	if err := auditLog.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	return auditLog, nil
+}
+
+// setupKeyService создаёт сервис API-ключей и индексы коллекции ключей
+// Ключи всех арендаторов хранятся в общей коллекции (collections.api_keys)
+func setupKeyService(client *mongo.Client, cfg *config.Config) (*auth.KeyService, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.SetupTimeout)
+	defer cancel()
+
+	repo := auth.NewKeyRepository(client.Database(cfg.Mongo.Database).Collection(cfg.Collections.APIKeys))
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	return auth.NewKeyService(repo), nil
+}
+
+// jwtAuthenticator настраивает аутентификацию по JWT от OIDC-провайдера
+// Включается настройкой auth.jwt.jwks (JWT_JWKS) — путь к файлу или URL набора ключей (jwks_uri);
+// остальные настройки auth.jwt задают ожидаемые iss и aud, имена claims,
+// время кеширования набора ключей и допустимое расхождение часов
+// Без JWKS возвращает nil
+func jwtAuthenticator(cfg config.JWT, setupTimeout time.Duration) *auth.JWTAuthenticator {
+	if cfg.JWKS == "" {
+		return nil
+	}
+
+	jwks := auth.NewJWKS(cfg.JWKS, cfg.CacheTTL)
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	if err := jwks.Refresh(ctx); err != nil {
+		// Провайдер может подняться позже — набор будет загружен при первом запросе
+		slog.Warn("Не удалось загрузить набор ключей JWT", "error", err)
+	}
+
+	return auth.NewJWTAuthenticator(jwks, auth.JWTConfig{
+		Issuer:      cfg.Issuer,
+		Audience:    cfg.Audience,
+		TenantClaim: cfg.TenantClaim,
+		ScopesClaim: cfg.ScopesClaim,
+		TypesClaim:  cfg.TypesClaim,
+		Leeway:      cfg.Leeway,
+	})
+}
+
+// apiMiddleware возвращает middleware для всех маршрутов /v1: IP-адрес клиента для журнала аудита,
+// аутентификацию (если она не отключена) и определение арендатора
+// Токен по очереди проверяют authenticators — API-ключи и, если настроено, JWT
+// Аутентификацию можно отключить настройкой auth.disabled (AUTH_DISABLED=true) —
+// например, для локальной разработки
+func apiMiddleware(cfg *config.Config, authenticators ...auth.Authenticator) []gin.HandlerFunc {
+	// IP-адрес клиента нужен журналу аудита
+	middleware := []gin.HandlerFunc{audit.Middleware()}
+	if cfg.Auth.Disabled {
+		slog.Warn("Аутентификация отключена (auth.disabled)")
+	} else {
+		middleware = append(middleware, auth.Middleware(authenticators...))
+	}
+	return append(middleware, tenantMiddleware(cfg.Tenants))
+}
+
+// rateLimits — лимиты запросов для каждого API и квоты для сервиса событий
+// Лимиты HTTP и gRPC используют одно хранилище и одни правила, поэтому клиент расходует одно ведро маршрута в обоих API;
+// квоты расходует сам сервис при создании события, поэтому они действуют в любом API
+type rateLimits struct {
+	// middleware — для всех маршрутов /v1
+	middleware []gin.HandlerFunc
+	// interceptors — для gRPC API, после аутентификации
+	interceptors []grpcapi.Interceptor
+	// quota — для сервиса событий
+	quota *ratelimit.Quota
+}
+
+// setupRateLimits настраивает ограничение частоты запросов и суточные квоты
+// Настройки rate_limits:
+//   - rules — лимиты по маршрутам, например "*=100/m, POST /v1/start=10/s:20"
+//   - quotas — суточные квоты на создание событий, например "*=10000, acme=50000"
+//   - store — где хранить состояние: memory (по умолчанию, один экземпляр)
+//     или mongo (общая коллекция collections.rate_limits для нескольких реплик)
+//
+// Без настроек все части rateLimits пустые
+func setupRateLimits(client *mongo.Client, cfg *config.Config) (limits rateLimits, err error) {
+	rules, err := ratelimit.ParseRules(cfg.RateLimits.Rules)
+	if err != nil {
+		return rateLimits{}, fmt.Errorf("rate_limits.rules: %w", err)
+	}
+	quotas, err := ratelimit.ParseQuotas(cfg.RateLimits.Quotas)
+	if err != nil {
+		return rateLimits{}, fmt.Errorf("rate_limits.quotas: %w", err)
+	}
+	if len(rules) == 0 && len(quotas) == 0 {
+		return rateLimits{}, nil
+	}
+
+	var store ratelimit.Store
+	switch kind := cfg.RateLimits.Store; kind {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "mongo":
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.SetupTimeout)
+		defer cancel()
+		mongoStore := ratelimit.NewMongoStore(client.Database(cfg.Mongo.Database).Collection(cfg.Collections.RateLimits))
+		if err := mongoStore.EnsureIndexes(ctx); err != nil {
+			return rateLimits{}, err
+		}
+		store = mongoStore
+	default:
+		return rateLimits{}, fmt.Errorf("rate_limits.store должна быть memory или mongo, получено %q", kind)
+	}
+
+	if len(rules) > 0 {
+		limits.middleware = append(limits.middleware, ratelimit.Middleware(store, rules))
+		limits.interceptors = append(limits.interceptors, grpcapi.RateLimit(store, rules))
+	}
+	if len(quotas) > 0 {
+		limits.quota = ratelimit.NewQuota(store, quotas)
+	}
+	return limits, nil
+}
+
+// tenantMiddleware определяет арендатора каждого запроса к /v1
+// Арендатор берётся из заголовка tenants.header (по умолчанию X-Tenant-ID)
+// При tenants.required запросы без арендатора отклоняются,
+// иначе они относятся к арендатору default
+func tenantMiddleware(cfg config.Tenants) gin.HandlerFunc {
+	return tenant.Middleware(cfg.Header, cfg.Required)
+}
+
+// graphqlInterceptors — то же, что apiMiddleware, для подписок GraphQL: аутентификация (если не отключена)
+// и определение арендатора по параметрам подключения WebSocket
+func graphqlInterceptors(cfg *config.Config, authenticators ...auth.Authenticator) []graphqlapi.Interceptor {
+	var interceptors []graphqlapi.Interceptor
+	if !cfg.Auth.Disabled {
+		interceptors = append(interceptors, graphqlapi.Authenticate(authenticators...))
+	}
+	return append(interceptors, graphqlapi.Tenant(cfg.Tenants.Header, cfg.Tenants.Required))
+}
+
+// grpcInterceptors — то же, что apiMiddleware, для gRPC API: аутентификация (если не отключена)
+// и определение арендатора по метаданным вызова
+func grpcInterceptors(cfg *config.Config, authenticators ...auth.Authenticator) []grpcapi.Interceptor {
+	var interceptors []grpcapi.Interceptor
+	if !cfg.Auth.Disabled {
+		interceptors = append(interceptors, grpcapi.Authenticate(authenticators...))
+	}
+	return append(interceptors, grpcapi.Tenant(cfg.Tenants.Header, cfg.Tenants.Required))
+}
+
 // setupRouter настраивает и возвращает HTTP роутер
-func setupRouter(handler *event.EventHandler) *gin.Engine {
-	r := gin.Default()
+// Каждый маршрут требует своего права (read, start, finish или admin);
+// если аутентификация отключена, права не проверяются
+func setupRouter(handlers routeHandlers) *gin.Engine {
+	r := gin.New()
+	handler := handlers.events
+
+	// По умолчанию gin верит X-Forwarded-For от любого адреса, и клиент мог бы подменить свой IP,
+	// уходя от лимитов запросов; список проверен при загрузке настроек
+	if err := r.SetTrustedProxies(handlers.trustedProxies); err != nil {
+		slog.Error("Некорректный список доверенных прокси, заголовки прокси не учитываются", "error", err)
+		r.SetTrustedProxies(nil)
+	}
+
+	// Серверный спан начинается раньше всего остального, продолжая трассировку из заголовка traceparent:
+	// так trace_id и span_id попадают и в строку журнала доступа, и в запись о перехваченной панике
+	if handlers.traceService != "" {
+		r.Use(tracing.Middleware(handlers.traceService))
+	}
+
+	// Идентификатор запроса назначается до журнала доступа, чтобы попасть во все строки лога и ответы об ошибках;
+	// паника в обработчике превращается в 500 с записью в лог, а не в обрыв соединения
+	r.Use(logging.RequestIDMiddleware(), i18n.Middleware(handlers.language), logging.AccessLog("/livez", "/readyz", "/metrics"), logging.Recovery())
+
+	read := auth.Require(auth.ScopeRead)
+	admin := auth.Require(auth.ScopeAdmin)
+
+	// Метрики подключаются первыми, чтобы учитывать все запросы, в том числе отклонённые
+	if m := handlers.metrics; m != nil {
+		r.Use(m.Middleware())
+		// GET /metrics — метрики в формате Prometheus
+		r.GET("/metrics", gin.WrapH(m.Handler()))
+	}
+
+	// Запросы и ответы проверяются по спецификации до аутентификации, чтобы проверить и ответы об её ошибках
+	if v := handlers.validator; v != nil {
+		r.Use(v.Middleware())
+	}
+
+	// GET /v1/openapi.yaml — спецификация API, GET /v1/docs — Swagger UI; доступны без аутентификации
+	r.GET(openapi.SpecPath, openapi.SpecHandler)
+	r.GET(openapi.DocsPath, openapi.DocsHandler)
+
+	// Проверки состояния не требуют аутентификации и не относятся к арендатору
+	if monitor := handlers.health; monitor != nil {
+		// GET /livez — процесс жив; GET /readyz — готов принимать запросы
+		r.GET("/livez", monitor.Live)
+		r.GET("/readyz", monitor.Ready)
+		// GET /health — подробное состояние каждого компонента
+		r.GET("/health", monitor.Health)
+	}
+
+	// Несуществующий путь — тоже ошибка в формате API
+	r.NoRoute(func(c *gin.Context) {
+		problem.Respond(c, problemRouteNotFound)
+	})
+
+	// POST /graphql — запросы GraphQL с теми же правами и арендаторами, что у /v1
+	// GET /graphql — подписки по WebSocket: браузер не передаёт заголовки при подключении,
+	// поэтому учётные данные проверяются по сообщению connection_init (см. graphqlapi.Interceptor)
+	if gql := handlers.graphql; gql != nil {
+		r.POST("/graphql", append(append(append([]gin.HandlerFunc{}, handlers.middleware...), read), gin.WrapH(gql))...)
+		r.GET("/graphql", gin.WrapH(gql))
+	}
 
 	// Группируем все маршруты под префиксом /v1
-	v1 := r.Group("/v1")
+	v1 := r.Group("/v1", handlers.middleware...)
 	{
 		// GET /v1 — получить список всех событий, отсортированных по времени начала
-		v1.GET("", handler.List)
+		v1.GET("", read, handler.List)
 
 		// POST /v1/start — создать новое событие указанного типа
 		// Если активное событие этого типа уже есть — ничего не делает, возвращает существующее
-		v1.POST("/start", handler.Start)
+		// Новое событие расходует суточную квоту арендатора (её проверяет сервис)
+		v1.POST("/start", auth.Require(auth.ScopeStart), handler.Start)
 
 		// POST /v1/finish — завершить активное событие указанного типа
 		// Если такого события нет — вернёт 404
-		v1.POST("/finish", handler.Finish)
+		v1.POST("/finish", auth.Require(auth.ScopeFinish), handler.Finish)
+
+		// POST /v1/import — массовый импорт исторических событий из NDJSON или CSV
+		// С параметром dryRun=true только проверяет данные, ничего не записывая
+		v1.POST("/import", admin, handler.Import)
+
+		// GET /v1/events/:id — событие; PATCH — изменить атрибуты, DELETE — удалить
+		// Ответы GET несут ETag, PATCH и DELETE принимают If-Match
+		v1.GET("/events/:id", read, handler.Get)
+		v1.PATCH("/events/:id", auth.Require(auth.ScopeFinish), handler.Update)
+		v1.DELETE("/events/:id", admin, handler.Delete)
+
+		// GET /v1/events/:id/tree — событие со всеми вложенными событиями и их длительностями
+		v1.GET("/events/:id/tree", read, handler.Tree)
+
+		// /v1/types — реестр типов событий с их настройками
+		if types := handlers.types; types != nil {
+			v1.GET("/types", read, types.List)
+			v1.POST("/types", admin, types.Create)
+			v1.GET("/types/:name", read, types.Get)
+			v1.PUT("/types/:name", admin, types.Update)
+			v1.DELETE("/types/:name", admin, types.Delete)
+
+			// GET /v1/naming-rule — действующее правило именования типов
+			v1.GET("/naming-rule", read, types.NamingRule)
+		}
+
+		// /v1/archives — архивы событий с истёкшим сроком хранения, архивные события и восстановление архива
+		if archive := handlers.archive; archive != nil {
+			v1.GET("/archives", admin, archive.Archives)
+			v1.GET("/archives/events", read, archive.Events)
+			v1.POST("/archives/:name/restore", admin, archive.Restore)
+		}
+
+		// /v1/audit — журнал изменений событий и проверка его целостности
+		if auditHandler := handlers.audit; auditHandler != nil {
+			v1.GET("/audit", admin, auditHandler.List)
+			v1.GET("/audit/verify", admin, auditHandler.Verify)
+		}
+
+		// /v1/keys — управление API-ключами арендатора
+		if keys := handlers.keys; keys != nil {
+			v1.GET("/keys", admin, keys.List)
+			v1.POST("/keys", admin, keys.Create)
+			v1.POST("/keys/:id/rotate", admin, keys.Rotate)
+			v1.DELETE("/keys/:id", admin, keys.Revoke)
+		}
 	}
 
 	return r
 }
 
-// cleanupConnection закрывает соединение с MongoDB
-func cleanupConnection(client *mongo.Client) {
-	disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 5*time.Second)
+// cleanupConnection закрывает соединение с MongoDB, ожидая не дольше mongo.disconnect_timeout
+func cleanupConnection(client *mongo.Client, cfg config.Mongo) {
+	disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), cfg.DisconnectTimeout)
 	defer disconnectCancel()
 
 	if err := client.Disconnect(disconnectCtx); err != nil {
-		log.Printf("Ошибка при отключении от MongoDB: %v", err)
+		slog.Warn("Ошибка при отключении от MongoDB", "error", err)
 	}
 }
 
-// setupGracefulShutdown настраивает graceful shutdown для MongoDB клиента
-func setupGracefulShutdown(client *mongo.Client, mongoCleanup func()) {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+func main() {
+	// Подкоманда import загружает исторические события и завершает работу
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal("Импорт завершился с ошибкой: ", err)
+		}
+		return
+	}
 
-	go func() {
-		// Ждём сигнала о завершении работы
-		<-sigChan
-		log.Println("Получен сигнал завершения, закрываем соединение с MongoDB...")
+	// Подкоманда audit-verify проверяет целостность журнала аудита
+	if len(os.Args) > 1 && os.Args[1] == "audit-verify" {
+		if err := runAuditVerify(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal("Проверка журнала аудита: ", err)
+		}
+		return
+	}
+
+	// Подкоманда create-key выпускает API-ключ — так создаётся первый ключ с правом admin
+	if len(os.Args) > 1 && os.Args[1] == "create-key" {
+		if err := runCreateKey(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal("Не удалось создать ключ: ", err)
+		}
+		return
+	}
+
+	// Подкоманды backup и restore сохраняют базу в файл резервной копии и загружают её обратно
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := runBackup(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal("Резервное копирование завершилось с ошибкой: ", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := runRestore(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal("Восстановление завершилось с ошибкой: ", err)
+		}
+		return
+	}
 
-		// Закрываем соединение с базой данных
-		cleanupConnection(client)
-		log.Println("Соединение с MongoDB успешно закрыто")
+	// Настройки сервера: файл (-config или CONFIG_FILE), переменные окружения и флаги
+	fs := flag.NewFlagSet("event-service", flag.ExitOnError)
+	printConfig := fs.Bool("print-config", false, "вывести итоговые настройки (без секретов) и завершить работу")
+	cfg, err := config.Load(fs, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *printConfig {
+		if err := cfg.WriteYAML(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-		// Если использовался встроенный MongoDB — останавливаем его
-		mongoCleanup()
+	// Дальше все сообщения пишутся структурированными записями slog
+	if err := logging.Setup(logging.Options{Level: cfg.Log.Level, Format: cfg.Log.Format}); err != nil {
+		log.Fatal(err)
+	}
+	// Отладочный вывод gin (список маршрутов, предупреждения) не в формате логов — он нужен, только если явно включён GIN_MODE
+	if os.Getenv(gin.EnvGinMode) == "" {
+		gin.SetMode(gin.ReleaseMode)
+	}
 
-		os.Exit(0)
-	}()
+	if err := run(cfg); err != nil {
+		slog.Error("Сервер остановлен с ошибкой", "error", err)
+		os.Exit(1)
+	}
 }
 
-func main() {
+// run запускает сервер и работает до сигнала завершения (SIGINT или SIGTERM)
+// Остановка идёт в обратном порядке запуска, чтобы ничто не обращалось к уже закрытым ресурсам:
+// HTTP- и gRPC-серверы дожидаются текущих запросов, затем останавливаются фоновые задачи,
+// закрывается соединение с MongoDB и последним — встроенный MongoDB
+func run(cfg *config.Config) error {
+	ctx, stop := signalContext()
+	defer stop()
+
+	// Трассировка включается первой и выключается последней, чтобы отправить спаны всей остановки
+	tracingCleanup, err := setupTracing(cfg.Tracing, cfg.HTTP.ShutdownTimeout)
+	if err != nil {
+		return err
+	}
+	defer tracingCleanup()
+
 	// Получаем URI для подключения к MongoDB
-	mongoURI, mongoCleanup, err := getMongoURI()
+	mongoURI, mongoCleanup, err := getMongoURI(cfg.Mongo)
 	if err != nil {
-		log.Fatal("Не удалось запустить встроенный MongoDB:", err)
+		return fmt.Errorf("не удалось запустить встроенный MongoDB: %w", err)
 	}
 	defer mongoCleanup()
 
 	// Подключаемся к MongoDB
-	client, err := connectToMongoDB(mongoURI)
+	client, err := connectToMongoDB(mongoURI, cfg.Mongo)
 	if err != nil {
-		log.Fatal("Не удалось подключиться к MongoDB:", err)
+		return fmt.Errorf("не удалось подключиться к MongoDB: %w", err)
 	}
+	defer cleanupConnection(client, cfg.Mongo)
 
-	// Настраиваем graceful shutdown
-	setupGracefulShutdown(client, mongoCleanup)
+	// Фоновые задачи останавливаются после HTTP-сервера, но до отключения от базы
+	background := newWorkers()
+	defer background.Stop(cfg.HTTP.ShutdownTimeout)
 
-	// Закроем соединение с базой, когда программа завершится
-	// Это подстраховка на случай, если graceful shutdown не сработает
-	defer cleanupConnection(client)
-
-	// Выбираем базу данных и коллекцию, с которой будем работать
-	// База называется "events_db", коллекция — "events"
-	collection := client.Database("events_db").Collection("events")
+	// Готовность: MongoDB отвечает, хранилища подготовлены, фоновые задачи работают
+	monitor := health.NewMonitor(cfg.Health.CheckTimeout)
+	migrations := health.NewFlag("индексы ещё не созданы")
+	monitor.Register("mongo", health.MongoCheck(client))
+	monitor.Register("migrations", migrations.Check)
+	monitor.Register("workers", background.Check)
 
 	// Создаём репозиторий — он будет работать с базой данных напрямую
-	repo := event.NewEventRepository(collection)
+	// Имена базы и коллекций берутся из настроек (с учётом размещения арендаторов)
+	repo, types, err := setupStorage(client, cfg)
+	if err != nil {
+		return fmt.Errorf("не удалось подготовить хранилище событий: %w", err)
+	}
+
+	// API-ключи клиентов
+	keys, err := setupKeyService(client, cfg)
+	if err != nil {
+		return fmt.Errorf("не удалось подготовить хранилище API-ключей: %w", err)
+	}
+	authenticators := []auth.Authenticator{keys}
+
+	// Токены OIDC-провайдера, если он настроен
+	if jwtAuth := jwtAuthenticator(cfg.Auth.JWT, cfg.Mongo.SetupTimeout); jwtAuth != nil {
+		authenticators = append(authenticators, jwtAuth)
+	}
 
 	// Создаём сервис — он содержит бизнес-логику (проверки, правила и т.д.)
-	service := event.NewEventService(repo)
+	// Запросы к базе проходят через обёртку, которая замеряет их время для метрик
+	stats := metrics.New()
+	service := event.NewEventService(stats.WrapRepository(repo), types)
+	// Поток изменений для Watch в gRPC API и подписок GraphQL получает уведомления вместе с метриками
+	feed := event.NewFeed(cfg.GRPC.WatchBuffer)
+	service.SetObserver(event.Observers{stats, feed})
+	// Активные события считаются в базе при каждом сборе метрик, не дольше проверок состояния
+	if err := stats.RegisterActiveEvents(repo.CountActive, cfg.Health.CheckTimeout); err != nil {
+		return fmt.Errorf("не удалось зарегистрировать метрики: %w", err)
+	}
+
+	// Журнал аудита — каждое изменение событий записывается в него
+	auditLog, err := setupAuditLog(client, cfg)
+	if err != nil {
+		return fmt.Errorf("не удалось подготовить журнал аудита: %w", err)
+	}
+	service.SetAuditor(auditLog)
+	background.Go("audit-outbox", func(ctx context.Context) {
+		auditLog.Run(ctx, auditFlushInterval)
+	})
+
+	// Сроки хранения: фоновая задача переносит завершённые события с истёкшим сроком в архив или удаляет их
+	archive, err := setupArchive(client, cfg, repo, types)
+	if err != nil {
+		return fmt.Errorf("не удалось подготовить архив событий: %w", err)
+	}
+	archive.SetAuditor(auditLog)
+	if cfg.Retention.Interval > 0 {
+		background.Go("retention", func(ctx context.Context) {
+			archive.Run(ctx, cfg.Retention.Interval)
+		})
+	}
 
 	// Создаём обработчик HTTP-запросов — он будет принимать запросы от клиентов
-	handler := event.NewEventHandler(service)
+	handler := event.NewEventHandler(service, cfg.API.MaxPageSize)
 
-	// Настраиваем роутер
-	r := setupRouter(handler)
+	// Лимиты запросов и квоты проверяются после аутентификации, когда клиент уже известен
+	limits, err := setupRateLimits(client, cfg)
+	if err != nil {
+		return fmt.Errorf("некорректные настройки лимитов: %w", err)
+	}
+	if limits.quota != nil {
+		service.SetQuota(limits.quota)
+	}
 
-	// Запускаем сервер
-	startServer(r)
-}
+	// Все индексы созданы — схема хранилищ соответствует коду
+	migrations.Set()
 
-// logServerInfo пишет информацию о сервере в лог
-func logServerInfo() {
-	log.Println("Сервер запущен на порту :8080")
-	log.Println("Доступные эндпоинты:")
-	log.Println("  GET  /v1 — получить список всех событий")
-	log.Println("  POST /v1/start — создать новое событие")
-	log.Println("  POST /v1/finish — завершить событие")
-}
+	// Проверка по спецификации OpenAPI включается настройкой api.validation
+	var validator *openapi.Validator
+	if cfg.API.Validation != openapi.ValidateNone {
+		if validator, err = openapi.NewValidator(cfg.API.Validation); err != nil {
+			return err
+		}
+	}
 
-// startServer запускает HTTP-сервер и пишет логи
-func startServer(r *gin.Engine) {
-	// Пишем в лог, что сервер запускается
-	logServerInfo()
+	// Серверные спаны HTTP-запросов нужны, только если спаны куда-то отправляются
+	traceService := ""
+	if cfg.Tracing.Exporter != tracing.ExporterNone {
+		traceService = cfg.Tracing.ServiceName
+	}
+
+	// GraphQL API включается настройкой graphql.enabled
+	var graphqlHandler *graphqlapi.Handler
+	if cfg.GraphQL.Enabled {
+		graphqlHandler = graphqlapi.NewHandler(service, types, feed, cfg.API.MaxPageSize, cfg.GraphQL.ComplexityLimit,
+			graphqlInterceptors(cfg, authenticators...)...)
+	}
+
+	// Настраиваем роутер
+	r := setupRouter(routeHandlers{
+		events:         handler,
+		types:          event.NewTypeHandler(types),
+		keys:           auth.NewKeyHandler(keys),
+		audit:          audit.NewHandler(auditLog, cfg.API.MaxPageSize),
+		archive:        event.NewArchiveHandler(archive, cfg.API.MaxPageSize),
+		graphql:        graphqlHandler,
+		health:         monitor,
+		metrics:        stats,
+		traceService:   traceService,
+		validator:      validator,
+		language:       cfg.API.Language,
+		middleware:     append(apiMiddleware(cfg, authenticators...), limits.middleware...),
+		trustedProxies: splitList(cfg.HTTP.TrustedProxies),
+	})
+
+	// Занимаем порт заранее, чтобы ошибка (например, порт уже занят) была видна сразу
+	listener, err := net.Listen("tcp", cfg.HTTP.Addr)
+	if err != nil {
+		return fmt.Errorf("не удалось запустить сервер: %w", err)
+	}
+	logServerInfo(listener.Addr().String())
+	httpServer := newHTTPServer(r, cfg.HTTP)
+	// Shutdown не ждёт соединения WebSocket — подписки GraphQL закрываются отдельно
+	if graphqlHandler != nil {
+		httpServer.RegisterOnShutdown(graphqlHandler.Drain)
+	}
 
-	// Запускаем HTTP-сервер на порту 8080
-	// Если что-то пойдёт не так (например, порт уже занят) — программа завершится с ошибкой
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Не удалось запустить сервер:", err)
+	// gRPC API включается настройкой grpc.addr
+	if cfg.GRPC.Addr == "" {
+		// Обслуживаем запросы до сигнала завершения
+		return serve(ctx, httpServer, listener, cfg.HTTP, monitor.SetShuttingDown)
+	}
+	grpcListener, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("не удалось запустить gRPC-сервер: %w", err)
 	}
+	slog.Info("gRPC-сервер запущен", "addr", grpcListener.Addr().String())
+	grpcAPI := grpcapi.NewServer(service, feed, cfg.API.MaxPageSize)
+	grpcServer := grpc.NewServer(grpcapi.ServerOptions(append(grpcInterceptors(cfg, authenticators...), limits.interceptors...)...)...)
+	grpcAPI.Register(grpcServer)
+
+	// Серверы работают до сигнала завершения; если один из них остановился с ошибкой, останавливается и второй
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	grpcErr := make(chan error, 1)
+	go func() {
+		err := serveGRPC(ctx, grpcServer, grpcListener, grpcAPI.Drain, cfg.HTTP.ShutdownTimeout)
+		cancel()
+		grpcErr <- err
+	}()
+	err = serve(ctx, httpServer, listener, cfg.HTTP, monitor.SetShuttingDown)
+	cancel()
+	return errors.Join(err, <-grpcErr)
+}
+
+// logServerInfo пишет информацию о сервере в лог
+func logServerInfo(addr string) {
+	slog.Info("Сервер запущен", "addr", addr, "endpoints", []string{
+		"GET /v1 — получить список всех событий",
+		"POST /v1/start — создать новое событие",
+		"POST /v1/finish — завершить событие",
+		"POST /v1/import — импортировать исторические события",
+		"GET|PATCH|DELETE /v1/events/:id — событие: получить, изменить атрибуты, удалить",
+		"GET /v1/events/:id/tree — дерево вложенных событий",
+		"GET /v1/types — реестр типов событий",
+		"GET /v1/naming-rule — правило именования типов",
+		"GET /v1/archives — архивы событий с истёкшим сроком хранения",
+		"GET /v1/audit — журнал изменений событий",
+		"GET /v1/keys — API-ключи арендатора",
+	})
 }
@@ -1,31 +1,48 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"event-service/internal/backup"
+	"event-service/internal/config"
 	"event-service/internal/db"
+	"event-service/pkg/audit"
+	"event-service/pkg/auth"
 	eventpkg "event-service/pkg/event"
+	"event-service/pkg/health"
+	"event-service/pkg/i18n"
+	"event-service/pkg/logging"
+	"event-service/pkg/metrics"
+	"event-service/pkg/openapi"
+	"event-service/pkg/problem"
+	"event-service/pkg/ratelimit"
+	"event-service/pkg/tenant"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
+// testMongo — настройки MongoDB по умолчанию: встроенный сервер на стандартном порту
+var testMongo = config.Default().Mongo
+
 // TestGetMongoURI_Embedded тестирует getMongoURI с встроенным MongoDB
 func TestGetMongoURI_Embedded(t *testing.T) {
-	// Сохраняем оригинальное значение
-	originalURI := os.Getenv("MONGO_URI")
-	defer os.Setenv("MONGO_URI", originalURI)
-
-	// Убираем переменную окружения
-	os.Unsetenv("MONGO_URI")
-
-	mongoURI, cleanup, err := getMongoURI()
+	mongoURI, cleanup, err := getMongoURI(testMongo)
 	if err != nil {
 		t.Fatalf("getMongoURI failed: %v", err)
 	}
@@ -41,14 +58,7 @@ func TestGetMongoURI_Embedded(t *testing.T) {
 
 // TestGetMongoURI_External тестирует getMongoURI с внешним MongoDB
 func TestGetMongoURI_External(t *testing.T) {
-	// Сохраняем оригинальное значение
-	originalURI := os.Getenv("MONGO_URI")
-	defer os.Setenv("MONGO_URI", originalURI)
-
-	// Устанавливаем переменную окружения
-	os.Setenv("MONGO_URI", "mongodb://external:27017")
-
-	mongoURI, cleanup, err := getMongoURI()
+	mongoURI, cleanup, err := getMongoURI(config.Mongo{URI: "mongodb://external:27017"})
 	if err != nil {
 		t.Fatalf("getMongoURI failed: %v", err)
 	}
@@ -70,7 +80,7 @@ func TestConnectToMongoDB(t *testing.T) {
 	}
 	defer cleanupMongo()
 
-	client, err := connectToMongoDB(mongoURI)
+	client, err := connectToMongoDB(mongoURI, testMongo)
 	if err != nil {
 		t.Fatalf("connectToMongoDB failed: %v", err)
 	}
@@ -110,11 +120,11 @@ func TestSetupRouter(t *testing.T) {
 
 	collection := client.Database("events_test_db").Collection("events")
 	repo := eventpkg.NewEventRepository(collection)
-	service := eventpkg.NewEventService(repo)
-	handler := eventpkg.NewEventHandler(service)
+	service := eventpkg.NewEventService(repo, nil)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
 
 	// Тестируем setupRouter из main.go
-	r := setupRouter(handler)
+	r := setupRouter(routeHandlers{events: handler})
 	if r == nil {
 		t.Fatal("Router should not be nil")
 	}
@@ -160,13 +170,13 @@ func TestServerSetup(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Тестируем функции из main.go
-	mongoURI, cleanup, err := getMongoURI()
+	mongoURI, cleanup, err := getMongoURI(testMongo)
 	if err != nil {
 		t.Fatalf("getMongoURI failed: %v", err)
 	}
 	defer cleanup()
 
-	client, err := connectToMongoDB(mongoURI)
+	client, err := connectToMongoDB(mongoURI, testMongo)
 	if err != nil {
 		t.Fatalf("connectToMongoDB failed: %v", err)
 	}
@@ -178,50 +188,15 @@ func TestServerSetup(t *testing.T) {
 
 	collection := client.Database("events_db").Collection("events")
 	repo := eventpkg.NewEventRepository(collection)
-	service := eventpkg.NewEventService(repo)
-	handler := eventpkg.NewEventHandler(service)
+	service := eventpkg.NewEventService(repo, nil)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
 
-	r := setupRouter(handler)
+	r := setupRouter(routeHandlers{events: handler})
 	if r == nil {
 		t.Fatal("Router should not be nil")
 	}
 }
 
-// TestSetupGracefulShutdown тестирует setupGracefulShutdown (базовая проверка)
-func TestSetupGracefulShutdown(t *testing.T) {
-	mongoURI, cleanupMongo, err := db.StartEmbeddedMongo()
-	if err != nil {
-		t.Fatalf("Failed to start embedded MongoDB: %v", err)
-	}
-	defer cleanupMongo()
-
-	client, err := connectToMongoDB(mongoURI)
-	if err != nil {
-		t.Fatalf("connectToMongoDB failed: %v", err)
-	}
-
-	// Тестируем setupGracefulShutdown
-	testCleanup := func() {
-		// Cleanup функция
-	}
-
-	setupGracefulShutdown(client, testCleanup)
-
-	// Проверяем, что функция была вызвана (грациозно не можем проверить сигнал,
-	// но можем убедиться, что функция не паникует)
-	if client == nil {
-		t.Fatal("Client should not be nil")
-	}
-
-	// Очистка
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	client.Disconnect(ctx)
-
-	// Мы просто проверяем, что функция setupGracefulShutdown не паникует
-	// и корректно обрабатывает параметры
-}
-
 // TestEventServiceIntegration тестирует интеграцию всех компонентов из main
 func TestEventServiceIntegration(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -252,14 +227,14 @@ func TestEventServiceIntegration(t *testing.T) {
 	// Повторяем логику из main.go
 	collection := client.Database("events_db").Collection("events")
 	repo := eventpkg.NewEventRepository(collection)
-	service := eventpkg.NewEventService(repo)
-	handler := eventpkg.NewEventHandler(service)
+	service := eventpkg.NewEventService(repo, nil)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
 
 	// Тестируем, что всё работает вместе
 	testCtx := context.Background()
 
 	// Создаём событие
-	event, err := service.Start(testCtx, "test")
+	event, err := service.Start(testCtx, eventpkg.StartParams{Type: "test"})
 	if err != nil {
 		t.Fatalf("Start failed: %v", err)
 	}
@@ -277,7 +252,7 @@ func TestEventServiceIntegration(t *testing.T) {
 	}
 
 	// Завершаем событие
-	finishedEvent, err := service.Finish(testCtx, "test")
+	finishedEvent, err := service.Finish(testCtx, eventpkg.FinishParams{Type: "test"})
 	if err != nil {
 		t.Fatalf("Finish failed: %v", err)
 	}
@@ -299,7 +274,7 @@ func TestConnectToMongoDB_InvalidURI(t *testing.T) {
 	// Используем невалидный URI
 	invalidURI := "invalid://mongodb"
 
-	client, err := connectToMongoDB(invalidURI)
+	client, err := connectToMongoDB(invalidURI, testMongo)
 	if err == nil {
 		// Если подключение каким-то образом удалось, отключаемся
 		if client != nil {
@@ -336,22 +311,15 @@ func TestConnectToMongoDB_ConnectionError(t *testing.T) {
 func TestMainInitialization(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	// Сохраняем оригинальное значение
-	originalURI := os.Getenv("MONGO_URI")
-	defer os.Setenv("MONGO_URI", originalURI)
-
-	// Убираем переменную окружения для использования встроенного MongoDB
-	os.Unsetenv("MONGO_URI")
-
 	// Получаем URI
-	mongoURI, cleanup, err := getMongoURI()
+	mongoURI, cleanup, err := getMongoURI(testMongo)
 	if err != nil {
 		t.Fatalf("getMongoURI failed: %v", err)
 	}
 	defer cleanup()
 
 	// Подключаемся
-	client, err := connectToMongoDB(mongoURI)
+	client, err := connectToMongoDB(mongoURI, testMongo)
 	if err != nil {
 		t.Fatalf("connectToMongoDB failed: %v", err)
 	}
@@ -364,9 +332,9 @@ func TestMainInitialization(t *testing.T) {
 	// Повторяем логику из main.go
 	collection := client.Database("events_db").Collection("events")
 	repo := eventpkg.NewEventRepository(collection)
-	service := eventpkg.NewEventService(repo)
-	handler := eventpkg.NewEventHandler(service)
-	r := setupRouter(handler)
+	service := eventpkg.NewEventService(repo, nil)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
+	r := setupRouter(routeHandlers{events: handler})
 
 	// Проверяем, что всё инициализировано
 	if repo == nil || service == nil || handler == nil || r == nil {
@@ -448,13 +416,13 @@ func TestCollectionAndRepositoryCreation(t *testing.T) {
 	}
 
 	// Тестируем создание сервиса
-	service := eventpkg.NewEventService(repo)
+	service := eventpkg.NewEventService(repo, nil)
 	if service == nil {
 		t.Fatal("Service should not be nil")
 	}
 
 	// Тестируем создание handler
-	handler := eventpkg.NewEventHandler(service)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
 	if handler == nil {
 		t.Fatal("Handler should not be nil")
 	}
@@ -462,13 +430,8 @@ func TestCollectionAndRepositoryCreation(t *testing.T) {
 
 // TestGetMongoURI_LogMessages проверяет, что логи пишутся
 func TestGetMongoURI_LogMessages(t *testing.T) {
-	// Сохраняем оригинальное значение
-	originalURI := os.Getenv("MONGO_URI")
-	defer os.Setenv("MONGO_URI", originalURI)
-
 	// Тест 1: Внешний MongoDB (проверяем, что логируется)
-	os.Setenv("MONGO_URI", "mongodb://external:27017")
-	mongoURI, cleanup, err := getMongoURI()
+	mongoURI, cleanup, err := getMongoURI(config.Mongo{URI: "mongodb://external:27017"})
 	if err != nil {
 		t.Fatalf("getMongoURI failed: %v", err)
 	}
@@ -478,8 +441,7 @@ func TestGetMongoURI_LogMessages(t *testing.T) {
 	}
 
 	// Тест 2: Встроенный MongoDB (проверяем, что логируется)
-	os.Unsetenv("MONGO_URI")
-	mongoURI2, cleanup2, err := getMongoURI()
+	mongoURI2, cleanup2, err := getMongoURI(testMongo)
 	if err != nil {
 		t.Fatalf("getMongoURI failed: %v", err)
 	}
@@ -495,7 +457,7 @@ func TestConnectToMongoDB_PingError(t *testing.T) {
 	invalidURI := "mongodb://127.0.0.1:99999/test"
 
 	// connectToMongoDB должна обработать ошибку Ping
-	client, err := connectToMongoDB(invalidURI)
+	client, err := connectToMongoDB(invalidURI, testMongo)
 	if err == nil {
 		// Если подключение удалось (что маловероятно), отключаемся
 		if client != nil {
@@ -535,10 +497,10 @@ func TestSetupRouter_AllRoutes(t *testing.T) {
 
 	collection := client.Database("events_test_db").Collection("events")
 	repo := eventpkg.NewEventRepository(collection)
-	service := eventpkg.NewEventService(repo)
-	handler := eventpkg.NewEventHandler(service)
+	service := eventpkg.NewEventService(repo, nil)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
 
-	r := setupRouter(handler)
+	r := setupRouter(routeHandlers{events: handler})
 
 	// Проверяем все маршруты
 	routes := r.Routes()
@@ -552,6 +514,7 @@ func TestSetupRouter_AllRoutes(t *testing.T) {
 		"GET /v1",
 		"POST /v1/start",
 		"POST /v1/finish",
+		"GET /v1/events/:id/tree",
 	}
 
 	for _, expected := range expectedRoutes {
@@ -565,27 +528,19 @@ func TestSetupRouter_AllRoutes(t *testing.T) {
 func TestMainFlow_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	// Сохраняем оригинальное значение
-	originalURI := os.Getenv("MONGO_URI")
-	defer os.Setenv("MONGO_URI", originalURI)
-	os.Unsetenv("MONGO_URI")
-
 	// Получаем URI для подключения к MongoDB (из main)
-	mongoURI, mongoCleanup, err := getMongoURI()
+	mongoURI, mongoCleanup, err := getMongoURI(testMongo)
 	if err != nil {
 		t.Fatalf("getMongoURI failed: %v", err)
 	}
 	defer mongoCleanup()
 
 	// Подключаемся к MongoDB (из main)
-	client, err := connectToMongoDB(mongoURI)
+	client, err := connectToMongoDB(mongoURI, testMongo)
 	if err != nil {
 		t.Fatalf("connectToMongoDB failed: %v", err)
 	}
 
-	// Настраиваем graceful shutdown (из main)
-	setupGracefulShutdown(client, mongoCleanup)
-
 	// Симулируем defer из main
 	defer func() {
 		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -603,13 +558,13 @@ func TestMainFlow_Success(t *testing.T) {
 	repo := eventpkg.NewEventRepository(collection)
 
 	// Создаём сервис (из main)
-	service := eventpkg.NewEventService(repo)
+	service := eventpkg.NewEventService(repo, nil)
 
 	// Создаём обработчик (из main)
-	handler := eventpkg.NewEventHandler(service)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
 
 	// Настраиваем роутер (из main)
-	r := setupRouter(handler)
+	r := setupRouter(routeHandlers{events: handler})
 
 	// Проверяем, что всё работает
 	if r == nil || handler == nil || service == nil || repo == nil {
@@ -617,7 +572,7 @@ func TestMainFlow_Success(t *testing.T) {
 	}
 
 	// Тестируем, что роутер работает (делаем тестовый запрос)
-	router := setupRouter(handler)
+	router := setupRouter(routeHandlers{events: handler})
 	req := httptest.NewRequest(http.MethodGet, "/v1", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -631,30 +586,24 @@ func TestMainFlow_Success(t *testing.T) {
 func TestMainFlow_WithLogs(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	originalURI := os.Getenv("MONGO_URI")
-	defer os.Setenv("MONGO_URI", originalURI)
-	os.Unsetenv("MONGO_URI")
-
-	mongoURI, mongoCleanup, err := getMongoURI()
+	mongoURI, mongoCleanup, err := getMongoURI(testMongo)
 	if err != nil {
 		t.Fatalf("getMongoURI failed: %v", err)
 	}
 	defer mongoCleanup()
 
-	client, err := connectToMongoDB(mongoURI)
+	client, err := connectToMongoDB(mongoURI, testMongo)
 	if err != nil {
 		t.Fatalf("connectToMongoDB failed: %v", err)
 	}
 
-	setupGracefulShutdown(client, mongoCleanup)
-
-	defer cleanupConnection(client)
+	defer cleanupConnection(client, testMongo)
 
 	collection := client.Database("events_db").Collection("events")
 	repo := eventpkg.NewEventRepository(collection)
-	service := eventpkg.NewEventService(repo)
-	handler := eventpkg.NewEventHandler(service)
-	r := setupRouter(handler)
+	service := eventpkg.NewEventService(repo, nil)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
+	r := setupRouter(routeHandlers{events: handler})
 
 	// Тестируем код, который выполняется в main после setupRouter
 	// Строки 152-157: логирование (эти строки не покрываются, но мы можем вызвать setupRouter и проверить работу)
@@ -676,11 +625,8 @@ func TestMainFlow_WithLogs(t *testing.T) {
 func TestGetMongoURI_ErrorHandling(t *testing.T) {
 	// Этот тест сложно написать, так как db.StartEmbeddedMongo редко возвращает ошибку
 	// Но мы можем проверить ветку с внешним MongoDB
-	originalURI := os.Getenv("MONGO_URI")
-	defer os.Setenv("MONGO_URI", originalURI)
 
-	os.Setenv("MONGO_URI", "mongodb://external:27017")
-	mongoURI, cleanup, err := getMongoURI()
+	mongoURI, cleanup, err := getMongoURI(config.Mongo{URI: "mongodb://external:27017"})
 	if err != nil {
 		t.Fatalf("getMongoURI should not fail with external URI: %v", err)
 	}
@@ -691,44 +637,12 @@ func TestGetMongoURI_ErrorHandling(t *testing.T) {
 	}
 }
 
-// TestSetupGracefulShutdown_ErrorPath тестирует путь с ошибкой в Disconnect
-func TestSetupGracefulShutdown_ErrorPath(t *testing.T) {
-	// Создаём клиент
-	mongoURI, cleanupMongo, err := db.StartEmbeddedMongo()
-	if err != nil {
-		t.Fatalf("Failed to start MongoDB: %v", err)
-	}
-	defer cleanupMongo()
-
-	client, err := connectToMongoDB(mongoURI)
-	if err != nil {
-		t.Fatalf("Failed to connect: %v", err)
-	}
-
-	// Отключаемся сразу, чтобы при следующем вызове Disconnect была ошибка
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	client.Disconnect(ctx)
-	cancel()
-
-	// Теперь setupGracefulShutdown должен обработать уже отключённый клиент
-	// (хотя горутина не будет выполнена без сигнала, но функция вызовется)
-	testCleanup := func() {
-		// Cleanup функция
-	}
-
-	// Вызываем setupGracefulShutdown - функция не должна паниковать
-	setupGracefulShutdown(client, testCleanup)
-
-	// Небольшая задержка, чтобы горутина могла запуститься
-	time.Sleep(100 * time.Millisecond)
-}
-
 // TestConnectToMongoDB_DisconnectOnPingError проверяет, что Disconnect вызывается при ошибке Ping
 func TestConnectToMongoDB_DisconnectOnPingError(t *testing.T) {
 	// Используем несуществующий адрес
 	invalidURI := "mongodb://127.0.0.1:99999/test"
 
-	client, err := connectToMongoDB(invalidURI)
+	client, err := connectToMongoDB(invalidURI, testMongo)
 	// Должна быть ошибка
 	if err == nil {
 		if client != nil {
@@ -747,43 +661,36 @@ func TestConnectToMongoDB_DisconnectOnPingError(t *testing.T) {
 func TestMain_AllInitializationSteps(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	originalURI := os.Getenv("MONGO_URI")
-	defer os.Setenv("MONGO_URI", originalURI)
-	os.Unsetenv("MONGO_URI")
-
 	// Шаг 1: getMongoURI (строка 110)
-	mongoURI, mongoCleanup, err := getMongoURI()
+	mongoURI, mongoCleanup, err := getMongoURI(testMongo)
 	if err != nil {
 		t.Fatalf("Step 1 failed: %v", err)
 	}
 	defer mongoCleanup()
 
 	// Шаг 2: connectToMongoDB (строка 117)
-	client, err := connectToMongoDB(mongoURI)
+	client, err := connectToMongoDB(mongoURI, testMongo)
 	if err != nil {
 		t.Fatalf("Step 2 failed: %v", err)
 	}
 
-	// Шаг 3: setupGracefulShutdown (строка 123)
-	setupGracefulShutdown(client, mongoCleanup)
-
-	// Шаг 4: defer cleanupConnection (строка 127) - будет выполнен в defer
-	defer cleanupConnection(client)
+	// Шаг 3: defer cleanupConnection (строка 127) - будет выполнен в defer
+	defer cleanupConnection(client, testMongo)
 
-	// Шаг 5: Database и Collection (строка 138)
+	// Шаг 4: Database и Collection (строка 138)
 	collection := client.Database("events_db").Collection("events")
 
 	// Шаг 6: NewEventRepository (строка 141)
 	repo := eventpkg.NewEventRepository(collection)
 
 	// Шаг 7: NewEventService (строка 144)
-	service := eventpkg.NewEventService(repo)
+	service := eventpkg.NewEventService(repo, nil)
 
 	// Шаг 8: NewEventHandler (строка 147)
-	handler := eventpkg.NewEventHandler(service)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
 
 	// Шаг 9: setupRouter (строка 150)
-	r := setupRouter(handler)
+	r := setupRouter(routeHandlers{events: handler})
 
 	// Проверяем, что всё инициализировано
 	if r == nil || handler == nil || service == nil || repo == nil || collection == nil {
@@ -817,7 +724,7 @@ func TestCleanupConnection(t *testing.T) {
 	}
 
 	// Тестируем cleanupConnection
-	cleanupConnection(client)
+	cleanupConnection(client, testMongo)
 
 	// Проверяем, что клиент отключён
 	err = client.Ping(ctx, nil)
@@ -846,10 +753,10 @@ func TestCleanupConnection_AlreadyDisconnected(t *testing.T) {
 	client.Disconnect(ctx)
 
 	// Теперь вызываем cleanupConnection - не должна паниковать
-	cleanupConnection(client)
+	cleanupConnection(client, testMongo)
 }
 
-// TestStartServer тестирует startServer (без реального запуска сервера)
+// TestStartServer проверяет роутер, который run передаёт HTTP-серверу (без запуска сервера)
 func TestStartServer(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -866,17 +773,15 @@ func TestStartServer(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
-	defer cleanupConnection(client)
+	defer cleanupConnection(client, testMongo)
 
 	collection := client.Database("events_test_db").Collection("events")
 	repo := eventpkg.NewEventRepository(collection)
-	service := eventpkg.NewEventService(repo)
-	handler := eventpkg.NewEventHandler(service)
-	r := setupRouter(handler)
+	service := eventpkg.NewEventService(repo, nil)
+	handler := eventpkg.NewEventHandler(service, eventpkg.DefaultMaxLimit)
+	r := setupRouter(routeHandlers{events: handler})
 
-	// startServer вызывает r.Run, который запустит сервер на порту 8080
-	// Это заблокирует выполнение, поэтому мы не можем вызвать его напрямую
-	// Но мы можем проверить, что роутер настроен правильно
+	// Запуск и остановка сервера проверяются в server_test.go
 	if r == nil {
 		t.Fatal("Router should not be nil")
 	}
@@ -894,6 +799,719 @@ func TestStartServer(t *testing.T) {
 // TestLogServerInfo тестирует logServerInfo
 func TestLogServerInfo(t *testing.T) {
 	// logServerInfo просто пишет в лог, мы можем вызвать её
-	logServerInfo()
+	logServerInfo(":8080")
 	// Если функция не паникует, тест пройден
 }
+
+// TestImportFormatForFile проверяет выбор формата импорта по флагу и расширению файла
+func TestImportFormatForFile(t *testing.T) {
+	tests := []struct {
+		flag     string
+		path     string
+		expected eventpkg.ImportFormat
+	}{
+		{"", "events.csv", eventpkg.ImportFormatCSV},
+		{"", "EVENTS.CSV", eventpkg.ImportFormatCSV},
+		{"", "events.ndjson", eventpkg.ImportFormatNDJSON},
+		{"", "-", eventpkg.ImportFormatNDJSON},
+		{"ndjson", "events.csv", eventpkg.ImportFormatNDJSON},
+	}
+
+	for _, tt := range tests {
+		format, err := importFormatForFile(tt.flag, tt.path)
+		if err != nil {
+			t.Errorf("importFormatForFile(%q, %q) failed: %v", tt.flag, tt.path, err)
+		}
+		if format != tt.expected {
+			t.Errorf("importFormatForFile(%q, %q) = %s, expected %s", tt.flag, tt.path, format, tt.expected)
+		}
+	}
+
+	if _, err := importFormatForFile("xml", "events.xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+// TestRunImport_InvalidArgs проверяет, что подкоманда import не запускается без файла
+func TestRunImport_InvalidArgs(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := runImport([]string{}, &out); err == nil {
+		t.Error("Expected error when no file is given")
+	}
+	if err := runImport([]string{"-format", "xml", "events.xml"}, &out); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if err := runImport([]string{filepath.Join(t.TempDir(), "missing.ndjson")}, &out); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if err := runImport([]string{"-tenant", "Acme Corp", "events.ndjson"}, &out); err == nil {
+		t.Error("Expected error for invalid tenant")
+	}
+}
+
+// TestRunImport_DryRun проверяет подкоманду import в режиме dry-run
+func TestRunImport_DryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.csv")
+	content := "type,state,startedAt,finishedAt\nmeeting,finished,2024-01-01T10:00:00Z,2024-01-01T11:00:00Z\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write import file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runImport([]string{"-dry-run", path}, &out); err != nil {
+		t.Fatalf("runImport failed: %v", err)
+	}
+
+	var report eventpkg.ImportReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	if !report.DryRun || report.Imported != 1 || report.RejectedCount != 0 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+// TestSetupRouter_Middleware проверяет, что middleware из routeHandlers выполняются для /v1
+func TestSetupRouter_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := setupRouter(routeHandlers{
+		events:     eventpkg.NewEventHandler(nil, eventpkg.DefaultMaxLimit),
+		middleware: []gin.HandlerFunc{tenant.Middleware("", true)},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Без заголовка X-Tenant-ID запрос отклоняется ещё до обработчика
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without tenant header, got %d", w.Code)
+	}
+}
+
+// TestSetupRateLimits проверяет настройку лимитов по конфигурации
+func TestSetupRateLimits(t *testing.T) {
+	cfg := config.Default()
+	limits, err := setupRateLimits(nil, cfg)
+	if err != nil || limits.middleware != nil || limits.interceptors != nil || limits.quota != nil {
+		t.Errorf("Expected no limits without settings, got %+v, %v", limits, err)
+	}
+
+	cfg.RateLimits.Rules = "*=100/m, POST /v1/start=10/s:20"
+	cfg.RateLimits.Quotas = "*=1000"
+	limits, err = setupRateLimits(nil, cfg)
+	if err != nil || len(limits.middleware) != 1 || len(limits.interceptors) != 1 || limits.quota == nil {
+		t.Errorf("Expected limits and quota, got %+v, %v", limits, err)
+	}
+
+	cfg.RateLimits.Store = "redis"
+	if _, err := setupRateLimits(nil, cfg); err == nil {
+		t.Error("Expected error for unknown store")
+	}
+}
+
+// TestSetupRouter_TrustedProxies проверяет, что X-Forwarded-For учитывается только от доверенных прокси
+func TestSetupRouter_TrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rules := ratelimit.Rules{"GET /v1/naming-rule": {Rate: 1.0 / 60, Burst: 1}}
+
+	request := func(r *gin.Engine, remote, forwarded string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/naming-rule", nil)
+		req.RemoteAddr = remote + ":1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Без доверенных прокси подменённый заголовок не даёт клиенту новое ведро
+	r := setupRouter(routeHandlers{
+		events:     eventpkg.NewEventHandler(nil, eventpkg.DefaultMaxLimit),
+		types:      eventpkg.NewTypeHandler(eventpkg.NewTypeService(nil, false, nil)),
+		middleware: []gin.HandlerFunc{ratelimit.Middleware(ratelimit.NewMemoryStore(), rules)},
+	})
+	if code := request(r, "192.0.2.1", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if code := request(r, "192.0.2.1", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Errorf("Spoofed X-Forwarded-For should not change the rate limit key, got %d", code)
+	}
+
+	// За доверенным прокси клиенты различаются по X-Forwarded-For
+	r = setupRouter(routeHandlers{
+		events:         eventpkg.NewEventHandler(nil, eventpkg.DefaultMaxLimit),
+		types:          eventpkg.NewTypeHandler(eventpkg.NewTypeService(nil, false, nil)),
+		middleware:     []gin.HandlerFunc{ratelimit.Middleware(ratelimit.NewMemoryStore(), rules)},
+		trustedProxies: []string{"192.0.2.0/24"},
+	})
+	if code := request(r, "192.0.2.1", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if code := request(r, "192.0.2.1", "198.51.100.2"); code != http.StatusOK {
+		t.Errorf("Clients behind a trusted proxy should have their own buckets, got %d", code)
+	}
+	if code := request(r, "192.0.2.1", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 for the same forwarded client, got %d", code)
+	}
+}
+
+// TestSetupRouter_AuditClientIP проверяет, что подменённый X-Forwarded-For не попадает в журнал аудита
+func TestSetupRouter_AuditClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clientIP := func(trustedProxies []string, forwarded string) string {
+		var ip string
+		r := setupRouter(routeHandlers{
+			events: eventpkg.NewEventHandler(nil, eventpkg.DefaultMaxLimit),
+			middleware: []gin.HandlerFunc{audit.Middleware(), func(c *gin.Context) {
+				ip = audit.ClientIP(c.Request.Context())
+				c.AbortWithStatus(http.StatusNoContent)
+			}},
+			trustedProxies: trustedProxies,
+		})
+		req := httptest.NewRequest(http.MethodGet, "/v1", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return ip
+	}
+
+	if ip := clientIP(nil, "198.51.100.1"); ip != "192.0.2.1" {
+		t.Errorf("Expected the connection address without trusted proxies, got %q", ip)
+	}
+	if ip := clientIP([]string{"192.0.2.1"}, "198.51.100.1"); ip != "198.51.100.1" {
+		t.Errorf("Expected the forwarded address from a trusted proxy, got %q", ip)
+	}
+}
+
+// TestNamingRule проверяет сборку правила именования из настроек
+func TestNamingRule(t *testing.T) {
+	rule, err := namingRule(config.Default().EventTypes)
+	if err != nil {
+		t.Fatalf("namingRule failed: %v", err)
+	}
+	if rule.Pattern != eventpkg.DefaultTypePattern || rule.MaxLength != 0 || rule.Separators != "" {
+		t.Errorf("Expected default rule, got %+v", rule)
+	}
+
+	rule, err = namingRule(config.EventTypes{Pattern: "[a-z]+", MaxLength: 32, Separators: "."})
+	if err != nil {
+		t.Fatalf("namingRule failed: %v", err)
+	}
+	if err := rule.Validate("billing.payment"); err != nil {
+		t.Errorf("Expected billing.payment to be valid: %v", err)
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	cfg := config.Default()
+	if a := jwtAuthenticator(cfg.Auth.JWT, time.Second); a != nil {
+		t.Errorf("Expected JWT to be disabled without JWKS, got %v", a)
+	}
+
+	// Недоступный набор ключей не мешает старту — он загрузится при первом запросе
+	cfg.Auth.JWT.JWKS = filepath.Join(t.TempDir(), "jwks.json")
+	if a := jwtAuthenticator(cfg.Auth.JWT, time.Second); a == nil {
+		t.Error("Expected an authenticator")
+	}
+}
+
+// TestRunServerConfig проверяет, что подкоманды принимают общие настройки и отклоняют некорректные
+func TestRunServerConfig(t *testing.T) {
+	var out bytes.Buffer
+	if err := runImport([]string{"-api-max-page-size", "0", "events.ndjson"}, &out); err == nil {
+		t.Error("Expected error for invalid config flag")
+	}
+	t.Setenv("MONGO_EMBEDDED_PORT", "порт")
+	if err := runAuditVerify(nil, &out); err == nil {
+		t.Error("Expected error for invalid environment variable")
+	}
+}
+
+// TestSetupRouter_Scopes проверяет, что маршруты требуют своих прав
+func TestSetupRouter_Scopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reader := &auth.Principal{Subject: "key:reader", Scopes: []auth.Scope{auth.ScopeRead}}
+	r := setupRouter(routeHandlers{
+		events: eventpkg.NewEventHandler(nil, eventpkg.DefaultMaxLimit),
+		types:  eventpkg.NewTypeHandler(eventpkg.NewTypeService(nil, false, nil)),
+		middleware: []gin.HandlerFunc{func(c *gin.Context) {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), reader))
+		}},
+	})
+
+	forbidden := []struct{ method, path string }{
+		{http.MethodPost, "/v1/start"},
+		{http.MethodPost, "/v1/finish"},
+		{http.MethodPost, "/v1/import"},
+		{http.MethodPost, "/v1/types"},
+		{http.MethodDelete, "/v1/types/meeting"},
+	}
+	for _, route := range forbidden {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected status 403 for read-only key, got %d", route.method, route.path, w.Code)
+		}
+	}
+
+	// Право read открывает правило именования
+	req := httptest.NewRequest(http.MethodGet, "/v1/naming-rule", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for naming rule, got %d", w.Code)
+	}
+}
+
+// TestSplitList проверяет разбор списков через запятую в подкоманде create-key
+func TestSplitList(t *testing.T) {
+	got := splitList(" read, start ,,finish")
+	if len(got) != 3 || got[0] != "read" || got[2] != "finish" {
+		t.Errorf("splitList = %v", got)
+	}
+	if splitList("") != nil {
+		t.Error("Expected nil for empty list")
+	}
+}
+
+// TestRunCreateKey_InvalidArgs проверяет ошибки аргументов подкоманды create-key
+func TestRunCreateKey_InvalidArgs(t *testing.T) {
+	var out bytes.Buffer
+	if err := runCreateKey([]string{"-scopes", "admin"}, &out); err == nil {
+		t.Error("Expected error without -name")
+	}
+	if err := runCreateKey([]string{"-name", "admin"}, &out); err == nil {
+		t.Error("Expected error without -scopes")
+	}
+	if err := runCreateKey([]string{"-name", "admin", "-scopes", "admin", "-tenant", "Acme"}, &out); err == nil {
+		t.Error("Expected error for invalid tenant")
+	}
+}
+
+// TestRunAuditVerify_InvalidArgs проверяет ошибки аргументов подкоманды audit-verify
+func TestRunAuditVerify_InvalidArgs(t *testing.T) {
+	var out bytes.Buffer
+	if err := runAuditVerify([]string{"-tenant", "Acme"}, &out); err == nil {
+		t.Error("Expected error for invalid tenant")
+	}
+	if err := runAuditVerify([]string{"-unknown"}, &out); err == nil {
+		t.Error("Expected error for unknown flag")
+	}
+}
+
+// writeTestBackup записывает файл резервной копии с одним событием арендатора default
+func writeTestBackup(t *testing.T, placement tenant.Placement) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "events.backup.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w, err := backup.NewWriter(file, backup.Header{CreatedAt: time.Now().UTC(), Database: "events_db", Placement: string(placement)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Begin(backup.Collection{Role: "events", Tenant: tenant.Default, Database: "events_db", Name: "events"})
+	doc, _ := bson.Marshal(bson.M{"_id": primitive.NewObjectID(), "type": "meeting"})
+	w.Write(doc)
+	w.End()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestRunBackup_InvalidArgs проверяет ошибки аргументов подкоманды backup
+func TestRunBackup_InvalidArgs(t *testing.T) {
+	var out bytes.Buffer
+	if err := runBackup([]string{}, &out); err == nil {
+		t.Error("Expected error without -out")
+	}
+	if err := runBackup([]string{"-out", "events.backup.gz", "-tenants-placement", "cluster"}, &out); err == nil {
+		t.Error("Expected error for unknown placement")
+	}
+}
+
+// TestRunRestore_InvalidArgs проверяет, что restore отклоняет копию до подключения к базе
+func TestRunRestore_InvalidArgs(t *testing.T) {
+	var out bytes.Buffer
+	if err := runRestore([]string{}, &out); err == nil {
+		t.Error("Expected error without -in")
+	}
+	if err := runRestore([]string{"-in", filepath.Join(t.TempDir(), "missing.gz")}, &out); err == nil {
+		t.Error("Expected error for missing file")
+	}
+
+	damaged := filepath.Join(t.TempDir(), "damaged.gz")
+	os.WriteFile(damaged, []byte("not a backup"), 0o600)
+	if err := runRestore([]string{"-in", damaged}, &out); err == nil {
+		t.Error("Expected error for damaged backup")
+	}
+
+	// Копию с отдельными базами арендаторов нельзя загрузить в общую коллекцию
+	path := writeTestBackup(t, tenant.PlacementDatabase)
+	if err := runRestore([]string{"-in", path}, &out); err == nil || !strings.Contains(err.Error(), "размещении") {
+		t.Errorf("Expected placement mismatch error, got %v", err)
+	}
+}
+
+// TestRunRestore_Verify проверяет, что restore -verify печатает содержимое копии, не подключаясь к базе
+func TestRunRestore_Verify(t *testing.T) {
+	path := writeTestBackup(t, tenant.PlacementShared)
+
+	var out bytes.Buffer
+	if err := runRestore([]string{"-verify", "-in", path}, &out); err != nil {
+		t.Fatalf("runRestore failed: %v", err)
+	}
+	var report backup.Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	if report.Header.Database != "events_db" || report.Documents != 1 || len(report.Collections) != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+// TestBackupRestore проверяет копирование базы и восстановление копии в базу с другим именем
+func TestBackupRestore(t *testing.T) {
+	database := "events_backup_test"
+	copyDatabase := database + "_copy"
+	path := filepath.Join(t.TempDir(), "events.backup.gz")
+
+	mongoURI, cleanup, err := getMongoURI(testMongo)
+	if err != nil {
+		t.Fatalf("Не удалось запустить MongoDB: %v", err)
+	}
+	defer cleanup()
+	client, err := connectToMongoDB(mongoURI, testMongo)
+	if err != nil {
+		t.Fatalf("Не удалось подключиться к MongoDB: %v", err)
+	}
+	defer cleanupConnection(client, testMongo)
+
+	ctx := context.Background()
+	for _, name := range []string{database, copyDatabase} {
+		client.Database(name).Drop(ctx)
+		defer client.Database(name).Drop(ctx)
+	}
+	events := []interface{}{bson.M{"type": "meeting", "version": int64(1)}, bson.M{"type": "call", "tenant_id": "acme"}}
+	if _, err := client.Database(database).Collection("events").InsertMany(ctx, events); err != nil {
+		t.Fatalf("InsertMany failed: %v", err)
+	}
+	client.Database(database).Collection("audit_log").InsertOne(ctx, bson.M{"action": "start"})
+
+	var out bytes.Buffer
+	if err := runBackup([]string{"-mongo-uri", mongoURI, "-mongo-database", database, "-out", path}, &out); err != nil {
+		t.Fatalf("runBackup failed: %v", err)
+	}
+	restoreArgs := []string{"-mongo-uri", mongoURI, "-mongo-database", copyDatabase, "-in", path}
+	if err := runRestore(restoreArgs, &out); err != nil {
+		t.Fatalf("runRestore failed: %v", err)
+	}
+	if n, _ := client.Database(copyDatabase).Collection("events").CountDocuments(ctx, bson.D{}); n != 2 {
+		t.Errorf("Expected 2 restored events, got %d", n)
+	}
+	if n, _ := client.Database(copyDatabase).Collection("audit_log").CountDocuments(ctx, bson.D{}); n != 1 {
+		t.Errorf("Expected 1 restored audit record, got %d", n)
+	}
+
+	// Повторное восстановление в непустую базу требует -drop
+	if err := runRestore(restoreArgs, &out); err == nil {
+		t.Error("Expected error when restoring into a non-empty database")
+	}
+	if err := runRestore(append(restoreArgs, "-drop"), &out); err != nil {
+		t.Fatalf("runRestore -drop failed: %v", err)
+	}
+	if n, _ := client.Database(copyDatabase).Collection("events").CountDocuments(ctx, bson.D{}); n != 2 {
+		t.Errorf("Expected 2 events after -drop, got %d", n)
+	}
+}
+
+// TestBackupRestore_CollectionPlacement проверяет копию при отдельных коллекциях арендаторов:
+// архив и его коллекции арендаторов копируются один раз и не принимаются за коллекции событий
+func TestBackupRestore_CollectionPlacement(t *testing.T) {
+	database := "events_backup_placement_test"
+	copyDatabase := database + "_copy"
+	path := filepath.Join(t.TempDir(), "events.backup.gz")
+
+	mongoURI, cleanup, err := getMongoURI(testMongo)
+	if err != nil {
+		t.Fatalf("Не удалось запустить MongoDB: %v", err)
+	}
+	defer cleanup()
+	client, err := connectToMongoDB(mongoURI, testMongo)
+	if err != nil {
+		t.Fatalf("Не удалось подключиться к MongoDB: %v", err)
+	}
+	defer cleanupConnection(client, testMongo)
+
+	ctx := context.Background()
+	for _, name := range []string{database, copyDatabase} {
+		client.Database(name).Drop(ctx)
+		defer client.Database(name).Drop(ctx)
+	}
+	cfg := config.Default()
+	source := map[string]int{
+		cfg.Collections.Events:            2,
+		cfg.Collections.Events + "_acme":  1,
+		cfg.Collections.Archive:           3,
+		cfg.Collections.Archive + "_acme": 1,
+		cfg.Collections.Audit:             1,
+	}
+	for name, n := range source {
+		for i := 0; i < n; i++ {
+			if _, err := client.Database(database).Collection(name).InsertOne(ctx, bson.M{"type": "meeting"}); err != nil {
+				t.Fatalf("InsertOne failed: %v", err)
+			}
+		}
+	}
+
+	placement := []string{"-mongo-uri", mongoURI, "-tenants-placement", "collection"}
+	var out bytes.Buffer
+	if err := runBackup(append(placement, "-mongo-database", database, "-out", path), &out); err != nil {
+		t.Fatalf("runBackup failed: %v", err)
+	}
+	var report backup.Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	if report.Documents != 8 {
+		t.Errorf("Expected 8 documents in the backup, got %d: %+v", report.Documents, report.Collections)
+	}
+	for _, section := range report.Collections {
+		if section.Tenant != tenant.Default && section.Tenant != "acme" {
+			t.Errorf("Unexpected tenant in section %+v", section)
+		}
+	}
+
+	if err := runRestore(append(placement, "-mongo-database", copyDatabase, "-in", path), &out); err != nil {
+		t.Fatalf("runRestore failed: %v", err)
+	}
+	for name, want := range source {
+		if n, _ := client.Database(copyDatabase).Collection(name).CountDocuments(ctx, bson.D{}); n != int64(want) {
+			t.Errorf("Expected %d documents in %s, got %d", want, name, n)
+		}
+	}
+}
+
+// TestSetupRouter_Metrics проверяет, что /metrics отдаёт счётчики запросов, в том числе к проверкам состояния
+func TestSetupRouter_Metrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(routeHandlers{health: health.NewMonitor(time.Second), metrics: metrics.New()})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/livez", nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `http_requests_total{method="GET",route="/livez",status="200"} 1`) {
+		t.Errorf("Expected /livez request in metrics:\n%s", w.Body.String())
+	}
+}
+
+// TestProblemCodes_Translated проверяет, что у каждого кода ошибки API есть заголовок на каждом языке
+// Сервис импортирует все пакеты с ошибками API, поэтому здесь известны все коды
+func TestProblemCodes_Translated(t *testing.T) {
+	codes := append(problem.Codes(), "internal_error")
+	if len(codes) < 10 {
+		t.Fatalf("Expected problem codes of all packages, got %v", codes)
+	}
+	for _, lang := range i18n.Languages() {
+		for _, code := range codes {
+			if !i18n.Has(lang, code) {
+				t.Errorf("%s: no title for problem code %q", lang, code)
+			}
+		}
+	}
+}
+
+// TestSetupRouter_TraceInAccessLog проверяет, что спан запроса начинается раньше журнала доступа
+// и восстановления после паники, поэтому их записи несут trace_id и span_id
+func TestSetupRouter_TraceInAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	logger, err := logging.New(logging.Options{Level: "info", Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultLogger, propagator := slog.Default(), otel.GetTextMapPropagator()
+	slog.SetDefault(logger)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	r := setupRouter(routeHandlers{traceService: "event-service-test"})
+	r.GET("/panic", func(*gin.Context) { panic("boom") })
+	for _, path := range []string{"/v2/unknown", "/panic"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected an access log line per request and a panic record, got:\n%s", out.String())
+	}
+	for _, line := range lines {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || record["span_id"] == nil {
+			t.Errorf("Expected trace_id and span_id in %s", line)
+		}
+	}
+}
+
+// TestSetupRouter_AccessLogClientIP проверяет, что подменённый X-Forwarded-For не попадает в журнал доступа
+func TestSetupRouter_AccessLogClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	logger, err := logging.New(logging.Options{Level: "info", Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultLogger := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	for _, trustedProxies := range [][]string{nil, {"192.0.2.0/24"}} {
+		req := httptest.NewRequest(http.MethodGet, "/v2/unknown", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		setupRouter(routeHandlers{trustedProxies: trustedProxies}).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var ips []any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		ips = append(ips, record["client_ip"])
+	}
+	if len(ips) != 2 || ips[0] != "192.0.2.1" || ips[1] != "198.51.100.1" {
+		t.Errorf("Expected the connection address, then the forwarded one from a trusted proxy, got %v", ips)
+	}
+}
+
+// TestSetupRouter_Language проверяет выбор языка ошибок по Accept-Language и языку по умолчанию
+func TestSetupRouter_Language(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(routeHandlers{language: i18n.English})
+
+	for header, want := range map[string]string{"": "Route not found", "ru-RU, en;q=0.5": "Маршрут не найден"} {
+		req := httptest.NewRequest(http.MethodGet, "/v2/unknown", nil)
+		if header != "" {
+			req.Header.Set("Accept-Language", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var p problem.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || p.Code != "route_not_found" || p.Title != want {
+			t.Errorf("Accept-Language %q: expected route_not_found %q, got %s", header, want, w.Body.String())
+		}
+	}
+}
+
+// TestSetupRouter_MatchesSpec проверяет, что каждый маршрут API описан в спецификации OpenAPI и наоборот
+func TestSetupRouter_MatchesSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(routeHandlers{
+		events:  eventpkg.NewEventHandler(nil, eventpkg.DefaultMaxLimit),
+		types:   eventpkg.NewTypeHandler(nil),
+		keys:    auth.NewKeyHandler(nil),
+		audit:   audit.NewHandler(nil, 100),
+		archive: eventpkg.NewArchiveHandler(nil, 100),
+		health:  health.NewMonitor(time.Second),
+		metrics: metrics.New(),
+	})
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Служебные маршруты не входят в контракт API
+	skip := map[string]bool{"/metrics": true, openapi.SpecPath: true, openapi.DocsPath: true}
+	routes := map[string]bool{}
+	for _, route := range r.Routes() {
+		if skip[route.Path] {
+			continue
+		}
+		// Параметры пути gin (:id) в OpenAPI записываются как {id}
+		parts := strings.Split(route.Path, "/")
+		for i, part := range parts {
+			if strings.HasPrefix(part, ":") {
+				parts[i] = "{" + part[1:] + "}"
+			}
+		}
+		path := strings.Join(parts, "/")
+		routes[route.Method+" "+path] = true
+
+		item := doc.Paths.Find(path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			t.Errorf("Route %s %s is not described in the spec", route.Method, path)
+		}
+	}
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !routes[method+" "+path] {
+				t.Errorf("Spec describes %s %s, but the router does not serve it", method, path)
+			}
+		}
+	}
+}
+
+// TestSetupRouter_Validation проверяет, что с проверкой по спецификации некорректный запрос
+// отклоняется ещё до обработчика, а ответы об ошибках соответствуют спецификации
+func TestSetupRouter_Validation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	validator, err := openapi.NewValidator(openapi.ValidateAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator.OnResponseError(func(c *gin.Context, err error) {
+		t.Errorf("%s %s: response does not match the spec: %v", c.Request.Method, c.Request.URL, err)
+	})
+	r := setupRouter(routeHandlers{
+		events:    eventpkg.NewEventHandler(nil, eventpkg.DefaultMaxLimit),
+		archive:   eventpkg.NewArchiveHandler(nil, 100),
+		health:    health.NewMonitor(time.Second),
+		validator: validator,
+	})
+
+	for _, tt := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/v1/start", `{"type":""}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/finish", `{}`, http.StatusBadRequest},
+		{http.MethodGet, "/v1?limit=-5", ``, http.StatusBadRequest},
+		{http.MethodGet, "/v1/events/42/tree", ``, http.StatusBadRequest},
+		{http.MethodGet, "/v1/events/42", ``, http.StatusBadRequest},
+		{http.MethodPatch, "/v1/events/42", `{}`, http.StatusBadRequest},
+		{http.MethodGet, "/v1/archives/events?limit=0", ``, http.StatusBadRequest},
+		{http.MethodGet, "/livez", ``, http.StatusOK},
+		{http.MethodGet, openapi.SpecPath, ``, http.StatusOK},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.method, tt.path, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
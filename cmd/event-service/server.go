@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"event-service/internal/config"
+
+	"google.golang.org/grpc"
+)
+
+// signalContext возвращает контекст, который отменяется по SIGINT или SIGTERM
+// После первого сигнала обработка возвращается по умолчанию: повторный Ctrl+C завершит процесс сразу
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// newHTTPServer создаёт HTTP-сервер с таймаутами из настроек http
+// Таймауты защищают от медленных клиентов, которые иначе держали бы соединения бесконечно
+func newHTTPServer(handler http.Handler, cfg config.HTTP) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// serve обслуживает запросы на listener, пока не будет отменён ctx (сигнал завершения)
+// После отмены вызывает unready (снятие готовности), ещё cfg.ShutdownDelay принимает запросы,
+// чтобы балансировщик успел заметить 503 на /readyz, затем перестаёт принимать новые соединения
+// и ждёт завершения текущих запросов не дольше cfg.ShutdownTimeout; незавершённые к этому времени соединения закрываются
+// Возвращает nil после штатной остановки или ошибку, если сервер не смог работать
+func serve(ctx context.Context, srv *http.Server, listener net.Listener, cfg config.HTTP, unready func()) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("HTTP-сервер остановился: %w", err)
+	case <-ctx.Done():
+	}
+
+	if unready != nil {
+		unready()
+	}
+	if cfg.ShutdownDelay > 0 {
+		slog.Info("Получен сигнал завершения, готовность снята", "shutdown_delay", cfg.ShutdownDelay.String())
+		select {
+		case <-time.After(cfg.ShutdownDelay):
+		case err := <-serveErr:
+			return fmt.Errorf("HTTP-сервер остановился: %w", err)
+		}
+	}
+
+	slog.Info("Ждём завершения текущих запросов", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("не все запросы завершились за %s: %w", cfg.ShutdownTimeout, err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("HTTP-сервер остановлен")
+	return nil
+}
+
+// serveGRPC обслуживает gRPC-вызовы на listener, пока не будет отменён ctx
+// После отмены вызывает drain (завершение бесконечных потоков вроде Watch), перестаёт принимать
+// новые вызовы — клиенты получают GOAWAY и переподключаются к другим экземплярам — и ждёт текущие
+// не дольше timeout; незавершённые к этому времени вызовы обрываются
+func serveGRPC(ctx context.Context, srv *grpc.Server, listener net.Listener, drain func(), timeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("gRPC-сервер остановился: %w", err)
+	case <-ctx.Done():
+	}
+
+	if drain != nil {
+		drain()
+	}
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		srv.Stop()
+		<-stopped
+		return fmt.Errorf("не все gRPC-вызовы завершились за %s", timeout)
+	}
+	slog.Info("gRPC-сервер остановлен")
+	return nil
+}
+
+// workers — фоновые задачи сервиса (например, обслуживание данных по расписанию)
+// Задачи получают общий контекст, который отменяется при остановке сервиса
+type workers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// failed — задачи, завершившиеся раньше остановки сервиса
+	mu     sync.Mutex
+	failed []string
+}
+
+// newWorkers создаёт пустой набор фоновых задач
+func newWorkers() *workers {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workers{ctx: ctx, cancel: cancel}
+}
+
+// Go запускает фоновую задачу run; задача должна завершиться после отмены ctx
+func (w *workers) Go(name string, run func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		run(w.ctx)
+		if w.ctx.Err() == nil {
+			slog.Error("Фоновая задача неожиданно завершилась", "worker", name)
+			w.mu.Lock()
+			w.failed = append(w.failed, name)
+			w.mu.Unlock()
+			return
+		}
+		slog.Info("Фоновая задача остановлена", "worker", name)
+	}()
+}
+
+// Check — проверка готовности для /readyz: ошибка, если какая-то задача завершилась раньше времени
+func (w *workers) Check(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.failed) > 0 {
+		return fmt.Errorf("завершились фоновые задачи: %s", strings.Join(w.failed, ", "))
+	}
+	return nil
+}
+
+// Stop отменяет контекст задач и ждёт их завершения не дольше timeout
+// Возвращает false, если какие-то задачи не успели завершиться
+func (w *workers) Stop(timeout time.Duration) bool {
+	w.cancel()
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		slog.Warn("Фоновые задачи не завершились вовремя", "timeout", timeout.String())
+		return false
+	}
+}
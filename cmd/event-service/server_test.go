@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"event-service/internal/config"
+	"event-service/pkg/health"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// listen занимает свободный порт на localhost
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	return ln
+}
+
+// slowHandler сообщает в started о начале запроса и отвечает только после закрытия release
+func slowHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.Write([]byte("done"))
+	})
+}
+
+func TestServe_InFlightRequestCompletesOnSIGTERM(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not supported on windows")
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	ln := listen(t)
+	addr := ln.Addr().String()
+	srv := newHTTPServer(slowHandler(started, release), config.Default().HTTP)
+
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, ln, config.Default().HTTP, nil) }()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	<-started
+
+	// Процесс получает SIGTERM, пока запрос ещё обрабатывается
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	<-ctx.Done()
+
+	// Сервер не должен остановиться, пока запрос не завершён
+	select {
+	case err := <-served:
+		t.Fatalf("serve returned before the in-flight request finished: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Новые соединения уже не принимаются
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		t.Error("Server should stop accepting connections after SIGTERM")
+	}
+
+	close(release)
+	res := <-response
+	if res.err != nil || res.status != http.StatusOK || res.body != "done" {
+		t.Errorf("In-flight request should complete: status=%d body=%q err=%v", res.status, res.body, res.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serve should return nil after graceful shutdown, got %v", err)
+	}
+}
+
+func TestServe_ShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+
+	ln := listen(t)
+	addr := ln.Addr().String()
+	srv := newHTTPServer(slowHandler(started, release), config.Default().HTTP)
+
+	cfg := config.Default().HTTP
+	cfg.ShutdownTimeout = 100 * time.Millisecond
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, ln, cfg, nil) }()
+
+	go http.Get("http://" + addr + "/slow")
+	<-started
+	cancel()
+
+	select {
+	case err := <-served:
+		if err == nil {
+			t.Error("Expected error when requests don't finish in time")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve should give up after the shutdown timeout")
+	}
+}
+
+func TestServe_ListenerError(t *testing.T) {
+	ln := listen(t)
+	ln.Close()
+
+	srv := newHTTPServer(http.NotFoundHandler(), config.Default().HTTP)
+	if err := serve(context.Background(), srv, ln, config.Default().HTTP, nil); err == nil {
+		t.Error("Expected error for a closed listener")
+	}
+}
+
+// watchHealth открывает бесконечный поток Watch сервиса проверки состояния gRPC
+// Поток закрывается отменой возвращённой функции
+func watchHealth(t *testing.T, addr string) context.CancelFunc {
+	t.Helper()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Первый ответ приходит, когда поток уже открыт на сервере
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	return cancel
+}
+
+func TestServeGRPC_Drain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ln := listen(t)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, grpchealth.NewServer())
+
+	// drain завершает открытый поток; без него поток держал бы остановку до таймаута
+	var closeStream context.CancelFunc
+	drain := func() { closeStream() }
+	served := make(chan error, 1)
+	go func() { served <- serveGRPC(ctx, srv, ln, drain, 5*time.Second) }()
+	closeStream = watchHealth(t, ln.Addr().String())
+	cancel()
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("serveGRPC should return nil after graceful stop, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Open stream should be drained")
+	}
+}
+
+func TestServeGRPC_ShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ln := listen(t)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, grpchealth.NewServer())
+
+	served := make(chan error, 1)
+	go func() { served <- serveGRPC(ctx, srv, ln, nil, 100*time.Millisecond) }()
+	watchHealth(t, ln.Addr().String())
+	cancel()
+
+	select {
+	case err := <-served:
+		if err == nil {
+			t.Error("Expected error when streams don't finish in time")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveGRPC should give up after the shutdown timeout")
+	}
+}
+
+func TestServe_ReadinessDuringShutdownDelay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	monitor := health.NewMonitor(time.Second)
+	router := setupRouter(routeHandlers{health: monitor})
+
+	ln := listen(t)
+	addr := ln.Addr().String()
+	cfg := config.Default().HTTP
+	cfg.ShutdownDelay = 300 * time.Millisecond
+	srv := newHTTPServer(router, cfg)
+
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, ln, cfg, monitor.SetShuttingDown) }()
+
+	status := func(path string) int {
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := status("/readyz"); code != http.StatusOK {
+		t.Fatalf("Expected ready before shutdown, got %d", code)
+	}
+
+	cancel()
+	// Сервер ещё принимает запросы, но уже сообщает, что не готов
+	deadline := time.Now().Add(time.Second)
+	for status("/readyz") != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("Readiness should flip to 503 after the shutdown signal")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := status("/livez"); code != http.StatusOK {
+		t.Errorf("Liveness should stay 200 during shutdown, got %d", code)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("serve should return nil after graceful shutdown, got %v", err)
+	}
+}
+
+func TestNewHTTPServer(t *testing.T) {
+	cfg := config.Default().HTTP
+	cfg.ReadTimeout = 7 * time.Second
+
+	srv := newHTTPServer(http.NotFoundHandler(), cfg)
+	if srv.Addr != cfg.Addr || srv.ReadHeaderTimeout != cfg.ReadHeaderTimeout || srv.ReadTimeout != 7*time.Second ||
+		srv.WriteTimeout != cfg.WriteTimeout || srv.IdleTimeout != cfg.IdleTimeout {
+		t.Errorf("Timeouts from config should be applied: %+v", srv)
+	}
+}
+
+func TestWorkers_Stop(t *testing.T) {
+	w := newWorkers()
+	var stopped atomic.Bool
+	w.Go("test", func(ctx context.Context) {
+		<-ctx.Done()
+		stopped.Store(true)
+	})
+
+	if !w.Stop(time.Second) {
+		t.Fatal("Stop should wait for the worker")
+	}
+	if !stopped.Load() {
+		t.Error("Worker should finish before Stop returns")
+	}
+	if err := w.Check(context.Background()); err != nil {
+		t.Errorf("Stopped workers should stay healthy, got %v", err)
+	}
+
+	// Задача, которая игнорирует отмену, не задерживает остановку дольше таймаута
+	stuck := newWorkers()
+	release := make(chan struct{})
+	defer close(release)
+	stuck.Go("stuck", func(ctx context.Context) { <-release })
+	if stuck.Stop(50 * time.Millisecond) {
+		t.Error("Stop should report workers that didn't finish in time")
+	}
+}
+
+func TestWorkers_Check(t *testing.T) {
+	w := newWorkers()
+	defer w.Stop(time.Second)
+
+	done := make(chan struct{})
+	w.Go("retention", func(ctx context.Context) { close(done) })
+	<-done
+
+	deadline := time.Now().Add(time.Second)
+	for w.Check(context.Background()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Worker that exited before shutdown should fail the check")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := w.Check(context.Background()); !strings.Contains(err.Error(), "retention") {
+		t.Errorf("Check should name the failed worker, got %v", err)
+	}
+}
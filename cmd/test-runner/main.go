@@ -139,9 +139,11 @@ func isValidObjectID(id string) bool {
 	return true
 }
 
-// ErrorResponse представляет ошибку из API согласно OpenAPI спецификации
+// ErrorResponse представляет ошибку из API в формате RFC 7807 (application/problem+json)
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
 }
 
 func makeRequest(method, url string, body interface{}) (*http.Response, []byte, error) {
@@ -451,11 +453,11 @@ func test7_POST_finish_nonexistent(ts *TestSuite) error {
 		return fmt.Errorf("ошибка парсинга JSON ответа об ошибке: %v", err)
 	}
 
-	if errorResp.Message == "" {
-		return fmt.Errorf("ожидалось сообщение об ошибке в поле 'message'")
+	if errorResp.Code != "event_not_found" {
+		return fmt.Errorf("ожидался код ошибки 'event_not_found', получен %q", errorResp.Code)
 	}
 
-	ts.Writef("  Получена ожидаемая ошибка 404: %s\n", errorResp.Message)
+	ts.Writef("  Получена ожидаемая ошибка 404 (%s): %s\n", errorResp.Code, errorResp.Title)
 	return nil
 }
 
@@ -649,8 +651,8 @@ func setupTestServer() (*mongo.Client, func(), error) {
 
 	// Создаём репозиторий, сервис и обработчик
 	repo := event.NewEventRepository(collection)
-	service := event.NewEventService(repo)
-	handler := event.NewEventHandler(service)
+	service := event.NewEventService(repo, nil)
+	handler := event.NewEventHandler(service, event.DefaultMaxLimit)
 
 	// Настраиваем Gin
 	gin.SetMode(gin.TestMode)
@@ -0,0 +1,351 @@
+// Package backup — формат резервной копии базы сервиса
+//
+// Копия — поток gzip со строками JSON (NDJSON), его можно просмотреть обычными инструментами (zcat, jq):
+//
+//	{"header":{"format":"event-service-backup","version":1,...}}
+//	{"collection":{"role":"events","tenant":"default","database":"events_db","name":"events"}}
+//	{"doc":{...}}
+//	...
+//	{"end":{"documents":2,"sha256":"..."}}
+//	...
+//	{"trailer":{"collections":5,"documents":42,"sha256":"..."}}
+//
+// Документы записываются в каноническом Extended JSON, поэтому типы BSON (ObjectId, даты, int64)
+// восстанавливаются без потерь. Контрольная сумма раздела считается по строкам его документов,
+// итоговая — по всем строкам перед trailer; копия без trailer считается обрезанной
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// Format — значение поля format в заголовке копии
+	Format = "event-service-backup"
+	// Version — версия формата, которую пишет Writer; Reader читает копии этой и более ранних версий
+	Version = 1
+)
+
+// Header — заголовок копии
+type Header struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	// CreatedAt — время начала копирования
+	CreatedAt time.Time `json:"createdAt"`
+	// Database — база, из которой сделана копия
+	Database string `json:"database"`
+	// Placement — размещение арендаторов (tenants.placement) в исходной базе
+	Placement string `json:"placement"`
+	// Snapshot — все коллекции прочитаны из одного снимка базы
+	Snapshot bool `json:"snapshot"`
+}
+
+// Collection — раздел копии: одна коллекция MongoDB
+type Collection struct {
+	// Role — назначение коллекции (events, types, audit...); по нему при восстановлении
+	// выбирается коллекция из настроек, поэтому имена коллекций могут отличаться
+	Role string `json:"role"`
+	// Tenant — арендатор, которому принадлежит коллекция
+	Tenant string `json:"tenant"`
+	// Database и Name — где коллекция лежала в исходной базе
+	Database string `json:"database"`
+	Name     string `json:"name"`
+}
+
+// Checksum — число документов и их контрольная сумма SHA-256
+type Checksum struct {
+	Documents int64  `json:"documents"`
+	SHA256    string `json:"sha256"`
+}
+
+// Trailer — итоговая запись копии
+type Trailer struct {
+	Collections int `json:"collections"`
+	Checksum
+}
+
+// line — одна строка копии; заполнено ровно одно поле
+type line struct {
+	Header     *Header         `json:"header,omitempty"`
+	Collection *Collection     `json:"collection,omitempty"`
+	Doc        json.RawMessage `json:"doc,omitempty"`
+	End        *Checksum       `json:"end,omitempty"`
+	Trailer    *Trailer        `json:"trailer,omitempty"`
+}
+
+// ErrChecksum возвращается, если содержимое копии не совпадает с записанными в ней контрольными суммами
+var ErrChecksum = errors.New("контрольная сумма резервной копии не совпадает")
+
+// Writer записывает копию потоком: заголовок, разделы коллекций и итоговую запись
+type Writer struct {
+	gz *gzip.Writer
+	// total — контрольная сумма всех строк, section — строк документов текущего раздела
+	total   hash.Hash
+	section hash.Hash
+	open    bool
+	// count — документов в текущем разделе; trailer — итоги по всей копии
+	count   int64
+	trailer Trailer
+}
+
+// NewWriter начинает копию в w и записывает заголовок; поля Format и Version заполняются сами
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	header.Format = Format
+	header.Version = Version
+	bw := &Writer{gz: gzip.NewWriter(w), total: sha256.New()}
+	if err := bw.writeLine(line{Header: &header}); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// Begin начинает раздел коллекции c
+func (w *Writer) Begin(c Collection) error {
+	if w.open {
+		return errors.New("предыдущий раздел резервной копии не завершён")
+	}
+	w.open = true
+	w.count = 0
+	w.section = sha256.New()
+	return w.writeLine(line{Collection: &c})
+}
+
+// Write добавляет документ в текущий раздел
+func (w *Writer) Write(doc bson.Raw) error {
+	if !w.open {
+		return errors.New("документ резервной копии вне раздела коллекции")
+	}
+	ext, err := bson.MarshalExtJSON(doc, true, false)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, 0, len(ext)+9)
+	data = append(data, `{"doc":`...)
+	data = append(data, ext...)
+	data = append(data, "}\n"...)
+	w.section.Write(data)
+	w.count++
+	return w.write(data)
+}
+
+// End завершает текущий раздел и возвращает его контрольную сумму
+func (w *Writer) End() (Checksum, error) {
+	if !w.open {
+		return Checksum{}, errors.New("нет начатого раздела резервной копии")
+	}
+	w.open = false
+	sum := Checksum{Documents: w.count, SHA256: hex.EncodeToString(w.section.Sum(nil))}
+	w.trailer.Collections++
+	w.trailer.Documents += w.count
+	return sum, w.writeLine(line{End: &sum})
+}
+
+// Close записывает итоговую запись и завершает поток gzip; сам w не закрывается
+func (w *Writer) Close() error {
+	if w.open {
+		return errors.New("последний раздел резервной копии не завершён")
+	}
+	w.trailer.SHA256 = hex.EncodeToString(w.total.Sum(nil))
+	if err := w.writeLine(line{Trailer: &w.trailer}); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// writeLine записывает служебную строку
+func (w *Writer) writeLine(l line) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return w.write(append(data, '\n'))
+}
+
+// write записывает строку и учитывает её в итоговой контрольной сумме
+func (w *Writer) write(data []byte) error {
+	w.total.Write(data)
+	_, err := w.gz.Write(data)
+	return err
+}
+
+// Reader читает копию потоком и проверяет контрольные суммы по мере чтения:
+// раздела — дочитав его до конца, всей копии — дойдя до итоговой записи
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+	// total и section — контрольные суммы, как у Writer
+	total   hash.Hash
+	section hash.Hash
+	current *Collection
+	count   int64
+	// collections и documents — прочитано полностью
+	collections int
+	documents   int64
+}
+
+// NewReader начинает чтение копии из r: читает заголовок и проверяет формат и версию
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("файл не является резервной копией: %w", err)
+	}
+	br := &Reader{r: bufio.NewReader(gz), total: sha256.New()}
+
+	l, _, err := br.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if l.Header == nil || l.Header.Format != Format {
+		return nil, errors.New("файл не является резервной копией: нет заголовка " + Format)
+	}
+	if l.Header.Version < 1 || l.Header.Version > Version {
+		return nil, fmt.Errorf("неподдерживаемая версия резервной копии %d (поддерживаются 1–%d)", l.Header.Version, Version)
+	}
+	br.header = *l.Header
+	return br, nil
+}
+
+// Header возвращает заголовок копии
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next переходит к следующему разделу, пропуская непрочитанные документы текущего
+// В конце копии сверяет итоговую контрольную сумму и возвращает io.EOF
+func (r *Reader) Next() (*Collection, error) {
+	for r.current != nil {
+		if _, err := r.Document(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	total := r.total.Sum(nil)
+	l, _, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case l.Collection != nil:
+		r.current = l.Collection
+		r.count = 0
+		r.section = sha256.New()
+		return l.Collection, nil
+	case l.Trailer != nil:
+		if l.Trailer.Collections != r.collections || l.Trailer.Documents != r.documents ||
+			l.Trailer.SHA256 != hex.EncodeToString(total) {
+			return nil, ErrChecksum
+		}
+		if _, err := r.r.ReadByte(); err != io.EOF {
+			return nil, errors.New("лишние данные после итоговой записи резервной копии")
+		}
+		return nil, io.EOF
+	default:
+		return nil, errors.New("повреждённая резервная копия: ожидался раздел коллекции")
+	}
+}
+
+// Document возвращает следующий документ текущего раздела
+// В конце раздела сверяет его контрольную сумму и возвращает io.EOF
+func (r *Reader) Document() (bson.Raw, error) {
+	if r.current == nil {
+		return nil, io.EOF
+	}
+	l, data, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case l.Doc != nil:
+		r.section.Write(data)
+		r.count++
+		var doc bson.Raw
+		if err := bson.UnmarshalExtJSON(l.Doc, true, &doc); err != nil {
+			return nil, fmt.Errorf("повреждённый документ в разделе %s: %w", r.current.Name, err)
+		}
+		return doc, nil
+	case l.End != nil:
+		if l.End.Documents != r.count || l.End.SHA256 != hex.EncodeToString(r.section.Sum(nil)) {
+			return nil, fmt.Errorf("%w: раздел %s.%s", ErrChecksum, r.current.Database, r.current.Name)
+		}
+		r.current = nil
+		r.collections++
+		r.documents += r.count
+		return nil, io.EOF
+	default:
+		return nil, fmt.Errorf("повреждённая резервная копия: незавершённый раздел %s", r.current.Name)
+	}
+}
+
+// readLine читает строку копии и учитывает её в итоговой контрольной сумме
+func (r *Reader) readLine() (line, []byte, error) {
+	data, err := r.r.ReadBytes('\n')
+	if err == io.EOF {
+		return line{}, nil, fmt.Errorf("резервная копия обрезана: %w", io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return line{}, nil, err
+	}
+	r.total.Write(data)
+
+	var l line
+	if err := json.Unmarshal(bytes.TrimSuffix(data, []byte("\n")), &l); err != nil {
+		return line{}, nil, fmt.Errorf("повреждённая строка резервной копии: %w", err)
+	}
+	return l, data, nil
+}
+
+// Section — раздел копии и число документов в нём
+type Section struct {
+	Collection
+	Documents int64 `json:"documents"`
+}
+
+// Report — содержимое проверенной копии
+type Report struct {
+	Header      Header    `json:"header"`
+	Collections []Section `json:"collections"`
+	Documents   int64     `json:"documents"`
+}
+
+// Verify читает копию целиком и сверяет все контрольные суммы, ничего не восстанавливая
+func Verify(r io.Reader) (*Report, error) {
+	br, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Header: br.Header(), Collections: []Section{}}
+	for {
+		c, err := br.Next()
+		if err == io.EOF {
+			return report, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		section := Section{Collection: *c}
+		for {
+			if _, err := br.Document(); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			section.Documents++
+		}
+		report.Collections = append(report.Collections, section)
+		report.Documents += section.Documents
+	}
+}
@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// writeBackup записывает копию с разделами sections: имя коллекции → документы
+func writeBackup(t *testing.T, sections map[string][]bson.M) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Header{CreatedAt: time.Now().UTC(), Database: "events_db", Placement: "shared"})
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	for _, name := range []string{"events", "event_types", "audit_log"} {
+		docs, ok := sections[name]
+		if !ok {
+			continue
+		}
+		if err := w.Begin(Collection{Role: name, Tenant: "default", Database: "events_db", Name: name}); err != nil {
+			t.Fatalf("Begin failed: %v", err)
+		}
+		for _, doc := range docs {
+			raw, err := bson.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Write(raw); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+		if sum, err := w.End(); err != nil || sum.Documents != int64(len(docs)) {
+			t.Fatalf("End failed: %+v %v", sum, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// unpack распаковывает копию
+func unpack(t *testing.T, data []byte) string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(plain)
+}
+
+// pack упаковывает строки копии в gzip
+func pack(plain string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(plain))
+	w.Close()
+	return buf.Bytes()
+}
+
+// rewrite заменяет в копии первое вхождение old на new
+func rewrite(t *testing.T, data []byte, old, new string) []byte {
+	t.Helper()
+	return pack(strings.Replace(unpack(t, data), old, new, 1))
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	started := time.Date(2026, 1, 2, 3, 4, 5, 6_000_000, time.UTC)
+	data := writeBackup(t, map[string][]bson.M{
+		"events": {
+			{"_id": id, "type": "meeting", "started_at": started, "version": int64(3), "attributes": bson.M{"seats": int32(12)}},
+			{"_id": primitive.NewObjectID(), "type": "call"},
+		},
+		"event_types": {},
+		"audit_log":   {{"_id": primitive.NewObjectID(), "hash": "abc"}},
+	})
+
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if h := r.Header(); h.Format != Format || h.Version != Version || h.Database != "events_db" {
+		t.Errorf("Unexpected header %+v", h)
+	}
+
+	c, err := r.Next()
+	if err != nil || c.Name != "events" {
+		t.Fatalf("Expected the events section, got %+v (%v)", c, err)
+	}
+	doc, err := r.Document()
+	if err != nil {
+		t.Fatalf("Document failed: %v", err)
+	}
+	// Типы BSON сохраняются: ObjectId, дата с миллисекундами, int64 и int32
+	if got, ok := doc.Lookup("_id").ObjectIDOK(); !ok || got != id {
+		t.Errorf("Unexpected _id %v", doc.Lookup("_id"))
+	}
+	if got, ok := doc.Lookup("started_at").TimeOK(); !ok || !got.Equal(started) {
+		t.Errorf("Unexpected started_at %v", doc.Lookup("started_at"))
+	}
+	if got, ok := doc.Lookup("version").Int64OK(); !ok || got != 3 {
+		t.Errorf("Unexpected version %v", doc.Lookup("version"))
+	}
+	if got, ok := doc.Lookup("attributes", "seats").Int32OK(); !ok || got != 12 {
+		t.Errorf("Unexpected seats %v", doc.Lookup("attributes", "seats"))
+	}
+
+	// Next пропускает непрочитанные документы раздела
+	if c, err := r.Next(); err != nil || c.Name != "event_types" {
+		t.Fatalf("Expected the event_types section, got %+v (%v)", c, err)
+	}
+	if _, err := r.Document(); err != io.EOF {
+		t.Errorf("Expected an empty section, got %v", err)
+	}
+	if c, err := r.Next(); err != nil || c.Name != "audit_log" {
+		t.Fatalf("Expected the audit_log section, got %+v (%v)", c, err)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end, got %v", err)
+	}
+
+	report, err := Verify(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(report.Collections) != 3 || report.Collections[0].Documents != 2 || report.Documents != 3 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestVerify_Damaged(t *testing.T) {
+	data := writeBackup(t, map[string][]bson.M{
+		"events":    {{"type": "meeting"}, {"type": "call"}},
+		"audit_log": {{"hash": "abc"}},
+	})
+
+	plain := unpack(t, data)
+	tests := map[string]struct {
+		data []byte
+		want error
+	}{
+		"document changed": {rewrite(t, data, `"meeting"`, `"meetinG"`), ErrChecksum},
+		"document removed": {rewrite(t, data, `{"doc":{"type":"call"}}`+"\n", ""), ErrChecksum},
+		"header changed":   {rewrite(t, data, `"events_db"`, `"other_db"`), ErrChecksum},
+		"trailer damaged":  {rewrite(t, data, `{"trailer"`, `{"x"`), nil},
+		"no trailer":       {pack(plain[:strings.Index(plain, `{"trailer"`)]), io.ErrUnexpectedEOF},
+		"extra data":       {pack(plain + plain), nil},
+		"truncated gzip":   {data[:len(data)/2], nil},
+	}
+
+	for name, tt := range tests {
+		_, err := Verify(bytes.NewReader(tt.data))
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, err)
+		}
+	}
+}
+
+func TestNewReader_Unsupported(t *testing.T) {
+	data := writeBackup(t, map[string][]bson.M{})
+	for name, input := range map[string][]byte{
+		"not gzip":      []byte("{}"),
+		"other format":  rewrite(t, data, Format, "mongodump"),
+		"newer version": rewrite(t, data, `"version":1`, `"version":99`),
+	} {
+		if _, err := NewReader(bytes.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWriter_Sections(t *testing.T) {
+	w, err := NewWriter(io.Discard, Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(bson.Raw{}); err == nil {
+		t.Error("Expected an error for a document outside a section")
+	}
+	if _, err := w.End(); err == nil {
+		t.Error("Expected an error for End without Begin")
+	}
+	w.Begin(Collection{Name: "events"})
+	if err := w.Begin(Collection{Name: "audit_log"}); err == nil {
+		t.Error("Expected an error for a nested section")
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Expected an error for an unfinished section")
+	}
+}
@@ -0,0 +1,405 @@
+// Package config описывает настройки сервиса и собирает их из нескольких источников
+// Порядок применения: значения по умолчанию, файл конфигурации (YAML или TOML),
+// переменные окружения и, наконец, флаги командной строки — каждый следующий важнее предыдущего
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/event"
+	"event-service/pkg/graphqlapi"
+	"event-service/pkg/i18n"
+	"event-service/pkg/logging"
+	"event-service/pkg/openapi"
+	"event-service/pkg/ratelimit"
+	"event-service/pkg/tenant"
+	"event-service/pkg/tracing"
+)
+
+// Config — все настройки сервиса
+// У каждого параметра есть ключ в файле (тег config, вложенные разделы через точку),
+// переменная окружения (тег env) и флаг командной строки (ключ, где точки и _ заменены на -)
+type Config struct {
+	HTTP        HTTP        `config:"http"`
+	GRPC        GRPC        `config:"grpc"`
+	GraphQL     GraphQL     `config:"graphql"`
+	Mongo       Mongo       `config:"mongo"`
+	Collections Collections `config:"collections"`
+	API         API         `config:"api"`
+	Auth        Auth        `config:"auth"`
+	Tenants     Tenants     `config:"tenants"`
+	EventTypes  EventTypes  `config:"event_types"`
+	RateLimits  RateLimits  `config:"rate_limits"`
+	Retention   Retention   `config:"retention"`
+	Health      Health      `config:"health"`
+	Tracing     Tracing     `config:"tracing"`
+	Log         Log         `config:"log"`
+}
+
+// HTTP — настройки HTTP-сервера
+type HTTP struct {
+	// Addr — адрес, на котором сервер принимает запросы
+	Addr string `config:"addr" env:"HTTP_ADDR" usage:"адрес HTTP-сервера"`
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout, IdleTimeout — таймауты http.Server
+	// (0 — без ограничения); ReadTimeout и WriteTimeout должны вмещать импорт больших файлов
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" usage:"таймаут чтения заголовков запроса"`
+	ReadTimeout       time.Duration `config:"read_timeout" env:"HTTP_READ_TIMEOUT" usage:"таймаут чтения запроса целиком"`
+	WriteTimeout      time.Duration `config:"write_timeout" env:"HTTP_WRITE_TIMEOUT" usage:"таймаут записи ответа"`
+	IdleTimeout       time.Duration `config:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" usage:"время жизни простаивающего соединения keep-alive"`
+	// ShutdownTimeout — сколько при остановке ждать завершения текущих запросов и фоновых задач
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" usage:"сколько ждать текущие запросы при остановке"`
+	// ShutdownDelay — сколько после сигнала завершения продолжать принимать запросы, отвечая на /readyz 503,
+	// чтобы балансировщик успел убрать экземпляр из ротации (0 — сразу начинать остановку)
+	ShutdownDelay time.Duration `config:"shutdown_delay" env:"HTTP_SHUTDOWN_DELAY" usage:"задержка остановки после снятия готовности"`
+	// TrustedProxies — адреса и подсети прокси через запятую (например, "10.0.0.0/8, 192.0.2.1"),
+	// которым можно верить в X-Forwarded-For и X-Real-IP; пусто — адрес клиента берётся из соединения
+	TrustedProxies string `config:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES" usage:"доверенные прокси через запятую (IP или CIDR)"`
+}
+
+// GRPC — настройки gRPC-сервера; он работает рядом с HTTP-сервером на отдельном порту
+type GRPC struct {
+	// Addr — адрес, на котором gRPC-сервер принимает вызовы; пусто — gRPC API выключен
+	Addr string `config:"addr" env:"GRPC_ADDR" usage:"адрес gRPC-сервера (пусто — не запускать)"`
+	// WatchBuffer — сколько изменений может ждать отправки одному клиенту Watch;
+	// клиент, который отстал сильнее, отключается
+	WatchBuffer int `config:"watch_buffer" env:"GRPC_WATCH_BUFFER" usage:"очередь изменений на клиента Watch"`
+}
+
+// GraphQL — настройки GraphQL API (/graphql на HTTP-сервере)
+type GraphQL struct {
+	// Enabled включает GraphQL API
+	Enabled bool `config:"enabled" env:"GRAPHQL_ENABLED" usage:"включить GraphQL API на /graphql"`
+	// ComplexityLimit — наибольшая сложность запроса: каждое поле стоит 1, а списки умножают
+	// стоимость вложенных полей на число элементов; более сложные запросы отклоняются
+	ComplexityLimit int `config:"complexity_limit" env:"GRAPHQL_COMPLEXITY_LIMIT" usage:"наибольшая сложность запроса GraphQL"`
+}
+
+// Mongo — подключение к MongoDB
+type Mongo struct {
+	// URI — адрес внешнего MongoDB; пусто — запустить встроенный
+	// Может содержать пароль, поэтому при выводе настроек скрывается
+	URI string `config:"uri" env:"MONGO_URI" secret:"true" usage:"URI внешнего MongoDB (пусто — запустить встроенный)"`
+	// Database — база данных с событиями
+	Database string `config:"database" env:"MONGO_DATABASE" usage:"имя базы данных"`
+	// ConnectTimeout — сколько ждать подключения к MongoDB при запуске
+	ConnectTimeout time.Duration `config:"connect_timeout" env:"MONGO_CONNECT_TIMEOUT" usage:"таймаут подключения к MongoDB"`
+	// SetupTimeout — сколько ждать подготовки хранилищ (создания индексов) при запуске
+	SetupTimeout time.Duration `config:"setup_timeout" env:"MONGO_SETUP_TIMEOUT" usage:"таймаут создания индексов при запуске"`
+	// DisconnectTimeout — сколько ждать закрытия соединения при остановке
+	DisconnectTimeout time.Duration `config:"disconnect_timeout" env:"MONGO_DISCONNECT_TIMEOUT" usage:"таймаут отключения от MongoDB"`
+	// EmbeddedPort — порт встроенного MongoDB
+	EmbeddedPort int `config:"embedded_port" env:"MONGO_EMBEDDED_PORT" usage:"порт встроенного MongoDB"`
+}
+
+// Collections — имена коллекций в базе данных
+type Collections struct {
+	Events     string `config:"events" env:"COLLECTION_EVENTS" usage:"коллекция событий"`
+	Types      string `config:"types" env:"COLLECTION_TYPES" usage:"коллекция реестра типов"`
+	APIKeys    string `config:"api_keys" env:"COLLECTION_API_KEYS" usage:"коллекция API-ключей"`
+	Audit      string `config:"audit" env:"COLLECTION_AUDIT" usage:"коллекция журнала аудита"`
+	RateLimits string `config:"rate_limits" env:"COLLECTION_RATE_LIMITS" usage:"коллекция состояния лимитов"`
+	Archive    string `config:"archive" env:"COLLECTION_ARCHIVE" usage:"коллекция архива событий"`
+	// AuditOutbox — записи аудита, которые не удалось сразу добавить в журнал (см. audit.Log.SetOutbox)
+	AuditOutbox string `config:"audit_outbox" env:"COLLECTION_AUDIT_OUTBOX" usage:"коллекция отложенных записей аудита"`
+}
+
+// Names возвращает имена всех коллекций
+func (c Collections) Names() []string {
+	return []string{c.Events, c.Types, c.APIKeys, c.Audit, c.RateLimits, c.Archive, c.AuditOutbox}
+}
+
+// keys возвращает ключи настроек коллекций в порядке Names
+func (c Collections) keys() []string {
+	return []string{"collections.events", "collections.types", "collections.api_keys",
+		"collections.audit", "collections.rate_limits", "collections.archive", "collections.audit_outbox"}
+}
+
+// API — ограничения HTTP API
+type API struct {
+	// MaxPageSize — наибольшее значение параметра limit в списках
+	MaxPageSize int `config:"max_page_size" env:"API_MAX_PAGE_SIZE" usage:"наибольшее значение параметра limit"`
+	// Language — язык сообщений об ошибках, если клиент не указал в Accept-Language ни одного поддерживаемого
+	Language string `config:"language" env:"API_LANGUAGE" usage:"язык ответов по умолчанию: ru или en"`
+	// Validation — проверка по спецификации OpenAPI: none, requests (отклонять некорректные запросы)
+	// или all (ещё и писать в лог ответы, расходящиеся со спецификацией)
+	Validation string `config:"validation" env:"API_VALIDATION" usage:"проверка по OpenAPI: none, requests или all"`
+}
+
+// Auth — аутентификация клиентов
+type Auth struct {
+	// Disabled отключает аутентификацию и проверку прав — например, для локальной разработки
+	Disabled bool `config:"disabled" env:"AUTH_DISABLED" usage:"отключить аутентификацию"`
+	JWT      JWT  `config:"jwt"`
+}
+
+// JWT — проверка токенов OIDC-провайдера; включается, если задан JWKS
+type JWT struct {
+	// JWKS — путь к файлу или URL набора ключей (jwks_uri)
+	JWKS string `config:"jwks" env:"JWT_JWKS" usage:"файл или URL набора ключей JWKS"`
+	// Issuer и Audience — ожидаемые iss и aud (пусто — не проверяются)
+	Issuer   string `config:"issuer" env:"JWT_ISSUER" usage:"ожидаемый iss"`
+	Audience string `config:"audience" env:"JWT_AUDIENCE" usage:"ожидаемый aud"`
+	// TenantClaim, ScopesClaim, TypesClaim — имена claims с арендатором, правами и типами событий
+	TenantClaim string `config:"tenant_claim" env:"JWT_TENANT_CLAIM" usage:"claim с арендатором"`
+	ScopesClaim string `config:"scopes_claim" env:"JWT_SCOPES_CLAIM" usage:"claim с правами"`
+	TypesClaim  string `config:"types_claim" env:"JWT_TYPES_CLAIM" usage:"claim с шаблонами типов событий"`
+	// CacheTTL — сколько кешировать набор ключей
+	CacheTTL time.Duration `config:"cache_ttl" env:"JWT_JWKS_CACHE_TTL" usage:"время кеширования JWKS"`
+	// Leeway — допустимое расхождение часов
+	Leeway time.Duration `config:"leeway" env:"JWT_LEEWAY" usage:"допустимое расхождение часов"`
+}
+
+// Tenants — определение и размещение арендаторов
+type Tenants struct {
+	// Header — заголовок с идентификатором арендатора
+	Header string `config:"header" env:"TENANT_HEADER" usage:"заголовок с арендатором"`
+	// Required — отклонять запросы без арендатора вместо арендатора default
+	Required bool `config:"required" env:"TENANT_REQUIRED" usage:"требовать арендатора в каждом запросе"`
+	// Placement — размещение данных: shared, collection или database
+	Placement string `config:"placement" env:"TENANT_PLACEMENT" usage:"размещение данных: shared, collection или database"`
+}
+
+// EventTypes — реестр и правило именования типов событий
+type EventTypes struct {
+	// Strict — разрешать запуск только зарегистрированных типов
+	Strict bool `config:"strict" env:"EVENT_TYPES_STRICT" usage:"разрешить только зарегистрированные типы"`
+	// Pattern — регулярное выражение для каждой части имени
+	Pattern string `config:"pattern" env:"EVENT_TYPE_PATTERN" usage:"регулярное выражение для частей имени типа"`
+	// MaxLength — максимальная длина имени (0 — без ограничения)
+	MaxLength int `config:"max_length" env:"EVENT_TYPE_MAX_LENGTH" usage:"максимальная длина имени типа"`
+	// Separators — разделители пространств имён, например "." или ".-"
+	Separators string `config:"separators" env:"EVENT_TYPE_SEPARATORS" usage:"разделители пространств имён"`
+}
+
+// RateLimits — лимиты запросов и суточные квоты
+type RateLimits struct {
+	// Rules — лимиты по маршрутам, например "*=100/m, POST /v1/start=10/s:20"
+	Rules string `config:"rules" env:"RATE_LIMITS" usage:"лимиты по маршрутам"`
+	// Quotas — суточные квоты на создание событий, например "*=10000, acme=50000"
+	Quotas string `config:"quotas" env:"EVENT_QUOTAS_DAILY" usage:"суточные квоты арендаторов"`
+	// Store — где хранить состояние: memory (один экземпляр) или mongo (общее для реплик)
+	Store string `config:"store" env:"RATE_LIMIT_STORE" usage:"хранилище лимитов: memory или mongo"`
+}
+
+// Retention — сроки хранения завершённых событий и их архивация
+// Срок типа из реестра (retentionDays) важнее общего срока Days
+type Retention struct {
+	// Days — сколько дней хранить завершённые события типов без собственного срока (0 — хранить всегда)
+	Days int `config:"days" env:"RETENTION_DAYS" usage:"общий срок хранения завершённых событий в днях (0 — всегда)"`
+	// Mode — что делать с событиями, срок которых истёк: archive (перенести в архив) или delete (удалить)
+	Mode string `config:"mode" env:"RETENTION_MODE" usage:"по истечении срока: archive или delete"`
+	// Interval — как часто проверять сроки хранения (0 — фоновая задача выключена)
+	Interval time.Duration `config:"interval" env:"RETENTION_INTERVAL" usage:"период проверки сроков хранения (0 — не проверять)"`
+	// BatchSize — сколько событий переносится за один шаг
+	BatchSize int `config:"batch_size" env:"RETENTION_BATCH_SIZE" usage:"событий за один шаг архивации"`
+	// Archive — где хранится архив: collection (collections.archive) или files (сжатые NDJSON-файлы в Dir)
+	Archive string `config:"archive" env:"RETENTION_ARCHIVE" usage:"хранилище архива: collection или files"`
+	// Dir — папка архивных файлов (для archive: files)
+	Dir string `config:"dir" env:"RETENTION_DIR" usage:"папка архивных файлов"`
+}
+
+// Health — проверки состояния (/readyz и /health)
+type Health struct {
+	// CheckTimeout — сколько ждать ответа каждого компонента (например, ping MongoDB)
+	CheckTimeout time.Duration `config:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" usage:"таймаут проверки компонента"`
+}
+
+// Tracing — трассировка запросов OpenTelemetry
+type Tracing struct {
+	// Exporter — куда отправлять спаны: none (трассировка выключена), stdout или otlp
+	Exporter string `config:"exporter" env:"TRACING_EXPORTER" usage:"экспорт спанов: none, stdout или otlp"`
+	// Endpoint — адрес OTLP/HTTP-коллектора (host:port)
+	Endpoint string `config:"endpoint" env:"TRACING_OTLP_ENDPOINT" usage:"адрес OTLP/HTTP-коллектора"`
+	// Insecure — подключаться к коллектору по HTTP без TLS
+	Insecure bool `config:"insecure" env:"TRACING_OTLP_INSECURE" usage:"подключаться к коллектору без TLS"`
+	// ServiceName — имя сервиса в спанах (service.name)
+	ServiceName string `config:"service_name" env:"TRACING_SERVICE_NAME" usage:"имя сервиса в трассировке"`
+}
+
+// Log — логи сервиса
+type Log struct {
+	// Level — минимальный уровень записей: debug, info, warn или error
+	Level string `config:"level" env:"LOG_LEVEL" usage:"уровень логов: debug, info, warn или error"`
+	// Format — json (строка JSON на каждую запись) или text (key=value, удобнее читать в консоли)
+	Format string `config:"format" env:"LOG_FORMAT" usage:"формат логов: json или text"`
+}
+
+// Default возвращает настройки по умолчанию — с ними сервис работает как раньше,
+// когда все значения были зашиты в код
+func Default() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       time.Minute,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		GRPC:    GRPC{WatchBuffer: event.DefaultFeedBuffer},
+		GraphQL: GraphQL{Enabled: true, ComplexityLimit: graphqlapi.DefaultComplexityLimit},
+		Mongo: Mongo{
+			Database:          "events_db",
+			ConnectTimeout:    10 * time.Second,
+			SetupTimeout:      10 * time.Second,
+			DisconnectTimeout: 5 * time.Second,
+			EmbeddedPort:      27017,
+		},
+		Collections: Collections{
+			Events:     "events",
+			Types:      "event_types",
+			APIKeys:    "api_keys",
+			Audit:      "audit_log",
+			RateLimits: "rate_limits",
+			// Не events_archive: при tenants.placement=collection это коллекция событий арендатора archive
+			Archive:     "archive_events",
+			AuditOutbox: "audit_outbox",
+		},
+		API: API{MaxPageSize: 100, Language: i18n.Russian, Validation: openapi.ValidateNone},
+		Auth: Auth{JWT: JWT{
+			TenantClaim: auth.DefaultTenantClaim,
+			ScopesClaim: auth.DefaultScopesClaim,
+			TypesClaim:  auth.DefaultTypesClaim,
+			CacheTTL:    auth.DefaultJWKSCacheTTL,
+		}},
+		Tenants: Tenants{
+			Header:    tenant.DefaultHeader,
+			Placement: string(tenant.PlacementShared),
+		},
+		EventTypes: EventTypes{Pattern: event.DefaultTypePattern},
+		RateLimits: RateLimits{Store: "memory"},
+		Retention: Retention{
+			Mode:      string(event.RetentionArchive),
+			Interval:  time.Hour,
+			BatchSize: event.DefaultRetentionBatchSize,
+			Archive:   "collection",
+			Dir:       "archive",
+		},
+		Health: Health{CheckTimeout: 2 * time.Second},
+		Tracing: Tracing{
+			Exporter:    tracing.ExporterNone,
+			Endpoint:    "localhost:4318",
+			ServiceName: "event-service",
+		},
+		Log: Log{Level: "info", Format: logging.FormatJSON},
+	}
+}
+
+// Validate проверяет настройки и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+	parses := func(key string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.HTTP.Addr)
+	check(err == nil, "http.addr", "ожидается адрес вида host:port или :port, получено %q", c.HTTP.Addr)
+
+	if c.GRPC.Addr != "" {
+		_, _, err := net.SplitHostPort(c.GRPC.Addr)
+		check(err == nil, "grpc.addr", "ожидается адрес вида host:port или :port, получено %q", c.GRPC.Addr)
+		check(c.GRPC.Addr != c.HTTP.Addr, "grpc.addr", "должен отличаться от http.addr")
+	}
+	check(c.GRPC.WatchBuffer > 0, "grpc.watch_buffer", "должен быть положительным")
+	check(c.GraphQL.ComplexityLimit > 0, "graphql.complexity_limit", "должен быть положительным")
+
+	for key, d := range map[string]time.Duration{
+		"http.read_header_timeout": c.HTTP.ReadHeaderTimeout,
+		"http.read_timeout":        c.HTTP.ReadTimeout,
+		"http.write_timeout":       c.HTTP.WriteTimeout,
+		"http.idle_timeout":        c.HTTP.IdleTimeout,
+		"http.shutdown_delay":      c.HTTP.ShutdownDelay,
+	} {
+		check(d >= 0, key, "не может быть отрицательным")
+	}
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout", "должен быть положительным")
+	for _, proxy := range strings.Split(c.HTTP.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		_, errAddr := netip.ParseAddr(proxy)
+		_, errPrefix := netip.ParsePrefix(proxy)
+		check(errAddr == nil || errPrefix == nil, "http.trusted_proxies", "ожидается IP-адрес или подсеть CIDR, получено %q", proxy)
+	}
+
+	check(validDatabaseName(c.Mongo.Database), "mongo.database", "недопустимое имя базы данных %q", c.Mongo.Database)
+	check(c.Mongo.ConnectTimeout > 0, "mongo.connect_timeout", "должен быть положительным")
+	check(c.Mongo.SetupTimeout > 0, "mongo.setup_timeout", "должен быть положительным")
+	check(c.Mongo.DisconnectTimeout > 0, "mongo.disconnect_timeout", "должен быть положительным")
+	check(c.Mongo.EmbeddedPort > 0 && c.Mongo.EmbeddedPort <= 65535, "mongo.embedded_port", "ожидается порт от 1 до 65535")
+
+	keys, names := c.Collections.keys(), c.Collections.Names()
+	for i, name := range names {
+		check(validCollectionName(name), keys[i], "недопустимое имя коллекции %q", name)
+		// При размещении collection коллекции арендаторов называются <имя>_<арендатор>, поэтому
+		// имя вида events_archive нельзя отличить от коллекции событий арендатора archive
+		for j, other := range names {
+			check(i == j || (name != other && !strings.HasPrefix(name, other+"_")), keys[i],
+				"имя коллекции %q совпадает с %s или начинается с %q", name, keys[j], other+"_")
+		}
+	}
+
+	check(c.API.MaxPageSize > 0, "api.max_page_size", "должен быть положительным")
+	parses("api.language", i18n.ValidateLanguage(c.API.Language))
+	parses("api.validation", openapi.ValidateMode(c.API.Validation))
+
+	check(c.Auth.JWT.CacheTTL >= 0, "auth.jwt.cache_ttl", "не может быть отрицательным")
+	check(c.Auth.JWT.Leeway >= 0, "auth.jwt.leeway", "не может быть отрицательным")
+
+	check(c.Tenants.Header != "", "tenants.header", "не может быть пустым")
+	_, err = tenant.ParsePlacement(c.Tenants.Placement)
+	parses("tenants.placement", err)
+
+	check(c.EventTypes.MaxLength >= 0, "event_types.max_length", "не может быть отрицательной")
+	_, err = event.NewNamingRule(c.EventTypes.Pattern, c.EventTypes.MaxLength, c.EventTypes.Separators)
+	parses("event_types", err)
+
+	_, err = ratelimit.ParseRules(c.RateLimits.Rules)
+	parses("rate_limits.rules", err)
+	_, err = ratelimit.ParseQuotas(c.RateLimits.Quotas)
+	parses("rate_limits.quotas", err)
+	check(c.RateLimits.Store == "memory" || c.RateLimits.Store == "mongo", "rate_limits.store", "ожидается memory или mongo, получено %q", c.RateLimits.Store)
+
+	check(c.Retention.Days >= 0, "retention.days", "не может быть отрицательным")
+	_, err = event.ParseRetentionMode(c.Retention.Mode)
+	parses("retention.mode", err)
+	check(c.Retention.Interval >= 0, "retention.interval", "не может быть отрицательным")
+	check(c.Retention.BatchSize > 0, "retention.batch_size", "должен быть положительным")
+	check(c.Retention.Archive == "collection" || c.Retention.Archive == "files", "retention.archive", "ожидается collection или files, получено %q", c.Retention.Archive)
+	check(c.Retention.Archive != "files" || c.Retention.Dir != "", "retention.dir", "обязательна для архива в файлах")
+
+	check(c.Health.CheckTimeout > 0, "health.check_timeout", "должен быть положительным")
+
+	parses("tracing.exporter", tracing.ValidateExporter(c.Tracing.Exporter))
+	check(c.Tracing.Exporter != tracing.ExporterOTLP || c.Tracing.Endpoint != "", "tracing.endpoint", "обязателен для экспорта otlp")
+	check(c.Tracing.ServiceName != "", "tracing.service_name", "не может быть пустым")
+
+	_, err = logging.ParseLevel(c.Log.Level)
+	parses("log.level", err)
+	parses("log.format", logging.ValidateFormat(c.Log.Format))
+
+	return errors.Join(errs...)
+}
+
+// validDatabaseName проверяет имя базы по правилам MongoDB
+func validDatabaseName(name string) bool {
+	return name != "" && len(name) < 64 && !strings.ContainsAny(name, "/\\. \"$\x00")
+}
+
+// validCollectionName проверяет имя коллекции по правилам MongoDB
+func validCollectionName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "$\x00") && !strings.HasPrefix(name, "system.")
+}
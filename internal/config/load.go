@@ -0,0 +1,294 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// FileEnv — переменная окружения с путём к файлу конфигурации (если не указан флаг -config)
+const FileEnv = "CONFIG_FILE"
+
+// redacted заменяет секреты при выводе настроек
+const redacted = "******"
+
+// param — один параметр конфигурации и его имена во всех источниках
+type param struct {
+	// key — ключ в файле, например mongo.connect_timeout
+	key string
+	// env и flag — имя переменной окружения и флага
+	env  string
+	flag string
+	// usage — описание для справки по флагам
+	usage string
+	// secret — значение скрывается при выводе настроек
+	secret bool
+	// value — поле в Config
+	value reflect.Value
+}
+
+// params перечисляет все параметры конфигурации в порядке объявления полей
+func (c *Config) params() []param {
+	var params []param
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			key := prefix + field.Tag.Get("config")
+			if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			params = append(params, param{
+				key:    key,
+				env:    field.Tag.Get("env"),
+				flag:   strings.NewReplacer(".", "-", "_", "-").Replace(key),
+				usage:  field.Tag.Get("usage"),
+				secret: field.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+	return params
+}
+
+// Load регистрирует в fs флаги всех параметров и флаг -config, разбирает args
+// и собирает настройки: значения по умолчанию, затем файл (-config или CONFIG_FILE),
+// затем непустые переменные окружения, затем явно указанные флаги
+// Подкоманды передают свой FlagSet, чтобы рядом с параметрами конфигурации были их собственные флаги
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	file := fs.String("config", "", "файл конфигурации YAML или TOML (по умолчанию из "+FileEnv+")")
+	flags := map[string]string{}
+	for _, p := range Default().params() {
+		fs.Var(&flagValue{
+			key:    p.key,
+			values: flags,
+			def:    formatValue(p.value),
+			isBool: p.value.Kind() == reflect.Bool,
+		}, p.flag, p.usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	params := map[string]param{}
+	for _, p := range cfg.params() {
+		params[p.key] = p
+	}
+
+	path := *file
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range values {
+			p, ok := params[key]
+			if !ok {
+				return nil, fmt.Errorf("%s: неизвестный параметр %q", path, key)
+			}
+			if err := setValue(p.value, value); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+		}
+	}
+
+	for _, p := range params {
+		// Пустая переменная считается незаданной — как и до появления файла конфигурации
+		if value := os.Getenv(p.env); value != "" {
+			if err := setValue(p.value, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", p.env, err)
+			}
+		}
+	}
+
+	for key, value := range flags {
+		p := params[key]
+		if err := setValue(p.value, value); err != nil {
+			return nil, fmt.Errorf("-%s: %w", p.flag, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("некорректные настройки:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// flagValue запоминает значение флага, чтобы применить его после файла и окружения
+type flagValue struct {
+	key    string
+	values map[string]string
+	def    string
+	isBool bool
+}
+
+func (f *flagValue) String() string   { return f.def }
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+func (f *flagValue) Set(s string) error {
+	f.values[f.key] = s
+	return nil
+}
+
+// readFile читает файл конфигурации; формат определяется по расширению (.yaml, .yml или .toml)
+// Возвращает значения по полным ключам вида mongo.database
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("%s: неизвестный формат файла конфигурации (ожидается .yaml, .yml или .toml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten(tree, "", values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flatten раскладывает вложенные разделы файла в плоский список ключей через точку
+func flatten(tree map[string]interface{}, prefix string, values map[string]string) error {
+	for name, value := range tree {
+		key := prefix + name
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(v, key+".", values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%s: ожидается одно значение, а не список", key)
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// setValue записывает строковое значение в поле конфигурации нужного типа
+func setValue(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("ожидается длительность, например 10s или 5m, получено %q", s)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("ожидается true или false, получено %q", s)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число, получено %q", s)
+		}
+		v.SetInt(int64(n))
+	default:
+		return fmt.Errorf("неподдерживаемый тип параметра %s", v.Type())
+	}
+	return nil
+}
+
+// formatValue возвращает значение поля в том виде, в каком его можно указать в любом источнике
+func formatValue(v reflect.Value) string {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// WriteYAML выводит настройки в формате файла конфигурации; секреты скрываются
+// Вывод можно сохранить и использовать как файл конфигурации (заменив скрытые значения)
+func (c *Config) WriteYAML(w io.Writer) error {
+	var root yaml.MapSlice
+	for _, p := range c.params() {
+		var value interface{}
+		switch {
+		case p.secret:
+			value = redactSecret(p.value.String())
+		case p.value.Type() == reflect.TypeOf(time.Duration(0)):
+			value = formatValue(p.value)
+		default:
+			value = p.value.Interface()
+		}
+		root = insert(root, strings.Split(p.key, "."), value)
+	}
+
+	data, err := yaml.Marshal(root)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// insert добавляет значение во вложенные разделы, сохраняя порядок ключей
+func insert(section yaml.MapSlice, path []string, value interface{}) yaml.MapSlice {
+	if len(path) == 1 {
+		return append(section, yaml.MapItem{Key: path[0], Value: value})
+	}
+	for i, item := range section {
+		if item.Key == path[0] {
+			section[i].Value = insert(item.Value.(yaml.MapSlice), path[1:], value)
+			return section
+		}
+	}
+	return append(section, yaml.MapItem{Key: path[0], Value: insert(nil, path[1:], value)})
+}
+
+// redactSecret скрывает секрет: у URL — только пароль, остальное — целиком
+func redactSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return redacted
+	}
+	if u.RawQuery != "" {
+		// В параметрах подключения тоже бывают пароли (например, tlsCertificateKeyFilePassword)
+		u.RawQuery = redacted
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			// url экранирует звёздочки в пароле, поэтому подставляем их после сборки строки
+			u.User = url.UserPassword(u.User.Username(), "")
+			return strings.Replace(u.String(), ":@", ":"+redacted+"@", 1)
+		}
+	}
+	return u.String()
+}
@@ -3,20 +3,30 @@ package db
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"time"
 
 	"event-service/embedded"
 )
 
-// StartEmbeddedMongo запускает встроенный MongoDB и возвращает URI для подключения
+// DefaultPort — стандартный порт MongoDB, на котором по умолчанию запускается встроенный сервер
+const DefaultPort = 27017
+
+// StartEmbeddedMongo запускает встроенный MongoDB на стандартном порту и возвращает URI для подключения
 // Также возвращает функцию cleanup для корректного завершения процесса
 // Временная папка с данными будет автоматически удалена при вызове cleanup
 func StartEmbeddedMongo() (uri string, cleanup func(), err error) {
+	return StartEmbeddedMongoOnPort(DefaultPort)
+}
+
+// StartEmbeddedMongoOnPort запускает встроенный MongoDB на порту port
+// Работает так же, как StartEmbeddedMongo
+func StartEmbeddedMongoOnPort(port int) (uri string, cleanup func(), err error) {
 	// Создаём временную папку для хранения данных MongoDB
 	// Эта папка будет удалена при завершении работы приложения
 	tempDir, err := os.MkdirTemp("", "embedded-mongo-*")
@@ -78,13 +88,13 @@ func StartEmbeddedMongo() (uri string, cleanup func(), err error) {
 
 	// Запускаем MongoDB с нужными параметрами
 	// --dbpath: где хранить данные
-	// --port: на каком порту слушать (27017 — стандартный порт MongoDB)
+	// --port: на каком порту слушать (по умолчанию 27017 — стандартный порт MongoDB)
 	// --bind_ip: только локальные подключения (127.0.0.1)
 	// --quiet: меньше логов
 	// --nojournal: отключаем journal для быстрого старта (для dev окружения)
 	cmd := exec.Command(mongodPath,
 		"--dbpath", dbPath,
-		"--port", "27017",
+		"--port", strconv.Itoa(port),
 		"--bind_ip", "127.0.0.1",
 		"--quiet",
 		"--nojournal",
@@ -103,7 +113,7 @@ func StartEmbeddedMongo() (uri string, cleanup func(), err error) {
 
 	// Функция для корректного завершения работы MongoDB
 	cleanupFunc := func() {
-		log.Println("Останавливаем встроенный MongoDB...")
+		slog.Info("Останавливаем встроенный MongoDB")
 
 		// Завершаем процесс MongoDB
 		// Используем Kill вместо Wait, чтобы точно убить процесс
@@ -115,15 +125,15 @@ func StartEmbeddedMongo() (uri string, cleanup func(), err error) {
 		// Удаляем временную папку со всеми данными
 		err := os.RemoveAll(tempDir)
 		if err != nil {
-			log.Printf("Не удалось удалить временную папку %s: %v", tempDir, err)
+			slog.Warn("Не удалось удалить временную папку встроенного MongoDB", "dir", tempDir, "error", err)
 		} else {
-			log.Println("Встроенный MongoDB остановлен, временные файлы удалены")
+			slog.Info("Встроенный MongoDB остановлен, временные файлы удалены")
 		}
 	}
 
 	// Ждём, пока MongoDB запустится и будет готов к работе
 	// Даём ему до 10 секунд на запуск
-	log.Println("Ожидание запуска встроенного MongoDB...")
+	slog.Info("Ожидание запуска встроенного MongoDB")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -137,10 +147,11 @@ func StartEmbeddedMongo() (uri string, cleanup func(), err error) {
 		return "", nil, fmt.Errorf("таймаут ожидания запуска MongoDB")
 	}
 
-	log.Println("Встроенный MongoDB запущен на порту :27017")
+	slog.Info("Встроенный MongoDB запущен", "port", port)
 
 	// Возвращаем URI для подключения и функцию очистки
-	uri = "mongodb://localhost:27017/events_db"
+	// Имя базы в URI не указываем: сервис берёт его из своих настроек
+	uri = fmt.Sprintf("mongodb://localhost:%d", port)
 	return uri, cleanupFunc, nil
 }
 
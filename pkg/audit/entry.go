@@ -0,0 +1,120 @@
+// Package audit ведёт неизменяемый журнал изменений событий
+// Записи каждого арендатора образуют цепочку: хеш записи включает хеш предыдущей,
+// поэтому изменение или удаление любой записи обнаруживается проверкой цепочки
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Action — вид изменения
+type Action string
+
+const (
+	// ActionStart — событие запущено
+	ActionStart Action = "start"
+	// ActionFinish — событие завершено (в том числе каскадно вместе с родителем)
+	ActionFinish Action = "finish"
+	// ActionImport — событие загружено импортом
+	ActionImport Action = "import"
+	// ActionUpdate — изменены атрибуты события
+	ActionUpdate Action = "update"
+	// ActionDelete — событие удалено
+	ActionDelete Action = "delete"
+	// ActionArchive — срок хранения события истёк, и оно перенесено в архив
+	ActionArchive Action = "archive"
+	// ActionExpire — срок хранения события истёк, и оно удалено без архивации
+	ActionExpire Action = "expire"
+	// ActionRestore — событие восстановлено из архива
+	ActionRestore Action = "restore"
+)
+
+// Record — изменение, которое нужно записать в журнал
+// Кто и откуда выполнил изменение, журнал узнаёт из контекста запроса
+type Record struct {
+	// Action — вид изменения
+	Action Action
+	// EventID и EventType — изменённое событие
+	EventID   primitive.ObjectID
+	EventType string
+	// Before и After — состояние события до и после изменения (nil — события не было)
+	// Сохраняются в виде JSON
+	Before interface{}
+	After  interface{}
+}
+
+// Entry — запись журнала аудита
+type Entry struct {
+	// ID — идентификатор записи в базе данных
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	// TenantID — арендатор; у каждого арендатора своя цепочка записей
+	TenantID string `bson:"tenant_id" json:"-"`
+	// Seq — номер записи в цепочке арендатора, начиная с 1
+	Seq int64 `bson:"seq" json:"seq"`
+	// Action — вид изменения
+	Action Action `bson:"action" json:"action"`
+	// EventID и EventType — изменённое событие
+	EventID   primitive.ObjectID `bson:"event_id" json:"eventId"`
+	EventType string             `bson:"event_type" json:"eventType"`
+	// Actor — кто выполнил изменение: subject токена или key:<имя ключа> (пусто без аутентификации)
+	Actor string `bson:"actor,omitempty" json:"actor,omitempty"`
+	// IP — адрес клиента (пусто, если изменение сделано не через HTTP)
+	IP string `bson:"ip,omitempty" json:"ip,omitempty"`
+	// At — время изменения с точностью до миллисекунды (как хранит MongoDB)
+	At time.Time `bson:"at" json:"at"`
+	// Before и After — JSON-снимки события до и после изменения
+	// Хранятся как исходные байты, чтобы хеш не зависел от преобразований BSON
+	Before json.RawMessage `bson:"before,omitempty" json:"before,omitempty"`
+	After  json.RawMessage `bson:"after,omitempty" json:"after,omitempty"`
+	// PrevHash — хеш предыдущей записи цепочки (пусто у первой записи)
+	PrevHash string `bson:"prev_hash" json:"prevHash"`
+	// Hash — SHA-256 содержимого записи вместе с PrevHash
+	Hash string `bson:"hash" json:"hash"`
+}
+
+// computeHash считает хеш записи по всем полям, кроме ID и самого Hash
+// Поля сериализуются массивом строк JSON — так границы полей однозначны
+func (e *Entry) computeHash() string {
+	fields := []string{
+		strconv.FormatInt(e.Seq, 10),
+		e.TenantID,
+		string(e.Action),
+		e.EventID.Hex(),
+		e.EventType,
+		e.Actor,
+		e.IP,
+		e.At.UTC().Format(time.RFC3339Nano),
+		digest(e.Before),
+		digest(e.After),
+		e.PrevHash,
+	}
+	data, _ := json.Marshal(fields)
+	return digest(data)
+}
+
+// digest возвращает SHA-256 данных в шестнадцатеричном виде
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// contextKey — ключ для хранения IP-адреса клиента в context.Context
+type contextKey struct{}
+
+// WithClientIP возвращает контекст с IP-адресом клиента для записей журнала
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// ClientIP возвращает IP-адрес клиента из контекста или пустую строку
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(contextKey{}).(string)
+	return ip
+}
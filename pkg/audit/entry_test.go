@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testEntry() Entry {
+	return Entry{
+		TenantID:  "acme",
+		Seq:       1,
+		Action:    ActionStart,
+		EventID:   primitive.NewObjectID(),
+		EventType: "meeting",
+		Actor:     "alice",
+		IP:        "192.0.2.1",
+		At:        time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		After:     json.RawMessage(`{"type":"meeting"}`),
+	}
+}
+
+func TestEntry_ComputeHash(t *testing.T) {
+	entry := testEntry()
+	hash := entry.computeHash()
+	if len(hash) != 64 {
+		t.Fatalf("Expected a SHA-256 hex digest, got %q", hash)
+	}
+
+	// Время в другой зоне — то же мгновение, хеш не меняется
+	same := entry
+	same.At = entry.At.In(time.FixedZone("MSK", 3*60*60))
+	if same.computeHash() != hash {
+		t.Error("Hash should not depend on the time zone")
+	}
+
+	changes := map[string]func(*Entry){
+		"seq":       func(e *Entry) { e.Seq = 2 },
+		"tenant":    func(e *Entry) { e.TenantID = "globex" },
+		"action":    func(e *Entry) { e.Action = ActionFinish },
+		"eventId":   func(e *Entry) { e.EventID = primitive.NewObjectID() },
+		"eventType": func(e *Entry) { e.EventType = "call" },
+		"actor":     func(e *Entry) { e.Actor = "mallory" },
+		"ip":        func(e *Entry) { e.IP = "198.51.100.1" },
+		"at":        func(e *Entry) { e.At = e.At.Add(time.Millisecond) },
+		"before":    func(e *Entry) { e.Before = json.RawMessage(`{}`) },
+		"after":     func(e *Entry) { e.After = json.RawMessage(`{"type":"call"}`) },
+		"prevHash":  func(e *Entry) { e.PrevHash = "abc" },
+	}
+	for name, change := range changes {
+		changed := testEntry()
+		changed.EventID = entry.EventID
+		change(&changed)
+		if changed.computeHash() == hash {
+			t.Errorf("Changing %s should change the hash", name)
+		}
+	}
+
+	// Границы полей однозначны: перенос символов между соседними полями меняет хеш
+	a, b := testEntry(), testEntry()
+	b.EventID = a.EventID
+	a.Actor, a.IP = "ab", "c"
+	b.Actor, b.IP = "a", "bc"
+	if a.computeHash() == b.computeHash() {
+		t.Error("Field boundaries should be part of the hash")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	if ip := ClientIP(context.Background()); ip != "" {
+		t.Errorf("Expected empty IP, got %q", ip)
+	}
+	if ip := ClientIP(WithClientIP(context.Background(), "192.0.2.1")); ip != "192.0.2.1" {
+		t.Errorf("Expected 192.0.2.1, got %q", ip)
+	}
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-service/pkg/problem"
+	"event-service/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Сбои чтения журнала
+var (
+	problemListFailed   = problem.New(http.StatusInternalServerError, "audit_list_failed")
+	problemVerifyFailed = problem.New(http.StatusInternalServerError, "audit_verify_failed")
+)
+
+// Middleware запоминает IP-адрес клиента в контексте запроса,
+// чтобы записи журнала знали, откуда пришло изменение
+// Адрес берётся из c.ClientIP(), поэтому роутер должен доверять заголовкам прокси только от известных адресов
+// (gin.Engine.SetTrustedProxies) — иначе клиент запишет в журнал любой IP через X-Forwarded-For
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithClientIP(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
+
+// Handler обрабатывает HTTP-запросы к журналу аудита (/v1/audit)
+type Handler struct {
+	// log — журнал аудита
+	log *Log
+	// maxLimit — наибольшее значение параметра limit, оно же размер страницы по умолчанию
+	maxLimit int
+}
+
+// NewHandler создаёт обработчик запросов к журналу аудита
+func NewHandler(log *Log, maxLimit int) *Handler {
+	return &Handler{log: log, maxLimit: maxLimit}
+}
+
+// List возвращает записи журнала арендатора, новые первыми
+// Фильтры в query: eventId, type, action, actor, from и to (RFC 3339), offset и limit (до maxLimit)
+func (h *Handler) List(c *gin.Context) {
+	var filter Filter
+
+	if s := c.Query("eventId"); s != "" {
+		id, err := primitive.ObjectIDFromHex(s)
+		if err != nil {
+			problem.Respond(c, problem.InvalidParameter("eventId", "expected.event_id"))
+			return
+		}
+		filter.EventID = &id
+	}
+	filter.EventType = c.Query("type")
+	filter.Action = Action(c.Query("action"))
+	filter.Actor = c.Query("actor")
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if s := c.Query(name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				problem.Respond(c, problem.InvalidParameter(name, "expected.timestamp"))
+				return
+			}
+			*target = &t
+		}
+	}
+
+	if s := c.Query("offset"); s != "" {
+		offset, err := strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			problem.Respond(c, problem.InvalidParameter("offset", "expected.non_negative"))
+			return
+		}
+		filter.Offset = offset
+	}
+	filter.Limit = h.maxLimit
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > h.maxLimit {
+			problem.Respond(c, problem.InvalidParameter("limit", "expected.range", 1, h.maxLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.log.Find(c.Request.Context(), filter)
+	if err != nil {
+		problem.Internal(c, err, problemListFailed)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// Verify проверяет цепочку записей арендатора и возвращает отчёт
+func (h *Handler) Verify(c *gin.Context) {
+	report, err := h.log.Verify(c.Request.Context(), tenant.ID(c.Request.Context()))
+	if err != nil {
+		problem.Internal(c, err, problemVerifyFailed)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
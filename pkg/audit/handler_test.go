@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"event-service/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	var ip string
+	router.GET("/", func(c *gin.Context) {
+		ip = ClientIP(c.Request.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:12345"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if ip != "192.0.2.1" {
+		t.Errorf("Expected client IP 192.0.2.1, got %q", ip)
+	}
+}
+
+func TestHandler_List_InvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Журнал не нужен: запросы отклоняются до обращения к базе
+	router.GET("/audit", NewHandler(nil, 100).List)
+
+	for _, query := range []string{
+		"eventId=123",
+		"from=yesterday",
+		"to=2024-01-01",
+		"offset=-1",
+		"limit=0",
+		"limit=101",
+		"limit=abc",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auditLog, cleanup := setupTestLog(t)
+	defer cleanup()
+
+	ctx := requestContext("acme", "alice", "192.0.2.1")
+	id := primitive.NewObjectID()
+	auditLog.Append(ctx, Record{Action: ActionStart, EventID: id, EventType: "meeting"})
+	auditLog.Append(ctx, Record{Action: ActionFinish, EventID: id, EventType: "meeting"})
+
+	handler := NewHandler(auditLog, 100)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(tenant.WithTenant(context.Background(), "acme"))
+	})
+	router.GET("/audit", handler.List)
+	router.GET("/audit/verify", handler.Verify)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit?action=finish&eventId="+id.Hex(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var entries []Entry
+	json.Unmarshal(w.Body.Bytes(), &entries)
+	if len(entries) != 1 || entries[0].Seq != 2 || entries[0].Actor != "alice" {
+		t.Errorf("Unexpected entries: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/verify", nil))
+	var report VerifyReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if w.Code != http.StatusOK || !report.Valid || report.Entries != 2 || report.TenantID != "acme" {
+		t.Errorf("Unexpected report: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,302 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxAppendAttempts — сколько раз Append повторяет запись, если другой экземпляр
+// сервиса успел занять следующий номер в цепочке
+const maxAppendAttempts = 10
+
+// appendBackoff и maxAppendBackoff — пауза перед повтором после конфликта номеров: она удваивается
+// с каждой попыткой до maxAppendBackoff, а из неё берётся случайная доля, чтобы экземпляры,
+// столкнувшиеся на одном номере, не сталкивались снова на следующем
+const (
+	appendBackoff    = 5 * time.Millisecond
+	maxAppendBackoff = 500 * time.Millisecond
+)
+
+// ErrAppendConflict возвращается, если записать в журнал не удалось из-за конкурентных записей
+var ErrAppendConflict = errors.New("не удалось записать в журнал аудита: слишком много одновременных записей")
+
+// Log — журнал аудита в MongoDB
+// Записи всех арендаторов лежат в одной коллекции; у каждого арендатора своя цепочка.
+// Журнал только дополняется: методов изменения и удаления записей нет
+// Записи, которые не удалось добавить в цепочку, откладываются в outbox (см. SetOutbox) и добавляются Flush
+type Log struct {
+	// collection — коллекция с записями журнала
+	collection *mongo.Collection
+	// outbox — коллекция отложенных записей; nil — записи не откладываются
+	outbox *mongo.Collection
+	// locks — мьютексы цепочек арендаторов: записи одного экземпляра сервиса в цепочку идут по очереди,
+	// поэтому за номер спорят только разные экземпляры
+	locks sync.Map
+	// now — текущее время, подменяется в тестах
+	now func() time.Time
+}
+
+// NewLog создаёт журнал аудита в коллекции col
+func NewLog(col *mongo.Collection) *Log {
+	return &Log{collection: col, now: time.Now}
+}
+
+// SetOutbox подключает коллекцию отложенных записей
+// Если записи не удалось добавить в цепочку (база недоступна, слишком много конфликтов), Append
+// сохраняет их в outbox и не возвращает ошибку: изменение уже сделано, и запись о нём не должна
+// ни теряться, ни превращать успешный запрос в ошибку. Отложенные записи добавляет Flush (см. Run)
+func (l *Log) SetOutbox(col *mongo.Collection) {
+	l.outbox = col
+}
+
+// lock захватывает мьютекс цепочки арендатора и возвращает функцию, которая его отпускает
+func (l *Log) lock(tenantID string) func() {
+	mu, _ := l.locks.LoadOrStore(tenantID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// EnsureIndexes создаёт индексы журнала
+// Уникальный индекс по (tenant_id, seq) не даёт двум экземплярам сервиса
+// записать разные записи под одним номером — так цепочка остаётся линейной
+func (l *Log) EnsureIndexes(ctx context.Context) error {
+	_, err := l.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "event_id", Value: 1}}},
+		{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "at", Value: 1}}},
+	})
+	return err
+}
+
+// Append добавляет записи в конец цепочки арендатора из контекста
+// Кто выполнил изменение и с какого адреса, берётся из контекста (auth.Subject и ClientIP)
+func (l *Log) Append(ctx context.Context, records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	tenantID := tenant.ID(ctx)
+	actor := auth.Subject(ctx)
+	ip := ClientIP(ctx)
+	// MongoDB хранит время с точностью до миллисекунды — хешируем ровно то, что сохранится
+	at := l.now().UTC().Truncate(time.Millisecond)
+
+	pending := make([]Entry, len(records))
+	for i, r := range records {
+		before, err := snapshot(r.Before)
+		if err != nil {
+			return err
+		}
+		after, err := snapshot(r.After)
+		if err != nil {
+			return err
+		}
+		pending[i] = Entry{
+			TenantID:  tenantID,
+			Action:    r.Action,
+			EventID:   r.EventID,
+			EventType: r.EventType,
+			Actor:     actor,
+			IP:        ip,
+			At:        at,
+			Before:    before,
+			After:     after,
+		}
+	}
+
+	pending, err := l.appendEntries(ctx, tenantID, pending)
+	if err == nil || l.outbox == nil {
+		return err
+	}
+	if outboxErr := l.postpone(ctx, tenantID, pending, err); outboxErr != nil {
+		return errors.Join(err, outboxErr)
+	}
+	slog.WarnContext(ctx, "Записи аудита отложены: не удалось добавить их в журнал", "tenant", tenantID,
+		"records", len(pending), "error", err)
+	return nil
+}
+
+// appendEntries добавляет записи в конец цепочки арендатора tenantID
+// Внутри экземпляра записи в одну цепочку идут по очереди; если номер занял другой экземпляр,
+// конец цепочки перечитывается и запись повторяется после паузы (не больше maxAppendAttempts раз)
+// При ошибке возвращает записи, которые так и не попали в цепочку
+func (l *Log) appendEntries(ctx context.Context, tenantID string, pending []Entry) ([]Entry, error) {
+	unlock := l.lock(tenantID)
+	defer unlock()
+
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return pending, ctx.Err()
+			case <-time.After(backoff(attempt)):
+			}
+		}
+		inserted, err := l.insertChain(ctx, tenantID, pending)
+		pending = pending[inserted:]
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return pending, err
+		}
+		// Другой экземпляр занял номер — перечитываем конец цепочки и продолжаем с него
+	}
+	return pending, ErrAppendConflict
+}
+
+// backoff возвращает паузу перед попыткой attempt: случайную долю удваивающегося интервала
+func backoff(attempt int) time.Duration {
+	limit := min(appendBackoff<<(attempt-1), maxAppendBackoff)
+	return limit/2 + rand.N(limit/2+1)
+}
+
+// insertChain привязывает записи к текущему концу цепочки и вставляет их по порядку
+// Возвращает, сколько записей успело вставиться до ошибки
+func (l *Log) insertChain(ctx context.Context, tenantID string, entries []Entry) (int, error) {
+	head, err := l.head(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	seq, prev := int64(0), ""
+	if head != nil {
+		seq, prev = head.Seq, head.Hash
+	}
+
+	docs := make([]interface{}, len(entries))
+	for i := range entries {
+		seq++
+		entries[i].Seq = seq
+		entries[i].PrevHash = prev
+		entries[i].Hash = entries[i].computeHash()
+		prev = entries[i].Hash
+		docs[i] = entries[i]
+	}
+
+	_, err = l.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(true))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		// При упорядоченной вставке всё до первой ошибки уже записано
+		return bulkErr.WriteErrors[0].Index, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// head возвращает последнюю запись цепочки арендатора или nil, если цепочка пуста
+func (l *Log) head(ctx context.Context, tenantID string) (*Entry, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+	var entry Entry
+	err := l.collection.FindOne(ctx, bson.M{"tenant_id": tenantID}, opts).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// snapshot сериализует состояние события в JSON (nil — нет состояния)
+func snapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сохранить снимок события: %w", err)
+	}
+	return data, nil
+}
+
+// Filter — условия выборки записей журнала
+type Filter struct {
+	// EventID — только записи об этом событии (nil = любые)
+	EventID *primitive.ObjectID
+	// EventType — только записи о событиях этого типа (пусто = любые)
+	EventType string
+	// Action — только изменения этого вида (пусто = любые)
+	Action Action
+	// Actor — только изменения, выполненные этим клиентом (пусто = любые)
+	Actor string
+	// From и To — интервал времени [From, To) (nil = без границы)
+	From *time.Time
+	To   *time.Time
+	// Offset и Limit — постраничный вывод (Limit 0 = без ограничения)
+	Offset int
+	Limit  int
+}
+
+// Find возвращает записи журнала арендатора из контекста, новые первыми
+func (l *Log) Find(ctx context.Context, filter Filter) ([]Entry, error) {
+	query := bson.M{"tenant_id": tenant.ID(ctx)}
+	if filter.EventID != nil {
+		query["event_id"] = *filter.EventID
+	}
+	if filter.EventType != "" {
+		query["event_type"] = filter.EventType
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.From != nil || filter.To != nil {
+		at := bson.M{}
+		if filter.From != nil {
+			at["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			at["$lt"] = *filter.To
+		}
+		query["at"] = at
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: -1}}).SetSkip(int64(filter.Offset))
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := l.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []Entry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Tenants возвращает арендаторов, у которых есть записи в журнале
+func (l *Log) Tenants(ctx context.Context) ([]string, error) {
+	values, err := l.collection.Distinct(ctx, "tenant_id", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			tenants = append(tenants, s)
+		}
+	}
+	return tenants, nil
+}
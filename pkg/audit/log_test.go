@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"event-service/internal/db"
+	"event-service/pkg/auth"
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setupTestLog создаёт журнал аудита на встроенном MongoDB
+func setupTestLog(t *testing.T) (*Log, func()) {
+	t.Helper()
+
+	mongoURI, cleanupMongo, err := db.StartEmbeddedMongo()
+	if err != nil {
+		t.Fatalf("Не удалось запустить встроенный MongoDB: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		cleanupMongo()
+		t.Fatalf("Не удалось подключиться к MongoDB: %v", err)
+	}
+
+	collection := client.Database("events_test_db").Collection("audit_log")
+	collection.Drop(ctx)
+
+	auditLog := NewLog(collection)
+	if err := auditLog.EnsureIndexes(ctx); err != nil {
+		client.Disconnect(ctx)
+		cleanupMongo()
+		t.Fatalf("Не удалось создать индексы: %v", err)
+	}
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client.Disconnect(ctx)
+		cleanupMongo()
+	}
+	return auditLog, cleanup
+}
+
+// requestContext имитирует контекст HTTP-запроса клиента subject из арендатора tenantID
+func requestContext(tenantID, subject, ip string) context.Context {
+	ctx := tenant.WithTenant(context.Background(), tenantID)
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: subject, Tenant: tenantID})
+	return WithClientIP(ctx, ip)
+}
+
+func TestLog_AppendAndFind(t *testing.T) {
+	auditLog, cleanup := setupTestLog(t)
+	defer cleanup()
+
+	ctx := requestContext("acme", "alice", "192.0.2.1")
+	id := primitive.NewObjectID()
+	if err := auditLog.Append(ctx, Record{Action: ActionStart, EventID: id, EventType: "meeting", After: map[string]string{"state": "started"}}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := auditLog.Append(ctx,
+		Record{Action: ActionFinish, EventID: id, EventType: "meeting", Before: map[string]string{"state": "started"}, After: map[string]string{"state": "finished"}},
+		Record{Action: ActionStart, EventID: primitive.NewObjectID(), EventType: "call"},
+	); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	entries, err := auditLog.Find(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	// Новые записи первыми, каждая ссылается на предыдущую
+	for i, entry := range entries {
+		if entry.Seq != int64(3-i) {
+			t.Errorf("Expected seq %d, got %d", 3-i, entry.Seq)
+		}
+		if i+1 < len(entries) && entry.PrevHash != entries[i+1].Hash {
+			t.Errorf("Entry %d is not linked to the previous one", entry.Seq)
+		}
+	}
+	if first := entries[2]; first.PrevHash != "" || first.Actor != "alice" || first.IP != "192.0.2.1" || string(first.After) != `{"state":"started"}` {
+		t.Errorf("Unexpected first entry: %+v", first)
+	}
+
+	if entries, _ := auditLog.Find(ctx, Filter{EventID: &id}); len(entries) != 2 {
+		t.Errorf("Expected 2 entries for the event, got %d", len(entries))
+	}
+	if entries, _ := auditLog.Find(ctx, Filter{Action: ActionFinish}); len(entries) != 1 {
+		t.Errorf("Expected 1 finish entry, got %d", len(entries))
+	}
+	if entries, _ := auditLog.Find(ctx, Filter{EventType: "call", Actor: "alice"}); len(entries) != 1 {
+		t.Errorf("Expected 1 call entry, got %d", len(entries))
+	}
+	future := time.Now().Add(time.Hour)
+	if entries, _ := auditLog.Find(ctx, Filter{From: &future}); len(entries) != 0 {
+		t.Errorf("Expected no entries from the future, got %d", len(entries))
+	}
+	if entries, _ := auditLog.Find(ctx, Filter{Offset: 1, Limit: 1}); len(entries) != 1 || entries[0].Seq != 2 {
+		t.Errorf("Unexpected page: %+v", entries)
+	}
+
+	// Другой арендатор не видит чужих записей и начинает свою цепочку
+	other := requestContext("globex", "bob", "")
+	if entries, _ := auditLog.Find(other, Filter{}); len(entries) != 0 {
+		t.Errorf("Tenant should not see other tenants' entries, got %d", len(entries))
+	}
+	if err := auditLog.Append(other, Record{Action: ActionStart, EventID: primitive.NewObjectID(), EventType: "meeting"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if entries, _ := auditLog.Find(other, Filter{}); len(entries) != 1 || entries[0].Seq != 1 || entries[0].PrevHash != "" {
+		t.Errorf("Each tenant should have its own chain: %+v", entries)
+	}
+
+	tenants, err := auditLog.Tenants(ctx)
+	if err != nil || len(tenants) != 2 {
+		t.Errorf("Expected 2 tenants, got %v (%v)", tenants, err)
+	}
+}
+
+func TestLog_ConcurrentAppend(t *testing.T) {
+	auditLog, cleanup := setupTestLog(t)
+	defer cleanup()
+
+	// Два журнала на одной коллекции имитируют два экземпляра сервиса
+	other := NewLog(auditLog.collection)
+	ctx := requestContext("acme", "alice", "")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(l *Log) {
+			defer wg.Done()
+			errs <- l.Append(ctx, Record{Action: ActionStart, EventID: primitive.NewObjectID(), EventType: "meeting"})
+		}([]*Log{auditLog, other}[i%2])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Append failed: %v", err)
+		}
+	}
+
+	report, err := auditLog.Verify(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.Valid || report.Entries != 20 {
+		t.Errorf("Concurrent appends should form a single chain: %+v", report)
+	}
+}
+
+func TestLog_Verify(t *testing.T) {
+	auditLog, cleanup := setupTestLog(t)
+	defer cleanup()
+
+	ctx := requestContext("acme", "alice", "192.0.2.1")
+	for i := 0; i < 4; i++ {
+		if err := auditLog.Append(ctx, Record{Action: ActionStart, EventID: primitive.NewObjectID(), EventType: "meeting"}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	report, err := auditLog.Verify(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.Valid || report.Entries != 4 || report.LastHash == "" {
+		t.Fatalf("Expected a valid chain of 4 entries: %+v", report)
+	}
+
+	// Пустая цепочка считается целой
+	if report, _ := auditLog.Verify(ctx, "globex"); !report.Valid || report.Entries != 0 {
+		t.Errorf("Empty chain should be valid: %+v", report)
+	}
+
+	// Подмена содержимого записи
+	if _, err := auditLog.collection.UpdateOne(ctx, bson.M{"tenant_id": "acme", "seq": 2}, bson.M{"$set": bson.M{"actor": "mallory"}}); err != nil {
+		t.Fatal(err)
+	}
+	report, _ = auditLog.Verify(ctx, "acme")
+	if report.Valid || report.BrokenSeq != 2 {
+		t.Errorf("Tampered entry should be detected: %+v", report)
+	}
+	if _, err := auditLog.collection.UpdateOne(ctx, bson.M{"tenant_id": "acme", "seq": 2}, bson.M{"$set": bson.M{"actor": "alice"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Удаление записи из середины цепочки
+	if _, err := auditLog.collection.DeleteOne(ctx, bson.M{"tenant_id": "acme", "seq": 3}); err != nil {
+		t.Fatal(err)
+	}
+	report, _ = auditLog.Verify(ctx, "acme")
+	if report.Valid || report.BrokenSeq != 3 {
+		t.Errorf("Deleted entry should be detected: %+v", report)
+	}
+}
+
+func TestLog_Outbox(t *testing.T) {
+	auditLog, cleanup := setupTestLog(t)
+	defer cleanup()
+	outbox := auditLog.collection.Database().Collection("audit_outbox")
+	outbox.Drop(context.Background())
+	auditLog.SetOutbox(outbox)
+
+	ctx := requestContext("acme", "alice", "192.0.2.1")
+	if err := auditLog.Append(ctx, Record{Action: ActionStart, EventID: primitive.NewObjectID(), EventType: "meeting"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	// Запрос отменён после изменения: записи не попадают в цепочку, но и не теряются
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	id := primitive.NewObjectID()
+	if err := auditLog.Append(cancelled, Record{Action: ActionFinish, EventID: id, EventType: "meeting"}); err != nil {
+		t.Fatalf("Append should postpone the records, got %v", err)
+	}
+	if n, _ := outbox.CountDocuments(ctx, bson.M{}); n != 1 {
+		t.Fatalf("Expected 1 postponed batch, got %d", n)
+	}
+
+	flushed, err := auditLog.Flush(context.Background())
+	if err != nil || flushed != 1 {
+		t.Fatalf("Flush = %d, %v; expected 1", flushed, err)
+	}
+	entries, err := auditLog.Find(ctx, Filter{EventID: &id})
+	if err != nil || len(entries) != 1 || entries[0].Actor != "alice" || entries[0].IP != "192.0.2.1" || entries[0].Seq != 2 {
+		t.Errorf("Expected the postponed entry with its actor at seq 2, got %+v (%v)", entries, err)
+	}
+	if report, _ := auditLog.Verify(ctx, "acme"); !report.Valid || report.Entries != 2 {
+		t.Errorf("Postponed entries should continue the chain: %+v", report)
+	}
+	if n, _ := outbox.CountDocuments(ctx, bson.M{}); n != 0 {
+		t.Errorf("Expected an empty outbox, got %d batches", n)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 1; attempt < maxAppendAttempts; attempt++ {
+		limit := min(appendBackoff<<(attempt-1), maxAppendBackoff)
+		for i := 0; i < 20; i++ {
+			if d := backoff(attempt); d < limit/2 || d > limit {
+				t.Fatalf("backoff(%d) = %s, expected between %s and %s", attempt, d, limit/2, limit)
+			}
+		}
+	}
+}
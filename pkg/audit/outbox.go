@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// postponed — записи арендатора, которые не удалось сразу добавить в цепочку
+// Записи хранятся уже подготовленными (кто, откуда, когда, снимки): при добавлении из outbox
+// контекста исходного запроса уже нет
+type postponed struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID string             `bson:"tenant_id"`
+	Entries  []Entry            `bson:"entries"`
+	// Error — почему записи не удалось добавить в последний раз
+	Error string `bson:"error"`
+	// PostponedAt — когда записи отложены
+	PostponedAt time.Time `bson:"postponed_at"`
+}
+
+// postpone откладывает записи арендатора в outbox
+// Запись в outbox не зависит от отмены запроса: изменение уже сделано, и запись о нём нужно сохранить
+func (l *Log) postpone(ctx context.Context, tenantID string, entries []Entry, cause error) error {
+	_, err := l.outbox.InsertOne(context.WithoutCancel(ctx), postponed{
+		TenantID:    tenantID,
+		Entries:     entries,
+		Error:       cause.Error(),
+		PostponedAt: l.now().UTC(),
+	})
+	return err
+}
+
+// Flush добавляет отложенные записи в цепочки арендаторов в том порядке, в каком они откладывались
+// Записи, которые снова не удалось добавить, остаются в outbox до следующего вызова
+// Возвращает, сколько записей добавлено
+func (l *Log) Flush(ctx context.Context) (int, error) {
+	if l.outbox == nil {
+		return 0, nil
+	}
+	cursor, err := l.outbox.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	flushed := 0
+	for cursor.Next(ctx) {
+		var batch postponed
+		if err := cursor.Decode(&batch); err != nil {
+			return flushed, err
+		}
+		remaining, err := l.appendEntries(ctx, batch.TenantID, batch.Entries)
+		flushed += len(batch.Entries) - len(remaining)
+		if err != nil {
+			// Часть записей могла попасть в цепочку — в outbox остаются только остальные
+			update := bson.M{"$set": bson.M{"entries": remaining, "error": err.Error()}}
+			if _, updateErr := l.outbox.UpdateByID(context.WithoutCancel(ctx), batch.ID, update); updateErr != nil {
+				slog.ErrorContext(ctx, "Не удалось обновить отложенные записи аудита", "tenant", batch.TenantID, "error", updateErr)
+			}
+			return flushed, err
+		}
+		if _, err := l.outbox.DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": batch.ID}); err != nil {
+			return flushed, err
+		}
+	}
+	return flushed, cursor.Err()
+}
+
+// Run добавляет отложенные записи сразу и затем каждые interval, пока ctx не отменён
+func (l *Log) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		flushed, err := l.Flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Не удалось добавить отложенные записи аудита", "error", err, "flushed", flushed)
+		} else if flushed > 0 {
+			slog.InfoContext(ctx, "Отложенные записи аудита добавлены в журнал", "flushed", flushed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VerifyReport — результат проверки цепочки арендатора
+type VerifyReport struct {
+	// TenantID — чья цепочка проверялась
+	TenantID string `json:"tenant"`
+	// Entries — сколько записей проверено
+	Entries int64 `json:"entries"`
+	// Valid — цепочка цела
+	Valid bool `json:"valid"`
+	// BrokenSeq — номер первой записи, на которой цепочка нарушена (0, если цела)
+	BrokenSeq int64 `json:"brokenSeq,omitempty"`
+	// Problem — что именно не так с записью BrokenSeq
+	Problem string `json:"problem,omitempty"`
+	// LastHash — хеш последней записи
+	// Удаление записей с конца цепочки можно обнаружить, только сравнив его
+	// с ранее сохранённым где-то ещё значением
+	LastHash string `json:"lastHash,omitempty"`
+}
+
+// Verify проверяет цепочку записей арендатора tenantID от первой записи до последней:
+// номера идут подряд, каждая запись ссылается на хеш предыдущей, хеш совпадает с содержимым
+func (l *Log) Verify(ctx context.Context, tenantID string) (*VerifyReport, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}})
+	cursor, err := l.collection.Find(ctx, bson.M{"tenant_id": tenantID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	report := &VerifyReport{TenantID: tenantID, Valid: true}
+	prev := ""
+	for cursor.Next(ctx) {
+		var entry Entry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		expected := report.Entries + 1
+		report.Entries++
+
+		var problem string
+		switch {
+		case entry.Seq != expected:
+			problem = fmt.Sprintf("ожидалась запись №%d, найдена №%d: записи удалены или вставлены", expected, entry.Seq)
+		case entry.PrevHash != prev:
+			problem = "запись не ссылается на хеш предыдущей записи"
+		case entry.computeHash() != entry.Hash:
+			problem = "содержимое записи не совпадает с её хешем"
+		}
+		if problem != "" {
+			report.Valid = false
+			report.BrokenSeq = expected
+			report.Problem = problem
+			return report, nil
+		}
+		prev = entry.Hash
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	report.LastHash = prev
+	return report, nil
+}
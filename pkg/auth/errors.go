@@ -0,0 +1,15 @@
+package auth
+
+import "errors"
+
+var (
+	// ErrInvalidCredentials возвращается, если ключ или токен неизвестен, отозван или просрочен
+	ErrInvalidCredentials = errors.New("недействительные учётные данные")
+
+	// ErrUnsupportedToken возвращается аутентификатором, если токен не его формата
+	// Middleware тогда пробует следующий аутентификатор
+	ErrUnsupportedToken = errors.New("неподдерживаемый формат токена")
+
+	// ErrInvalidKeyRequest оборачивает ошибки в параметрах нового ключа
+	ErrInvalidKeyRequest = errors.New("некорректные параметры ключа")
+)
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"event-service/pkg/problem"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Ответы об ошибках управления ключами
+var (
+	problemInvalidRequest    = problem.New(http.StatusBadRequest, "invalid_request")
+	problemInvalidKeyRequest = problem.New(http.StatusBadRequest, "invalid_key_request")
+	problemKeyNotFound       = problem.New(http.StatusNotFound, "key_not_found")
+	problemKeyListFailed     = problem.New(http.StatusInternalServerError, "key_list_failed")
+	problemKeyCreateFailed   = problem.New(http.StatusInternalServerError, "key_create_failed")
+	problemKeyRotateFailed   = problem.New(http.StatusInternalServerError, "key_rotate_failed")
+	problemKeyRevokeFailed   = problem.New(http.StatusInternalServerError, "key_revoke_failed")
+)
+
+// KeyHandler обрабатывает HTTP-запросы управления API-ключами (/v1/keys)
+// Все операции выполняются над ключами арендатора из контекста запроса
+type KeyHandler struct {
+	// service — бизнес-логика API-ключей
+	service *KeyService
+}
+
+// NewKeyHandler создаёт обработчик запросов управления ключами
+func NewKeyHandler(service *KeyService) *KeyHandler {
+	return &KeyHandler{service: service}
+}
+
+// KeyResponse — ключ вместе с секретом, возвращается только при создании и ротации
+type KeyResponse struct {
+	*APIKey
+	// Key — сам API-ключ; сохраните его, повторно получить ключ нельзя
+	Key string `json:"key"`
+}
+
+// List возвращает ключи арендатора без секретов
+func (h *KeyHandler) List(c *gin.Context) {
+	keys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		problem.Internal(c, err, problemKeyListFailed)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// Create выпускает новый ключ и возвращает его один раз со статусом 201
+func (h *KeyHandler) Create(c *gin.Context) {
+	var req KeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Respond(c, problemInvalidRequest.WithDetail(err.Error()))
+		return
+	}
+
+	key, plaintext, err := h.service.Create(c.Request.Context(), req)
+	if errors.Is(err, ErrInvalidKeyRequest) {
+		problem.Respond(c, problemInvalidKeyRequest.WithDetail(err.Error()))
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err, problemKeyCreateFailed)
+		return
+	}
+	c.JSON(http.StatusCreated, KeyResponse{APIKey: key, Key: plaintext})
+}
+
+// Rotate выпускает новый секрет для ключа; старый перестаёт действовать сразу
+func (h *KeyHandler) Rotate(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+
+	key, plaintext, err := h.service.Rotate(c.Request.Context(), id)
+	if err == mongo.ErrNoDocuments {
+		problem.Respond(c, problemKeyNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err, problemKeyRotateFailed)
+		return
+	}
+	c.JSON(http.StatusOK, KeyResponse{APIKey: key, Key: plaintext})
+}
+
+// Revoke отзывает ключ
+func (h *KeyHandler) Revoke(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.service.Revoke(c.Request.Context(), id)
+	if err == mongo.ErrNoDocuments {
+		problem.Respond(c, problemKeyNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err, problemKeyRevokeFailed)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+// keyID разбирает идентификатор ключа из пути запроса и отвечает 400, если он некорректен
+func keyID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.InvalidParameter("id", "expected.key_id"))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, cleanup := setupTestKeyService(t)
+	defer cleanup()
+
+	handler := NewKeyHandler(service)
+	router := gin.New()
+	router.GET("/keys", handler.List)
+	router.POST("/keys", handler.Create)
+	router.POST("/keys/:id/rotate", handler.Rotate)
+	router.DELETE("/keys/:id", handler.Revoke)
+
+	do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/keys", KeyRequest{Name: "worker", Scopes: []string{"read"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		ID   string `json:"id"`
+		Key  string `json:"key"`
+		Hash string `json:"hash"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Key == "" || created.Hash != "" {
+		t.Errorf("Create should return the key once and never the hash: %s", w.Body.String())
+	}
+
+	if w := do(http.MethodPost, "/keys", KeyRequest{Name: "worker", Scopes: []string{"write"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown scope, got %d", w.Code)
+	}
+
+	w = do(http.MethodGet, "/keys", nil)
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(created.Key)) {
+		t.Errorf("List should not reveal keys: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := do(http.MethodPost, "/keys/"+created.ID+"/rotate", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for rotate, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/keys/"+created.ID, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for revoke, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/keys/"+created.ID, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for second revoke, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/keys/not-an-id", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid id, got %d", w.Code)
+	}
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultJWKSCacheTTL — сколько по умолчанию доверять загруженному набору ключей
+	DefaultJWKSCacheTTL = 15 * time.Minute
+
+	// jwksMinRefreshInterval — как часто можно перечитывать набор из-за неизвестного kid
+	// Защищает провайдера от потока запросов с поддельными kid
+	jwksMinRefreshInterval = 30 * time.Second
+
+	// jwksMaxSize — максимальный размер документа JWKS в байтах
+	jwksMaxSize = 1 << 20
+)
+
+// errKeyNotFound возвращается, если ключа с нужным kid нет даже в свежем наборе
+var errKeyNotFound = errors.New("ключ подписи не найден в JWKS")
+
+// jwksLoadError — ошибка загрузки набора ключей
+// Отличает недоступного провайдера (ошибка сервера) от плохого токена (ошибка клиента)
+type jwksLoadError struct {
+	err error
+}
+
+func (e *jwksLoadError) Error() string { return e.err.Error() }
+
+func (e *jwksLoadError) Unwrap() error { return e.err }
+
+// JWKS — набор открытых ключей провайдера (RFC 7517) с кешированием
+// Источник — локальный файл или URL (например, jwks_uri из OIDC discovery)
+// Набор перечитывается по истечении TTL, а также при встрече неизвестного kid:
+// так подхватываются новые ключи после их ротации у провайдера
+type JWKS struct {
+	// source — путь к файлу или http(s) URL набора ключей
+	source string
+	// ttl — сколько доверять загруженному набору
+	ttl time.Duration
+	// minRefresh — минимальный интервал между загрузками из-за неизвестного kid
+	minRefresh time.Duration
+	// client — HTTP-клиент для загрузки по URL
+	client *http.Client
+	// now — текущее время, подменяется в тестах
+	now func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWKS создаёт набор ключей с источником source
+// ttl <= 0 означает DefaultJWKSCacheTTL; загрузка происходит при первом обращении
+func NewJWKS(source string, ttl time.Duration) *JWKS {
+	if ttl <= 0 {
+		ttl = DefaultJWKSCacheTTL
+	}
+	return &JWKS{
+		source:     source,
+		ttl:        ttl,
+		minRefresh: jwksMinRefreshInterval,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// Key возвращает открытый ключ с идентификатором kid
+// Пустой kid допустим, только если в наборе ровно один ключ
+// Если ключа нет, набор перечитывается (не чаще minRefresh); не нашёлся и тогда — errKeyNotFound
+func (s *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys == nil || s.now().Sub(s.fetchedAt) >= s.ttl {
+		// Если провайдер недоступен, продолжаем пользоваться прежним набором
+		if err := s.refresh(ctx); err != nil && s.keys == nil {
+			return nil, err
+		}
+	}
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+
+	// Неизвестный kid — вероятно, провайдер выпустил новый ключ
+	if s.now().Sub(s.fetchedAt) < s.minRefresh {
+		return nil, errKeyNotFound
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, errKeyNotFound
+}
+
+// Refresh принудительно перечитывает набор ключей
+// Удобно вызвать при старте, чтобы сразу обнаружить ошибку в настройках
+func (s *JWKS) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refresh(ctx)
+}
+
+// lookup ищет ключ в загруженном наборе; вызывается под s.mu
+func (s *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh загружает набор из источника и заменяет им кеш; вызывается под s.mu
+// Время загрузки запоминается и при ошибке, чтобы не долбить недоступный источник
+func (s *JWKS) refresh(ctx context.Context) error {
+	s.fetchedAt = s.now()
+
+	data, err := s.read(ctx)
+	if err != nil {
+		return &jwksLoadError{fmt.Errorf("не удалось загрузить JWKS из %s: %w", s.source, err)}
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return &jwksLoadError{fmt.Errorf("некорректный JWKS из %s: %w", s.source, err)}
+	}
+	s.keys = keys
+	return nil
+}
+
+// read читает документ JWKS из файла или по URL
+func (s *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(strings.TrimPrefix(s.source, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("неожиданный статус ответа %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+}
+
+// jsonWebKey — ключ из документа JWKS; используются только поля открытых ключей
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// N и E — модуль и экспонента ключа RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// Crv, X и Y — кривая и координаты ключа EC или OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS разбирает документ JWKS в словарь ключей по kid
+// Ключи шифрования и неизвестных типов пропускаются, а некорректный ключ — ошибка
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("ключ %q: %w", jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("в наборе нет ключей подписи")
+	}
+	return keys, nil
+}
+
+// publicKey превращает JWK в открытый ключ crypto
+// Для неизвестного типа ключа возвращает nil без ошибки
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("недопустимая экспонента RSA")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("точка не лежит на кривой")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("некорректный ключ Ed25519")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+// decodeBigInt декодирует число из base64url без выравнивания, как принято в JWK
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("отсутствует параметр ключа")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testJWK описывает открытый ключ key в формате JWK с идентификатором kid
+func testJWK(t *testing.T, kid string, key crypto.PublicKey) map[string]string {
+	t.Helper()
+	enc := base64.RawURLEncoding.EncodeToString
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": enc(k.N.Bytes()), "e": enc(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return map[string]string{"kty": "EC", "kid": kid, "crv": k.Curve.Params().Name, "x": enc(k.X.FillBytes(make([]byte, size))), "y": enc(k.Y.FillBytes(make([]byte, size)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": enc(k)}
+	default:
+		t.Fatalf("unsupported key type %T", key)
+		return nil
+	}
+}
+
+// testJWKSDocument собирает документ JWKS из ключей
+func testJWKSDocument(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// jwksServer отдаёт текущий документ JWKS и считает запросы
+type jwksServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	doc    []byte
+	status int
+	hits   int
+}
+
+func newJWKSServer(t *testing.T, doc []byte) *jwksServer {
+	s := &jwksServer{doc: doc, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.hits++
+		w.WriteHeader(s.status)
+		w.Write(s.doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(doc []byte, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doc, s.status = doc, status
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	enc := testJWK(t, "enc", &rsaKey.PublicKey)
+	enc["use"] = "enc"
+	doc := testJWKSDocument(t,
+		testJWK(t, "rsa", &rsaKey.PublicKey),
+		testJWK(t, "ec", &ecKey.PublicKey),
+		testJWK(t, "ed", edKey),
+		enc,
+		map[string]string{"kty": "oct", "kid": "secret", "k": "c2VjcmV0"},
+	)
+
+	keys, err := parseJWKS(doc)
+	if err != nil {
+		t.Fatalf("parseJWKS failed: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("Expected 3 signing keys, got %d", len(keys))
+	}
+	if k, ok := keys["rsa"].(*rsa.PublicKey); !ok || !k.Equal(&rsaKey.PublicKey) {
+		t.Error("RSA key was not parsed correctly")
+	}
+	if k, ok := keys["ec"].(*ecdsa.PublicKey); !ok || !k.Equal(&ecKey.PublicKey) {
+		t.Error("EC key was not parsed correctly")
+	}
+	if k, ok := keys["ed"].(ed25519.PublicKey); !ok || !k.Equal(edKey) {
+		t.Error("Ed25519 key was not parsed correctly")
+	}
+
+	invalid := map[string][]byte{
+		"not json":        []byte(`{`),
+		"no keys":         []byte(`{"keys":[]}`),
+		"only enc":        testJWKSDocument(t, enc),
+		"rsa without n":   []byte(`{"keys":[{"kty":"RSA","kid":"a","e":"AQAB"}]}`),
+		"unknown curve":   []byte(`{"keys":[{"kty":"EC","kid":"a","crv":"P-192","x":"AQ","y":"AQ"}]}`),
+		"point off curve": []byte(`{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"AQ","y":"AQ"}]}`),
+	}
+	for name, data := range invalid {
+		if _, err := parseJWKS(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestJWKS_File(t *testing.T) {
+	key := newRSAKey(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, testJWKSDocument(t, testJWK(t, "k1", &key.PublicKey)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := NewJWKS(path, 0)
+	got, err := jwks.Key(context.Background(), "k1")
+	if err != nil {
+		t.Fatalf("Key failed: %v", err)
+	}
+	if !got.(*rsa.PublicKey).Equal(&key.PublicKey) {
+		t.Error("Unexpected key")
+	}
+	// Без kid подходит единственный ключ набора
+	if _, err := jwks.Key(context.Background(), ""); err != nil {
+		t.Errorf("Key without kid failed: %v", err)
+	}
+
+	if err := NewJWKS(filepath.Join(t.TempDir(), "missing.json"), 0).Refresh(context.Background()); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestJWKS_CacheAndRotation(t *testing.T) {
+	oldKey, newKey := newRSAKey(t), newRSAKey(t)
+	server := newJWKSServer(t, testJWKSDocument(t, testJWK(t, "old", &oldKey.PublicKey)))
+
+	now := time.Now()
+	jwks := NewJWKS(server.URL, time.Hour)
+	jwks.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := jwks.Key(ctx, "old"); err != nil {
+			t.Fatalf("Key failed: %v", err)
+		}
+	}
+	if server.count() != 1 {
+		t.Errorf("Expected the set to be fetched once, got %d", server.count())
+	}
+
+	// Провайдер выпустил новый ключ
+	server.set(testJWKSDocument(t, testJWK(t, "old", &oldKey.PublicKey), testJWK(t, "new", &newKey.PublicKey)), http.StatusOK)
+
+	// Сразу после загрузки неизвестный kid не приводит к новому запросу
+	if _, err := jwks.Key(ctx, "new"); !errors.Is(err, errKeyNotFound) {
+		t.Errorf("Expected errKeyNotFound, got %v", err)
+	}
+	if server.count() != 1 {
+		t.Errorf("Unknown kid should not refetch within minRefresh, got %d fetches", server.count())
+	}
+
+	now = now.Add(jwksMinRefreshInterval)
+	if _, err := jwks.Key(ctx, "new"); err != nil {
+		t.Fatalf("Rotated key should be picked up: %v", err)
+	}
+	if server.count() != 2 {
+		t.Errorf("Expected a refetch for the unknown kid, got %d fetches", server.count())
+	}
+
+	// Старый ключ отозван; после истечения TTL он пропадает из кеша
+	server.set(testJWKSDocument(t, testJWK(t, "new", &newKey.PublicKey)), http.StatusOK)
+	now = now.Add(time.Hour)
+	if _, err := jwks.Key(ctx, "old"); !errors.Is(err, errKeyNotFound) {
+		t.Errorf("Expected errKeyNotFound for the revoked key, got %v", err)
+	}
+	if server.count() != 3 {
+		t.Errorf("Expected a refetch after TTL, got %d fetches", server.count())
+	}
+}
+
+func TestJWKS_Unavailable(t *testing.T) {
+	key := newRSAKey(t)
+	server := newJWKSServer(t, nil)
+	server.set([]byte("oops"), http.StatusInternalServerError)
+
+	now := time.Now()
+	jwks := NewJWKS(server.URL, time.Minute)
+	jwks.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	var loadErr *jwksLoadError
+	if _, err := jwks.Key(ctx, "k1"); !errors.As(err, &loadErr) {
+		t.Fatalf("Expected a load error, got %v", err)
+	}
+
+	// Провайдер поднялся — набора в кеше нет, поэтому он загружается при следующем обращении
+	server.set(testJWKSDocument(t, testJWK(t, "k1", &key.PublicKey)), http.StatusOK)
+	now = now.Add(time.Minute)
+	if _, err := jwks.Key(ctx, "k1"); err != nil {
+		t.Fatalf("Key failed: %v", err)
+	}
+
+	// Провайдер снова упал — после TTL продолжаем пользоваться прежним набором
+	server.set([]byte("oops"), http.StatusInternalServerError)
+	now = now.Add(2 * time.Minute)
+	if _, err := jwks.Key(ctx, "k1"); err != nil {
+		t.Errorf("Stale keys should be used while the provider is down: %v", err)
+	}
+}
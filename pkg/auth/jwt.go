@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"event-service/pkg/tenant"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Имена claims по умолчанию, из которых берутся арендатор, права и типы событий
+const (
+	DefaultTenantClaim = "tenant"
+	DefaultScopesClaim = "scope"
+	DefaultTypesClaim  = "event_types"
+)
+
+// jwtAlgorithms — допустимые алгоритмы подписи
+// Симметричные HS* и "none" не принимаются: ключи берутся только из JWKS
+var jwtAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// JWTConfig — настройки проверки JWT от OIDC-провайдера
+type JWTConfig struct {
+	// Issuer — ожидаемое значение claim "iss" (пусто = не проверять)
+	Issuer string
+	// Audience — ожидаемое значение claim "aud" (пусто = не проверять)
+	Audience string
+	// TenantClaim — claim с арендатором; если в токене его нет, клиент относится к арендатору default
+	TenantClaim string
+	// ScopesClaim — claim с правами: строка через пробел или массив строк
+	// Права, которых сервис не знает (например, "openid"), пропускаются
+	ScopesClaim string
+	// TypesClaim — claim с шаблонами типов событий: строка через пробел или массив строк
+	TypesClaim string
+	// Leeway — допустимое расхождение часов при проверке exp, nbf и iat
+	Leeway time.Duration
+}
+
+// JWTAuthenticator аутентифицирует клиентов по JWT, подписанным ключами из JWKS
+type JWTAuthenticator struct {
+	// keys — открытые ключи провайдера
+	keys *JWKS
+	// config — ожидаемые iss/aud и имена claims
+	config JWTConfig
+	// parser — проверяет подпись, алгоритм и стандартные claims
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator создаёт аутентификатор JWT
+// Незаполненные имена claims заменяются значениями по умолчанию
+func NewJWTAuthenticator(keys *JWKS, config JWTConfig) *JWTAuthenticator {
+	if config.TenantClaim == "" {
+		config.TenantClaim = DefaultTenantClaim
+	}
+	if config.ScopesClaim == "" {
+		config.ScopesClaim = DefaultScopesClaim
+	}
+	if config.TypesClaim == "" {
+		config.TypesClaim = DefaultTypesClaim
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtAlgorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		opts = append(opts, jwt.WithAudience(config.Audience))
+	}
+	return &JWTAuthenticator{keys: keys, config: config, parser: jwt.NewParser(opts...)}
+}
+
+// Authenticate проверяет подпись и claims токена и возвращает клиента
+// Токены не в формате JWT — ErrUnsupportedToken, невалидные — ErrInvalidCredentials
+// Если JWKS недоступен, возвращается исходная ошибка загрузки
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if strings.Count(token, ".") != 2 {
+		return nil, ErrUnsupportedToken
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		var loadErr *jwksLoadError
+		if errors.As(err, &loadErr) {
+			return nil, loadErr.err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	principal, err := a.principal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return principal, nil
+}
+
+// principal переносит claims токена в Principal
+func (a *JWTAuthenticator) principal(claims jwt.MapClaims) (*Principal, error) {
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, errors.New("в токене нет claim 'sub'")
+	}
+
+	tenantID := tenant.Default
+	if value, ok := claims[a.config.TenantClaim]; ok {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("claim %q должен быть строкой", a.config.TenantClaim)
+		}
+		if err := tenant.Validate(s); err != nil {
+			return nil, err
+		}
+		tenantID = s
+	}
+
+	rawScopes, err := stringList(claims[a.config.ScopesClaim])
+	if err != nil {
+		return nil, fmt.Errorf("claim %q: %w", a.config.ScopesClaim, err)
+	}
+	var scopes []Scope
+	for _, s := range rawScopes {
+		switch scope := Scope(s); scope {
+		case ScopeRead, ScopeStart, ScopeFinish, ScopeAdmin:
+			scopes = append(scopes, scope)
+		}
+	}
+
+	types, err := stringList(claims[a.config.TypesClaim])
+	if err != nil {
+		return nil, fmt.Errorf("claim %q: %w", a.config.TypesClaim, err)
+	}
+	if err := ValidateTypePatterns(types); err != nil {
+		return nil, err
+	}
+
+	return &Principal{Subject: subject, Tenant: tenantID, Scopes: scopes, Types: types}, nil
+}
+
+// stringList читает claim-список: строку через пробел (как "scope" в OAuth 2.0) или массив строк
+func stringList(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.Fields(v), nil
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errors.New("ожидался массив строк")
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	default:
+		return nil, errors.New("ожидалась строка или массив строк")
+	}
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testProvider — локальный «OIDC-провайдер»: ключи подписи и JWKS-файл с ними
+type testProvider struct {
+	keys map[string]crypto.Signer
+	path string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	p := &testProvider{
+		keys: map[string]crypto.Signer{"rsa": newRSAKey(t), "ec": ecKey, "ed": edKey},
+		path: filepath.Join(t.TempDir(), "jwks.json"),
+	}
+
+	var jwks []map[string]string
+	for kid, key := range p.keys {
+		jwks = append(jwks, testJWK(t, kid, key.Public()))
+	}
+	if err := os.WriteFile(p.path, testJWKSDocument(t, jwks...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// authenticator возвращает аутентификатор, доверяющий ключам провайдера
+func (p *testProvider) authenticator(config JWTConfig) *JWTAuthenticator {
+	return NewJWTAuthenticator(NewJWKS(p.path, 0), config)
+}
+
+// sign подписывает claims ключом kid алгоритмом method
+func (p *testProvider) sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(p.keys[kid])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// validClaims — claims корректного токена; тесты портят отдельные поля
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":         "https://issuer.test",
+		"aud":         "event-service",
+		"sub":         "alice",
+		"iat":         now.Unix(),
+		"exp":         now.Add(time.Hour).Unix(),
+		"tenant":      "acme",
+		"scope":       "openid read start",
+		"event_types": []interface{}{"billing*", "meeting"},
+	}
+}
+
+var testJWTConfig = JWTConfig{Issuer: "https://issuer.test", Audience: "event-service"}
+
+func TestJWTAuthenticator_Valid(t *testing.T) {
+	provider := newTestProvider(t)
+	a := provider.authenticator(testJWTConfig)
+
+	methods := map[string]jwt.SigningMethod{"rsa": jwt.SigningMethodRS256, "ec": jwt.SigningMethodES256, "ed": jwt.SigningMethodEdDSA}
+	for kid, method := range methods {
+		p, err := a.Authenticate(context.Background(), provider.sign(t, method, kid, validClaims()))
+		if err != nil {
+			t.Fatalf("%s: Authenticate failed: %v", kid, err)
+		}
+		if p.Subject != "alice" || p.Tenant != "acme" {
+			t.Errorf("%s: unexpected principal %+v", kid, p)
+		}
+		// Неизвестное сервису право openid пропускается
+		if len(p.Scopes) != 2 || !p.HasScope(ScopeRead) || !p.HasScope(ScopeStart) || p.HasScope(ScopeFinish) {
+			t.Errorf("%s: unexpected scopes %v", kid, p.Scopes)
+		}
+		if !p.AllowsType(ScopeStart, "billing.payment") || p.AllowsType(ScopeStart, "call") {
+			t.Errorf("%s: unexpected types %v", kid, p.Types)
+		}
+	}
+}
+
+func TestJWTAuthenticator_ClaimMapping(t *testing.T) {
+	provider := newTestProvider(t)
+
+	// Свои имена claims, права массивом и типы строкой через пробел
+	a := provider.authenticator(JWTConfig{TenantClaim: "org", ScopesClaim: "permissions", TypesClaim: "types"})
+	claims := validClaims()
+	claims["org"] = "globex"
+	claims["permissions"] = []interface{}{"admin"}
+	claims["types"] = "meeting call"
+
+	p, err := a.Authenticate(context.Background(), provider.sign(t, jwt.SigningMethodRS256, "rsa", claims))
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if p.Tenant != "globex" || !p.IsAdmin() || len(p.Types) != 2 {
+		t.Errorf("Unexpected principal %+v", p)
+	}
+
+	// Без claim с арендатором клиент относится к арендатору default
+	claims = validClaims()
+	delete(claims, "tenant")
+	p, err = provider.authenticator(testJWTConfig).Authenticate(context.Background(), provider.sign(t, jwt.SigningMethodRS256, "rsa", claims))
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if p.Tenant != "default" {
+		t.Errorf("Expected default tenant, got %q", p.Tenant)
+	}
+}
+
+func TestJWTAuthenticator_Invalid(t *testing.T) {
+	provider := newTestProvider(t)
+	a := provider.authenticator(testJWTConfig)
+
+	tests := map[string]func(jwt.MapClaims){
+		"expired":         func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no exp":          func(c jwt.MapClaims) { delete(c, "exp") },
+		"not yet valid":   func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"wrong issuer":    func(c jwt.MapClaims) { c["iss"] = "https://evil.test" },
+		"wrong audience":  func(c jwt.MapClaims) { c["aud"] = "other-service" },
+		"no subject":      func(c jwt.MapClaims) { delete(c, "sub") },
+		"invalid tenant":  func(c jwt.MapClaims) { c["tenant"] = "Not Valid" },
+		"tenant not text": func(c jwt.MapClaims) { c["tenant"] = 42 },
+		"invalid types":   func(c jwt.MapClaims) { c["event_types"] = []interface{}{"Billing!"} },
+		"types not text":  func(c jwt.MapClaims) { c["event_types"] = []interface{}{1} },
+	}
+	for name, corrupt := range tests {
+		claims := validClaims()
+		corrupt(claims)
+		_, err := a.Authenticate(context.Background(), provider.sign(t, jwt.SigningMethodRS256, "rsa", claims))
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: expected ErrInvalidCredentials, got %v", name, err)
+		}
+	}
+
+	// Подпись ключом, которого нет в JWKS
+	stranger := &testProvider{keys: map[string]crypto.Signer{"rsa": newRSAKey(t)}}
+	if _, err := a.Authenticate(context.Background(), stranger.sign(t, jwt.SigningMethodRS256, "rsa", validClaims())); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("foreign key: expected ErrInvalidCredentials, got %v", err)
+	}
+
+	// Симметричная подпись и alg=none не принимаются
+	hs, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("secret"))
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	for name, token := range map[string]string{"HS256": hs, "none": none, "garbage": "a.b.c"} {
+		if _, err := a.Authenticate(context.Background(), token); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: expected ErrInvalidCredentials, got %v", name, err)
+		}
+	}
+}
+
+func TestJWTAuthenticator_UnsupportedAndUnavailable(t *testing.T) {
+	provider := newTestProvider(t)
+
+	// API-ключи и прочие не-JWT токены оставляем другим аутентификаторам
+	if _, err := provider.authenticator(testJWTConfig).Authenticate(context.Background(), "esk_abcdef"); !errors.Is(err, ErrUnsupportedToken) {
+		t.Errorf("Expected ErrUnsupportedToken, got %v", err)
+	}
+
+	// Недоступный JWKS — ошибка сервера, а не клиента
+	a := NewJWTAuthenticator(NewJWKS(filepath.Join(t.TempDir(), "missing.json"), 0), testJWTConfig)
+	_, err := a.Authenticate(context.Background(), provider.sign(t, jwt.SigningMethodRS256, "rsa", validClaims()))
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a non-credential error, got %v", err)
+	}
+}
+
+func TestMiddleware_JWTAndAPIKey(t *testing.T) {
+	provider := newTestProvider(t)
+	keys := &fakeAuthenticator{prefix: "esk_", tokens: map[string]*Principal{
+		"esk_reader": {Subject: "key:reader", Tenant: "default", Scopes: []Scope{ScopeRead}},
+	}}
+	router := setupAuthRouter(keys, provider.authenticator(testJWTConfig))
+
+	token := provider.sign(t, jwt.SigningMethodRS256, "rsa", validClaims())
+	w := doAuthRequest(router, http.MethodGet, "/read", map[string]string{"Authorization": "Bearer " + token})
+	if w.Code != http.StatusOK || w.Body.String() != "alice@acme" {
+		t.Errorf("JWT: expected 200 alice@acme, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doAuthRequest(router, http.MethodPost, "/admin", map[string]string{"Authorization": "Bearer " + token}); w.Code != http.StatusForbidden {
+		t.Errorf("JWT without admin scope: expected 403, got %d", w.Code)
+	}
+
+	w = doAuthRequest(router, http.MethodGet, "/read", map[string]string{"Authorization": "Bearer esk_reader"})
+	if w.Code != http.StatusOK || w.Body.String() != "key:reader@default" {
+		t.Errorf("API key: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	expired := provider.sign(t, jwt.SigningMethodRS256, "rsa", claims)
+	if w := doAuthRequest(router, http.MethodGet, "/read", map[string]string{"Authorization": "Bearer " + expired}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expired JWT: expected 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	first, err := generateKey()
+	if err != nil {
+		t.Fatalf("generateKey failed: %v", err)
+	}
+	second, _ := generateKey()
+
+	if !isAPIKey(first) || len(first) != len(keyPrefix)+43 {
+		t.Errorf("Unexpected key format: %s", first)
+	}
+	if first == second {
+		t.Error("Keys should be unique")
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	if hashKey("esk_a") != hashKey("esk_a") {
+		t.Error("Hash should be deterministic")
+	}
+	if hashKey("esk_a") == hashKey("esk_b") {
+		t.Error("Different keys should have different hashes")
+	}
+	if len(hashKey("esk_a")) != 64 {
+		t.Error("Hash should be hex-encoded SHA-256")
+	}
+}
+
+func TestKeyHint(t *testing.T) {
+	key := "esk_abcdefghijklmnop"
+	hint := keyHint(key)
+	if !strings.HasPrefix(hint, "esk_abcd") || strings.Contains(hint, "efgh") {
+		t.Errorf("Hint should reveal only the beginning of the key, got %s", hint)
+	}
+}
+
+func TestAPIKey_Principal(t *testing.T) {
+	key := &APIKey{Name: "worker", Tenant: "acme", Scopes: []Scope{ScopeStart}, Types: []string{"billing.*"}}
+	p := key.principal()
+	if p.Subject != "key:worker" || p.Tenant != "acme" || !p.AllowsType(ScopeStart, "billing.payment") {
+		t.Errorf("Unexpected principal: %+v", p)
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"event-service/pkg/problem"
+	"event-service/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader — альтернативный заголовок для API-ключа
+const APIKeyHeader = "X-API-Key"
+
+// Ответы об ошибках аутентификации и прав
+var (
+	problemAuthenticationRequired = problem.New(http.StatusUnauthorized, "authentication_required")
+	problemInvalidCredentials     = problem.New(http.StatusUnauthorized, "invalid_credentials")
+	problemAuthenticationFailed   = problem.New(http.StatusInternalServerError, "authentication_failed")
+	problemForbidden              = problem.New(http.StatusForbidden, "forbidden")
+)
+
+// Middleware аутентифицирует каждый запрос
+// Токен берётся из заголовка "Authorization: Bearer <токен>" или X-API-Key
+// и по очереди передаётся аутентификаторам, пока один из них не узнает формат
+// Клиент и его арендатор кладутся в контекст запроса; без токена или
+// с недействительным токеном запрос отклоняется с 401
+func Middleware(authenticators ...Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := tokenFromRequest(c.Request)
+		if token == "" {
+			unauthorized(c, problemAuthenticationRequired)
+			return
+		}
+
+		ctx, err := Authenticate(c.Request.Context(), token, authenticators...)
+		if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrUnsupportedToken) {
+			unauthorized(c, problemInvalidCredentials)
+			return
+		}
+		if err != nil {
+			c.Abort()
+			problem.Internal(c, err, problemAuthenticationFailed)
+			return
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Authenticate передаёт токен аутентификаторам по очереди, пока один из них не узнает формат,
+// и возвращает контекст с клиентом и, если он привязан к арендатору, с его арендатором
+// Токен, который не узнал ни один аутентификатор, — ErrUnsupportedToken
+func Authenticate(ctx context.Context, token string, authenticators ...Authenticator) (context.Context, error) {
+	for _, a := range authenticators {
+		principal, err := a.Authenticate(ctx, token)
+		if errors.Is(err, ErrUnsupportedToken) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ctx = WithPrincipal(ctx, principal)
+		if principal.Tenant != "" {
+			// Арендатор из учётных данных важнее заголовка X-Tenant-ID
+			ctx = tenant.WithTenant(ctx, principal.Tenant)
+		}
+		return ctx, nil
+	}
+	return nil, ErrUnsupportedToken
+}
+
+// tokenFromRequest достаёт токен из заголовков запроса
+func tokenFromRequest(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	return BearerToken(r.Header.Get("Authorization"))
+}
+
+// BearerToken достаёт токен из значения заголовка "Authorization: Bearer <токен>"
+// Для другой схемы или пустого заголовка возвращает пустую строку
+func BearerToken(authorization string) string {
+	if len(authorization) > len("Bearer ") && strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(authorization[len("Bearer "):])
+	}
+	return ""
+}
+
+// unauthorized отвечает 401 с подсказкой, как аутентифицироваться
+func unauthorized(c *gin.Context, p problem.Problem) {
+	c.Header("WWW-Authenticate", `Bearer realm="event-service"`)
+	problem.Abort(c, p)
+}
+
+// Require пропускает запрос, только если у клиента есть право scope
+// Ограничение по типам событий проверяют сами обработчики — тип известен только из тела запроса
+// Если аутентификация отключена (клиента в контексте нет), запрос пропускается
+func Require(scope Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p := FromContext(c.Request.Context()); p != nil && !p.HasScope(scope) {
+			Forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Forbidden отвечает 403 в формате ошибок API (код forbidden)
+func Forbidden(c *gin.Context) {
+	problem.Abort(c, problemForbidden)
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"event-service/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAuthenticator узнаёт токены с префиксом prefix и возвращает для token principal
+type fakeAuthenticator struct {
+	prefix string
+	tokens map[string]*Principal
+	err    error
+}
+
+func (f *fakeAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if len(token) < len(f.prefix) || token[:len(f.prefix)] != f.prefix {
+		return nil, ErrUnsupportedToken
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.tokens[token]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return p, nil
+}
+
+func setupAuthRouter(authenticators ...Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(authenticators...))
+	router.GET("/read", Require(ScopeRead), func(c *gin.Context) {
+		p := FromContext(c.Request.Context())
+		c.String(http.StatusOK, p.Subject+"@"+tenant.ID(c.Request.Context()))
+	})
+	router.POST("/admin", Require(ScopeAdmin), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func doAuthRequest(router *gin.Engine, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	keys := &fakeAuthenticator{prefix: "esk_", tokens: map[string]*Principal{
+		"esk_reader": {Subject: "key:reader", Tenant: "acme", Scopes: []Scope{ScopeRead}},
+	}}
+	jwts := &fakeAuthenticator{prefix: "ey", tokens: map[string]*Principal{
+		"eyJ.admin": {Subject: "svc", Tenant: "globex", Scopes: []Scope{ScopeAdmin}},
+	}}
+	router := setupAuthRouter(keys, jwts)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{"no credentials", http.MethodGet, "/read", nil, http.StatusUnauthorized, ""},
+		{"unknown key", http.MethodGet, "/read", map[string]string{"Authorization": "Bearer esk_unknown"}, http.StatusUnauthorized, ""},
+		{"unsupported token", http.MethodGet, "/read", map[string]string{"Authorization": "Bearer xyz"}, http.StatusUnauthorized, ""},
+		{"bearer key", http.MethodGet, "/read", map[string]string{"Authorization": "Bearer esk_reader"}, http.StatusOK, "key:reader@acme"},
+		{"lowercase bearer", http.MethodGet, "/read", map[string]string{"Authorization": "bearer esk_reader"}, http.StatusOK, "key:reader@acme"},
+		{"x-api-key header", http.MethodGet, "/read", map[string]string{APIKeyHeader: "esk_reader"}, http.StatusOK, "key:reader@acme"},
+		{"second authenticator", http.MethodGet, "/read", map[string]string{"Authorization": "Bearer eyJ.admin"}, http.StatusOK, "svc@globex"},
+		{"missing scope", http.MethodPost, "/admin", map[string]string{APIKeyHeader: "esk_reader"}, http.StatusForbidden, ""},
+		{"admin scope", http.MethodPost, "/admin", map[string]string{"Authorization": "Bearer eyJ.admin"}, http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doAuthRequest(router, tt.method, tt.path, tt.headers)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %s, got %s", tt.wantBody, w.Body.String())
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 response should include WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestMiddleware_AuthenticatorError(t *testing.T) {
+	router := setupAuthRouter(&fakeAuthenticator{prefix: "esk_", err: errors.New("база недоступна")})
+
+	w := doAuthRequest(router, http.MethodGet, "/read", map[string]string{APIKeyHeader: "esk_reader"})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+}
+
+func TestRequire_WithoutPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", Require(ScopeAdmin), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// Аутентификация отключена — права не проверяются
+	if w := doAuthRequest(router, http.MethodGet, "/", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,153 @@
+// Package auth отвечает за аутентификацию клиентов и проверку их прав
+// Аутентифицированный клиент описывается Principal и передаётся через context.Context
+package auth
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Scope — право на группу операций API
+type Scope string
+
+const (
+	// ScopeRead разрешает читать события, типы и правило именования
+	ScopeRead Scope = "read"
+	// ScopeStart разрешает запускать события
+	ScopeStart Scope = "start"
+	// ScopeFinish разрешает завершать события
+	ScopeFinish Scope = "finish"
+	// ScopeAdmin разрешает всё, включая импорт, реестр типов и управление ключами
+	ScopeAdmin Scope = "admin"
+)
+
+// ParseScopes проверяет список прав
+func ParseScopes(values []string) ([]Scope, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("нужно указать хотя бы одно право: read, start, finish или admin")
+	}
+	scopes := make([]Scope, 0, len(values))
+	for _, v := range values {
+		switch s := Scope(strings.TrimSpace(v)); s {
+		case ScopeRead, ScopeStart, ScopeFinish, ScopeAdmin:
+			scopes = append(scopes, s)
+		default:
+			return nil, fmt.Errorf("неизвестное право: %q", v)
+		}
+	}
+	return scopes, nil
+}
+
+// typePatternSyntax — допустимый шаблон типа: символы имён типов и '*' как «любые символы»
+var typePatternSyntax = regexp.MustCompile(`^[a-z0-9.\-_:/*]+$`)
+
+// ValidateTypePatterns проверяет шаблоны типов, например "billing.*" или "meeting"
+func ValidateTypePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if !typePatternSyntax.MatchString(p) {
+			return fmt.Errorf("некорректный шаблон типа %q: допустимы символы имён типов и '*'", p)
+		}
+	}
+	return nil
+}
+
+// TypePatternRegexp превращает шаблоны типов в одно регулярное выражение для MongoDB
+// Например, ["billing.*", "meeting"] → ^(?:billing\..*|meeting)$
+func TypePatternRegexp(patterns []string) string {
+	parts := make([]string, len(patterns))
+	for i, p := range patterns {
+		parts[i] = strings.ReplaceAll(regexp.QuoteMeta(p), `\*`, `.*`)
+	}
+	return `^(?:` + strings.Join(parts, "|") + `)$`
+}
+
+// Principal — аутентифицированный клиент и его права
+type Principal struct {
+	// Subject — кто выполняет запрос: имя ключа или subject токена
+	Subject string
+	// Tenant — арендатор, к данным которого у клиента есть доступ
+	Tenant string
+	// Scopes — права клиента
+	Scopes []Scope
+	// Types — шаблоны типов событий, с которыми можно работать (пусто = любые)
+	// На право admin ограничение не распространяется
+	Types []string
+	// KeyID — идентификатор API-ключа, если клиент вошёл по ключу
+	KeyID string
+}
+
+// HasScope проверяет, есть ли у клиента право scope
+// Право admin включает все остальные
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin сообщает, есть ли у клиента право admin
+func (p *Principal) IsAdmin() bool {
+	return p.HasScope(ScopeAdmin)
+}
+
+// TypeRestricted сообщает, ограничен ли клиент определёнными типами событий
+func (p *Principal) TypeRestricted() bool {
+	return len(p.Types) > 0 && !p.IsAdmin()
+}
+
+// AllowsType проверяет, можно ли клиенту выполнить scope над событиями типа eventType
+func (p *Principal) AllowsType(scope Scope, eventType string) bool {
+	if !p.HasScope(scope) {
+		return false
+	}
+	if !p.TypeRestricted() {
+		return true
+	}
+	// Шаблоны проверены при создании ключа, поэтому выражение всегда компилируется
+	return regexp.MustCompile(TypePatternRegexp(p.Types)).MatchString(eventType)
+}
+
+// contextKey — ключ для хранения Principal в context.Context
+type contextKey struct{}
+
+// WithPrincipal возвращает контекст с аутентифицированным клиентом
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext возвращает аутентифицированного клиента или nil,
+// если аутентификация отключена
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Subject возвращает, кто выполняет запрос, или пустую строку,
+// если аутентификация отключена
+func Subject(ctx context.Context) string {
+	if p := FromContext(ctx); p != nil {
+		return p.Subject
+	}
+	return ""
+}
+
+// Allowed проверяет право scope на тип eventType у клиента из контекста
+// Без клиента в контексте (аутентификация отключена) разрешено всё
+func Allowed(ctx context.Context, scope Scope, eventType string) bool {
+	p := FromContext(ctx)
+	return p == nil || p.AllowsType(scope, eventType)
+}
+
+// AllowedTypes возвращает шаблоны типов, которыми ограничен клиент из контекста
+// nil означает, что ограничений нет
+func AllowedTypes(ctx context.Context) []string {
+	p := FromContext(ctx)
+	if p == nil || !p.TypeRestricted() {
+		return nil
+	}
+	return p.Types
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes([]string{"read", " start"})
+	if err != nil || len(scopes) != 2 || scopes[1] != ScopeStart {
+		t.Errorf("ParseScopes = %v, %v", scopes, err)
+	}
+	if _, err := ParseScopes(nil); err == nil {
+		t.Error("Expected error for empty scopes")
+	}
+	if _, err := ParseScopes([]string{"write"}); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	reader := &Principal{Scopes: []Scope{ScopeRead}}
+	if !reader.HasScope(ScopeRead) || reader.HasScope(ScopeStart) || reader.IsAdmin() {
+		t.Error("Reader should only have read scope")
+	}
+
+	admin := &Principal{Scopes: []Scope{ScopeAdmin}}
+	for _, scope := range []Scope{ScopeRead, ScopeStart, ScopeFinish, ScopeAdmin} {
+		if !admin.HasScope(scope) {
+			t.Errorf("Admin should have %s scope", scope)
+		}
+	}
+}
+
+func TestPrincipal_AllowsType(t *testing.T) {
+	billing := &Principal{Scopes: []Scope{ScopeStart, ScopeRead}, Types: []string{"billing.*", "meeting"}}
+
+	tests := []struct {
+		scope     Scope
+		eventType string
+		expected  bool
+	}{
+		{ScopeStart, "billing.payment", true},
+		{ScopeStart, "billing.refund", true},
+		{ScopeStart, "meeting", true},
+		{ScopeStart, "meetings", false},
+		{ScopeStart, "billingxpayment", false},
+		{ScopeStart, "ui.click", false},
+		{ScopeFinish, "billing.payment", false},
+	}
+	for _, tt := range tests {
+		if got := billing.AllowsType(tt.scope, tt.eventType); got != tt.expected {
+			t.Errorf("AllowsType(%s, %s) = %v, expected %v", tt.scope, tt.eventType, got, tt.expected)
+		}
+	}
+
+	// На admin ограничение по типам не распространяется
+	admin := &Principal{Scopes: []Scope{ScopeAdmin}, Types: []string{"billing.*"}}
+	if !admin.AllowsType(ScopeStart, "ui.click") || admin.TypeRestricted() {
+		t.Error("Admin should not be restricted by type patterns")
+	}
+}
+
+func TestValidateTypePatterns(t *testing.T) {
+	if err := ValidateTypePatterns([]string{"billing.*", "meeting", "*"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, p := range []string{"", "Billing", "billing.(x)", "a|b", "$where"} {
+		if err := ValidateTypePatterns([]string{p}); err == nil {
+			t.Errorf("Expected error for pattern %q", p)
+		}
+	}
+}
+
+func TestTypePatternRegexp(t *testing.T) {
+	if got := TypePatternRegexp([]string{"billing.*", "meeting"}); got != `^(?:billing\..*|meeting)$` {
+		t.Errorf("Unexpected regexp: %s", got)
+	}
+}
+
+func TestAllowed_Context(t *testing.T) {
+	ctx := context.Background()
+	// Без клиента в контексте (аутентификация отключена) разрешено всё
+	if !Allowed(ctx, ScopeAdmin, "meeting") || AllowedTypes(ctx) != nil {
+		t.Error("Everything should be allowed without a principal")
+	}
+
+	ctx = WithPrincipal(ctx, &Principal{Scopes: []Scope{ScopeRead}, Types: []string{"meeting"}})
+	if Allowed(ctx, ScopeRead, "call") || !Allowed(ctx, ScopeRead, "meeting") {
+		t.Error("Type restriction from context is not applied")
+	}
+	if types := AllowedTypes(ctx); len(types) != 1 || types[0] != "meeting" {
+		t.Errorf("AllowedTypes = %v", types)
+	}
+	if FromContext(ctx) == nil {
+		t.Error("FromContext should return the principal")
+	}
+}
+
+func TestSubject(t *testing.T) {
+	if s := Subject(context.Background()); s != "" {
+		t.Errorf("Subject without a principal = %q", s)
+	}
+	ctx := WithPrincipal(context.Background(), &Principal{Subject: "alice"})
+	if s := Subject(ctx); s != "alice" {
+		t.Errorf("Subject = %q", s)
+	}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// KeyRepository хранит API-ключи в MongoDB
+// Ключи всех арендаторов лежат в одной коллекции: при аутентификации
+// арендатор ещё неизвестен, он определяется по самому ключу
+type KeyRepository struct {
+	// collection — коллекция с ключами
+	collection *mongo.Collection
+}
+
+// NewKeyRepository создаёт репозиторий API-ключей
+func NewKeyRepository(col *mongo.Collection) *KeyRepository {
+	return &KeyRepository{collection: col}
+}
+
+// EnsureIndexes создаёт уникальный индекс по хешу ключа и индекс по арендатору
+// Вызывается один раз при старте приложения
+func (r *KeyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	return err
+}
+
+// Create сохраняет новый ключ и проставляет ему ID
+func (r *KeyRepository) Create(ctx context.Context, key *APIKey) error {
+	result, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return err
+	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		key.ID = oid
+	}
+	return nil
+}
+
+// FindByHash ищет ключ по хешу
+// Если такого ключа нет, вернёт nil без ошибки
+func (r *KeyRepository) FindByHash(ctx context.Context, hash string) (*APIKey, error) {
+	return r.findOne(ctx, bson.M{"hash": hash})
+}
+
+// Get ищет ключ арендатора по идентификатору
+// Если такого ключа нет, вернёт nil без ошибки
+func (r *KeyRepository) Get(ctx context.Context, tenantID string, id primitive.ObjectID) (*APIKey, error) {
+	return r.findOne(ctx, bson.M{"_id": id, "tenant_id": tenantID})
+}
+
+// findOne ищет один ключ по фильтру, отсутствие ключа — не ошибка
+func (r *KeyRepository) findOne(ctx context.Context, filter bson.M) (*APIKey, error) {
+	var key APIKey
+	err := r.collection.FindOne(ctx, filter).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List возвращает все ключи арендатора, включая отозванные, в порядке создания
+func (r *KeyRepository) List(ctx context.Context, tenantID string) ([]APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"tenant_id": tenantID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Rotate заменяет хеш действующего ключа на новый и возвращает обновлённую запись
+// Если ключа нет или он отозван, вернёт mongo.ErrNoDocuments
+func (r *KeyRepository) Rotate(ctx context.Context, tenantID string, id primitive.ObjectID, hash, hint string, at time.Time) (*APIKey, error) {
+	filter := bson.M{"_id": id, "tenant_id": tenantID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"hash": hash, "hint": hint, "rotated_at": at}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated APIKey
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Revoke отзывает действующий ключ и возвращает обновлённую запись
+// Запись остаётся в базе, чтобы было видно, когда ключ перестал действовать
+// Если ключа нет или он уже отозван, вернёт mongo.ErrNoDocuments
+func (r *KeyRepository) Revoke(ctx context.Context, tenantID string, id primitive.ObjectID, at time.Time) (*APIKey, error) {
+	filter := bson.M{"_id": id, "tenant_id": tenantID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": at}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated APIKey
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Authenticator проверяет токен из запроса и возвращает клиента
+// Если токен не его формата, возвращает ErrUnsupportedToken
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// KeyService управляет API-ключами и аутентифицирует запросы по ним
+type KeyService struct {
+	// repo — репозиторий с ключами
+	repo *KeyRepository
+}
+
+// NewKeyService создаёт сервис API-ключей
+func NewKeyService(repo *KeyRepository) *KeyService {
+	return &KeyService{repo: repo}
+}
+
+// KeyRequest — параметры нового API-ключа
+type KeyRequest struct {
+	// Name — название ключа, обязательно
+	Name string `json:"name"`
+	// Scopes — права ключа: read, start, finish, admin
+	Scopes []string `json:"scopes"`
+	// Types — необязательные шаблоны типов событий, например "billing.*"
+	Types []string `json:"types"`
+}
+
+// Authenticate находит клиента по API-ключу
+// Неизвестный или отозванный ключ — ErrInvalidCredentials
+func (s *KeyService) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if !isAPIKey(token) {
+		return nil, ErrUnsupportedToken
+	}
+	key, err := s.repo.FindByHash(ctx, hashKey(token))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.Revoked() {
+		return nil, ErrInvalidCredentials
+	}
+	return key.principal(), nil
+}
+
+// Create выпускает новый ключ для арендатора из контекста
+// Возвращает сохранённую запись и сам ключ — его больше нигде не получить
+func (s *KeyService) Create(ctx context.Context, req KeyRequest) (*APIKey, string, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, "", fmt.Errorf("%w: поле 'name' обязательно", ErrInvalidKeyRequest)
+	}
+	scopes, err := ParseScopes(req.Scopes)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidKeyRequest, err)
+	}
+	if err := ValidateTypePatterns(req.Types); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidKeyRequest, err)
+	}
+
+	plaintext, err := generateKey()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &APIKey{
+		Name:      req.Name,
+		Tenant:    tenant.ID(ctx),
+		Scopes:    scopes,
+		Types:     req.Types,
+		Hint:      keyHint(plaintext),
+		Hash:      hashKey(plaintext),
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// List возвращает ключи арендатора из контекста
+func (s *KeyService) List(ctx context.Context) ([]APIKey, error) {
+	return s.repo.List(ctx, tenant.ID(ctx))
+}
+
+// Rotate выпускает новый секрет для существующего ключа, права и название сохраняются
+// Старый секрет перестаёт действовать сразу
+// Если ключа нет или он отозван, вернёт mongo.ErrNoDocuments
+func (s *KeyService) Rotate(ctx context.Context, id primitive.ObjectID) (*APIKey, string, error) {
+	plaintext, err := generateKey()
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := s.repo.Rotate(ctx, tenant.ID(ctx), id, hashKey(plaintext), keyHint(plaintext), time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// Revoke отзывает ключ арендатора из контекста
+// Если ключа нет или он уже отозван, вернёт mongo.ErrNoDocuments
+func (s *KeyService) Revoke(ctx context.Context, id primitive.ObjectID) (*APIKey, error) {
+	return s.repo.Revoke(ctx, tenant.ID(ctx), id, time.Now())
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-service/internal/db"
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setupTestKeyService создаёт сервис API-ключей на встроенном MongoDB
+func setupTestKeyService(t *testing.T) (*KeyService, func()) {
+	t.Helper()
+
+	mongoURI, cleanupMongo, err := db.StartEmbeddedMongo()
+	if err != nil {
+		t.Fatalf("Не удалось запустить встроенный MongoDB: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		cleanupMongo()
+		t.Fatalf("Не удалось подключиться к MongoDB: %v", err)
+	}
+
+	collection := client.Database("events_test_db").Collection("api_keys")
+	collection.Drop(ctx)
+
+	repo := NewKeyRepository(collection)
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		client.Disconnect(ctx)
+		cleanupMongo()
+		t.Fatalf("Не удалось создать индексы: %v", err)
+	}
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client.Disconnect(ctx)
+		cleanupMongo()
+	}
+	return NewKeyService(repo), cleanup
+}
+
+func TestKeyService_Lifecycle(t *testing.T) {
+	service, cleanup := setupTestKeyService(t)
+	defer cleanup()
+
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	key, plaintext, err := service.Create(ctx, KeyRequest{Name: "worker", Scopes: []string{"start", "finish"}, Types: []string{"billing.*"}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if key.Tenant != "acme" || key.Hash == plaintext || key.Hash != hashKey(plaintext) {
+		t.Errorf("Key should be stored hashed for tenant acme: %+v", key)
+	}
+
+	principal, err := service.Authenticate(context.Background(), plaintext)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if principal.Tenant != "acme" || !principal.AllowsType(ScopeStart, "billing.payment") || principal.HasScope(ScopeRead) {
+		t.Errorf("Unexpected principal: %+v", principal)
+	}
+
+	// После ротации работает только новый ключ
+	rotated, newPlaintext, err := service.Rotate(ctx, key.ID)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if rotated.RotatedAt == nil || newPlaintext == plaintext {
+		t.Error("Rotate should issue a new secret")
+	}
+	if _, err := service.Authenticate(context.Background(), plaintext); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Old key should stop working after rotation, got %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), newPlaintext); err != nil {
+		t.Errorf("New key should work: %v", err)
+	}
+
+	// Отозванный ключ не работает и не может быть отозван или перевыпущен повторно
+	if _, err := service.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), newPlaintext); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Revoked key should not work, got %v", err)
+	}
+	if _, err := service.Revoke(ctx, key.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("Expected ErrNoDocuments on second revoke, got %v", err)
+	}
+	if _, _, err := service.Rotate(ctx, key.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("Expected ErrNoDocuments when rotating revoked key, got %v", err)
+	}
+}
+
+func TestKeyService_TenantIsolation(t *testing.T) {
+	service, cleanup := setupTestKeyService(t)
+	defer cleanup()
+
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	key, _, err := service.Create(acme, KeyRequest{Name: "worker", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	keys, err := service.List(globex)
+	if err != nil || len(keys) != 0 {
+		t.Errorf("Expected no keys for globex, got %d (%v)", len(keys), err)
+	}
+	if _, err := service.Revoke(globex, key.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("Expected ErrNoDocuments when revoking another tenant's key, got %v", err)
+	}
+	if _, _, err := service.Rotate(globex, key.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("Expected ErrNoDocuments when rotating another tenant's key, got %v", err)
+	}
+}
+
+func TestKeyService_Create_Invalid(t *testing.T) {
+	service := NewKeyService(nil)
+	ctx := context.Background()
+
+	tests := []KeyRequest{
+		{Scopes: []string{"read"}},
+		{Name: "worker"},
+		{Name: "worker", Scopes: []string{"write"}},
+		{Name: "worker", Scopes: []string{"read"}, Types: []string{"Billing(.*)"}},
+	}
+	for _, req := range tests {
+		if _, _, err := service.Create(ctx, req); !errors.Is(err, ErrInvalidKeyRequest) {
+			t.Errorf("Create(%+v) expected ErrInvalidKeyRequest, got %v", req, err)
+		}
+	}
+}
+
+func TestKeyService_Authenticate_Unsupported(t *testing.T) {
+	service := NewKeyService(nil)
+	if _, err := service.Authenticate(context.Background(), "eyJhbGciOi.x.y"); !errors.Is(err, ErrUnsupportedToken) {
+		t.Errorf("Expected ErrUnsupportedToken for non-key token, got %v", err)
+	}
+}
@@ -0,0 +1,574 @@
+package event
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// archiveFileExt — расширение файла архива: NDJSON, сжатый gzip
+const archiveFileExt = ".ndjson.gz"
+
+// archiveSummaryExt — расширение файла сводки архива, который лежит рядом с файлом событий
+const archiveSummaryExt = ".summary.json"
+
+// maxArchiveLine — наибольшая длина строки архивного файла (одно событие вместе с атрибутами)
+const maxArchiveLine = 16 << 20
+
+// ArchiveFiles хранит архивные события в сжатых NDJSON-файлах: <dir>/<арендатор>/<архив>.ndjson.gz
+// Рядом лежит сводка <архив>.summary.json, чтобы список архивов не распаковывал файлы событий
+// Каждая строка — документ события в каноническом Extended JSON MongoDB вместе с полями archive и archived_at,
+// поэтому типы значений (идентификаторы, время, числа) сохраняются без потерь, а файл можно загрузить
+// обратно даже без сервиса, например через mongoimport
+// Каждый вызов Append дописывает в файл отдельный поток gzip: склеенные потоки читаются как один
+type ArchiveFiles struct {
+	dir string
+
+	// mu не даёт двум проходам архивации одновременно дописывать один файл
+	mu sync.Mutex
+	// written — идентификаторы событий архива, который дописывается сейчас; по ним сводка
+	// не учитывает повторно заархивированные события. Имя архива своё у каждого прохода,
+	// поэтому хранится только последний архив
+	written *archiveIDs
+}
+
+// archiveIDs — идентификаторы событий, записанных в архив name в папке dir
+type archiveIDs struct {
+	dir, name string
+	ids       map[primitive.ObjectID]struct{}
+}
+
+// NewArchiveFiles создаёт архив в папке dir; папка создаётся при первой записи
+func NewArchiveFiles(dir string) *ArchiveFiles {
+	return &ArchiveFiles{dir: dir}
+}
+
+// tenantDir возвращает папку архивов арендатора из контекста
+func (a *ArchiveFiles) tenantDir(ctx context.Context) (string, error) {
+	id := tenant.ID(ctx)
+	if err := tenant.Validate(id); err != nil {
+		return "", err
+	}
+	return filepath.Join(a.dir, id), nil
+}
+
+// Append дописывает события в файл архива name
+// Если проход прервётся между записью в файл и удалением из коллекции событий, события попадут
+// в архив повторно; Find и восстановление оставляют от повторов только последнюю запись
+func (a *ArchiveFiles) Append(ctx context.Context, name string, events []Event, archivedAt time.Time) (err error) {
+	_, span := startSpan(ctx, "ArchiveFiles.Append", attribute.Int("event.count", len(events)))
+	defer endSpan(span, &err)
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	dir, err := a.tenantDir(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(dir, name+archiveFileExt), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(f)
+	for i := range events {
+		line, err := bson.MarshalExtJSON(ArchivedEvent{Event: events[i], Archive: name, ArchivedAt: archivedAt}, true, false)
+		if err != nil {
+			return fmt.Errorf("событие %s: %w", events[i].ID.Hex(), err)
+		}
+		if _, err := zw.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	// События удаляются из коллекции сразу после записи — архив должен быть на диске к этому моменту
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// События уже в архиве, поэтому ошибка сводки не прерывает проход: устаревшую сводку
+	// Archives пересчитает по файлу
+	if err := a.appendSummary(ctx, dir, name, stat.Size(), events, archivedAt); err != nil {
+		slog.WarnContext(ctx, "Не удалось обновить сводку архива", "archive", name, "error", err)
+	}
+	return nil
+}
+
+// appendSummary учитывает в сводке архива name события, дописанные к файлу размера size
+// Сводка дополняется, только если она посчитана ровно по тому, что было в файле до записи,
+// и известны идентификаторы уже записанных событий; иначе (архив другого прохода, перезапуск сервиса,
+// сбой между записью событий и сводки) она пересчитывается по файлу
+// Вызывается под a.mu
+func (a *ArchiveFiles) appendSummary(ctx context.Context, dir, name string, size int64, events []Event, archivedAt time.Time) error {
+	var summary *archiveSummary
+	switch w := a.written; {
+	case size == 0:
+		summary = &archiveSummary{}
+		a.written = &archiveIDs{dir: dir, name: name, ids: make(map[primitive.ObjectID]struct{})}
+	case w != nil && w.dir == dir && w.name == name:
+		if saved, err := a.readSummary(dir, name); err == nil && saved.Size == size {
+			summary = saved
+		}
+	}
+	if summary == nil {
+		ids, err := a.updateSummary(ctx, dir, name)
+		if err != nil {
+			a.written = nil
+			return err
+		}
+		a.written = &archiveIDs{dir: dir, name: name, ids: ids}
+		return nil
+	}
+
+	stat, err := os.Stat(filepath.Join(dir, name+archiveFileExt))
+	if err != nil {
+		return err
+	}
+	summary.Size = stat.Size()
+	for i := range events {
+		if _, ok := a.written.ids[events[i].ID]; ok {
+			continue
+		}
+		a.written.ids[events[i].ID] = struct{}{}
+		summary.add(&events[i], archivedAt)
+	}
+	return a.writeSummary(dir, name, summary)
+}
+
+// archiveSummary — содержимое файла сводки архива
+// Size — размер файла событий, по которому посчитана сводка: если файл с тех пор изменился,
+// сводка устарела. Повторно заархивированное событие (см. Append) учитывается в Count один раз
+type archiveSummary struct {
+	ArchiveInfo
+	Size int64 `json:"size"`
+}
+
+// add учитывает в сводке событие, перенесённое в архив в archivedAt
+func (s *archiveSummary) add(e *Event, archivedAt time.Time) {
+	s.Count++
+	if finished := e.FinishedAt; finished != nil {
+		if s.From.IsZero() || finished.Before(s.From) {
+			s.From = *finished
+		}
+		if finished.After(s.To) {
+			s.To = *finished
+		}
+	}
+	if archivedAt.After(s.ArchivedAt) {
+		s.ArchivedAt = archivedAt
+	}
+}
+
+// readSummary читает сводку архива name
+func (a *ArchiveFiles) readSummary(dir, name string) (*archiveSummary, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name+archiveSummaryExt))
+	if err != nil {
+		return nil, err
+	}
+	summary := &archiveSummary{}
+	if err := json.Unmarshal(data, summary); err != nil {
+		return nil, fmt.Errorf("сводка архива %s: %w", name, err)
+	}
+	return summary, nil
+}
+
+// writeSummary записывает сводку архива name через временный файл, чтобы сводку не прочитали наполовину
+func (a *ArchiveFiles) writeSummary(dir, name string, summary *archiveSummary) error {
+	summary.Name = name
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, name+archiveSummaryExt+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name+archiveSummaryExt))
+}
+
+// updateSummary пересчитывает сводку архива name по файлу событий, сохраняет её
+// и возвращает идентификаторы событий архива
+// Вызывается под a.mu
+func (a *ArchiveFiles) updateSummary(ctx context.Context, dir, name string) (map[primitive.ObjectID]struct{}, error) {
+	stat, err := os.Stat(filepath.Join(dir, name+archiveFileExt))
+	if err != nil {
+		return nil, err
+	}
+	events, err := a.read(ctx, dir, name)
+	if err != nil {
+		return nil, err
+	}
+	summary := &archiveSummary{Size: stat.Size()}
+	ids := make(map[primitive.ObjectID]struct{}, len(events))
+	for i := range events {
+		summary.add(&events[i].Event, events[i].ArchivedAt)
+		ids[events[i].ID] = struct{}{}
+	}
+	return ids, a.writeSummary(dir, name, summary)
+}
+
+// names возвращает имена архивов арендатора, новые первыми
+func (a *ArchiveFiles) names(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), archiveFileExt)
+		if ok && !entry.IsDir() && validArchiveName(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// each читает записи архива name по порядку и передаёт fn каждую вместе с номером строки
+func (a *ArchiveFiles) each(ctx context.Context, dir, name string, fn func(line int, event *ArchivedEvent) error) error {
+	f, err := os.Open(filepath.Join(dir, name+archiveFileExt))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrArchiveNotFound, name)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("архив %s: %w", name, err)
+	}
+	defer zr.Close()
+
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), maxArchiveLine)
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var event ArchivedEvent
+		if err := bson.UnmarshalExtJSON(scanner.Bytes(), true, &event); err != nil {
+			return fmt.Errorf("архив %s, строка %d: %w", name, line, err)
+		}
+		if err := fn(line, &event); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("архив %s: %w", name, err)
+	}
+	return nil
+}
+
+// read читает события архива name; повторы одного события (см. Append) схлопываются в последнюю запись
+func (a *ArchiveFiles) read(ctx context.Context, dir, name string) ([]ArchivedEvent, error) {
+	var events []ArchivedEvent
+	index := make(map[[12]byte]int)
+	err := a.each(ctx, dir, name, func(_ int, event *ArchivedEvent) error {
+		if i, ok := index[event.ID]; ok {
+			events[i] = *event
+			return nil
+		}
+		index[event.ID] = len(events)
+		events = append(events, *event)
+		return nil
+	})
+	return events, err
+}
+
+// Archives возвращает сводку по архивам арендатора, новые первыми
+// Сводки читаются из файлов сводок; устаревшая или отсутствующая сводка пересчитывается по файлу событий
+func (a *ArchiveFiles) Archives(ctx context.Context) (_ []ArchiveInfo, err error) {
+	ctx, span := startSpan(ctx, "ArchiveFiles.Archives")
+	defer endSpan(span, &err)
+
+	dir, err := a.tenantDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names, err := a.names(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	archives := []ArchiveInfo{}
+	for _, name := range names {
+		summary, err := a.summary(ctx, dir, name)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, summary.ArchiveInfo)
+	}
+	return archives, nil
+}
+
+// summary возвращает актуальную сводку архива name
+func (a *ArchiveFiles) summary(ctx context.Context, dir, name string) (*archiveSummary, error) {
+	stat, err := os.Stat(filepath.Join(dir, name+archiveFileExt))
+	if err != nil {
+		return nil, err
+	}
+	if summary, err := a.readSummary(dir, name); err == nil && summary.Size == stat.Size() {
+		return summary, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.updateSummary(ctx, dir, name); err != nil {
+		return nil, err
+	}
+	return a.readSummary(dir, name)
+}
+
+// Find возвращает архивные события арендатора по фильтру
+// Файлы просматриваются целиком: архив в файлах рассчитан на редкие запросы операторов
+func (a *ArchiveFiles) Find(ctx context.Context, f ArchiveFilter) (_ []ArchivedEvent, err error) {
+	ctx, span := startSpan(ctx, "ArchiveFiles.Find", eventTypeAttr(f.Type))
+	defer endSpan(span, &err)
+
+	dir, err := a.tenantDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names, err := a.names(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var typePattern *regexp.Regexp
+	if len(f.TypePatterns) > 0 {
+		typePattern = regexp.MustCompile(auth.TypePatternRegexp(f.TypePatterns))
+	}
+	matches := func(e *ArchivedEvent) bool {
+		switch {
+		case f.Type != "" && e.Type != f.Type:
+			return false
+		case typePattern != nil && !typePattern.MatchString(e.Type):
+			return false
+		case f.From != nil && (e.FinishedAt == nil || e.FinishedAt.Before(*f.From)):
+			return false
+		case f.To != nil && (e.FinishedAt == nil || !e.FinishedAt.Before(*f.To)):
+			return false
+		}
+		return true
+	}
+
+	found := []ArchivedEvent{}
+	for _, name := range names {
+		if f.Archive != "" && name != f.Archive {
+			continue
+		}
+		events, err := a.read(ctx, dir, name)
+		if err != nil {
+			return nil, err
+		}
+		for i := range events {
+			if matches(&events[i]) {
+				found = append(found, events[i])
+			}
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].StartedAt.After(found[j].StartedAt) })
+	if f.Offset >= len(found) {
+		return []ArchivedEvent{}, nil
+	}
+	found = found[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(found) {
+		found = found[:f.Limit]
+	}
+	return found, nil
+}
+
+// Scan читает файл архива name дважды: первый проход запоминает, в какой строке последняя запись
+// каждого события, второй передаёт fn только эти записи пачками по batch
+// В памяти остаются лишь идентификаторы событий и одна пачка
+func (a *ArchiveFiles) Scan(ctx context.Context, name string, batch int, fn func([]Event) error) (err error) {
+	ctx, span := startSpan(ctx, "ArchiveFiles.Scan", attribute.String("archive.name", name))
+	defer endSpan(span, &err)
+
+	dir, err := a.tenantDir(ctx)
+	if err != nil {
+		return err
+	}
+	last := make(map[[12]byte]int)
+	err = a.each(ctx, dir, name, func(line int, event *ArchivedEvent) error {
+		last[event.ID] = line
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	events := make([]Event, 0, batch)
+	err = a.each(ctx, dir, name, func(line int, event *ArchivedEvent) error {
+		if last[event.ID] != line {
+			return nil
+		}
+		if events = append(events, event.Event); len(events) == batch {
+			if err := fn(events); err != nil {
+				return err
+			}
+			events = make([]Event, 0, batch)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(events) > 0 {
+		return fn(events)
+	}
+	return nil
+}
+
+// Remove удаляет файл архива name
+func (a *ArchiveFiles) Remove(ctx context.Context, name string) (err error) {
+	_, span := startSpan(ctx, "ArchiveFiles.Remove", attribute.String("archive.name", name))
+	defer endSpan(span, &err)
+
+	dir, err := a.tenantDir(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	err = os.Remove(filepath.Join(dir, name+archiveFileExt))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrArchiveNotFound, name)
+	}
+	if err != nil {
+		return err
+	}
+	if w := a.written; w != nil && w.dir == dir && w.name == name {
+		a.written = nil
+	}
+	if err := os.Remove(filepath.Join(dir, name+archiveSummaryExt)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Keep переписывает файл архива name, оставляя в нём только последние записи событий ids
+// Новый файл пишется рядом и подменяет старый целиком, поэтому при сбое остаётся прежний архив
+func (a *ArchiveFiles) Keep(ctx context.Context, name string, ids []primitive.ObjectID) (err error) {
+	ctx, span := startSpan(ctx, "ArchiveFiles.Keep", attribute.String("archive.name", name))
+	defer endSpan(span, &err)
+
+	dir, err := a.tenantDir(ctx)
+	if err != nil {
+		return err
+	}
+	keep := make(map[[12]byte]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	last := make(map[[12]byte]int)
+	err = a.each(ctx, dir, name, func(line int, event *ArchivedEvent) error {
+		last[event.ID] = line
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, name+archiveFileExt+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := gzip.NewWriter(tmp)
+	err = a.each(ctx, dir, name, func(line int, event *ArchivedEvent) error {
+		if !keep[event.ID] || last[event.ID] != line {
+			return nil
+		}
+		data, err := bson.MarshalExtJSON(event, true, false)
+		if err != nil {
+			return fmt.Errorf("событие %s: %w", event.ID.Hex(), err)
+		}
+		_, err = zw.Write(append(data, '\n'))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name+archiveFileExt)); err != nil {
+		return err
+	}
+
+	if w := a.written; w != nil && w.dir == dir && w.name == name {
+		a.written = nil
+	}
+	if _, err := a.updateSummary(ctx, dir, name); err != nil {
+		slog.WarnContext(ctx, "Не удалось обновить сводку архива", "archive", name, "error", err)
+	}
+	return nil
+}
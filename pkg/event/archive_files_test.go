@@ -0,0 +1,209 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// finishedEvent возвращает завершённое событие типа eventType, завершённое age назад
+func finishedEvent(eventType string, age time.Duration) Event {
+	finished := time.Now().UTC().Add(-age).Truncate(time.Millisecond)
+	return Event{
+		ID:         primitive.NewObjectID(),
+		Type:       eventType,
+		State:      Finished,
+		StartedAt:  finished.Add(-time.Hour),
+		FinishedAt: &finished,
+		UpdatedAt:  finished,
+		Version:    2,
+	}
+}
+
+func TestArchiveFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	files := NewArchiveFiles(dir)
+	archivedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+	meeting := finishedEvent("meeting", 48*time.Hour)
+	meeting.Attributes = map[string]interface{}{"room": "A", "seats": int64(12)}
+	call := finishedEvent("call", 24*time.Hour)
+	old := finishedEvent("call", 72*time.Hour)
+
+	// Два прохода в один архив дописывают в файл отдельные потоки gzip
+	if err := files.Append(ctx, "20260101T000000Z", []Event{meeting, call}, archivedAt); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := files.Append(ctx, "20260101T000000Z", []Event{call}, archivedAt); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := files.Append(ctx, "20260102T000000Z", []Event{old}, archivedAt); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, tenant.Default, "20260101T000000Z.ndjson.gz")); err != nil {
+		t.Fatalf("Archive file not created: %v", err)
+	}
+
+	archives, err := files.Archives(ctx)
+	if err != nil {
+		t.Fatalf("Archives failed: %v", err)
+	}
+	if len(archives) != 2 || archives[0].Name != "20260102T000000Z" || archives[1].Count != 2 {
+		t.Fatalf("Unexpected archives %+v", archives)
+	}
+	if !archives[1].From.Equal(*meeting.FinishedAt) || !archives[1].To.Equal(*call.FinishedAt) {
+		t.Errorf("Unexpected archive range %+v", archives[1])
+	}
+
+	// Повторно записанное событие возвращается один раз, типы атрибутов сохраняются
+	all, err := files.Find(ctx, ArchiveFilter{})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(all) != 3 || all[0].ID != call.ID || all[2].ID != old.ID {
+		t.Fatalf("Expected 3 events newest first, got %+v", all)
+	}
+	if all[1].Attributes["seats"] != int64(12) || all[1].Archive != "20260101T000000Z" || !all[1].ArchivedAt.Equal(archivedAt) {
+		t.Errorf("Unexpected archived meeting %+v", all[1])
+	}
+
+	from := time.Now().Add(-60 * time.Hour)
+	for name, tt := range map[string]struct {
+		filter ArchiveFilter
+		want   int
+	}{
+		"archive":  {ArchiveFilter{Archive: "20260102T000000Z"}, 1},
+		"type":     {ArchiveFilter{Type: "call"}, 2},
+		"patterns": {ArchiveFilter{TypePatterns: []string{"meet*"}}, 1},
+		"from":     {ArchiveFilter{From: &from}, 2},
+		"to":       {ArchiveFilter{To: &from}, 1},
+		"offset":   {ArchiveFilter{Offset: 2, Limit: 5}, 1},
+		"limit":    {ArchiveFilter{Limit: 1}, 1},
+		"beyond":   {ArchiveFilter{Offset: 10}, 0},
+	} {
+		events, err := files.Find(ctx, tt.filter)
+		if err != nil || len(events) != tt.want {
+			t.Errorf("%s: expected %d events, got %d (%v)", name, tt.want, len(events), err)
+		}
+	}
+
+	// Архивы других арендаторов не видны
+	acme := tenant.WithTenant(ctx, "acme")
+	if events, err := files.Find(acme, ArchiveFilter{}); err != nil || len(events) != 0 {
+		t.Errorf("Expected no events for another tenant, got %d (%v)", len(events), err)
+	}
+
+	// Scan отдаёт каждое событие один раз, пачками не больше batch
+	var batches [][]Event
+	err = files.Scan(ctx, "20260101T000000Z", 1, func(events []Event) error {
+		batches = append(batches, events)
+		return nil
+	})
+	if err != nil || len(batches) != 2 || batches[0][0].ID != meeting.ID || batches[1][0].ID != call.ID {
+		t.Errorf("Unexpected scan batches %+v (%v)", batches, err)
+	}
+	if err := files.Scan(ctx, "20260103T000000Z", 10, func([]Event) error { return nil }); !errors.Is(err, ErrArchiveNotFound) {
+		t.Errorf("Expected ErrArchiveNotFound, got %v", err)
+	}
+
+	if err := files.Remove(ctx, "20260102T000000Z"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := files.Remove(ctx, "20260102T000000Z"); !errors.Is(err, ErrArchiveNotFound) {
+		t.Errorf("Expected ErrArchiveNotFound, got %v", err)
+	}
+}
+
+func TestArchiveFiles_Corrupted(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, tenant.Default), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, tenant.Default, "20260101T000000Z.ndjson.gz"), []byte("not gzip"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewArchiveFiles(dir).Find(ctx, ArchiveFilter{}); err == nil {
+		t.Error("Expected an error for a corrupted archive")
+	}
+}
+
+func TestArchiveFiles_Summary(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	files := NewArchiveFiles(dir)
+	data := filepath.Join(dir, tenant.Default, "20260101T000000Z.ndjson.gz")
+	summary := filepath.Join(dir, tenant.Default, "20260101T000000Z.summary.json")
+
+	meeting, call := finishedEvent("meeting", 3*time.Hour), finishedEvent("call", time.Hour)
+	if err := files.Append(ctx, "20260101T000000Z", []Event{meeting}, time.Now()); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := files.Append(ctx, "20260101T000000Z", []Event{call}, time.Now()); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	// После записи сводка соответствует файлу, и список архивов берёт её, не читая события
+	stat, err := os.Stat(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := files.readSummary(filepath.Dir(summary), "20260101T000000Z")
+	if err != nil || saved.Size != stat.Size() || saved.Count != 2 {
+		t.Fatalf("Unexpected summary %+v (%v), file size %d", saved, err, stat.Size())
+	}
+	check := func(name string) {
+		t.Helper()
+		archives, err := files.Archives(ctx)
+		if err != nil {
+			t.Fatalf("%s: Archives failed: %v", name, err)
+		}
+		if len(archives) != 1 || archives[0].Count != 2 ||
+			!archives[0].From.Equal(*meeting.FinishedAt) || !archives[0].To.Equal(*call.FinishedAt) {
+			t.Errorf("%s: unexpected archives %+v", name, archives)
+		}
+	}
+	check("saved")
+
+	// Без сводки, с повреждённой или устаревшей сводкой список пересчитывает её по файлу
+	for name, damage := range map[string]func() error{
+		"removed":   func() error { return os.Remove(summary) },
+		"corrupted": func() error { return os.WriteFile(summary, []byte("{"), 0o640) },
+		"stale":     func() error { return os.WriteFile(summary, []byte(`{"count":7,"size":1}`), 0o640) },
+	} {
+		if err := damage(); err != nil {
+			t.Fatal(err)
+		}
+		check(name)
+		if saved, err := files.readSummary(filepath.Dir(summary), "20260101T000000Z"); err != nil || saved.Count != 2 {
+			t.Errorf("%s: expected the summary to be rewritten, got %+v (%v)", name, saved, err)
+		}
+	}
+
+	if err := files.Remove(ctx, "20260101T000000Z"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(summary); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the summary to be removed, got %v", err)
+	}
+}
+
+func TestValidArchiveName(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if name := archiveName(at); name != "20260102T030405Z" || !validArchiveName(name) {
+		t.Errorf("Unexpected archive name %q", name)
+	}
+	for _, name := range []string{"", "latest", "../20260102T030405Z", "20260102T030405Z.ndjson.gz"} {
+		if validArchiveName(name) {
+			t.Errorf("%q should not be a valid archive name", name)
+		}
+	}
+}
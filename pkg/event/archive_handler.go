@@ -0,0 +1,106 @@
+package event
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-service/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArchiveHandler обрабатывает HTTP-запросы к архиву событий (/v1/archives)
+type ArchiveHandler struct {
+	// service — сроки хранения, архив и восстановление
+	service *ArchiveService
+	// maxLimit — наибольшее значение параметра limit в выборке архивных событий
+	maxLimit int
+}
+
+// NewArchiveHandler создаёт обработчик запросов к архиву
+func NewArchiveHandler(service *ArchiveService, maxLimit int) *ArchiveHandler {
+	return &ArchiveHandler{service: service, maxLimit: maxLimit}
+}
+
+// Archives возвращает сводку по архивам арендатора, новые первыми
+func (h *ArchiveHandler) Archives(c *gin.Context) {
+	defer traceHandler(c, "ArchiveHandler.Archives")()
+
+	archives, err := h.service.Archives(c.Request.Context())
+	if err != nil {
+		respondError(c, err, failedArchives)
+		return
+	}
+	c.JSON(http.StatusOK, archives)
+}
+
+// Events возвращает архивные события, отсортированные по времени начала в порядке убывания
+// Фильтры в query: archive, type, from и to (время завершения, RFC 3339), offset и limit (до maxLimit)
+// Клиент с ограниченным ключом видит только события разрешённых типов, как в GET /v1
+func (h *ArchiveHandler) Events(c *gin.Context) {
+	defer traceHandler(c, "ArchiveHandler.Events")()
+
+	filter := ArchiveFilter{
+		Archive:      c.Query("archive"),
+		Type:         c.Query("type"),
+		TypePatterns: auth.AllowedTypes(c.Request.Context()),
+		Limit:        h.maxLimit,
+	}
+	if filter.Type != "" && !auth.Allowed(c.Request.Context(), auth.ScopeRead, filter.Type) {
+		auth.Forbidden(c)
+		return
+	}
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if s := c.Query(name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				respondError(c, invalidParameter(name, "expected.timestamp"), failedArchived)
+				return
+			}
+			*target = &t
+		}
+	}
+
+	if s := c.Query("offset"); s != "" {
+		offset, err := strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			respondError(c, invalidParameter("offset", "expected.non_negative"), failedArchived)
+			return
+		}
+		filter.Offset = offset
+	}
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > h.maxLimit {
+			respondError(c, invalidParameter("limit", "expected.range", 1, h.maxLimit), failedArchived)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	events, err := h.service.Find(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err, failedArchived)
+		return
+	}
+	resp := make([]ArchivedEventResponse, len(events))
+	for i := range events {
+		resp[i] = events[i].ToResponse()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Restore возвращает события архива из пути запроса в коллекцию событий и удаляет их из архива
+// Возвращает отчёт с числом восстановленных и списком пропущенных событий или 404, если архива нет
+func (h *ArchiveHandler) Restore(c *gin.Context) {
+	defer traceHandler(c, "ArchiveHandler.Restore")()
+
+	report, err := h.service.Restore(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		respondError(c, err, failedRestore)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,139 @@
+package event
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetentionMode — что делать с завершёнными событиями, срок хранения которых истёк
+type RetentionMode string
+
+const (
+	// RetentionArchive — перенести события в архив (коллекцию или файлы), откуда их можно восстановить
+	RetentionArchive RetentionMode = "archive"
+	// RetentionDelete — удалить события насовсем
+	RetentionDelete RetentionMode = "delete"
+)
+
+// ParseRetentionMode превращает строку из настроек в RetentionMode
+func ParseRetentionMode(s string) (RetentionMode, error) {
+	switch RetentionMode(s) {
+	case RetentionArchive, RetentionDelete:
+		return RetentionMode(s), nil
+	default:
+		return "", fmt.Errorf("неизвестный режим хранения %q (ожидается archive или delete)", s)
+	}
+}
+
+// RetentionPolicy — правила хранения завершённых событий
+// Срок хранения типа берётся из реестра (retentionDays), а для типов без своего срока — из Days
+type RetentionPolicy struct {
+	// Days — сколько дней хранить завершённые события типов без собственного срока (0 — хранить всегда)
+	Days int
+	// Mode — архивировать или удалять события с истёкшим сроком
+	Mode RetentionMode
+	// BatchSize — сколько событий переносится за один шаг; каждый шаг — отдельные запросы к базе,
+	// поэтому большой архив не держит в памяти все события сразу
+	BatchSize int
+}
+
+// DefaultRetentionBatchSize — размер шага архивации по умолчанию
+const DefaultRetentionBatchSize = 1000
+
+// archiveNameLayout — формат имени архива: время запуска архивации в UTC
+// Все события, перенесённые за один проход, попадают в один архив
+const archiveNameLayout = "20060102T150405Z"
+
+// archiveName возвращает имя архива для прохода, начатого в момент at
+func archiveName(at time.Time) string {
+	return at.UTC().Format(archiveNameLayout)
+}
+
+// validArchiveName проверяет имя архива из запроса
+// Имя становится частью пути к файлу, поэтому допускается только формат archiveNameLayout
+func validArchiveName(name string) bool {
+	_, err := time.Parse(archiveNameLayout, name)
+	return err == nil
+}
+
+// ArchivedEvent — событие в архиве вместе с отметкой о том, когда и в какой архив оно перенесено
+type ArchivedEvent struct {
+	Event `bson:",inline"`
+
+	// Archive — имя архива (см. archiveNameLayout)
+	Archive string `bson:"archive"`
+	// ArchivedAt — когда событие перенесено в архив
+	ArchivedAt time.Time `bson:"archived_at"`
+}
+
+// ArchivedEventResponse — архивное событие в ответе API
+type ArchivedEventResponse struct {
+	EventResponse
+	Archive    string    `json:"archive"`
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
+// ToResponse преобразует ArchivedEvent в ArchivedEventResponse для ответа API
+func (e *ArchivedEvent) ToResponse() ArchivedEventResponse {
+	return ArchivedEventResponse{EventResponse: e.Event.ToResponse(), Archive: e.Archive, ArchivedAt: e.ArchivedAt}
+}
+
+// ArchiveInfo — сводка по одному архиву арендатора
+type ArchiveInfo struct {
+	// Name — имя архива, по нему архив восстанавливается
+	Name string `json:"name"`
+	// Count — число событий в архиве
+	Count int64 `json:"count"`
+	// From и To — самое раннее и самое позднее время завершения событий архива
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// ArchivedAt — когда в архив последний раз переносились события
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
+// ArchiveFilter — условия выборки архивных событий
+type ArchiveFilter struct {
+	// Archive — только события этого архива (пусто — из всех архивов)
+	Archive string
+	// Type — точный тип события (пусто — любой)
+	Type string
+	// TypePatterns — шаблоны допустимых типов, как в ListFilter (пусто — любые)
+	TypePatterns []string
+	// From и To — время завершения события не раньше From и раньше To (nil — без ограничения)
+	From *time.Time
+	To   *time.Time
+	// Offset и Limit — страница выборки (Limit 0 — без ограничения)
+	Offset int
+	Limit  int
+}
+
+// ExpiredFilter — условия выборки завершённых событий с истёкшим сроком хранения
+type ExpiredFilter struct {
+	// Types — только события этих типов (пусто — любых, кроме ExcludeTypes)
+	Types []string
+	// ExcludeTypes — кроме событий этих типов: у них свой срок хранения
+	ExcludeTypes []string
+	// Before — события, завершённые раньше этого времени
+	Before time.Time
+	// Limit — сколько событий вернуть за раз (самые давние первыми)
+	Limit int
+}
+
+// RetentionReport — итог прохода по срокам хранения
+type RetentionReport struct {
+	// Archived — сколько событий перенесено в архив
+	Archived int64 `json:"archived"`
+	// Deleted — сколько событий удалено без архивации
+	Deleted int64 `json:"deleted"`
+}
+
+// RestoreReport — итог восстановления архива
+type RestoreReport struct {
+	// Archive — восстановленный архив
+	Archive string `json:"archive"`
+	// Restored — сколько событий возвращено в коллекцию событий
+	Restored int64 `json:"restored"`
+	// Skipped — идентификаторы событий, которые уже есть в коллекции событий: они не перезаписаны
+	// и остались в архиве
+	Skipped []string `json:"skipped"`
+}
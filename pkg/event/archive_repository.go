@@ -0,0 +1,291 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ArchiveStore — место, где хранятся события, перенесённые из коллекции событий по истечении срока хранения
+// Есть две реализации: ArchiveRepository (коллекция MongoDB) и ArchiveFiles (сжатые NDJSON-файлы на диске)
+// Каждый метод работает с архивами арендатора из контекста
+type ArchiveStore interface {
+	// Append дописывает события в архив name, отмечая их временем archivedAt
+	Append(ctx context.Context, name string, events []Event, archivedAt time.Time) error
+	// Archives возвращает сводку по архивам, новые первыми
+	Archives(ctx context.Context) ([]ArchiveInfo, error)
+	// Find возвращает архивные события по фильтру, отсортированные по времени начала в порядке убывания
+	Find(ctx context.Context, filter ArchiveFilter) ([]ArchivedEvent, error)
+	// Scan читает все события архива name и передаёт их fn пачками не больше batch, не держа архив в памяти
+	// Если архива нет — ErrArchiveNotFound
+	Scan(ctx context.Context, name string, batch int, fn func([]Event) error) error
+	// Remove удаляет архив name целиком; если архива нет — ErrArchiveNotFound
+	Remove(ctx context.Context, name string) error
+	// Keep оставляет в архиве name только события ids, остальные удаляет
+	Keep(ctx context.Context, name string, ids []primitive.ObjectID) error
+}
+
+// ArchiveRepository хранит архивные события в отдельной коллекции MongoDB (collections.archive)
+// Документы — те же события с полями archive и archived_at; арендаторы размещаются так же, как в коллекции событий
+type ArchiveRepository struct {
+	// collections выбирает коллекцию архива для арендатора из контекста
+	collections *tenant.Collections
+
+	// indexed — коллекции арендаторов, для которых индексы уже созданы
+	indexed sync.Map
+}
+
+// NewArchiveRepository создаёт архив в коллекциях collections
+func NewArchiveRepository(collections *tenant.Collections) *ArchiveRepository {
+	return &ArchiveRepository{collections: collections}
+}
+
+// archiveIndexes — индексы коллекции архива
+var archiveIndexes = []mongo.IndexModel{
+	// Сводка по архивам, выборка и удаление архива (Archives, Find, Remove)
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "archive", Value: 1}}},
+	// Выборка архивных событий по времени начала (Find)
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "started_at", Value: -1}}},
+}
+
+// EnsureIndexes создаёт индексы в коллекции архива арендатора из контекста
+func (r *ArchiveRepository) EnsureIndexes(ctx context.Context) error {
+	_, _, err := r.collection(ctx)
+	return err
+}
+
+// collection возвращает коллекцию архива арендатора из контекста и идентификатор арендатора
+// При первом обращении к коллекции создаёт в ней индексы
+func (r *ArchiveRepository) collection(ctx context.Context) (*mongo.Collection, string, error) {
+	col, tenantID, err := r.collections.For(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := col.Database().Name() + "." + col.Name()
+	if _, ok := r.indexed.Load(key); !ok {
+		if _, err := col.Indexes().CreateMany(ctx, archiveIndexes); err != nil {
+			return nil, "", err
+		}
+		slog.DebugContext(ctx, "Индексы коллекции архива созданы", "collection", key, "tenant", tenantID)
+		r.indexed.Store(key, true)
+	}
+	return col, tenantID, nil
+}
+
+// Append дописывает события в архив name
+// Событие, которое уже есть в архиве (например, после сбоя между архивацией и удалением), заменяется
+func (r *ArchiveRepository) Append(ctx context.Context, name string, events []Event, archivedAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, "ArchiveRepository.Append", attribute.Int("event.count", len(events)))
+	defer endSpan(span, &err)
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	models := make([]mongo.WriteModel, len(events))
+	for i := range events {
+		doc := ArchivedEvent{Event: events[i], Archive: name, ArchivedAt: archivedAt}
+		doc.TenantID = tenantID
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": doc.ID, "tenant_id": tenantID}).
+			SetReplacement(doc).
+			SetUpsert(true)
+	}
+	_, err = col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Archives возвращает сводку по архивам арендатора, новые первыми
+func (r *ArchiveRepository) Archives(ctx context.Context) (_ []ArchiveInfo, err error) {
+	ctx, span := startSpan(ctx, "ArchiveRepository.Archives")
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"tenant_id": tenantID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$archive",
+			"count":       bson.M{"$sum": 1},
+			"from":        bson.M{"$min": "$finished_at"},
+			"to":          bson.M{"$max": "$finished_at"},
+			"archived_at": bson.M{"$max": "$archived_at"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": -1}}},
+	}
+	cursor, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Name       string    `bson:"_id"`
+		Count      int64     `bson:"count"`
+		From       time.Time `bson:"from"`
+		To         time.Time `bson:"to"`
+		ArchivedAt time.Time `bson:"archived_at"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	archives := make([]ArchiveInfo, len(groups))
+	for i, g := range groups {
+		archives[i] = ArchiveInfo{Name: g.Name, Count: g.Count, From: g.From, To: g.To, ArchivedAt: g.ArchivedAt}
+	}
+	return archives, nil
+}
+
+// Find возвращает архивные события арендатора по фильтру
+func (r *ArchiveRepository) Find(ctx context.Context, f ArchiveFilter) (_ []ArchivedEvent, err error) {
+	ctx, span := startSpan(ctx, "ArchiveRepository.Find", eventTypeAttr(f.Type))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"tenant_id": tenantID}
+	if f.Archive != "" {
+		filter["archive"] = f.Archive
+	}
+	typeFilter := bson.M{}
+	if f.Type != "" {
+		typeFilter["$eq"] = f.Type
+	}
+	if len(f.TypePatterns) > 0 {
+		typeFilter["$regex"] = auth.TypePatternRegexp(f.TypePatterns)
+	}
+	if len(typeFilter) > 0 {
+		filter["type"] = typeFilter
+	}
+	finished := bson.M{}
+	if f.From != nil {
+		finished["$gte"] = *f.From
+	}
+	if f.To != nil {
+		finished["$lt"] = *f.To
+	}
+	if len(finished) > 0 {
+		filter["finished_at"] = finished
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if f.Offset > 0 {
+		opts.SetSkip(int64(f.Offset))
+	}
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []ArchivedEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Scan читает события архива name курсором в порядке _id и передаёт их fn пачками по batch
+func (r *ArchiveRepository) Scan(ctx context.Context, name string, batch int, fn func([]Event) error) (err error) {
+	ctx, span := startSpan(ctx, "ArchiveRepository.Scan", attribute.String("archive.name", name))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(int32(batch))
+	cursor, err := col.Find(ctx, bson.M{"tenant_id": tenantID, "archive": name}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	found := false
+	events := make([]Event, 0, batch)
+	for cursor.Next(ctx) {
+		var archived ArchivedEvent
+		if err := cursor.Decode(&archived); err != nil {
+			return err
+		}
+		found = true
+		if events = append(events, archived.Event); len(events) == batch {
+			if err := fn(events); err != nil {
+				return err
+			}
+			events = make([]Event, 0, batch)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrArchiveNotFound, name)
+	}
+	if len(events) > 0 {
+		return fn(events)
+	}
+	return nil
+}
+
+// Remove удаляет все события архива name
+func (r *ArchiveRepository) Remove(ctx context.Context, name string) (err error) {
+	ctx, span := startSpan(ctx, "ArchiveRepository.Remove", attribute.String("archive.name", name))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := col.DeleteMany(ctx, bson.M{"tenant_id": tenantID, "archive": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrArchiveNotFound, name)
+	}
+	return nil
+}
+
+// Keep удаляет из архива name все события, кроме ids
+func (r *ArchiveRepository) Keep(ctx context.Context, name string, ids []primitive.ObjectID) (err error) {
+	ctx, span := startSpan(ctx, "ArchiveRepository.Keep", attribute.String("archive.name", name))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = col.DeleteMany(ctx, bson.M{"tenant_id": tenantID, "archive": name, "_id": bson.M{"$nin": ids}})
+	return err
+}
@@ -0,0 +1,157 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// setupTestArchive создаёт репозиторий событий и архив в соседней коллекции той же тестовой базы
+func setupTestArchive(t *testing.T) (*EventRepository, *ArchiveRepository, func()) {
+	t.Helper()
+	repo, cleanup := setupTestRepo(t)
+
+	col, _, err := repo.collection(context.Background())
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	archiveCol := col.Database().Collection("archive_events")
+	archiveCol.Drop(context.Background())
+	return repo, NewArchiveRepository(tenant.Shared(archiveCol)), cleanup
+}
+
+func TestEventRepository_Retention(t *testing.T) {
+	repo, _, cleanup := setupTestArchive(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	old := finishedEvent("call", 40*24*time.Hour)
+	fresh := finishedEvent("call", time.Hour)
+	meeting := finishedEvent("meeting", 50*24*time.Hour)
+	if err := repo.InsertMany(ctx, []Event{old, fresh, meeting}); err != nil {
+		t.Fatalf("InsertMany failed: %v", err)
+	}
+	if err := repo.InsertMany(tenant.WithTenant(ctx, "acme"), []Event{finishedEvent("call", time.Hour)}); err != nil {
+		t.Fatalf("InsertMany failed: %v", err)
+	}
+	if _, err := repo.Create(ctx, &Event{Type: "call"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	tenants, err := repo.Tenants(ctx)
+	if err != nil || len(tenants) != 2 {
+		t.Errorf("Expected 2 tenants, got %v (%v)", tenants, err)
+	}
+
+	before := time.Now().Add(-30 * 24 * time.Hour)
+	expired, err := repo.FindExpired(ctx, ExpiredFilter{Before: before, ExcludeTypes: []string{"meeting"}})
+	if err != nil || len(expired) != 1 || expired[0].ID != old.ID {
+		t.Fatalf("Expected only the old call, got %+v (%v)", expired, err)
+	}
+	if all, _ := repo.FindExpired(ctx, ExpiredFilter{Before: before, Limit: 1}); len(all) != 1 || all[0].ID != meeting.ID {
+		t.Errorf("Expected the oldest event first, got %+v", all)
+	}
+
+	// Событие, изменённое после чтения, не удаляется
+	stale := []Event{old, meeting}
+	stale[1].Version--
+	if removed, err := repo.Expire(ctx, stale); err != nil || len(removed) != 1 || removed[0] != old.ID {
+		t.Errorf("Expected only the old call removed, got %v (%v)", removed, err)
+	}
+	if e, _ := repo.FindByID(ctx, old.ID); e != nil {
+		t.Error("Expired event should be removed")
+	}
+
+	// Восстановление только вставляет: существующее событие не перезаписывается, повтор ничего не дублирует
+	restoredAt := time.Now().UTC().Truncate(time.Millisecond)
+	old.RestoredAt = &restoredAt
+	changed := fresh
+	changed.Version--
+	if inserted, err := repo.Restore(ctx, []Event{old, changed}); err != nil || len(inserted) != 1 || inserted[0] != old.ID {
+		t.Fatalf("Expected only the old call inserted, got %v (%v)", inserted, err)
+	}
+	if inserted, err := repo.Restore(ctx, []Event{old}); err != nil || len(inserted) != 0 {
+		t.Errorf("Expected nothing inserted on repeat, got %v (%v)", inserted, err)
+	}
+	if e, _ := repo.FindByID(ctx, old.ID); e == nil || e.Version != old.Version || e.RestoredAt == nil {
+		t.Errorf("Expected the restored event, got %+v", e)
+	}
+	if e, _ := repo.FindByID(ctx, fresh.ID); e == nil || e.Version != fresh.Version {
+		t.Errorf("Live event should not be overwritten, got %+v", e)
+	}
+
+	// Восстановленное событие хранится заново от момента восстановления
+	if expired, _ := repo.FindExpired(ctx, ExpiredFilter{Before: before, Types: []string{"call"}}); len(expired) != 0 {
+		t.Errorf("Restored event should not expire yet, got %+v", expired)
+	}
+	if expired, _ := repo.FindExpired(ctx, ExpiredFilter{Before: time.Now().Add(time.Hour), Types: []string{"call"}}); len(expired) != 2 {
+		t.Errorf("Expected both finished calls to expire later, got %+v", expired)
+	}
+}
+
+func TestArchiveRepository(t *testing.T) {
+	_, archive, cleanup := setupTestArchive(t)
+	defer cleanup()
+	ctx := context.Background()
+	archivedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+	call := finishedEvent("call", 40*24*time.Hour)
+	meeting := finishedEvent("meeting", 50*24*time.Hour)
+	if err := archive.Append(ctx, "20260101T000000Z", []Event{call, meeting}, archivedAt); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	// Повторная запись того же события заменяет его, а не дублирует
+	if err := archive.Append(ctx, "20260101T000000Z", []Event{call}, archivedAt); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := archive.Append(tenant.WithTenant(ctx, "acme"), "20260102T000000Z", []Event{finishedEvent("call", time.Hour)}, archivedAt); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	archives, err := archive.Archives(ctx)
+	if err != nil || len(archives) != 1 || archives[0].Count != 2 || !archives[0].From.Equal(*meeting.FinishedAt) {
+		t.Fatalf("Unexpected archives %+v (%v)", archives, err)
+	}
+
+	events, err := archive.Find(ctx, ArchiveFilter{Type: "call"})
+	if err != nil || len(events) != 1 || events[0].ID != call.ID || events[0].Archive != "20260101T000000Z" {
+		t.Errorf("Unexpected archived events %+v (%v)", events, err)
+	}
+	from := time.Now().Add(-45 * 24 * time.Hour)
+	if events, _ := archive.Find(ctx, ArchiveFilter{From: &from}); len(events) != 1 {
+		t.Errorf("Expected 1 event finished after %s, got %d", from, len(events))
+	}
+
+	var scanned []Event
+	err = archive.Scan(ctx, "20260101T000000Z", 1, func(events []Event) error {
+		if len(events) != 1 {
+			t.Errorf("Expected batches of 1 event, got %d", len(events))
+		}
+		scanned = append(scanned, events...)
+		return nil
+	})
+	if err != nil || len(scanned) != 2 {
+		t.Errorf("Expected 2 scanned events, got %d (%v)", len(scanned), err)
+	}
+
+	// Keep оставляет в архиве только перечисленные события
+	if err := archive.Keep(ctx, "20260101T000000Z", []primitive.ObjectID{meeting.ID}); err != nil {
+		t.Fatalf("Keep failed: %v", err)
+	}
+	if events, _ := archive.Find(ctx, ArchiveFilter{}); len(events) != 1 || events[0].ID != meeting.ID {
+		t.Errorf("Expected only the meeting to remain, got %+v", events)
+	}
+
+	if err := archive.Remove(ctx, "20260101T000000Z"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := archive.Remove(ctx, "20260101T000000Z"); !errors.Is(err, ErrArchiveNotFound) {
+		t.Errorf("Expected ErrArchiveNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"event-service/pkg/audit"
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RetentionRepository — операции с коллекцией событий, которые нужны хранению и архивации
+// Реализуется EventRepository
+type RetentionRepository interface {
+	Tenants(ctx context.Context) ([]string, error)
+	FindExpired(ctx context.Context, filter ExpiredFilter) ([]Event, error)
+	Expire(ctx context.Context, events []Event) ([]primitive.ObjectID, error)
+	Restore(ctx context.Context, events []Event) ([]primitive.ObjectID, error)
+}
+
+// TypeLister — реестр типов, из которого берутся сроки хранения типов (см. TypeService)
+type TypeLister interface {
+	List(ctx context.Context) ([]EventType, error)
+}
+
+// ArchiveService применяет сроки хранения завершённых событий, отвечает на запросы к архиву
+// и восстанавливает архивы
+// Проход (Apply) обходит всех арендаторов: для каждого типа со своим retentionDays и для остальных
+// типов по общему сроку (RetentionPolicy.Days) находит события, завершённые раньше срока,
+// переносит их в архив (или просто удаляет) и записывает это в журнал аудита
+// Все события одного прохода попадают в один архив, названный временем начала прохода
+type ArchiveService struct {
+	// events — коллекция событий, из которой события уходят в архив и куда восстанавливаются
+	events RetentionRepository
+
+	// archive — хранилище архива (коллекция или файлы)
+	archive ArchiveStore
+
+	// types — реестр типов со сроками хранения; может быть nil — тогда действует только общий срок
+	types TypeLister
+
+	// policy — общий срок хранения, режим и размер шага
+	policy RetentionPolicy
+
+	// audit — журнал аудита; может быть nil
+	audit Auditor
+
+	// now — текущее время (подменяется в тестах)
+	now func() time.Time
+}
+
+// NewArchiveService создаёт сервис хранения и архива
+// Нулевые поля policy заменяются значениями по умолчанию: режим archive и шаг DefaultRetentionBatchSize
+func NewArchiveService(events RetentionRepository, archive ArchiveStore, types TypeLister, policy RetentionPolicy) *ArchiveService {
+	if policy.Mode == "" {
+		policy.Mode = RetentionArchive
+	}
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = DefaultRetentionBatchSize
+	}
+	return &ArchiveService{events: events, archive: archive, types: types, policy: policy, now: time.Now}
+}
+
+// SetAuditor подключает журнал аудита: каждое архивированное, удалённое по сроку
+// и восстановленное событие записывается в него
+func (s *ArchiveService) SetAuditor(a Auditor) {
+	s.audit = a
+}
+
+// Run применяет сроки хранения сразу и затем каждые interval, пока не будет отменён ctx
+// Ошибки прохода пишутся в лог, следующий проход продолжит с того же места
+// Предназначен для фоновой задачи; на нескольких экземплярах проходы безопасно пересекаются:
+// архивация идемпотентна, а удаление не трогает события, изменённые после чтения
+func (s *ArchiveService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := s.Apply(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Ошибка при применении сроков хранения событий", "error", err,
+				"archived", report.Archived, "deleted", report.Deleted)
+		} else if report.Archived > 0 || report.Deleted > 0 {
+			slog.InfoContext(ctx, "Применены сроки хранения событий", "archived", report.Archived, "deleted", report.Deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Apply выполняет один проход по всем арендаторам
+// Ошибка у одного арендатора не останавливает остальных; отчёт учитывает всё, что успело выполниться
+func (s *ArchiveService) Apply(ctx context.Context) (report RetentionReport, err error) {
+	ctx, span := startSpan(ctx, "ArchiveService.Apply")
+	defer endSpan(span, &err)
+
+	tenants, err := s.events.Tenants(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	now := s.now().UTC().Truncate(time.Millisecond)
+	name := archiveName(now)
+	var errs []error
+	for _, id := range tenants {
+		tenantReport, err := s.applyTenant(tenant.WithTenant(ctx, id), name, now)
+		report.Archived += tenantReport.Archived
+		report.Deleted += tenantReport.Deleted
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("арендатор %s: %w", id, err))
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// expiredFilters возвращает условия выборки событий с истёкшим сроком для арендатора из контекста:
+// по одному на каждый тип со своим сроком и одно общее для остальных типов
+func (s *ArchiveService) expiredFilters(ctx context.Context, now time.Time) ([]ExpiredFilter, error) {
+	var types []EventType
+	if s.types != nil {
+		var err error
+		if types, err = s.types.List(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var filters []ExpiredFilter
+	var own []string
+	for i := range types {
+		if types[i].RetentionDays > 0 {
+			filters = append(filters, ExpiredFilter{Types: []string{types[i].Name}, Before: now.Add(-types[i].Retention())})
+			own = append(own, types[i].Name)
+		}
+	}
+	if s.policy.Days > 0 {
+		global := EventType{RetentionDays: s.policy.Days}
+		filters = append(filters, ExpiredFilter{ExcludeTypes: own, Before: now.Add(-global.Retention())})
+	}
+	return filters, nil
+}
+
+// applyTenant применяет сроки хранения к событиям арендатора из контекста шагами по policy.BatchSize
+func (s *ArchiveService) applyTenant(ctx context.Context, name string, now time.Time) (RetentionReport, error) {
+	var report RetentionReport
+	filters, err := s.expiredFilters(ctx, now)
+	if err != nil {
+		return report, err
+	}
+
+	for _, filter := range filters {
+		filter.Limit = s.policy.BatchSize
+		for {
+			events, err := s.events.FindExpired(ctx, filter)
+			if err != nil {
+				return report, err
+			}
+			if len(events) == 0 {
+				break
+			}
+			removed, err := s.expire(ctx, name, now, events)
+			if s.policy.Mode == RetentionArchive {
+				report.Archived += removed
+			} else {
+				report.Deleted += removed
+			}
+			if err != nil {
+				return report, err
+			}
+			// Неполный шаг — событий больше нет; ни одного удалённого — все изменились после чтения,
+			// и повтор прямо сейчас снова прочитал бы их же
+			if len(events) < filter.Limit || removed == 0 {
+				break
+			}
+		}
+	}
+	return report, nil
+}
+
+// expire переносит события в архив name (в режиме archive) и удаляет их из коллекции событий
+// Возвращает, сколько событий удалено из коллекции
+func (s *ArchiveService) expire(ctx context.Context, name string, now time.Time, events []Event) (int64, error) {
+	action := audit.ActionExpire
+	if s.policy.Mode == RetentionArchive {
+		// Сначала архив, потом удаление: при сбое между ними событие останется в коллекции
+		// и будет заархивировано повторно, но не потеряется
+		if err := s.archive.Append(ctx, name, events, now); err != nil {
+			return 0, err
+		}
+		action = audit.ActionArchive
+	}
+
+	removed, err := s.events.Expire(ctx, events)
+	// В журнал попадают только действительно удалённые события, даже если удаление прервалось:
+	// изменённые после чтения остаются в коллекции и будут обработаны в следующий проход
+	deleted := make(map[primitive.ObjectID]bool, len(removed))
+	for _, id := range removed {
+		deleted[id] = true
+	}
+	records := make([]audit.Record, 0, len(removed))
+	for i := range events {
+		if deleted[events[i].ID] {
+			records = append(records, auditRecord(action, &events[i], nil))
+		}
+	}
+	appendAudit(ctx, s.audit, records...)
+	slog.DebugContext(ctx, "События с истёкшим сроком хранения удалены из коллекции", "archive", name,
+		"mode", s.policy.Mode, "found", len(events), "removed", len(removed))
+	return int64(len(removed)), err
+}
+
+// Archives возвращает архивы арендатора из контекста, новые первыми
+func (s *ArchiveService) Archives(ctx context.Context) (_ []ArchiveInfo, err error) {
+	ctx, span := startSpan(ctx, "ArchiveService.Archives")
+	defer endSpan(span, &err)
+
+	return s.archive.Archives(ctx)
+}
+
+// Find возвращает архивные события арендатора из контекста по фильтру
+func (s *ArchiveService) Find(ctx context.Context, filter ArchiveFilter) (_ []ArchivedEvent, err error) {
+	ctx, span := startSpan(ctx, "ArchiveService.Find", eventTypeAttr(filter.Type))
+	defer endSpan(span, &err)
+
+	if filter.Archive != "" && !validArchiveName(filter.Archive) {
+		return nil, invalidParameter("archive", "expected.archive_name")
+	}
+	return s.archive.Find(ctx, filter)
+}
+
+// Restore возвращает события архива name в коллекцию событий и удаляет их из архива
+// Архив читается пачками по policy.BatchSize, и каждая пачка сразу восстанавливается, поэтому
+// размер архива не ограничен памятью
+// События восстанавливаются с прежними идентификаторами и версиями и с отметкой restoredAt, от которой
+// их срок хранения отсчитывается заново. Событие, которое уже есть в коллекции, не перезаписывается:
+// оно попадает в report.Skipped и остаётся в архиве. Если восстановление прервётся, архив останется
+// на месте, и его можно восстановить повторно без дублей
+func (s *ArchiveService) Restore(ctx context.Context, name string) (_ *RestoreReport, err error) {
+	ctx, span := startSpan(ctx, "ArchiveService.Restore", attribute.String("archive.name", name))
+	defer endSpan(span, &err)
+
+	if !validArchiveName(name) {
+		return nil, invalidParameter("name", "expected.archive_name")
+	}
+	report := &RestoreReport{Archive: name, Skipped: []string{}}
+	var skipped []primitive.ObjectID
+	restoredAt := s.now().UTC()
+	err = s.archive.Scan(ctx, name, s.policy.BatchSize, func(events []Event) error {
+		for i := range events {
+			events[i].RestoredAt = &restoredAt
+		}
+		inserted, err := s.events.Restore(ctx, events)
+		if err != nil {
+			return err
+		}
+		report.Restored += int64(len(inserted))
+
+		restored := make(map[primitive.ObjectID]bool, len(inserted))
+		for _, id := range inserted {
+			restored[id] = true
+		}
+		records := make([]audit.Record, 0, len(inserted))
+		for i := range events {
+			if restored[events[i].ID] {
+				records = append(records, auditRecord(audit.ActionRestore, nil, &events[i]))
+			} else {
+				skipped = append(skipped, events[i].ID)
+				report.Skipped = append(report.Skipped, events[i].ID.Hex())
+			}
+		}
+		appendAudit(ctx, s.audit, records...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(skipped) == 0 {
+		err = s.archive.Remove(ctx, name)
+	} else {
+		err = s.archive.Keep(ctx, name, skipped)
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Архив восстановлен", "archive", name, "restored", report.Restored, "skipped", len(skipped))
+	return report, nil
+}
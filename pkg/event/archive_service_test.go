@@ -0,0 +1,408 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"event-service/pkg/audit"
+	"event-service/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// retentionRepository хранит события арендаторов в памяти и ведёт себя как EventRepository
+type retentionRepository struct {
+	events map[string][]Event
+}
+
+func (r *retentionRepository) Tenants(ctx context.Context) ([]string, error) {
+	var tenants []string
+	for id := range r.events {
+		tenants = append(tenants, id)
+	}
+	slices.Sort(tenants)
+	return tenants, nil
+}
+
+func (r *retentionRepository) FindExpired(ctx context.Context, f ExpiredFilter) ([]Event, error) {
+	var found []Event
+	for _, e := range r.events[tenant.ID(ctx)] {
+		switch {
+		case e.State != Finished || !e.FinishedAt.Before(f.Before):
+		case e.RestoredAt != nil && !e.RestoredAt.Before(f.Before):
+		case len(f.Types) > 0 && !slices.Contains(f.Types, e.Type):
+		case slices.Contains(f.ExcludeTypes, e.Type):
+		default:
+			found = append(found, e)
+		}
+	}
+	slices.SortFunc(found, func(a, b Event) int { return a.FinishedAt.Compare(*b.FinishedAt) })
+	if f.Limit > 0 && len(found) > f.Limit {
+		found = found[:f.Limit]
+	}
+	return found, nil
+}
+
+func (r *retentionRepository) Expire(ctx context.Context, events []Event) ([]primitive.ObjectID, error) {
+	var removed []primitive.ObjectID
+	id := tenant.ID(ctx)
+	r.events[id] = slices.DeleteFunc(r.events[id], func(e Event) bool {
+		if slices.ContainsFunc(events, func(expired Event) bool { return expired.ID == e.ID && expired.Version == e.Version }) {
+			removed = append(removed, e.ID)
+			return true
+		}
+		return false
+	})
+	return removed, nil
+}
+
+func (r *retentionRepository) Restore(ctx context.Context, events []Event) ([]primitive.ObjectID, error) {
+	var inserted []primitive.ObjectID
+	id := tenant.ID(ctx)
+	for _, e := range events {
+		if !slices.ContainsFunc(r.events[id], func(existing Event) bool { return existing.ID == e.ID }) {
+			r.events[id] = append(r.events[id], e)
+			inserted = append(inserted, e.ID)
+		}
+	}
+	return inserted, nil
+}
+
+// staticTypes — реестр типов с заранее заданными типами
+type staticTypes []EventType
+
+func (t staticTypes) List(ctx context.Context) ([]EventType, error) {
+	return t, nil
+}
+
+// retentionFixture — события двух арендаторов: у meeting свой срок 10 дней, общий срок — 30 дней
+func retentionFixture() (*retentionRepository, staticTypes, map[string]Event) {
+	events := map[string]Event{
+		"meeting-expired": finishedEvent("meeting", 20*24*time.Hour),
+		"meeting-fresh":   finishedEvent("meeting", 5*24*time.Hour),
+		"call-expired":    finishedEvent("call", 40*24*time.Hour),
+		"call-fresh":      finishedEvent("call", 20*24*time.Hour),
+		"acme-expired":    finishedEvent("call", 50*24*time.Hour),
+	}
+	active := finishedEvent("call", 60*24*time.Hour)
+	active.State, active.FinishedAt = Active, nil
+
+	repo := &retentionRepository{events: map[string][]Event{
+		tenant.Default: {events["meeting-expired"], events["meeting-fresh"], events["call-expired"], events["call-fresh"], active},
+		"acme":         {events["acme-expired"]},
+	}}
+	return repo, staticTypes{{Name: "meeting", RetentionDays: 10}, {Name: "call"}}, events
+}
+
+func TestParseRetentionMode(t *testing.T) {
+	for _, s := range []string{"archive", "delete"} {
+		if mode, err := ParseRetentionMode(s); err != nil || string(mode) != s {
+			t.Errorf("ParseRetentionMode(%q) = %q, %v", s, mode, err)
+		}
+	}
+	if _, err := ParseRetentionMode("compress"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func TestArchiveService_Apply(t *testing.T) {
+	ctx := context.Background()
+	repo, types, events := retentionFixture()
+	archive := NewArchiveFiles(t.TempDir())
+	auditor := &recordingAuditor{}
+
+	// Шаг в одно событие проверяет, что проход продолжается, пока события не кончатся
+	service := NewArchiveService(repo, archive, types, RetentionPolicy{Days: 30, BatchSize: 1})
+	service.SetAuditor(auditor)
+	report, err := service.Apply(ctx)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if report.Archived != 3 || report.Deleted != 0 {
+		t.Errorf("Expected 3 archived events, got %+v", report)
+	}
+
+	remaining := map[string]bool{}
+	for _, e := range repo.events[tenant.Default] {
+		remaining[e.ID.Hex()] = true
+	}
+	for name, kept := range map[string]bool{"meeting-expired": false, "meeting-fresh": true, "call-expired": false, "call-fresh": true} {
+		if remaining[events[name].ID.Hex()] != kept {
+			t.Errorf("%s: expected kept=%v", name, kept)
+		}
+	}
+	if len(repo.events[tenant.Default]) != 3 || len(repo.events["acme"]) != 0 {
+		t.Errorf("Unexpected remaining events %+v", repo.events)
+	}
+
+	archived, err := archive.Find(ctx, ArchiveFilter{})
+	if err != nil || len(archived) != 2 {
+		t.Fatalf("Expected 2 archived events of the default tenant, got %d (%v)", len(archived), err)
+	}
+	if acme, _ := archive.Find(tenant.WithTenant(ctx, "acme"), ArchiveFilter{}); len(acme) != 1 || acme[0].Archive != archived[0].Archive {
+		t.Errorf("Expected the acme event in the same archive, got %+v", acme)
+	}
+	if len(auditor.records) != 3 || auditor.records[0].Action != audit.ActionArchive || auditor.records[0].After != nil {
+		t.Errorf("Unexpected audit records %+v", auditor.records)
+	}
+
+	// Повторный проход ничего не находит
+	if report, err := service.Apply(ctx); err != nil || report.Archived != 0 {
+		t.Errorf("Expected nothing to archive, got %+v (%v)", report, err)
+	}
+}
+
+func TestArchiveService_DeleteMode(t *testing.T) {
+	ctx := context.Background()
+	repo, types, _ := retentionFixture()
+	archive := NewArchiveFiles(t.TempDir())
+	auditor := &recordingAuditor{}
+
+	service := NewArchiveService(repo, archive, types, RetentionPolicy{Mode: RetentionDelete})
+	service.SetAuditor(auditor)
+	report, err := service.Apply(ctx)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	// Без общего срока истекает только срок meeting
+	if report.Deleted != 1 || report.Archived != 0 || len(repo.events[tenant.Default]) != 4 {
+		t.Errorf("Expected 1 deleted event, got %+v", report)
+	}
+	if archives, _ := archive.Archives(ctx); len(archives) != 0 {
+		t.Errorf("Delete mode should not archive, got %+v", archives)
+	}
+	if len(auditor.records) != 1 || auditor.records[0].Action != audit.ActionExpire {
+		t.Errorf("Unexpected audit records %+v", auditor.records)
+	}
+}
+
+// staleRepository возвращает события meeting в версии старше сохранённой, как если бы их изменили после чтения
+type staleRepository struct {
+	*retentionRepository
+}
+
+func (r staleRepository) FindExpired(ctx context.Context, f ExpiredFilter) ([]Event, error) {
+	events, err := r.retentionRepository.FindExpired(ctx, f)
+	for i := range events {
+		if events[i].Type == "meeting" {
+			events[i].Version--
+		}
+	}
+	return events, err
+}
+
+func TestArchiveService_ModifiedAfterRead(t *testing.T) {
+	ctx := context.Background()
+	repo, types, events := retentionFixture()
+	auditor := &recordingAuditor{}
+
+	service := NewArchiveService(staleRepository{repo}, NewArchiveFiles(t.TempDir()), types, RetentionPolicy{Days: 30, Mode: RetentionDelete})
+	service.SetAuditor(auditor)
+	report, err := service.Apply(ctx)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// Изменённое событие не удалено и не попало в журнал
+	if report.Deleted != 2 || !slices.ContainsFunc(repo.events[tenant.Default], func(e Event) bool { return e.ID == events["meeting-expired"].ID }) {
+		t.Errorf("Modified meeting should stay, got %+v", report)
+	}
+	if len(auditor.records) != 2 || slices.ContainsFunc(auditor.records, func(r audit.Record) bool { return r.EventType == "meeting" }) {
+		t.Errorf("Unexpected audit records %+v", auditor.records)
+	}
+}
+
+func TestArchiveService_Restore(t *testing.T) {
+	ctx := context.Background()
+	repo, types, events := retentionFixture()
+	archive := NewArchiveFiles(t.TempDir())
+	auditor := &recordingAuditor{}
+
+	// Шаг в одно событие: восстановление идёт несколькими пачками
+	service := NewArchiveService(repo, archive, types, RetentionPolicy{Days: 30, BatchSize: 1})
+	if _, err := service.Apply(ctx); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	service.SetAuditor(auditor)
+	archives, err := service.Archives(ctx)
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("Expected one archive, got %+v (%v)", archives, err)
+	}
+	name := archives[0].Name
+
+	report, err := service.Restore(ctx, name)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if report.Restored != 2 || len(report.Skipped) != 0 || len(repo.events[tenant.Default]) != 5 {
+		t.Errorf("Expected 2 restored events, got %+v", report)
+	}
+	restored := repo.events[tenant.Default]
+	if !slices.ContainsFunc(restored, func(e Event) bool {
+		return e.ID == events["call-expired"].ID && e.Version == 2 && e.RestoredAt != nil
+	}) {
+		t.Error("Restored event should keep its ID and version and be marked as restored")
+	}
+	if len(auditor.records) != 2 || auditor.records[0].Action != audit.ActionRestore || auditor.records[0].Before != nil {
+		t.Errorf("Unexpected audit records %+v", auditor.records)
+	}
+
+	// Архив удалён после восстановления; архив другого арендатора не тронут
+	if _, err := service.Restore(ctx, name); !errors.Is(err, ErrArchiveNotFound) {
+		t.Errorf("Expected ErrArchiveNotFound, got %v", err)
+	}
+	if archives, _ := service.Archives(tenant.WithTenant(ctx, "acme")); len(archives) != 1 {
+		t.Errorf("Expected the acme archive to remain, got %+v", archives)
+	}
+	if _, err := service.Restore(ctx, "../acme/"+name); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter, got %v", err)
+	}
+
+	// Срок хранения восстановленных событий отсчитывается от восстановления: следующий проход их не трогает
+	if report, err := service.Apply(ctx); err != nil || report.Archived != 0 || len(repo.events[tenant.Default]) != 5 {
+		t.Errorf("Restored events should stay live, got %+v (%v)", report, err)
+	}
+	// Когда истечёт и этот срок, события снова уходят в архив
+	service.now = func() time.Time { return time.Now().Add(31 * 24 * time.Hour) }
+	if report, err := service.Apply(ctx); err != nil || report.Archived != 4 {
+		t.Errorf("Expected restored events to expire again, got %+v (%v)", report, err)
+	}
+}
+
+func TestArchiveService_RestoreExisting(t *testing.T) {
+	ctx := context.Background()
+	repo, types, events := retentionFixture()
+	archive := NewArchiveFiles(t.TempDir())
+	auditor := &recordingAuditor{}
+
+	service := NewArchiveService(repo, archive, types, RetentionPolicy{Days: 30})
+	service.SetAuditor(auditor)
+	name := archiveName(time.Now())
+	// call-fresh уже в коллекции и изменён после архивации: восстановление не должно его затереть
+	live := events["call-fresh"]
+	archived := live
+	archived.Version = 1
+	if err := archive.Append(ctx, name, []Event{events["call-expired"], archived}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	repo.events[tenant.Default] = slices.DeleteFunc(repo.events[tenant.Default], func(e Event) bool { return e.ID == events["call-expired"].ID })
+
+	report, err := service.Restore(ctx, name)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if report.Restored != 1 || len(report.Skipped) != 1 || report.Skipped[0] != live.ID.Hex() {
+		t.Errorf("Expected one restored and one skipped event, got %+v", report)
+	}
+	if !slices.ContainsFunc(repo.events[tenant.Default], func(e Event) bool { return e.ID == live.ID && e.Version == live.Version }) {
+		t.Error("Live event should not be overwritten")
+	}
+	if len(auditor.records) != 1 || auditor.records[0].EventID != events["call-expired"].ID {
+		t.Errorf("Only the restored event should be audited, got %+v", auditor.records)
+	}
+
+	// Пропущенное событие осталось в архиве, восстановленное — нет
+	left, err := archive.Find(ctx, ArchiveFilter{Archive: name})
+	if err != nil || len(left) != 1 || left[0].ID != live.ID || left[0].Version != 1 {
+		t.Errorf("Expected only the skipped event in the archive, got %+v (%v)", left, err)
+	}
+}
+
+func TestArchiveService_Run(t *testing.T) {
+	repo, types, _ := retentionFixture()
+	service := NewArchiveService(repo, NewArchiveFiles(t.TempDir()), types, RetentionPolicy{Days: 30})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	// Первый проход выполняется сразу, не дожидаясь интервала
+	deadline := time.After(5 * time.Second)
+	for {
+		archives, _ := service.Archives(context.Background())
+		if len(archives) == 1 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("Run did not apply retention on start")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
+
+func TestArchiveHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, types, events := retentionFixture()
+	service := NewArchiveService(repo, NewArchiveFiles(t.TempDir()), types, RetentionPolicy{Days: 30})
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	name := archiveName(now)
+	if _, err := service.Apply(context.Background()); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	handler := NewArchiveHandler(service, DefaultMaxLimit)
+	router := gin.New()
+	router.GET("/archives", handler.Archives)
+	router.GET("/archives/events", handler.Events)
+	router.POST("/archives/:name/restore", handler.Restore)
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := serve(http.MethodGet, "/archives")
+	var archives []ArchiveInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &archives); err != nil || len(archives) != 1 || archives[0].Count != 2 {
+		t.Fatalf("Unexpected archives %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(http.MethodGet, "/archives/events?type=call&limit=10")
+	var archived []ArchivedEventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &archived); err != nil || len(archived) != 1 {
+		t.Fatalf("Unexpected archived events %d: %s", w.Code, w.Body.String())
+	}
+	if archived[0].ID != events["call-expired"].ID.Hex() || archived[0].Archive != name || archived[0].State != "finished" {
+		t.Errorf("Unexpected archived event %+v", archived[0])
+	}
+
+	for _, tt := range []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{http.MethodGet, "/archives/events?from=yesterday", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodGet, "/archives/events?limit=0", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodGet, "/archives/events?archive=latest", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodPost, "/archives/latest/restore", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodPost, "/archives/20250101T000000Z/restore", http.StatusNotFound, "archive_not_found"},
+		{http.MethodPost, "/archives/" + name + "/restore", http.StatusOK, `"restored":2`},
+	} {
+		w := serve(tt.method, tt.path)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.code) {
+			t.Errorf("%s %s: expected %d %s, got %d: %s", tt.method, tt.path, tt.status, tt.code, w.Code, w.Body.String())
+		}
+	}
+	if len(repo.events[tenant.Default]) != 5 {
+		t.Errorf("Expected restored events, got %d", len(repo.events[tenant.Default]))
+	}
+}
@@ -0,0 +1,155 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"event-service/pkg/audit"
+)
+
+// recordingAuditor запоминает записи журнала аудита вместо сохранения в базу
+type recordingAuditor struct {
+	records []audit.Record
+	err     error
+}
+
+func (a *recordingAuditor) Append(ctx context.Context, records ...audit.Record) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.records = append(a.records, records...)
+	return nil
+}
+
+// snapshotOf разворачивает снимок события из записи журнала
+func snapshotOf(t *testing.T, v interface{}) EventResponse {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp EventResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestEventService_Audit_StartAndFinish(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	auditor := &recordingAuditor{}
+	service := NewEventService(repo, nil)
+	service.SetAuditor(auditor)
+	ctx := context.Background()
+
+	started, err := service.Start(ctx, StartParams{Type: "meeting", StartedBy: "alice"})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	// Повторный запуск возвращает то же событие и ничего не меняет
+	if _, err := service.Start(ctx, StartParams{Type: "meeting"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if _, err := service.Finish(ctx, FinishParams{Type: "meeting", FinishedBy: "bob"}); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	if len(auditor.records) != 2 {
+		t.Fatalf("Expected 2 audit records, got %d", len(auditor.records))
+	}
+	start, finish := auditor.records[0], auditor.records[1]
+	if start.Action != audit.ActionStart || start.EventID != started.ID || start.Before != nil {
+		t.Errorf("Unexpected start record: %+v", start)
+	}
+	if finish.Action != audit.ActionFinish || finish.EventID != started.ID || finish.EventType != "meeting" {
+		t.Errorf("Unexpected finish record: %+v", finish)
+	}
+	if before := snapshotOf(t, finish.Before); before.State != "started" || before.FinishedAt != nil {
+		t.Errorf("Before snapshot should be the active event: %+v", before)
+	}
+	if after := snapshotOf(t, finish.After); after.State != "finished" || after.FinishedBy != "bob" {
+		t.Errorf("After snapshot should be the finished event: %+v", after)
+	}
+}
+
+func TestEventService_Audit_Cascade(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	auditor := &recordingAuditor{}
+	service := NewEventService(repo, nil)
+	service.SetAuditor(auditor)
+	ctx := context.Background()
+
+	meeting, _ := service.Start(ctx, StartParams{Type: "meeting"})
+	qa, _ := service.Start(ctx, StartParams{Type: "qa", ParentID: &meeting.ID})
+	auditor.records = nil
+
+	if _, err := service.Finish(ctx, FinishParams{Type: "meeting", Cascade: true}); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if len(auditor.records) != 2 {
+		t.Fatalf("Expected records for the parent and the child, got %d", len(auditor.records))
+	}
+	child := auditor.records[1]
+	if child.EventID != qa.ID || snapshotOf(t, child.Before).State != "started" || snapshotOf(t, child.After).State != "finished" {
+		t.Errorf("Unexpected cascade record: %+v", child)
+	}
+}
+
+func TestEventService_Audit_Import(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	auditor := &recordingAuditor{}
+	service := NewEventService(repo, nil)
+	service.SetAuditor(auditor)
+
+	input := `{"type":"meeting","state":"finished","startedAt":"2024-01-01T10:00:00Z","finishedAt":"2024-01-01T11:00:00Z"}
+{"type":"call","state":"started","startedAt":"2024-01-01T10:00:00Z"}
+`
+	if _, err := service.Import(context.Background(), strings.NewReader(input), ImportFormatNDJSON, true); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(auditor.records) != 0 {
+		t.Errorf("Dry run should not be audited, got %d records", len(auditor.records))
+	}
+
+	if _, err := service.Import(context.Background(), strings.NewReader(input), ImportFormatNDJSON, false); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(auditor.records) != 2 || auditor.records[0].Action != audit.ActionImport || auditor.records[0].EventID.IsZero() {
+		t.Errorf("Unexpected import records: %+v", auditor.records)
+	}
+}
+
+func TestEventService_Audit_Failure(t *testing.T) {
+	repo := &startRepository{active: map[string]*Event{}}
+	service := NewEventService(repo, nil)
+	service.SetAuditor(&recordingAuditor{err: errors.New("audit is down")})
+
+	// Событие уже создано — сбой журнала не превращает запуск в ошибку
+	started, err := service.Start(context.Background(), StartParams{Type: "meeting"})
+	if err != nil || started == nil {
+		t.Fatalf("Audit failure should not fail a committed start, got %v", err)
+	}
+	if active, _ := repo.FindActive(context.Background(), "meeting"); active == nil || active.ID != started.ID {
+		t.Errorf("Expected the started event to be saved, got %+v", active)
+	}
+}
+
+func TestAuditRecord(t *testing.T) {
+	record := auditRecord(audit.ActionStart, nil, &Event{Type: "meeting"})
+	if record.Before != nil {
+		// Пустой указатель в интерфейсе сериализовался бы как null
+		t.Error("Missing before snapshot should stay nil")
+	}
+	if record.EventType != "meeting" || record.After == nil {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+}
@@ -0,0 +1,120 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"event-service/pkg/problem"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrUnknownEventType возвращается в строгом режиме, если тип не зарегистрирован в реестре
+	ErrUnknownEventType = errors.New("тип события не зарегистрирован")
+
+	// ErrEventTypeExists возвращается при попытке зарегистрировать тип повторно
+	ErrEventTypeExists = errors.New("тип события уже зарегистрирован")
+
+	// ErrEventTypeNotFound возвращается, если типа нет в реестре (при получении, изменении или удалении)
+	ErrEventTypeNotFound = errors.New("тип события не найден")
+
+	// ErrInvalidEventType оборачивает ошибки проверки описания типа события
+	ErrInvalidEventType = errors.New("некорректное описание типа события")
+
+	// ErrInvalidTypeName возвращается, если имя типа не соответствует правилу именования
+	ErrInvalidTypeName = errors.New("тип события не соответствует правилу именования")
+
+	// ErrInvalidAttributes — атрибуты события не прошли проверку (см. AttributeValidationError)
+	ErrInvalidAttributes = errors.New("атрибуты события не соответствуют схеме типа")
+
+	// ErrEventNotFound возвращается, если события нет: при завершении — активного события этого типа
+	ErrEventNotFound = errors.New("событие не найдено")
+
+	// ErrParentNotFound возвращается, если указанное родительское событие не существует
+	ErrParentNotFound = errors.New("родительское событие не найдено")
+
+	// ErrParentNotActive возвращается, если родительское событие уже завершено
+	ErrParentNotActive = errors.New("родительское событие уже завершено")
+
+	// ErrPreconditionFailed возвращается, если событие изменилось после того, как клиент его прочитал:
+	// ETag из заголовка If-Match больше не соответствует событию
+	ErrPreconditionFailed = errors.New("событие изменилось, версия из If-Match устарела")
+
+	// ErrVersionConflict — событие изменили между чтением и записью, и изменение не сохранено
+	// Репозиторий возвращает его в виде *ConflictError
+	ErrVersionConflict = errors.New("событие изменено одновременно с другим запросом")
+
+	// ErrEventHasChildren возвращается при удалении события, в которое вложены другие события
+	ErrEventHasChildren = errors.New("у события есть вложенные события")
+
+	// ErrArchiveNotFound возвращается, если архива с таким именем нет (при восстановлении)
+	ErrArchiveNotFound = errors.New("архив не найден")
+
+	// ErrInvalidRequest возвращается, если тело запроса не разобрано или в нём нет обязательных полей
+	ErrInvalidRequest = errors.New("некорректное тело запроса")
+
+	// ErrInvalidParameter возвращается, если некорректен параметр запроса (query или путь)
+	ErrInvalidParameter = errors.New("некорректный параметр запроса")
+)
+
+// ConflictError — условное изменение не застало событие в ожидаемой версии:
+// между чтением и записью событие изменили, завершили или удалили
+// Находится через errors.Is(err, ErrVersionConflict)
+type ConflictError struct {
+	// ID — изменяемое событие
+	ID primitive.ObjectID
+	// Version — версия, которую ожидало изменение
+	Version int64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: событие %s, ожидалась версия %d", ErrVersionConflict, e.ID.Hex(), e.Version)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// FieldsError — ошибка проверки данных запроса вместе с ошибками отдельных полей
+// Вид ошибки (Err) находится через errors.Is, поля попадают в ответ API
+type FieldsError struct {
+	Err    error
+	Fields []FieldError
+}
+
+func (e *FieldsError) Error() string {
+	return e.Err.Error() + ": " + joinFields(e.Fields)
+}
+
+func (e *FieldsError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FieldsError) fieldErrors() []FieldError {
+	return e.Fields
+}
+
+// FieldErrors возвращает ошибки отдельных полей, если err их несёт (FieldsError или AttributeValidationError)
+func FieldErrors(err error) []FieldError {
+	var fields fieldErrors
+	if errors.As(err, &fields) {
+		return fields.fieldErrors()
+	}
+	return nil
+}
+
+// invalidParameter — ошибка в параметре name; сообщение key из каталога i18n объясняет, какое значение ожидается
+func invalidParameter(name, key string, args ...any) error {
+	return &FieldsError{Err: ErrInvalidParameter, Fields: []FieldError{problem.Field(name, key, args...)}}
+}
+
+// joinFields склеивает ошибки полей в одну строку: "/path: сообщение; ..."
+func joinFields(fields []FieldError) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Path + ": " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,89 @@
+package event
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagOf возвращает сильный ETag ответа со списком событий или одним событием
+// ETag строится из числа событий, времени изменения самого свежего из них, а также
+// идентификаторов и версий всех событий: любое изменение, удаление или сдвиг события на странице
+// меняют ETag, а пока в базе ничего не менялось, один и тот же запрос получает тот же ETag
+func etagOf(events []Event) string {
+	var newest time.Time
+	for i := range events {
+		if modified := events[i].ModifiedAt(); modified.After(newest) {
+			newest = modified
+		}
+	}
+
+	h := sha256.New()
+	var buf [8]byte
+	write := func(v uint64) {
+		binary.BigEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	write(uint64(len(events)))
+	// MongoDB хранит время с точностью до миллисекунды
+	write(uint64(newest.UnixMilli()))
+	for i := range events {
+		h.Write(events[i].ID[:])
+		write(uint64(events[i].Version))
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches сообщает, подходит ли etag к списку из заголовка If-Match или If-None-Match
+// "*" подходит к любому ETag. If-None-Match сравнивает ETag слабо (префикс W/ не учитывается),
+// If-Match — строго: слабый ETag в нём не подходит ни к какому (RFC 9110, раздел 8.8.3.2)
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified проставляет ответу заголовок ETag и, если клиент уже получал ответ с этим ETag
+// (If-None-Match), отвечает 304 Not Modified без тела
+// Возвращает true, если ответ уже отправлен
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	// Ответы зависят от прав клиента, поэтому хранить их может только сам клиент —
+	// и только с проверкой ETag перед каждым использованием
+	c.Header("Cache-Control", "private, no-cache")
+	if header := c.GetHeader("If-None-Match"); header != "" && etagMatches(header, etag, true) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// ifMatch проверяет заголовок If-Match по текущему состоянию события current
+// Возвращает версию, которую изменение должно застать (nil — заголовка нет или в нём "*"),
+// или ErrPreconditionFailed, если ETag из заголовка устарел
+func ifMatch(c *gin.Context, current *Event) (*int64, error) {
+	header := c.GetHeader("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return nil, nil
+	}
+	if !etagMatches(header, etagOf([]Event{*current}), false) {
+		return nil, ErrPreconditionFailed
+	}
+	version := current.Version
+	return &version, nil
+}
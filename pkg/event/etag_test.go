@@ -0,0 +1,202 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// versionedRepository хранит события в памяти и, как EventRepository,
+// изменяет и удаляет событие только при совпадении версии
+type versionedRepository struct {
+	Repository
+	events []*Event
+}
+
+func (r *versionedRepository) add(eventType string) *Event {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	e := &Event{ID: primitive.NewObjectID(), Type: eventType, StartedAt: now, UpdatedAt: now, Version: 1}
+	r.events = append(r.events, e)
+	return e
+}
+
+func (r *versionedRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*Event, error) {
+	for _, e := range r.events {
+		if e.ID == id {
+			found := *e
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *versionedRepository) Find(ctx context.Context, filter ListFilter) ([]Event, error) {
+	events := []Event{}
+	for _, e := range r.events {
+		events = append(events, *e)
+	}
+	return events, nil
+}
+
+func (r *versionedRepository) FindChildren(ctx context.Context, parentIDs []primitive.ObjectID) ([]Event, error) {
+	return nil, nil
+}
+
+func (r *versionedRepository) UpdateAttributes(ctx context.Context, id primitive.ObjectID, version int64, attributes map[string]interface{}) (*Event, error) {
+	for _, e := range r.events {
+		if e.ID == id && e.Version == version {
+			e.Attributes, e.UpdatedAt = attributes, time.Now().UTC()
+			e.Version++
+			updated := *e
+			return &updated, nil
+		}
+	}
+	return nil, &ConflictError{ID: id, Version: version}
+}
+
+func (r *versionedRepository) Delete(ctx context.Context, id primitive.ObjectID, version int64) error {
+	for i, e := range r.events {
+		if e.ID == id && e.Version == version {
+			r.events = append(r.events[:i], r.events[i+1:]...)
+			return nil
+		}
+	}
+	return &ConflictError{ID: id, Version: version}
+}
+
+func TestETag(t *testing.T) {
+	now := time.Now()
+	a := Event{ID: primitive.NewObjectID(), StartedAt: now, Version: 1}
+	b := Event{ID: primitive.NewObjectID(), StartedAt: now, Version: 1}
+
+	etag := etagOf([]Event{a, b})
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		t.Errorf("ETag should be a quoted strong tag, got %s", etag)
+	}
+	if etagOf([]Event{a, b}) != etag {
+		t.Error("ETag should be stable for the same events")
+	}
+
+	changed := b
+	changed.Version++
+	for name, events := range map[string][]Event{
+		"version": {a, changed},
+		"count":   {a},
+		"order":   {b, a},
+		"empty":   nil,
+	} {
+		if etagOf(events) == etag {
+			t.Errorf("%s: ETag should change", name)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		match  bool
+	}{
+		{`"abc"`, false, true},
+		{`"x", "abc"`, false, true},
+		{`*`, false, true},
+		{`"x"`, true, false},
+		{`W/"abc"`, true, true},
+		// If-Match сравнивает строго: слабый ETag не подходит
+		{`W/"abc"`, false, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`, tt.weak); got != tt.match {
+			t.Errorf("etagMatches(%s, weak=%v) = %v, expected %v", tt.header, tt.weak, got, tt.match)
+		}
+	}
+}
+
+// TestHandler_ConditionalRequests проверяет 304 по If-None-Match и 412 по устаревшему If-Match
+func TestHandler_ConditionalRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &versionedRepository{}
+	meeting := repo.add("meeting")
+	repo.add("call")
+
+	handler := NewEventHandler(NewEventService(repo, nil), DefaultMaxLimit)
+	router := gin.New()
+	router.GET("/", handler.List)
+	router.GET("/events/:id", handler.Get)
+	router.PATCH("/events/:id", handler.Update)
+	router.DELETE("/events/:id", handler.Delete)
+
+	serve := func(method, path, body string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	path := "/events/" + meeting.ID.Hex()
+
+	list := serve(http.MethodGet, "/", "")
+	listETag := list.Header().Get("ETag")
+	if list.Code != http.StatusOK || listETag == "" {
+		t.Fatalf("Expected 200 with ETag, got %d %q", list.Code, listETag)
+	}
+	if w := serve(http.MethodGet, "/", "", "If-None-Match", listETag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected 304 without body, got %d %s", w.Code, w.Body.String())
+	}
+
+	single := serve(http.MethodGet, path, "")
+	etag := single.Header().Get("ETag")
+	if single.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected 200 with ETag, got %d %q", single.Code, etag)
+	}
+	if w := serve(http.MethodGet, path, "", "If-None-Match", "W/"+etag); w.Code != http.StatusNotModified {
+		t.Errorf("Weak If-None-Match should match, got %d", w.Code)
+	}
+
+	patched := serve(http.MethodPatch, path, `{"attributes":{"room":"A"}}`, "If-Match", etag)
+	if patched.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", patched.Code, patched.Body.String())
+	}
+	var resp EventResponse
+	if err := json.Unmarshal(patched.Body.Bytes(), &resp); err != nil || resp.Version != 2 || resp.Attributes["room"] != "A" {
+		t.Errorf("Unexpected patched event %+v (%v)", resp, err)
+	}
+	newETag := patched.Header().Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Errorf("PATCH should return a new ETag, got %q", newETag)
+	}
+	if w := serve(http.MethodGet, "/", "", "If-None-Match", listETag); w.Code != http.StatusOK {
+		t.Errorf("List should change after PATCH, got %d", w.Code)
+	}
+
+	// Устаревший ETag: событие уже изменено
+	if w := serve(http.MethodPatch, path, `{"attributes":{"room":null}}`, "If-Match", etag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for stale If-Match, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(http.MethodDelete, path, "", "If-Match", etag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for stale If-Match, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Без If-Match изменение применяется к текущей версии
+	if w := serve(http.MethodPatch, path, `{"attributes":{"room":null}}`); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "room") {
+		t.Errorf("Expected room to be removed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	current := serve(http.MethodGet, path, "").Header().Get("ETag")
+	if w := serve(http.MethodDelete, path, "", "If-Match", current); w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after DELETE, got %d", w.Code)
+	}
+}
@@ -0,0 +1,140 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"event-service/pkg/tenant"
+)
+
+// ChangeKind — вид изменения события в потоке изменений
+type ChangeKind int
+
+const (
+	// ChangeStarted — событие запущено
+	ChangeStarted ChangeKind = iota + 1
+	// ChangeFinished — событие завершено, в том числе каскадно вместе с родителем
+	ChangeFinished
+)
+
+// Change — изменение события, которое получают подписчики Feed
+type Change struct {
+	Kind  ChangeKind
+	Event Event
+}
+
+// DefaultFeedBuffer — сколько изменений по умолчанию может ждать в очереди одного подписчика
+const DefaultFeedBuffer = 256
+
+// ErrSubscriberLagged — подписчик не успевал забирать изменения, и его очередь переполнилась
+// Подписка закрывается, чтобы клиент не пропустил изменения незаметно: он должен подписаться заново
+var ErrSubscriberLagged = errors.New("подписчик не успевал получать изменения событий")
+
+// Feed рассылает запуски и завершения событий подписчикам внутри процесса
+// Подключается к сервису как наблюдатель (SetObserver) и видит только изменения,
+// сделанные этим экземпляром сервиса; подписчик получает изменения только своего арендатора
+//
+// Рассылка не блокирует сервис: у каждого подписчика своя очередь на buffer изменений,
+// и подписчик, у которого она переполнилась, отключается с ErrSubscriberLagged
+type Feed struct {
+	buffer int
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// NewFeed создаёт поток изменений с очередью на buffer изменений для каждого подписчика
+// (0 — DefaultFeedBuffer)
+func NewFeed(buffer int) *Feed {
+	if buffer <= 0 {
+		buffer = DefaultFeedBuffer
+	}
+	return &Feed{buffer: buffer, subscribers: map[*Subscription]struct{}{}}
+}
+
+// Subscription — подписка на изменения событий
+// Изменения читаются из Changes; после закрытия канала причину сообщает Err
+type Subscription struct {
+	feed    *Feed
+	tenant  string
+	match   func(eventType string) bool
+	changes chan Change
+	err     error
+}
+
+// Subscribe подписывается на изменения событий арендатора из ctx
+// match отбирает типы событий (nil — все типы); подписку нужно закрыть через Close
+func (f *Feed) Subscribe(ctx context.Context, match func(eventType string) bool) *Subscription {
+	s := &Subscription{
+		feed:    f,
+		tenant:  tenant.ID(ctx),
+		match:   match,
+		changes: make(chan Change, f.buffer),
+	}
+	f.mu.Lock()
+	f.subscribers[s] = struct{}{}
+	f.mu.Unlock()
+	return s
+}
+
+// Changes возвращает канал изменений; он закрывается после Close или отключения отстающего подписчика
+func (s *Subscription) Changes() <-chan Change {
+	return s.changes
+}
+
+// Err возвращает ErrSubscriberLagged, если подписка закрыта из-за переполнения очереди
+// Читать его имеет смысл после закрытия канала Changes
+func (s *Subscription) Err() error {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	return s.err
+}
+
+// Close отменяет подписку; повторный вызов ничего не делает
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.remove(s, nil)
+}
+
+// remove отключает подписчика с причиной err; вызывается под f.mu
+func (f *Feed) remove(s *Subscription, err error) {
+	if _, ok := f.subscribers[s]; !ok {
+		return
+	}
+	delete(f.subscribers, s)
+	s.err = err
+	close(s.changes)
+}
+
+// EventStarted рассылает запуск события (event.Observer)
+// Возврат уже активного события — не изменение, поэтому он не рассылается
+func (f *Feed) EventStarted(ctx context.Context, event *Event, deduplicated bool) {
+	if !deduplicated {
+		f.publish(ctx, Change{Kind: ChangeStarted, Event: *event})
+	}
+}
+
+// EventFinished рассылает завершение события (event.Observer)
+func (f *Feed) EventFinished(ctx context.Context, event *Event) {
+	f.publish(ctx, Change{Kind: ChangeFinished, Event: *event})
+}
+
+// publish кладёт изменение в очереди подходящих подписчиков, не дожидаясь их
+func (f *Feed) publish(ctx context.Context, change Change) {
+	tenantID := tenant.ID(ctx)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for s := range f.subscribers {
+		if s.tenant != tenantID || (s.match != nil && !s.match(change.Event.Type)) {
+			continue
+		}
+		select {
+		case s.changes <- change:
+		default:
+			f.remove(s, ErrSubscriberLagged)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"event-service/pkg/tenant"
+)
+
+func TestFeed_Changes(t *testing.T) {
+	feed := NewFeed(0)
+	acme := tenant.WithTenant(context.Background(), "acme")
+
+	all := feed.Subscribe(acme, nil)
+	defer all.Close()
+	meetings := feed.Subscribe(acme, func(eventType string) bool { return eventType == "meeting" })
+	defer meetings.Close()
+	other := feed.Subscribe(tenant.WithTenant(context.Background(), "globex"), nil)
+	defer other.Close()
+
+	feed.EventStarted(acme, &Event{Type: "meeting"}, false)
+	feed.EventStarted(acme, &Event{Type: "meeting"}, true)
+	feed.EventFinished(acme, &Event{Type: "call", State: Finished})
+
+	if got := drain(all); len(got) != 2 || got[0].Kind != ChangeStarted || got[1].Kind != ChangeFinished {
+		t.Errorf("Expected start and finish, got %+v", got)
+	}
+	if got := drain(meetings); len(got) != 1 || got[0].Event.Type != "meeting" {
+		t.Errorf("Expected only the meeting, got %+v", got)
+	}
+	if got := drain(other); len(got) != 0 {
+		t.Errorf("Changes of another tenant leaked: %+v", got)
+	}
+}
+
+func TestFeed_Lagged(t *testing.T) {
+	feed := NewFeed(1)
+	ctx := context.Background()
+	slow := feed.Subscribe(ctx, nil)
+
+	feed.EventStarted(ctx, &Event{Type: "a"}, false)
+	feed.EventStarted(ctx, &Event{Type: "b"}, false)
+
+	if got := drain(slow); len(got) != 1 {
+		t.Errorf("Expected the buffered change, got %+v", got)
+	}
+	if _, open := <-slow.Changes(); open {
+		t.Fatal("Subscription of a lagging subscriber should be closed")
+	}
+	if !errors.Is(slow.Err(), ErrSubscriberLagged) {
+		t.Errorf("Expected ErrSubscriberLagged, got %v", slow.Err())
+	}
+	// Отключённый подписчик больше ничего не получает, Close после отключения безопасен
+	feed.EventStarted(ctx, &Event{Type: "c"}, false)
+	slow.Close()
+}
+
+func TestFeed_Close(t *testing.T) {
+	feed := NewFeed(0)
+	s := feed.Subscribe(context.Background(), nil)
+	s.Close()
+	s.Close()
+	if _, open := <-s.Changes(); open || s.Err() != nil {
+		t.Errorf("Expected closed subscription without error, got %v", s.Err())
+	}
+}
+
+// drain забирает изменения, уже лежащие в очереди подписки
+func drain(s *Subscription) []Change {
+	var changes []Change
+	for {
+		select {
+		case change, ok := <-s.Changes():
+			if !ok {
+				return changes
+			}
+			changes = append(changes, change)
+		default:
+			return changes
+		}
+	}
+}
@@ -1,12 +1,19 @@
 package event
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"regexp"
+	"reflect"
 	"strconv"
+	"strings"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/problem"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // StartRequest — это структура для запроса на создание/запуск события
@@ -14,20 +21,62 @@ import (
 type StartRequest struct {
 	// Type — тип события, который нужно создать или завершить
 	// Поле обязательно — если его нет, вернём ошибку 400
-	// Должен соответствовать pattern: ^[a-z0-9]+$
+	// Должен соответствовать настроенному правилу именования (настройки event_types, см. GET /v1/naming-rule)
 	Type string `json:"type" binding:"required"`
+
+	// Attributes — необязательная полезная нагрузка события
+	// При запуске становится атрибутами нового события, при завершении добавляется к ним
+	Attributes map[string]interface{} `json:"attributes"`
+
+	// ParentID — необязательный идентификатор родительского события
+	// Родитель должен существовать и быть активным
+	ParentID string `json:"parentId"`
+}
+
+// FinishRequest — структура для запроса на завершение события
+type FinishRequest struct {
+	// Type — тип события, которое нужно завершить
+	Type string `json:"type" binding:"required"`
+
+	// Attributes — необязательные атрибуты, которые добавятся к событию
+	Attributes map[string]interface{} `json:"attributes"`
+
+	// Cascade — завершить вместе с событием все его активные вложенные события
+	Cascade bool `json:"cascade"`
 }
 
-// ErrorResponse представляет ошибку в формате API согласно OpenAPI контракту
-type ErrorResponse struct {
-	Message string `json:"message"`
+// UpdateRequest — структура для запроса на изменение атрибутов события
+type UpdateRequest struct {
+	// Attributes — изменения атрибутов: null удаляет атрибут, другое значение добавляет или заменяет его
+	Attributes map[string]interface{} `json:"attributes" binding:"required"`
 }
 
-var typePattern = regexp.MustCompile(`^[a-z0-9]+$`)
+// bindJSON разбирает тело запроса в req
+// Ошибки оборачивают ErrInvalidRequest; незаполненные обязательные поля перечисляются в ошибках полей
+func bindJSON(c *gin.Context, req interface{}) error {
+	err := c.ShouldBindJSON(req)
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		fields := make([]FieldError, len(invalid))
+		for i, fe := range invalid {
+			fields[i] = problem.Field("/"+jsonFieldName(req, fe.StructField()), "field.required")
+		}
+		return &FieldsError{Err: ErrInvalidRequest, Fields: fields}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	return nil
+}
 
-// validateEventType проверяет, что тип события соответствует pattern '^[a-z0-9]+$'
-func validateEventType(eventType string) bool {
-	return typePattern.MatchString(eventType)
+// jsonFieldName возвращает имя поля структуры *req в JSON (из тега json)
+func jsonFieldName(req interface{}, field string) string {
+	if f, ok := reflect.TypeOf(req).Elem().FieldByName(field); ok {
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+			return name
+		}
+	}
+	return field
 }
 
 // EventHandler обрабатывает все HTTP-запросы, связанные с событиями
@@ -35,38 +84,64 @@ func validateEventType(eventType string) bool {
 type EventHandler struct {
 	// service — это наш бизнес-слой, который знает, как работать с событиями
 	service *EventService
+	// maxLimit — наибольшее значение параметра limit в списке событий
+	maxLimit int
 }
 
+// DefaultMaxLimit — наибольшее значение параметра limit по умолчанию
+const DefaultMaxLimit = 100
+
 // NewEventHandler создаёт новый обработчик HTTP-запросов
-// Нужно просто передать ему сервис, который будет выполнять всю работу
-func NewEventHandler(service *EventService) *EventHandler {
-	return &EventHandler{service: service}
+// Нужно передать ему сервис, который будет выполнять всю работу,
+// и наибольший размер страницы в списке событий (обычно DefaultMaxLimit)
+func NewEventHandler(service *EventService, maxLimit int) *EventHandler {
+	return &EventHandler{service: service, maxLimit: maxLimit}
 }
 
 // Start обрабатывает запрос на запуск нового события
 // Принимает JSON с полем "type" и создаёт новое событие, если активного ещё нет
 func (h *EventHandler) Start(c *gin.Context) {
+	defer traceHandler(c, "EventHandler.Start")()
+
 	var req StartRequest
 
 	// Проверяем, что в запросе есть поле "type" и оно не пустое
-	// Если нет — автоматически вернём 400 Bad Request
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Поле 'type' обязательно и не может быть пустым"})
+	// Если нет — вернём 400 с ошибкой поля /type
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err, failedStart)
 		return
 	}
 
-	// Валидируем формат типа события согласно OpenAPI контракту: ^[a-z0-9]+$
-	if !validateEventType(req.Type) {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Тип события должен содержать только строчные буквы и цифры"})
+	// Валидируем формат типа события по настроенному правилу именования
+	// (по умолчанию — ^[a-z0-9]+$, действующее правило отдаёт GET /v1/naming-rule)
+	if err := h.service.ValidateType(req.Type); err != nil {
+		respondError(c, err, failedStart)
 		return
 	}
 
+	// Ключ может быть ограничен определёнными типами событий
+	if !auth.Allowed(c.Request.Context(), auth.ScopeStart, req.Type) {
+		auth.Forbidden(c)
+		return
+	}
+
+	params := StartParams{Type: req.Type, Attributes: req.Attributes, StartedBy: auth.Subject(c.Request.Context())}
+	if req.ParentID != "" {
+		parentID, err := primitive.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			respondError(c, &FieldsError{Err: ErrInvalidRequest, Fields: []FieldError{problem.Field("/parentId", "expected.event_id")}}, failedStart)
+			return
+		}
+		params.ParentID = &parentID
+	}
+
 	// Просим сервис запустить событие
 	// Сервис сам решит, создавать ли новое или вернуть существующее
-	event, err := h.service.Start(c.Request.Context(), req.Type)
+	// Незарегистрированный тип в строгом режиме, атрибуты не по схеме типа, отсутствующий или завершённый
+	// родитель — ошибки клиента, всё остальное — 500
+	event, err := h.service.Start(c.Request.Context(), params)
 	if err != nil {
-		// Если что-то пошло не так — возвращаем ошибку 500
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Не удалось создать событие"})
+		respondError(c, err, failedStart)
 		return
 	}
 
@@ -76,32 +151,42 @@ func (h *EventHandler) Start(c *gin.Context) {
 
 // Finish обрабатывает запрос на завершение события
 // Принимает JSON с полем "type" и завершает активное событие этого типа
+// С полем "cascade": true завершает и все активные вложенные события
 func (h *EventHandler) Finish(c *gin.Context) {
-	var req StartRequest
+	defer traceHandler(c, "EventHandler.Finish")()
+
+	var req FinishRequest
 
 	// Проверяем, что в запросе есть поле "type"
 	// Если нет — возвращаем 400 Bad Request
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Поле 'type' обязательно и не может быть пустым"})
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err, failedFinish)
 		return
 	}
 
-	// Валидируем формат типа события согласно OpenAPI контракту: ^[a-z0-9]+$
-	if !validateEventType(req.Type) {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Тип события должен содержать только строчные буквы и цифры"})
+	// Валидируем формат типа события по настроенному правилу именования
+	// (по умолчанию — ^[a-z0-9]+$, действующее правило отдаёт GET /v1/naming-rule)
+	if err := h.service.ValidateType(req.Type); err != nil {
+		respondError(c, err, failedFinish)
 		return
 	}
 
-	// Просим сервис завершить событие
-	event, err := h.service.Finish(c.Request.Context(), req.Type)
-	if err == mongo.ErrNoDocuments {
-		// Если активного события такого типа нет — возвращаем 404 Not Found
-		c.JSON(http.StatusNotFound, ErrorResponse{Message: "Активное событие указанного типа не найдено"})
+	// Ключ может быть ограничен определёнными типами событий
+	if !auth.Allowed(c.Request.Context(), auth.ScopeFinish, req.Type) {
+		auth.Forbidden(c)
 		return
 	}
+
+	// Просим сервис завершить событие; атрибуты он проверяет вместе с уже сохранёнными у события
+	event, err := h.service.Finish(c.Request.Context(), FinishParams{
+		Type:       req.Type,
+		Attributes: req.Attributes,
+		Cascade:    req.Cascade,
+		FinishedBy: auth.Subject(c.Request.Context()),
+	})
 	if err != nil {
-		// Если произошла другая ошибка — возвращаем 500
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Не удалось завершить событие"})
+		// Некорректные атрибуты — 400, нет активного события такого типа — 404, при другой ошибке — 500
+		respondError(c, err, failedFinish)
 		return
 	}
 
@@ -109,10 +194,40 @@ func (h *EventHandler) Finish(c *gin.Context) {
 	c.JSON(http.StatusOK, event.ToResponse())
 }
 
+// Tree обрабатывает запрос на получение дерева вложенных событий
+// Возвращает событие с потомками и суммарными длительностями
+func (h *EventHandler) Tree(c *gin.Context) {
+	defer traceHandler(c, "EventHandler.Tree")()
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParameter("id", "expected.event_id"), failedTree)
+		return
+	}
+
+	tree, err := h.service.Tree(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, failedTree)
+		return
+	}
+	// Событие чужого типа для ограниченного ключа выглядит как несуществующее
+	if tree == nil || !auth.Allowed(c.Request.Context(), auth.ScopeRead, tree.Type) {
+		respondError(c, ErrEventNotFound, failedTree)
+		return
+	}
+	tree.prune(func(eventType string) bool {
+		return auth.Allowed(c.Request.Context(), auth.ScopeRead, eventType)
+	})
+
+	c.JSON(http.StatusOK, tree)
+}
+
 // List обрабатывает запрос на получение списка всех событий
 // Поддерживает query параметры: offset, limit, type
 // Возвращает события, отсортированные по времени начала в порядке убывания (descending)
 func (h *EventHandler) List(c *gin.Context) {
+	defer traceHandler(c, "EventHandler.List")()
+
 	// Парсим query параметры
 	var offset int
 	var limit int
@@ -122,7 +237,7 @@ func (h *EventHandler) List(c *gin.Context) {
 		var err error
 		offset, err = strconv.Atoi(offsetStr)
 		if err != nil || offset < 0 {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Параметр 'offset' должен быть неотрицательным числом"})
+			respondError(c, invalidParameter("offset", "expected.non_negative"), failedList)
 			return
 		}
 	}
@@ -130,22 +245,181 @@ func (h *EventHandler) List(c *gin.Context) {
 	if limitStr := c.Query("limit"); limitStr != "" {
 		var err error
 		limit, err = strconv.Atoi(limitStr)
-		if err != nil || limit < 0 || limit > 100 {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Параметр 'limit' должен быть числом от 0 до 100"})
+		if err != nil || limit < 0 || limit > h.maxLimit {
+			respondError(c, invalidParameter("limit", "expected.range", 0, h.maxLimit), failedList)
 			return
 		}
 	}
 
 	eventType = c.Query("type")
+	if eventType != "" && !auth.Allowed(c.Request.Context(), auth.ScopeRead, eventType) {
+		auth.Forbidden(c)
+		return
+	}
 
 	// Просим сервис вернуть события с учетом фильтров
-	events, err := h.service.List(c.Request.Context(), offset, limit, eventType)
+	// Клиент с ограниченным ключом видит только события разрешённых типов
+	events, err := h.service.Find(c.Request.Context(), ListFilter{
+		Offset:       offset,
+		Limit:        limit,
+		Type:         eventType,
+		TypePatterns: auth.AllowedTypes(c.Request.Context()),
+	})
 	if err != nil {
 		// Если произошла ошибка — возвращаем 500
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Не удалось получить список событий"})
+		respondError(c, err, failedList)
+		return
+	}
+
+	// Если клиент уже получал этот список (If-None-Match), тело не отправляем
+	if notModified(c, etagOf(events)) {
 		return
 	}
 
 	// Всё хорошо — возвращаем список событий со статусом 200
 	c.JSON(http.StatusOK, events)
 }
+
+// Get обрабатывает запрос на получение одного события
+// Поддерживает If-None-Match: если событие не менялось, отвечает 304 без тела
+func (h *EventHandler) Get(c *gin.Context) {
+	defer traceHandler(c, "EventHandler.Get")()
+
+	event, ok := h.load(c, auth.ScopeRead, failedGet)
+	if !ok {
+		return
+	}
+	if notModified(c, etagOf([]Event{*event})) {
+		return
+	}
+	c.JSON(http.StatusOK, event.ToResponse())
+}
+
+// Update обрабатывает запрос на изменение атрибутов события
+// С заголовком If-Match событие изменяется, только если его ETag не изменился, иначе — 412
+func (h *EventHandler) Update(c *gin.Context) {
+	defer traceHandler(c, "EventHandler.Update")()
+
+	var req UpdateRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err, failedUpdate)
+		return
+	}
+
+	current, ok := h.load(c, auth.ScopeFinish, failedUpdate)
+	if !ok {
+		return
+	}
+	version, err := ifMatch(c, current)
+	if err != nil {
+		respondError(c, err, failedUpdate)
+		return
+	}
+
+	event, err := h.service.Update(c.Request.Context(), UpdateParams{ID: current.ID, Attributes: req.Attributes, IfVersion: version})
+	if err != nil {
+		respondError(c, err, failedUpdate)
+		return
+	}
+	c.Header("ETag", etagOf([]Event{*event}))
+	c.JSON(http.StatusOK, event.ToResponse())
+}
+
+// Delete обрабатывает запрос на удаление события
+// If-Match работает так же, как в Update; событие с вложенными событиями не удаляется (409)
+func (h *EventHandler) Delete(c *gin.Context) {
+	defer traceHandler(c, "EventHandler.Delete")()
+
+	current, ok := h.load(c, auth.ScopeAdmin, failedDelete)
+	if !ok {
+		return
+	}
+	version, err := ifMatch(c, current)
+	if err != nil {
+		respondError(c, err, failedDelete)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), DeleteParams{ID: current.ID, IfVersion: version}); err != nil {
+		respondError(c, err, failedDelete)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// load читает событие из параметра пути id и проверяет право scope на его тип
+// Событие, которое ключ не может читать, выглядит как несуществующее; если ответ уже отправлен,
+// возвращает false
+func (h *EventHandler) load(c *gin.Context, scope auth.Scope, failure problem.Problem) (*Event, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		respondError(c, invalidParameter("id", "expected.event_id"), failure)
+		return nil, false
+	}
+
+	event, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, failure)
+		return nil, false
+	}
+	if !auth.Allowed(c.Request.Context(), auth.ScopeRead, event.Type) {
+		respondError(c, fmt.Errorf("%w: %s", ErrEventNotFound, id.Hex()), failure)
+		return nil, false
+	}
+	if !auth.Allowed(c.Request.Context(), scope, event.Type) {
+		auth.Forbidden(c)
+		return nil, false
+	}
+	return event, true
+}
+
+// Import обрабатывает запрос на массовый импорт исторических событий
+// Тело запроса — NDJSON или CSV, формат берётся из параметра format или из Content-Type
+// Параметр dryRun=true только проверяет записи, ничего не записывая в базу
+// Возвращает отчёт с количеством импортированных записей и списком отклонённых строк;
+// при сбое посреди импорта тот же отчёт приходит в поле report ответа об ошибке
+func (h *EventHandler) Import(c *gin.Context) {
+	defer traceHandler(c, "EventHandler.Import")()
+
+	format, err := importFormatFromRequest(c)
+	if err != nil {
+		respondError(c, invalidParameter("format", "expected.import_format"), failedImport)
+		return
+	}
+
+	var dryRun bool
+	if dryRunStr := c.Query("dryRun"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			respondError(c, invalidParameter("dryRun", "expected.boolean"), failedImport)
+			return
+		}
+	}
+
+	// Читаем тело запроса потоком — файл может содержать миллионы строк
+	report, err := h.service.Import(c.Request.Context(), c.Request.Body, format, dryRun)
+	if err != nil && report != nil {
+		// Пачки до ошибки уже записаны — клиент должен знать, что повторять импорт целиком нельзя
+		respondPartial(c, err, failedImport, report)
+		return
+	}
+	if err != nil {
+		respondError(c, err, failedImport)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// importFormatFromRequest определяет формат импорта
+// Явный параметр format важнее заголовка Content-Type
+func importFormatFromRequest(c *gin.Context) (ImportFormat, error) {
+	if format := c.Query("format"); format != "" {
+		return ParseImportFormat(format)
+	}
+	contentType := c.ContentType()
+	if contentType == "text/csv" || strings.HasSuffix(contentType, "/csv") {
+		return ImportFormatCSV, nil
+	}
+	return ImportFormatNDJSON, nil
+}
@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"event-service/internal/db"
+	"event-service/pkg/auth"
+	"event-service/pkg/logging"
+	"event-service/pkg/problem"
+	"event-service/pkg/ratelimit"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -45,8 +51,8 @@ func setupTestHandler(t *testing.T) (*EventHandler, func()) {
 	collection.Drop(ctx)
 
 	repo := NewEventRepository(collection)
-	service := NewEventService(repo)
-	handler := NewEventHandler(service)
+	service := NewEventService(repo, nil)
+	handler := NewEventHandler(service, DefaultMaxLimit)
 
 	cleanup := func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -62,8 +68,8 @@ func TestNewEventHandler(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
-	handler := NewEventHandler(service)
+	service := NewEventService(repo, nil)
+	handler := NewEventHandler(service, DefaultMaxLimit)
 
 	if handler == nil {
 		t.Fatal("NewEventHandler returned nil")
@@ -639,10 +645,13 @@ func TestHandler_Start_InvalidEventType_Uppercase(t *testing.T) {
 		t.Errorf("Expected status 400, got %d. Body: %s", w.Code, w.Body.String())
 	}
 
-	var errorResp ErrorResponse
+	var errorResp problem.Problem
 	if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err == nil {
-		if errorResp.Message != "Тип события должен содержать только строчные буквы и цифры" {
-			t.Errorf("Expected validation error message, got %s", errorResp.Message)
+		if errorResp.Code != "invalid_type_name" || errorResp.Title != "Тип события не соответствует правилу именования" {
+			t.Errorf("Expected invalid_type_name problem, got %s: %s", errorResp.Code, errorResp.Title)
+		}
+		if len(errorResp.Errors) != 1 || errorResp.Errors[0].Path != "/type" {
+			t.Errorf("Expected field error for /type, got %+v", errorResp.Errors)
 		}
 	}
 }
@@ -894,3 +903,396 @@ func TestHandler_List_InvalidLimit(t *testing.T) {
 		t.Errorf("Expected status 400, got %d. Body: %s", w3.Code, w3.Body.String())
 	}
 }
+
+// TestHandler_List_MaxLimit проверяет, что наибольший limit берётся из настроек обработчика
+func TestHandler_List_MaxLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Сервис не нужен: запрос отклоняется до обращения к нему
+	router := gin.New()
+	router.GET("/list", NewEventHandler(nil, 20).List)
+
+	req := httptest.NewRequest(http.MethodGet, "/list?limit=21", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "от 0 до 20") {
+		t.Errorf("Error should mention the configured maximum: %s", w.Body.String())
+	}
+}
+
+func TestHandler_Import_NDJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.POST("/import", handler.Import)
+	router.GET("/list", handler.List)
+
+	body := `{"type":"meeting","state":"finished","startedAt":"2024-01-01T10:00:00Z","finishedAt":"2024-01-01T11:00:00Z"}
+{"type":"meeting","state":"unknown","startedAt":"2024-01-01T10:00:00Z"}
+`
+	req := httptest.NewRequest(http.MethodPost, "/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var report ImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if report.Imported != 1 || report.RejectedCount != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(report.Rejected) != 1 || report.Rejected[0].Line != 2 {
+		t.Errorf("Expected line 2 to be rejected, got %+v", report.Rejected)
+	}
+}
+
+func TestHandler_Import_CSVDryRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.POST("/import", handler.Import)
+	router.GET("/list", handler.List)
+
+	body := "type,state,startedAt,finishedAt\nmeeting,finished,2024-01-01T10:00:00Z,2024-01-01T11:00:00Z\n"
+	req := httptest.NewRequest(http.MethodPost, "/import?dryRun=true", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var report ImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !report.DryRun || report.Imported != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	// В режиме dryRun база остаётся пустой
+	reqList := httptest.NewRequest(http.MethodGet, "/list", nil)
+	wList := httptest.NewRecorder()
+	router.ServeHTTP(wList, reqList)
+
+	var events []Event
+	if err := json.Unmarshal(wList.Body.Bytes(), &events); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no events after dry run, got %d", len(events))
+	}
+}
+
+func TestHandler_Import_Interrupted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/import", NewEventHandler(NewEventService(nil, nil), 100).Import)
+
+	req := httptest.NewRequest(http.MethodPost, "/import?dryRun=true", strings.NewReader(interruptedImport()))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Code   string        `json:"code"`
+		Report *ImportReport `json:"report"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if body.Code != "import_failed" || body.Report == nil || body.Report.Imported != importBatchSize {
+		t.Errorf("Expected import_failed with a partial report, got %s", w.Body.String())
+	}
+}
+
+func TestHandler_Start_QuotaExceeded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := NewEventService(&startRepository{active: map[string]*Event{}}, nil)
+	service.SetQuota(ratelimit.NewQuota(ratelimit.NewMemoryStore(), ratelimit.Quotas{ratelimit.DefaultRoute: 1}))
+	router := gin.New()
+	router.POST("/start", NewEventHandler(service, 100).Start)
+
+	start := func(eventType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(`{"type":"`+eventType+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := start("meeting"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w := start("call")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), `"code":"quota_exceeded"`) {
+		t.Fatalf("Expected 429 quota_exceeded, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry < 1 || retry > 24*60*60 {
+		t.Errorf("Retry-After should point to the next UTC midnight, got %q", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestHandler_Import_InvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.POST("/import", handler.Import)
+
+	for _, query := range []string{"format=xml", "dryRun=maybe"} {
+		req := httptest.NewRequest(http.MethodPost, "/import?"+query, bytes.NewBufferString(""))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d. Body: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHandler_ParentAndTree(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.POST("/start", handler.Start)
+	router.POST("/finish", handler.Finish)
+	router.GET("/events/:id/tree", handler.Tree)
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/start", StartRequest{Type: "meeting"})
+	var meeting EventResponse
+	json.Unmarshal(w.Body.Bytes(), &meeting)
+
+	w = post("/start", StartRequest{Type: "qa", ParentID: meeting.ID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for child, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var qa EventResponse
+	json.Unmarshal(w.Body.Bytes(), &qa)
+	if qa.ParentID != meeting.ID {
+		t.Errorf("Expected parentId %s, got %s", meeting.ID, qa.ParentID)
+	}
+
+	if w := post("/start", StartRequest{Type: "demo", ParentID: "not-an-id"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid parentId, got %d", w.Code)
+	}
+	if w := post("/start", StartRequest{Type: "demo", ParentID: "000000000000000000000000"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for missing parent, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/events/"+meeting.ID+"/tree", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for tree, got %d", w.Code)
+	}
+	var tree EventTree
+	if err := json.Unmarshal(w.Body.Bytes(), &tree); err != nil {
+		t.Fatalf("Failed to unmarshal tree: %v", err)
+	}
+	if tree.ID != meeting.ID || len(tree.Children) != 1 || tree.Children[0].ID != qa.ID {
+		t.Errorf("Unexpected tree: %s", w.Body.String())
+	}
+
+	// Каскадное завершение: после него родитель уже не принимает детей
+	if w := post("/finish", FinishRequest{Type: "meeting", Cascade: true}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for finish, got %d", w.Code)
+	}
+	if w := post("/start", StartRequest{Type: "demo", ParentID: meeting.ID}); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for finished parent, got %d", w.Code)
+	}
+	if w := post("/finish", FinishRequest{Type: "qa"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected qa to be finished by cascade, got %d", w.Code)
+	}
+}
+
+func TestHandler_Tree_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.GET("/events/:id/tree", handler.Tree)
+
+	for id, expected := range map[string]int{
+		"not-an-id":                http.StatusBadRequest,
+		"000000000000000000000000": http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/events/"+id+"/tree", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("Expected status %d for %s, got %d", expected, id, w.Code)
+		}
+	}
+}
+
+func TestHandler_TypeRestrictedPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	principal := &auth.Principal{Subject: "key:billing", Scopes: []auth.Scope{auth.ScopeRead, auth.ScopeStart}, Types: []string{"billing*"}}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("X-Test-Restricted") != "" {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		}
+	})
+	router.GET("/", handler.List)
+	router.POST("/start", handler.Start)
+	router.POST("/finish", handler.Finish)
+
+	do := func(method, path, body string, restricted bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if restricted {
+			req.Header.Set("X-Test-Restricted", "1")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Событие другого типа запускаем без ограничений
+	do(http.MethodPost, "/start", `{"type":"meeting"}`, false)
+
+	if w := do(http.MethodPost, "/start", `{"type":"meeting"}`, true); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for disallowed type, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/finish", `{"type":"billing"}`, true); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without finish scope, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/?type=meeting", "", true); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 when filtering by disallowed type, got %d", w.Code)
+	}
+
+	// Список содержит только разрешённые типы
+	if w := do(http.MethodPost, "/start", `{"type":"billing"}`, false); w.Code != http.StatusOK {
+		t.Fatalf("Start failed: %d", w.Code)
+	}
+	w := do(http.MethodGet, "/", "", true)
+	var events []EventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != "billing" {
+		t.Errorf("Restricted key should see only billing events: %s", w.Body.String())
+	}
+}
+
+func TestHandler_RecordsActors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		principal := &auth.Principal{Subject: c.GetHeader("X-Test-Subject"), Scopes: []auth.Scope{auth.ScopeAdmin}}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+	})
+	router.POST("/start", handler.Start)
+	router.POST("/finish", handler.Finish)
+
+	do := func(path, subject string) EventResponse {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{"type":"meeting"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-Subject", subject)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s failed: %d %s", path, w.Code, w.Body.String())
+		}
+		var resp EventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		return resp
+	}
+
+	started := do("/start", "alice")
+	if started.StartedBy != "alice" || started.FinishedBy != "" {
+		t.Errorf("Unexpected actors after start: %+v", started)
+	}
+	finished := do("/finish", "bob")
+	if finished.StartedBy != "alice" || finished.FinishedBy != "bob" {
+		t.Errorf("Unexpected actors after finish: %+v", finished)
+	}
+}
+
+// TestHandler_ErrorResponse_RequestID проверяет, что ответ об ошибке и строки лога
+// обработчика и сервиса содержат идентификатор запроса
+func TestHandler_ErrorResponse_RequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	logger, err := logging.New(logging.Options{Level: "debug", Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	handler := NewEventHandler(NewEventService(&failingRepository{}, nil), DefaultMaxLimit)
+	router := gin.New()
+	router.Use(logging.RequestIDMiddleware())
+	router.POST("/start", handler.Start)
+
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{`{}`, http.StatusBadRequest},
+		{`{"type":"meeting"}`, http.StatusInternalServerError},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(tt.body))
+		req.Header.Set(logging.RequestIDHeader, "req-"+strconv.Itoa(tt.status))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Fatalf("Expected status %d, got %d", tt.status, w.Code)
+		}
+		var response problem.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.RequestID != "req-"+strconv.Itoa(tt.status) {
+			t.Errorf("Expected requestId in error response, got %s", w.Body.String())
+		}
+	}
+
+	// Сбой записан в лог с причиной и тем же идентификатором, который получил клиент
+	var record map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &record); err != nil {
+		t.Fatalf("Expected a single JSON log record, got %q", out.String())
+	}
+	if record["level"] != "ERROR" || record["request_id"] != "req-500" || record["error"] != "нет соединения" {
+		t.Errorf("Unexpected log record: %v", record)
+	}
+}
@@ -0,0 +1,366 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"event-service/pkg/audit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ImportFormat — формат входных данных для массового импорта событий
+type ImportFormat string
+
+const (
+	// ImportFormatNDJSON — по одному JSON-объекту на строку
+	ImportFormatNDJSON ImportFormat = "ndjson"
+	// ImportFormatCSV — CSV с заголовком type,state,startedAt,finishedAt
+	ImportFormatCSV ImportFormat = "csv"
+)
+
+const (
+	// importBatchSize — сколько событий отправляем в базу за один InsertMany
+	importBatchSize = 1000
+	// maxImportRejections — сколько отклонённых строк перечисляем в отчёте
+	// Остальные только считаются, чтобы отчёт по миллионам строк не занимал всю память
+	maxImportRejections = 10000
+	// maxImportLineSize — максимальная длина одной строки NDJSON
+	maxImportLineSize = 1024 * 1024
+)
+
+// ParseImportFormat превращает строку из запроса или флага в ImportFormat
+func ParseImportFormat(s string) (ImportFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "ndjson", "jsonl":
+		return ImportFormatNDJSON, nil
+	case "csv":
+		return ImportFormatCSV, nil
+	default:
+		return "", fmt.Errorf("неизвестный формат импорта: %s", s)
+	}
+}
+
+// ImportRecord — одна запись исторического события во входных данных
+type ImportRecord struct {
+	Type       string     `json:"type"`
+	State      string     `json:"state"` // "started" или "finished"
+	StartedAt  *time.Time `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ImportRejection описывает строку, которая не прошла проверку
+type ImportRejection struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport — итог импорта: сколько строк прочитано, сколько записано и что отклонено
+type ImportReport struct {
+	DryRun        bool              `json:"dryRun"`
+	Total         int               `json:"total"`
+	Imported      int               `json:"imported"`
+	RejectedCount int               `json:"rejectedCount"`
+	Rejected      []ImportRejection `json:"rejected"`
+}
+
+// reject добавляет отклонённую строку в отчёт
+func (r *ImportReport) reject(line int, reason string) {
+	r.RejectedCount++
+	if len(r.Rejected) < maxImportRejections {
+		r.Rejected = append(r.Rejected, ImportRejection{Line: line, Reason: reason})
+	}
+}
+
+// importReader последовательно читает записи из входного потока
+// Ошибка разбора отдельной строки возвращается как lineErr — импорт продолжается
+// Ошибка err означает, что читать поток дальше невозможно
+type importReader interface {
+	Next() (line int, rec ImportRecord, lineErr error, err error)
+}
+
+// newImportReader создаёт читатель для указанного формата
+func newImportReader(r io.Reader, format ImportFormat) (importReader, error) {
+	switch format {
+	case ImportFormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+		return &ndjsonReader{scanner: scanner}, nil
+	case ImportFormatCSV:
+		return newCSVReader(r)
+	default:
+		return nil, fmt.Errorf("неизвестный формат импорта: %s", format)
+	}
+}
+
+// ndjsonReader читает записи в формате NDJSON
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) Next() (int, ImportRecord, error, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.scanner.Text())
+		// Пустые строки просто пропускаем
+		if text == "" {
+			continue
+		}
+		var rec ImportRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return r.line, ImportRecord{}, fmt.Errorf("некорректный JSON: %v", err), nil
+		}
+		return r.line, rec, nil, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return r.line, ImportRecord{}, nil, fmt.Errorf("ошибка чтения строки %d: %w", r.line+1, err)
+	}
+	return r.line, ImportRecord{}, nil, io.EOF
+}
+
+// csvReader читает записи в формате CSV
+// Первая строка — заголовок, порядок колонок может быть любым
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV не содержит заголовка")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать заголовок CSV: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"type", "state", "startedAt"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("в заголовке CSV нет колонки '%s'", required)
+		}
+	}
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Next() (int, ImportRecord, error, error) {
+	fields, err := r.reader.Read()
+	line, _ := r.reader.FieldPos(0)
+	if err == io.EOF {
+		return line, ImportRecord{}, nil, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.StartLine, ImportRecord{}, fmt.Errorf("некорректная строка CSV: %v", parseErr.Err), nil
+		}
+		return line, ImportRecord{}, nil, err
+	}
+
+	rec := ImportRecord{
+		Type:  r.field(fields, "type"),
+		State: r.field(fields, "state"),
+	}
+	if rec.StartedAt, err = parseImportTime(r.field(fields, "startedAt")); err != nil {
+		return line, ImportRecord{}, fmt.Errorf("некорректное значение startedAt: %v", err), nil
+	}
+	if rec.FinishedAt, err = parseImportTime(r.field(fields, "finishedAt")); err != nil {
+		return line, ImportRecord{}, fmt.Errorf("некорректное значение finishedAt: %v", err), nil
+	}
+	return line, rec, nil, nil
+}
+
+// field возвращает значение колонки или пустую строку, если колонки нет
+func (r *csvReader) field(fields []string, name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+// parseImportTime разбирает время в формате RFC 3339, пустая строка — nil
+func parseImportTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// validateImportRecord проверяет запись по тем же правилам, что и API,
+// включая правило именования типов, и превращает её в Event, готовый к записи в базу
+func validateImportRecord(rec ImportRecord, naming *NamingRule, now time.Time) (*Event, error) {
+	if rec.Type == "" {
+		return nil, errors.New("поле 'type' обязательно")
+	}
+	if err := naming.Validate(rec.Type); err != nil {
+		return nil, err
+	}
+	if rec.StartedAt == nil {
+		return nil, errors.New("поле 'startedAt' обязательно")
+	}
+	if rec.StartedAt.After(now) {
+		return nil, errors.New("startedAt не может быть в будущем")
+	}
+
+	event := &Event{
+		Type:      rec.Type,
+		StartedAt: rec.StartedAt.UTC(),
+	}
+
+	switch rec.State {
+	case Active.String():
+		// Активное событие ещё не завершено, времени окончания у него быть не может
+		if rec.FinishedAt != nil {
+			return nil, errors.New("у активного события не может быть finishedAt")
+		}
+		event.State = Active
+	case Finished.String():
+		if rec.FinishedAt == nil {
+			return nil, errors.New("у завершённого события должно быть finishedAt")
+		}
+		if rec.FinishedAt.Before(*rec.StartedAt) {
+			return nil, errors.New("finishedAt не может быть раньше startedAt")
+		}
+		if rec.FinishedAt.After(now) {
+			return nil, errors.New("finishedAt не может быть в будущем")
+		}
+		finishedAt := rec.FinishedAt.UTC()
+		event.State = Finished
+		event.FinishedAt = &finishedAt
+	default:
+		return nil, fmt.Errorf("поле 'state' должно быть '%s' или '%s'", Active, Finished)
+	}
+	return event, nil
+}
+
+// Import загружает исторические события из потока r
+// Каждая запись проверяется, корректные записываются пачками по importBatchSize
+// Правило "не больше одного активного события на тип" соблюдается как внутри файла,
+// так и с учётом уже существующих в базе событий
+// В режиме dryRun записи только проверяются, в базу ничего не пишется
+// Суточную квоту (см. SetQuota) импорт не расходует: это операция администратора над историческими данными
+// Если импорт прервался, вместе с ошибкой возвращается отчёт о том, что успело записаться:
+// пачки до ошибки уже в базе. Пачка, на которой InsertMany вернул ошибку, в Imported не входит,
+// хотя её начало могло быть записано
+func (s *EventService) Import(ctx context.Context, r io.Reader, format ImportFormat, dryRun bool) (_ *ImportReport, err error) {
+	ctx, span := startSpan(ctx, "EventService.Import", attribute.String("import.format", string(format)), attribute.Bool("import.dry_run", dryRun))
+	defer endSpan(span, &err)
+
+	reader, err := newImportReader(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: dryRun, Rejected: []ImportRejection{}}
+	// activeTypes запоминает, для каких типов уже есть активное событие (в базе или в файле)
+	activeTypes := make(map[string]bool)
+	// knownTypes запоминает результат проверки типа по реестру, чтобы не спрашивать базу на каждой строке
+	knownTypes := make(map[string]error)
+	batch := make([]Event, 0, importBatchSize)
+	naming := s.NamingRule()
+	now := time.Now()
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if !dryRun {
+			if err := s.repo.InsertMany(ctx, batch); err != nil {
+				return err
+			}
+			report.Imported += len(batch)
+			records := make([]audit.Record, len(batch))
+			for i := range batch {
+				records[i] = auditRecord(audit.ActionImport, nil, &batch[i])
+			}
+			s.record(ctx, records...)
+		} else {
+			report.Imported += len(batch)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		line, rec, lineErr, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Total++
+		if lineErr != nil {
+			report.reject(line, lineErr.Error())
+			continue
+		}
+
+		event, err := validateImportRecord(rec, naming, now)
+		if err != nil {
+			report.reject(line, err.Error())
+			continue
+		}
+
+		knownErr, checked := knownTypes[event.Type]
+		if !checked {
+			knownErr = s.checkKnownType(ctx, event.Type)
+			if knownErr != nil && !errors.Is(knownErr, ErrUnknownEventType) {
+				return report, knownErr
+			}
+			knownTypes[event.Type] = knownErr
+		}
+		if knownErr != nil {
+			report.reject(line, fmt.Sprintf("тип события '%s' не зарегистрирован", event.Type))
+			continue
+		}
+
+		if event.State == Active {
+			busy, known := activeTypes[event.Type]
+			if !known {
+				// Первый раз видим этот тип — проверяем, нет ли активного события в базе
+				existing, err := s.repo.FindActive(ctx, event.Type)
+				if err != nil {
+					return report, err
+				}
+				busy = existing != nil
+			}
+			if busy {
+				report.reject(line, fmt.Sprintf("активное событие типа '%s' уже существует", event.Type))
+				activeTypes[event.Type] = true
+				continue
+			}
+			activeTypes[event.Type] = true
+		}
+
+		batch = append(batch, *event)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
@@ -0,0 +1,302 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseImportFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ImportFormat
+		wantErr  bool
+	}{
+		{"", ImportFormatNDJSON, false},
+		{"ndjson", ImportFormatNDJSON, false},
+		{"JSONL", ImportFormatNDJSON, false},
+		{"csv", ImportFormatCSV, false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		format, err := ParseImportFormat(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseImportFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if format != tt.expected {
+			t.Errorf("ParseImportFormat(%q) = %q, expected %q", tt.input, format, tt.expected)
+		}
+	}
+}
+
+func TestValidateImportRecord(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-2 * time.Hour)
+	finished := now.Add(-time.Hour)
+	beforeStart := now.Add(-3 * time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		rec     ImportRecord
+		wantErr bool
+	}{
+		{"valid finished", ImportRecord{Type: "meeting", State: "finished", StartedAt: &started, FinishedAt: &finished}, false},
+		{"valid started", ImportRecord{Type: "meeting", State: "started", StartedAt: &started}, false},
+		{"empty type", ImportRecord{State: "started", StartedAt: &started}, true},
+		{"bad type", ImportRecord{Type: "Meeting!", State: "started", StartedAt: &started}, true},
+		{"no startedAt", ImportRecord{Type: "meeting", State: "started"}, true},
+		{"startedAt in future", ImportRecord{Type: "meeting", State: "started", StartedAt: &future}, true},
+		{"unknown state", ImportRecord{Type: "meeting", State: "paused", StartedAt: &started}, true},
+		{"started with finishedAt", ImportRecord{Type: "meeting", State: "started", StartedAt: &started, FinishedAt: &finished}, true},
+		{"finished without finishedAt", ImportRecord{Type: "meeting", State: "finished", StartedAt: &started}, true},
+		{"finishedAt before startedAt", ImportRecord{Type: "meeting", State: "finished", StartedAt: &started, FinishedAt: &beforeStart}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := validateImportRecord(tt.rec, DefaultNamingRule(), now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateImportRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && event.Type != tt.rec.Type {
+				t.Errorf("Expected type %s, got %s", tt.rec.Type, event.Type)
+			}
+		})
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+	input := `{"type":"meeting","state":"finished","startedAt":"2024-01-01T10:00:00Z","finishedAt":"2024-01-01T11:00:00Z"}
+
+not json
+{"type":"call","state":"started","startedAt":"2024-01-02T10:00:00Z"}
+`
+	reader, err := newImportReader(strings.NewReader(input), ImportFormatNDJSON)
+	if err != nil {
+		t.Fatalf("newImportReader failed: %v", err)
+	}
+
+	line, rec, lineErr, err := reader.Next()
+	if err != nil || lineErr != nil {
+		t.Fatalf("Unexpected error: %v / %v", err, lineErr)
+	}
+	if line != 1 || rec.Type != "meeting" || rec.FinishedAt == nil {
+		t.Errorf("Unexpected first record: line=%d rec=%+v", line, rec)
+	}
+
+	// Пустая строка пропускается, следующая — с ошибкой разбора
+	line, _, lineErr, err = reader.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lineErr == nil || line != 3 {
+		t.Errorf("Expected parse error on line 3, got line=%d err=%v", line, lineErr)
+	}
+
+	line, rec, lineErr, err = reader.Next()
+	if err != nil || lineErr != nil {
+		t.Fatalf("Unexpected error: %v / %v", err, lineErr)
+	}
+	if line != 4 || rec.Type != "call" {
+		t.Errorf("Unexpected last record: line=%d rec=%+v", line, rec)
+	}
+
+	if _, _, _, err = reader.Next(); err == nil {
+		t.Error("Expected io.EOF at the end of input")
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	input := "state,type,startedAt,finishedAt\n" +
+		"finished,meeting,2024-01-01T10:00:00Z,2024-01-01T11:00:00Z\n" +
+		"started,call,not-a-time,\n" +
+		"started,task,2024-01-02T10:00:00Z\n"
+
+	reader, err := newImportReader(strings.NewReader(input), ImportFormatCSV)
+	if err != nil {
+		t.Fatalf("newImportReader failed: %v", err)
+	}
+
+	line, rec, lineErr, err := reader.Next()
+	if err != nil || lineErr != nil {
+		t.Fatalf("Unexpected error: %v / %v", err, lineErr)
+	}
+	if line != 2 || rec.Type != "meeting" || rec.State != "finished" || rec.FinishedAt == nil {
+		t.Errorf("Unexpected first record: line=%d rec=%+v", line, rec)
+	}
+
+	line, _, lineErr, err = reader.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lineErr == nil || line != 3 {
+		t.Errorf("Expected time parse error on line 3, got line=%d err=%v", line, lineErr)
+	}
+
+	// Колонка finishedAt отсутствует в строке — это не ошибка
+	line, rec, lineErr, err = reader.Next()
+	if err != nil || lineErr != nil {
+		t.Fatalf("Unexpected error: %v / %v", err, lineErr)
+	}
+	if line != 4 || rec.Type != "task" || rec.FinishedAt != nil {
+		t.Errorf("Unexpected last record: line=%d rec=%+v", line, rec)
+	}
+}
+
+func TestCSVReader_MissingColumn(t *testing.T) {
+	_, err := newImportReader(strings.NewReader("type,state\nmeeting,started\n"), ImportFormatCSV)
+	if err == nil {
+		t.Error("Expected error when startedAt column is missing")
+	}
+
+	_, err = newImportReader(strings.NewReader(""), ImportFormatCSV)
+	if err == nil {
+		t.Error("Expected error for empty CSV")
+	}
+}
+
+func TestEventService_Import_NDJSON(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	service := NewEventService(repo, nil)
+	ctx := context.Background()
+
+	input := `{"type":"meeting","state":"finished","startedAt":"2024-01-01T10:00:00Z","finishedAt":"2024-01-01T11:00:00Z"}
+{"type":"meeting","state":"started","startedAt":"2024-01-02T10:00:00Z"}
+{"type":"meeting","state":"started","startedAt":"2024-01-03T10:00:00Z"}
+{"type":"BAD","state":"started","startedAt":"2024-01-03T10:00:00Z"}
+`
+	report, err := service.Import(ctx, strings.NewReader(input), ImportFormatNDJSON, false)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	if report.Total != 4 {
+		t.Errorf("Expected 4 records, got %d", report.Total)
+	}
+	if report.Imported != 2 {
+		t.Errorf("Expected 2 imported records, got %d", report.Imported)
+	}
+	if report.RejectedCount != 2 || len(report.Rejected) != 2 {
+		t.Fatalf("Expected 2 rejected records, got %+v", report.Rejected)
+	}
+	// Второе активное событие того же типа отклоняется
+	if report.Rejected[0].Line != 3 || report.Rejected[1].Line != 4 {
+		t.Errorf("Unexpected rejected lines: %+v", report.Rejected)
+	}
+
+	events, err := repo.List(ctx, 0, 0, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events in database, got %d", len(events))
+	}
+}
+
+func TestEventService_Import_DryRun(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	service := NewEventService(repo, nil)
+	ctx := context.Background()
+
+	input := "type,state,startedAt,finishedAt\n" +
+		"meeting,finished,2024-01-01T10:00:00Z,2024-01-01T11:00:00Z\n"
+	report, err := service.Import(ctx, strings.NewReader(input), ImportFormatCSV, true)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if !report.DryRun || report.Imported != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	events, err := repo.List(ctx, 0, 0, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Dry run should not write events, got %d", len(events))
+	}
+}
+
+func TestEventService_Import_ActiveAlreadyInDatabase(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	service := NewEventService(repo, nil)
+	ctx := context.Background()
+
+	if _, err := service.Start(ctx, StartParams{Type: "meeting"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	input := `{"type":"meeting","state":"started","startedAt":"2024-01-02T10:00:00Z"}`
+	report, err := service.Import(ctx, strings.NewReader(input), ImportFormatNDJSON, false)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if report.Imported != 0 || report.RejectedCount != 1 {
+		t.Errorf("Active event should be rejected when one already exists: %+v", report)
+	}
+}
+
+func TestEventService_Import_Batches(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	service := NewEventService(repo, nil)
+	ctx := context.Background()
+
+	// Больше одной пачки, чтобы проверить несколько вызовов InsertMany
+	count := importBatchSize + 10
+	var sb strings.Builder
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&sb, `{"type":"task","state":"finished","startedAt":"2024-01-01T10:00:00Z","finishedAt":"2024-01-01T10:%02d:00Z"}`+"\n", i%60)
+	}
+
+	report, err := service.Import(ctx, strings.NewReader(sb.String()), ImportFormatNDJSON, false)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if report.Imported != count {
+		t.Errorf("Expected %d imported events, got %d", count, report.Imported)
+	}
+
+	events, err := repo.List(ctx, 0, 0, "task")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(events) != count {
+		t.Errorf("Expected %d events in database, got %d", count, len(events))
+	}
+}
+
+// interruptedImport возвращает NDJSON из пачки завершённых событий и строки длиннее maxImportLineSize,
+// на которой чтение потока прерывается
+func interruptedImport() string {
+	var sb strings.Builder
+	for i := 0; i < importBatchSize; i++ {
+		sb.WriteString(`{"type":"task","state":"finished","startedAt":"2024-01-01T10:00:00Z","finishedAt":"2024-01-01T11:00:00Z"}` + "\n")
+	}
+	sb.WriteString(strings.Repeat("x", maxImportLineSize+1) + "\n")
+	return sb.String()
+}
+
+func TestEventService_Import_Interrupted(t *testing.T) {
+	service := NewEventService(nil, nil)
+
+	// Вместе с ошибкой возвращается отчёт о пачках, обработанных до неё
+	report, err := service.Import(context.Background(), strings.NewReader(interruptedImport()), ImportFormatNDJSON, true)
+	if err == nil {
+		t.Fatal("Expected an error for a line that is too long")
+	}
+	if report == nil || report.Total != importBatchSize || report.Imported != importBatchSize {
+		t.Errorf("Expected a partial report, got %+v", report)
+	}
+}
@@ -35,6 +35,10 @@ type Event struct {
 	// ID — уникальный идентификатор события в базе данных
 	ID primitive.ObjectID `bson:"_id,omitempty" json:"-"`
 
+	// TenantID — арендатор, которому принадлежит событие
+	// Проставляется репозиторием из контекста запроса
+	TenantID string `bson:"tenant_id" json:"-"`
+
 	// Type — тип события (например, "login", "logout", "payment" и т.д.)
 	// Это позволяет различать разные виды событий
 	Type string `bson:"type" json:"type"`
@@ -49,6 +53,129 @@ type Event struct {
 	// FinishedAt — время, когда событие завершилось
 	// Может быть null, потому что активные события ещё не имеют времени завершения
 	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"-"`
+
+	// Attributes — полезная нагрузка события (например, сумма и валюта платежа)
+	// Набор атрибутов можно ограничить JSON Schema в реестре типов
+	Attributes map[string]interface{} `bson:"attributes,omitempty" json:"-"`
+
+	// ParentID — идентификатор родительского события, если событие вложенное
+	// Например, "presentation" и "qa" внутри события "meeting"
+	ParentID *primitive.ObjectID `bson:"parent_id,omitempty" json:"-"`
+
+	// StartedBy и FinishedBy — кто запустил и кто завершил событие
+	// Берутся из аутентифицированного клиента: subject токена или имя API-ключа
+	StartedBy  string `bson:"started_by,omitempty" json:"-"`
+	FinishedBy string `bson:"finished_by,omitempty" json:"-"`
+
+	// Version — номер изменения документа: 1 при создании, +1 при каждом обновлении
+	// Из него и UpdatedAt строится ETag; у событий, сохранённых до появления поля, версия 0
+	Version int64 `bson:"version" json:"-"`
+
+	// UpdatedAt — время последнего изменения (запуск, завершение, правка атрибутов)
+	UpdatedAt time.Time `bson:"updated_at,omitempty" json:"-"`
+
+	// RestoredAt — когда событие восстановлено из архива (nil — не восстанавливалось)
+	// Срок хранения восстановленного события отсчитывается заново от этого времени,
+	// иначе следующий проход архивации сразу унёс бы его обратно в архив
+	RestoredAt *time.Time `bson:"restored_at,omitempty" json:"-"`
+}
+
+// ModifiedAt возвращает время последнего изменения события
+// У событий, сохранённых до появления UpdatedAt, это время завершения или запуска
+func (e *Event) ModifiedAt() time.Time {
+	switch {
+	case !e.UpdatedAt.IsZero():
+		return e.UpdatedAt
+	case e.FinishedAt != nil:
+		return *e.FinishedAt
+	default:
+		return e.StartedAt
+	}
+}
+
+// StartParams — параметры запуска события
+type StartParams struct {
+	// Type — тип запускаемого события
+	Type string
+	// Attributes — атрибуты нового события (может быть nil)
+	Attributes map[string]interface{}
+	// ParentID — родительское событие (nil — событие верхнего уровня)
+	// Родитель должен существовать и быть активным
+	ParentID *primitive.ObjectID
+	// StartedBy — кто запускает событие (пусто, если аутентификация отключена)
+	StartedBy string
+}
+
+// FinishParams — параметры завершения события
+type FinishParams struct {
+	// Type — тип завершаемого события
+	Type string
+	// Attributes — атрибуты, которые добавляются к событию при завершении (может быть nil)
+	Attributes map[string]interface{}
+	// Cascade — завершить вместе с событием все его активные вложенные события
+	Cascade bool
+	// FinishedBy — кто завершает событие (пусто, если аутентификация отключена)
+	FinishedBy string
+
+	// ID и Version заполняет EventService: репозиторий завершает событие ID, только если оно
+	// всё ещё активно и его версия равна Version, иначе возвращает *ConflictError
+	// Пустой ID — завершить любое активное событие типа Type
+	ID      primitive.ObjectID
+	Version int64
+}
+
+// UpdateParams — параметры изменения атрибутов события (PATCH /v1/events/{id})
+type UpdateParams struct {
+	// ID — изменяемое событие
+	ID primitive.ObjectID
+	// Attributes — изменения атрибутов, как в JSON Merge Patch (RFC 7396) на уровне атрибутов:
+	// ключ со значением null удаляет атрибут, остальные ключи добавляют или заменяют его целиком
+	Attributes map[string]interface{}
+	// IfVersion — изменить, только если версия события равна *IfVersion (nil — любая версия)
+	// Заполняется по заголовку If-Match
+	IfVersion *int64
+}
+
+// DeleteParams — параметры удаления события (DELETE /v1/events/{id})
+type DeleteParams struct {
+	// ID — удаляемое событие
+	ID primitive.ObjectID
+	// IfVersion — удалить, только если версия события равна *IfVersion (nil — любая версия)
+	IfVersion *int64
+}
+
+// ListFilter — условия выборки списка событий
+type ListFilter struct {
+	// Offset — смещение от начала списка (0 = с самого начала)
+	Offset int
+	// Limit — максимальное количество событий (0 = без ограничения)
+	Limit int
+	// Type — точный тип события (пустая строка = без фильтра)
+	Type string
+	// TypePatterns — шаблоны допустимых типов, например "billing.*" (пусто = любые)
+	// Используется, чтобы клиент с ограниченным ключом видел только свои типы
+	TypePatterns []string
+}
+
+// StatsFilter — условия выборки для сводки по типам событий
+type StatsFilter struct {
+	// Types — точные типы событий (пусто = все типы)
+	Types []string
+	// TypePatterns — шаблоны допустимых типов, как в ListFilter (пусто = любые)
+	TypePatterns []string
+	// Since — учитывать только события, начавшиеся не раньше этого времени (нулевое = все)
+	Since time.Time
+}
+
+// TypeStats — сводка по событиям одного типа
+type TypeStats struct {
+	Type string
+	// Total — число событий типа; Active и Finished делят его по состоянию
+	Total    int64
+	Active   int64
+	Finished int64
+	// AverageDuration — средняя длительность завершённых событий (0, если завершённых нет)
+	AverageDuration time.Duration
 }
 
 // EventResponse представляет событие в формате API согласно OpenAPI контракту
@@ -58,19 +185,35 @@ type EventResponse struct {
 	State      string     `json:"state"`                // "started" или "finished"
 	StartedAt  time.Time  `json:"startedAt"`            // camelCase
 	FinishedAt *time.Time `json:"finishedAt,omitempty"` // camelCase
+	ParentID   string     `json:"parentId,omitempty"`   // ObjectID родителя как строка
+	StartedBy  string     `json:"startedBy,omitempty"`  // кто запустил событие
+	FinishedBy string     `json:"finishedBy,omitempty"` // кто завершил событие
+	Version    int64      `json:"version"`              // номер изменения события
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // ToResponse преобразует Event в EventResponse для API ответа
 func (e *Event) ToResponse() EventResponse {
 	resp := EventResponse{
-		ID:        e.ID.Hex(), // Преобразуем ObjectID в строку
-		Type:      e.Type,
-		State:     e.State.String(),
-		StartedAt: e.StartedAt,
+		ID:         e.ID.Hex(), // Преобразуем ObjectID в строку
+		Type:       e.Type,
+		State:      e.State.String(),
+		StartedAt:  e.StartedAt,
+		StartedBy:  e.StartedBy,
+		FinishedBy: e.FinishedBy,
+		Version:    e.Version,
 	}
 	if e.FinishedAt != nil {
 		resp.FinishedAt = e.FinishedAt
 	}
+	if e.ParentID != nil {
+		resp.ParentID = e.ParentID.Hex()
+	}
+	if len(e.Attributes) > 0 {
+		// Вложенные документы из MongoDB превращаем в обычные объекты JSON
+		resp.Attributes = normalizeBSON(e.Attributes).(map[string]interface{})
+	}
 	return resp
 }
 
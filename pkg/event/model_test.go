@@ -2,6 +2,7 @@ package event
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -149,3 +150,53 @@ func TestEvent_MarshalJSON_ActiveEvent(t *testing.T) {
 		t.Error("FinishedAt should be nil for active event")
 	}
 }
+
+func TestEvent_ToResponse_WithAttributes(t *testing.T) {
+	event := &Event{
+		Type:      "payment",
+		State:     Active,
+		StartedAt: time.Now(),
+		Attributes: map[string]interface{}{
+			"amount": int32(10),
+			"card":   primitive.D{{Key: "number", Value: "1234"}},
+		},
+	}
+
+	resp := event.ToResponse()
+	card, ok := resp.Attributes["card"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Nested document should become a map, got %T", resp.Attributes["card"])
+	}
+	if card["number"] != "1234" {
+		t.Errorf("Expected card number 1234, got %v", card["number"])
+	}
+}
+
+func TestEvent_ToResponse_WithParent(t *testing.T) {
+	parentID := primitive.NewObjectID()
+	event := &Event{Type: "qa", State: Active, StartedAt: time.Now(), ParentID: &parentID}
+
+	if resp := event.ToResponse(); resp.ParentID != parentID.Hex() {
+		t.Errorf("Expected parentId %s, got %s", parentID.Hex(), resp.ParentID)
+	}
+
+	data, _ := json.Marshal(&Event{Type: "meeting", StartedAt: time.Now()})
+	if strings.Contains(string(data), "parentId") {
+		t.Errorf("parentId should be omitted for top-level events: %s", data)
+	}
+}
+
+func TestEvent_ToResponse_WithActors(t *testing.T) {
+	finishedAt := time.Now()
+	event := &Event{Type: "meeting", State: Finished, StartedAt: time.Now(), FinishedAt: &finishedAt, StartedBy: "alice", FinishedBy: "key:cron"}
+
+	resp := event.ToResponse()
+	if resp.StartedBy != "alice" || resp.FinishedBy != "key:cron" {
+		t.Errorf("Unexpected actors: startedBy=%q finishedBy=%q", resp.StartedBy, resp.FinishedBy)
+	}
+
+	data, _ := json.Marshal(&Event{Type: "meeting", StartedAt: time.Now()})
+	if strings.Contains(string(data), "startedBy") || strings.Contains(string(data), "finishedBy") {
+		t.Errorf("Actors should be omitted when unknown: %s", data)
+	}
+}
@@ -0,0 +1,91 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultTypePattern — шаблон имени типа по умолчанию (как в OpenAPI контракте)
+const DefaultTypePattern = `^[a-z0-9]+$`
+
+// NamingRule описывает, какими могут быть имена типов событий
+// Имя делится разделителями пространств имён на части (например, "billing.payment"),
+// и каждая часть должна соответствовать шаблону
+type NamingRule struct {
+	// Pattern — регулярное выражение для каждой части имени
+	Pattern string `json:"pattern"`
+	// MaxLength — максимальная длина всего имени в символах (0 = без ограничения)
+	MaxLength int `json:"maxLength"`
+	// Separators — допустимые разделители пространств имён, например ".-" (пусто = без пространств имён)
+	Separators string `json:"separators"`
+
+	// re — скомпилированный Pattern, привязанный к началу и концу части
+	re *regexp.Regexp
+}
+
+// NewNamingRule проверяет и компилирует правило именования типов
+func NewNamingRule(pattern string, maxLength int, separators string) (*NamingRule, error) {
+	if pattern == "" {
+		return nil, errors.New("шаблон имени типа не может быть пустым")
+	}
+	// Привязываем шаблон к началу и концу, даже если в нём нет ^ и $
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный шаблон имени типа: %w", err)
+	}
+	if maxLength < 0 {
+		return nil, errors.New("максимальная длина имени типа не может быть отрицательной")
+	}
+	for _, r := range separators {
+		if !strings.ContainsRune(".-_:/", r) {
+			return nil, fmt.Errorf("недопустимый разделитель пространства имён: %q", r)
+		}
+	}
+	return &NamingRule{Pattern: pattern, MaxLength: maxLength, Separators: separators, re: re}, nil
+}
+
+// DefaultNamingRule возвращает правило по умолчанию: ^[a-z0-9]+$ без пространств имён
+func DefaultNamingRule() *NamingRule {
+	rule, _ := NewNamingRule(DefaultTypePattern, 0, "")
+	return rule
+}
+
+// Validate проверяет имя типа по правилу и объясняет, что не так
+func (r *NamingRule) Validate(name string) error {
+	if name == "" {
+		return errors.New("имя типа не может быть пустым")
+	}
+	if r.MaxLength > 0 && utf8.RuneCountInString(name) > r.MaxLength {
+		return fmt.Errorf("имя типа длиннее %d символов", r.MaxLength)
+	}
+
+	for _, segment := range r.segments(name) {
+		if segment == "" {
+			return errors.New("части имени типа между разделителями не могут быть пустыми")
+		}
+		if !r.re.MatchString(segment) {
+			return fmt.Errorf("часть имени '%s' не соответствует шаблону %s", segment, r.Pattern)
+		}
+	}
+	return nil
+}
+
+// segments делит имя на части по разделителям пространств имён
+// В отличие от strings.FieldsFunc сохраняет пустые части, чтобы "a..b" считалось ошибкой
+func (r *NamingRule) segments(name string) []string {
+	if r.Separators == "" {
+		return []string{name}
+	}
+	var segments []string
+	start := 0
+	for i, c := range name {
+		if strings.ContainsRune(r.Separators, c) {
+			segments = append(segments, name[start:i])
+			start = i + utf8.RuneLen(c)
+		}
+	}
+	return append(segments, name[start:])
+}
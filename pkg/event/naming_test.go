@@ -0,0 +1,94 @@
+package event
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultNamingRule(t *testing.T) {
+	rule := DefaultNamingRule()
+
+	valid := []string{"meeting", "call123", "123"}
+	for _, name := range valid {
+		if err := rule.Validate(name); err != nil {
+			t.Errorf("Validate(%q) unexpected error: %v", name, err)
+		}
+	}
+
+	invalid := []string{"", "Meeting", "meeting-123", "meeting_123", "meeting.123", "meeting 123", "встреча"}
+	for _, name := range invalid {
+		if err := rule.Validate(name); err == nil {
+			t.Errorf("Validate(%q) expected error", name)
+		}
+	}
+}
+
+func TestNamingRule_Namespaces(t *testing.T) {
+	rule, err := NewNamingRule(DefaultTypePattern, 0, ".-")
+	if err != nil {
+		t.Fatalf("NewNamingRule failed: %v", err)
+	}
+
+	valid := []string{"billing.payment", "ui-click", "billing.card-payment", "meeting"}
+	for _, name := range valid {
+		if err := rule.Validate(name); err != nil {
+			t.Errorf("Validate(%q) unexpected error: %v", name, err)
+		}
+	}
+
+	invalid := []string{"billing..payment", ".billing", "billing.", "ui_click", "Billing.payment"}
+	for _, name := range invalid {
+		if err := rule.Validate(name); err == nil {
+			t.Errorf("Validate(%q) expected error", name)
+		}
+	}
+}
+
+func TestNamingRule_MaxLength(t *testing.T) {
+	rule, err := NewNamingRule(DefaultTypePattern, 8, "")
+	if err != nil {
+		t.Fatalf("NewNamingRule failed: %v", err)
+	}
+
+	if err := rule.Validate("meeting"); err != nil {
+		t.Errorf("Validate(meeting) unexpected error: %v", err)
+	}
+	err = rule.Validate("meetings1")
+	if err == nil || !strings.Contains(err.Error(), "8") {
+		t.Errorf("Expected max length error, got %v", err)
+	}
+}
+
+func TestNamingRule_PatternIsAnchored(t *testing.T) {
+	// Шаблон без ^ и $ не должен пропускать имена, где подходит только часть
+	rule, err := NewNamingRule(`[a-z]+`, 0, "")
+	if err != nil {
+		t.Fatalf("NewNamingRule failed: %v", err)
+	}
+	if err := rule.Validate("meeting1"); err == nil {
+		t.Error("Expected error for partially matching name")
+	}
+}
+
+func TestNewNamingRule_Invalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		maxLength  int
+		separators string
+	}{
+		{"empty pattern", "", 0, ""},
+		{"broken pattern", "[a-z", 0, ""},
+		{"negative max length", DefaultTypePattern, -1, ""},
+		{"letter separator", DefaultTypePattern, 0, "x"},
+		{"space separator", DefaultTypePattern, 0, " "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewNamingRule(tt.pattern, tt.maxLength, tt.separators); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package event
+
+import (
+	"errors"
+	"net/http"
+
+	"event-service/pkg/problem"
+	"event-service/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorProblems сопоставляет ошибки пакета с ответами API: HTTP-статусом и кодом (заголовок берётся из каталога i18n)
+// Это единственное место, где ошибка получает HTTP-статус, — обработчики только передают её в respondError
+// Ошибки проверяются по порядку через errors.Is
+var errorProblems = []struct {
+	err     error
+	problem problem.Problem
+}{
+	{ErrInvalidRequest, problem.New(http.StatusBadRequest, "invalid_request")},
+	{ErrInvalidParameter, problem.New(http.StatusBadRequest, "invalid_parameter")},
+	{ErrInvalidTypeName, problem.New(http.StatusBadRequest, "invalid_type_name")},
+	{ErrInvalidAttributes, problem.New(http.StatusBadRequest, "invalid_attributes")},
+	{ErrInvalidEventType, problem.New(http.StatusBadRequest, "invalid_event_type")},
+	{ErrUnknownEventType, problem.New(http.StatusBadRequest, "unknown_event_type")},
+	{ErrEventTypeNotFound, problem.New(http.StatusNotFound, "event_type_not_found")},
+	{ErrEventTypeExists, problem.New(http.StatusConflict, "event_type_exists")},
+	{ErrEventNotFound, problem.New(http.StatusNotFound, "event_not_found")},
+	{ErrParentNotFound, problem.New(http.StatusNotFound, "parent_not_found")},
+	{ErrParentNotActive, problem.New(http.StatusConflict, "parent_not_active")},
+	{ErrPreconditionFailed, problem.New(http.StatusPreconditionFailed, "precondition_failed")},
+	{ErrEventHasChildren, problem.New(http.StatusConflict, "event_has_children")},
+	{ErrVersionConflict, problem.New(http.StatusConflict, "version_conflict")},
+	{ErrArchiveNotFound, problem.New(http.StatusNotFound, "archive_not_found")},
+	{ratelimit.ErrQuotaExceeded, problem.New(http.StatusTooManyRequests, "quota_exceeded")},
+}
+
+// Сбои операций (500): у каждой операции свой код, чтобы сбой запуска отличался от сбоя завершения
+var (
+	failedStart      = problem.New(http.StatusInternalServerError, "start_failed")
+	failedFinish     = problem.New(http.StatusInternalServerError, "finish_failed")
+	failedTree       = problem.New(http.StatusInternalServerError, "tree_failed")
+	failedGet        = problem.New(http.StatusInternalServerError, "get_failed")
+	failedUpdate     = problem.New(http.StatusInternalServerError, "update_failed")
+	failedDelete     = problem.New(http.StatusInternalServerError, "delete_failed")
+	failedList       = problem.New(http.StatusInternalServerError, "list_failed")
+	failedImport     = problem.New(http.StatusInternalServerError, "import_failed")
+	failedTypeList   = problem.New(http.StatusInternalServerError, "type_list_failed")
+	failedTypeGet    = problem.New(http.StatusInternalServerError, "type_get_failed")
+	failedTypeCreate = problem.New(http.StatusInternalServerError, "type_create_failed")
+	failedTypeUpdate = problem.New(http.StatusInternalServerError, "type_update_failed")
+	failedTypeDelete = problem.New(http.StatusInternalServerError, "type_delete_failed")
+	failedArchives   = problem.New(http.StatusInternalServerError, "archive_list_failed")
+	failedArchived   = problem.New(http.StatusInternalServerError, "archive_find_failed")
+	failedRestore    = problem.New(http.StatusInternalServerError, "archive_restore_failed")
+)
+
+// fieldErrors реализуют ошибки, которые несут ошибки отдельных полей запроса
+type fieldErrors interface {
+	fieldErrors() []FieldError
+}
+
+// ProblemFor возвращает ответ API для ошибки err и признак того, что ошибка известна
+// Ошибки полей берутся из FieldsError и AttributeValidationError — они переводятся на язык клиента,
+// поэтому detail в этом случае не заполняется; иначе detail — текст ошибки, если она дополняет вид ошибки
+func ProblemFor(err error) (problem.Problem, bool) {
+	for _, known := range errorProblems {
+		if !errors.Is(err, known.err) {
+			continue
+		}
+		p := known.problem
+		switch fields := FieldErrors(err); {
+		case fields != nil:
+			p = p.WithErrors(fields...)
+		case err != known.err:
+			p = p.WithDetail(err.Error())
+		}
+		return p, true
+	}
+	return problem.Problem{}, false
+}
+
+// respondError отвечает на ошибку err документом problem+json
+// Ошибка не из errorProblems — сбой сервиса: она пишется в лог, а клиент получает failure
+func respondError(c *gin.Context, err error, failure problem.Problem) {
+	// Исчерпанная квота сообщает, когда можно повторить запрос
+	var quota *ratelimit.QuotaError
+	if errors.As(err, &quota) {
+		problem.RetryAfter(c, quota.RetryAfter())
+	}
+	if p, ok := ProblemFor(err); ok {
+		problem.Respond(c, p)
+		return
+	}
+	problem.Internal(c, err, failure)
+}
+
+// respondPartial — как respondError, но вместе с ошибкой отдаёт report — отчёт о том,
+// что операция успела сделать до неё (например, сколько записей импорта уже в базе)
+func respondPartial(c *gin.Context, err error, failure problem.Problem, report any) {
+	if p, ok := ProblemFor(err); ok {
+		problem.Respond(c, p.WithReport(report))
+		return
+	}
+	problem.Internal(c, err, failure.WithReport(report))
+}
@@ -0,0 +1,126 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"event-service/pkg/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail bool
+		fields int
+	}{
+		{"sentinel", ErrParentNotActive, http.StatusConflict, "parent_not_active", false, 0},
+		{"wrapped", fmt.Errorf("%w: нет активного события типа %q", ErrEventNotFound, "meeting"), http.StatusNotFound, "event_not_found", true, 0},
+		{"invalid type definition", fmt.Errorf("%w: имя атрибута не может быть пустым", ErrInvalidEventType), http.StatusBadRequest, "invalid_event_type", true, 0},
+		{"fields", invalidParameter("offset", "expected.non_negative"), http.StatusBadRequest, "invalid_parameter", false, 1},
+		{"conflict", &ConflictError{Version: 2}, http.StatusConflict, "version_conflict", true, 0},
+		{"attributes", &AttributeValidationError{Fields: []FieldError{{Path: "/attributes/a"}, {Path: "/attributes/b"}}}, http.StatusBadRequest, "invalid_attributes", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := ProblemFor(tt.err)
+			if !ok {
+				t.Fatalf("Expected %v to be a known error", tt.err)
+			}
+			if p.Status != tt.status || p.Code != tt.code {
+				t.Errorf("Expected %d %s, got %d %s", tt.status, tt.code, p.Status, p.Code)
+			}
+			if (p.Detail != "") != tt.detail || len(p.Errors) != tt.fields {
+				t.Errorf("Unexpected detail %q or errors %+v", p.Detail, p.Errors)
+			}
+		})
+	}
+
+	if _, ok := ProblemFor(errors.New("нет соединения")); ok {
+		t.Error("Unknown errors should not map to a client error")
+	}
+}
+
+// TestErrorProblems_UniqueCodes проверяет, что у каждой ошибки свой код
+func TestErrorProblems_UniqueCodes(t *testing.T) {
+	seen := map[string]bool{}
+	for _, p := range append(problemsOf(errorProblems), failedStart, failedFinish, failedTree, failedGet, failedUpdate, failedDelete, failedList, failedImport,
+		failedTypeList, failedTypeGet, failedTypeCreate, failedTypeUpdate, failedTypeDelete, failedArchives, failedArchived, failedRestore) {
+		if seen[p.Code] {
+			t.Errorf("Duplicate problem code %q", p.Code)
+		}
+		seen[p.Code] = true
+	}
+}
+
+func problemsOf(known []struct {
+	err     error
+	problem problem.Problem
+}) []problem.Problem {
+	problems := make([]problem.Problem, len(known))
+	for i, k := range known {
+		problems[i] = k.problem
+	}
+	return problems
+}
+
+// brokenRepository отвечает ошибкой на любую запись
+type brokenRepository struct {
+	failingRepository
+}
+
+func (r *brokenRepository) Finish(ctx context.Context, params FinishParams) (*Event, error) {
+	return nil, errors.New("нет соединения")
+}
+
+// TestHandler_ProblemCodes проверяет, что клиент различает ошибки по коду, не разбирая текст
+func TestHandler_ProblemCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewEventHandler(NewEventService(&brokenRepository{}, nil), DefaultMaxLimit)
+	router := gin.New()
+	router.POST("/start", handler.Start)
+	router.POST("/finish", handler.Finish)
+	router.GET("/", handler.List)
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		code               string
+		field              string
+	}{
+		{http.MethodPost, "/start", `{}`, http.StatusBadRequest, "invalid_request", "/type"},
+		{http.MethodPost, "/start", `{"type":"Bad Type"}`, http.StatusBadRequest, "invalid_type_name", "/type"},
+		{http.MethodPost, "/start", `{"type":"meeting","parentId":"42"}`, http.StatusBadRequest, "invalid_request", "/parentId"},
+		{http.MethodPost, "/start", `{"type":"meeting"}`, http.StatusInternalServerError, "start_failed", ""},
+		{http.MethodPost, "/finish", `{"type":"meeting"}`, http.StatusInternalServerError, "finish_failed", ""},
+		{http.MethodGet, "/?limit=1000", ``, http.StatusBadRequest, "invalid_parameter", "limit"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+		var p problem.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("%s %s: response is not a problem document: %s", tt.method, tt.path, w.Body.String())
+		}
+		if w.Code != tt.status || p.Code != tt.code {
+			t.Errorf("%s %s %s: expected %d %s, got %d %s", tt.method, tt.path, tt.body, tt.status, tt.code, w.Code, p.Code)
+		}
+		if w.Header().Get("Content-Type") != problem.ContentType {
+			t.Errorf("%s %s: expected problem+json, got %q", tt.method, tt.path, w.Header().Get("Content-Type"))
+		}
+		if tt.field != "" && (len(p.Errors) != 1 || p.Errors[0].Path != tt.field) {
+			t.Errorf("%s %s %s: expected field error for %s, got %+v", tt.method, tt.path, tt.body, tt.field, p.Errors)
+		}
+	}
+}
@@ -3,34 +3,228 @@ package event
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
+	"event-service/pkg/auth"
+	"event-service/pkg/tenant"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // EventRepository отвечает за всю работу с базой данных
 // Он знает, как сохранять события, как их находить и обновлять
+// Каждый запрос ограничен арендатором из контекста: чужие события не видны и не изменяются
 type EventRepository struct {
-	// collection — это ссылка на коллекцию MongoDB, с которой мы работаем
-	collection *mongo.Collection
+	// collections выбирает коллекцию MongoDB для арендатора из контекста
+	collections *tenant.Collections
+
+	// indexed — коллекции арендаторов, для которых индексы уже созданы
+	indexed sync.Map
 }
 
 // NewEventRepository создаёт новый репозиторий для работы с событиями
 // Нужно просто передать ему коллекцию из MongoDB, и он готов к работе
+// Все арендаторы хранятся в этой коллекции и различаются полем tenant_id
 func NewEventRepository(col *mongo.Collection) *EventRepository {
-	return &EventRepository{collection: col}
+	return NewTenantEventRepository(tenant.Shared(col))
+}
+
+// NewTenantEventRepository создаёт репозиторий, который размещает события
+// арендаторов согласно collections (общая коллекция, коллекция или база на арендатора)
+func NewTenantEventRepository(collections *tenant.Collections) *EventRepository {
+	return &EventRepository{collections: collections}
+}
+
+// eventIndexes — индексы коллекции событий
+// Первым полем в каждом стоит tenant_id, потому что им ограничен любой запрос
+var eventIndexes = []mongo.IndexModel{
+	// Поиск активного события нужного типа (FindActive, Finish)
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "type", Value: 1}, {Key: "state", Value: 1}}},
+	// Список событий по времени начала (List)
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "started_at", Value: -1}}},
+	// Поиск вложенных событий при построении дерева
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "parent_id", Value: 1}}},
+	// Поиск завершённых событий с истёкшим сроком хранения (FindExpired)
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "finished_at", Value: 1}}},
+}
+
+// EnsureIndexes создаёт индексы в коллекции арендатора из контекста
+// Вызывается при старте приложения; коллекции остальных арендаторов
+// получают индексы автоматически при первом обращении
+func (r *EventRepository) EnsureIndexes(ctx context.Context) error {
+	_, _, err := r.collection(ctx)
+	return err
+}
+
+// collection возвращает коллекцию арендатора из контекста и фильтр по арендатору,
+// который нужно добавить в каждый запрос
+// При первом обращении к коллекции создаёт в ней индексы
+func (r *EventRepository) collection(ctx context.Context) (*mongo.Collection, string, error) {
+	col, tenantID, err := r.collections.For(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := col.Database().Name() + "." + col.Name()
+	if _, ok := r.indexed.Load(key); !ok {
+		if _, err := col.Indexes().CreateMany(ctx, eventIndexes); err != nil {
+			return nil, "", err
+		}
+		slog.DebugContext(ctx, "Индексы коллекции событий созданы", "collection", key, "tenant", tenantID)
+		r.indexed.Store(key, true)
+	}
+	return col, tenantID, nil
+}
+
+// tenantFilter возвращает условие на поле tenant_id для арендатора
+// Документы без tenant_id сохранены до появления мультиарендности и принадлежат арендатору Default
+func tenantFilter(tenantID string) interface{} {
+	if tenantID == tenant.Default {
+		return bson.M{"$in": bson.A{tenant.Default, nil}}
+	}
+	return tenantID
+}
+
+// FindByID ищет событие по идентификатору
+// Если такого события нет, вернёт nil без ошибки
+func (r *EventRepository) FindByID(ctx context.Context, id primitive.ObjectID) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.FindByID", eventIDAttr(id.Hex()))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var event Event
+	err = col.FindOne(ctx, bson.M{"_id": id, "tenant_id": tenantFilter(tenantID)}).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// FindByIDs возвращает события с указанными идентификаторами в произвольном порядке
+// Несуществующие идентификаторы пропускаются; одним запросом заменяет серию вызовов FindByID
+func (r *EventRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.FindByIDs", attribute.Int("event.count", len(ids)))
+	defer endSpan(span, &err)
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := col.Find(ctx, bson.M{"tenant_id": tenantFilter(tenantID), "_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// FindChildren возвращает все события, вложенные в любое из указанных
+// Одним запросом достаётся целый уровень дерева, а не дети каждого события по отдельности
+// События отсортированы по времени начала в порядке возрастания
+func (r *EventRepository) FindChildren(ctx context.Context, parentIDs []primitive.ObjectID) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.FindChildren", attribute.Int("event.parents", len(parentIDs)))
+	defer endSpan(span, &err)
+
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"tenant_id": tenantFilter(tenantID), "parent_id": bson.M{"$in": parentIDs}}
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: 1}})
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// FinishByIDs завершает активные события из списка, проставляя им время окончания finishedAt
+// и того, кто их завершил (finishedBy, может быть пустым)
+// Уже завершённые события не трогает
+// События завершаются по одному, чтобы точно знать, какие из них завершил именно этот вызов
+// Возвращает идентификаторы завершённых событий, в том числе если завершение прервалось ошибкой
+func (r *EventRepository) FinishByIDs(ctx context.Context, ids []primitive.ObjectID, finishedAt time.Time, finishedBy string) (_ []primitive.ObjectID, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.FinishByIDs", attribute.Int("event.count", len(ids)))
+	defer endSpan(span, &err)
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"state": Finished, "finished_at": finishedAt, "updated_at": finishedAt}
+	if finishedBy != "" {
+		set["finished_by"] = finishedBy
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	var finished []primitive.ObjectID
+	for _, id := range ids {
+		filter := bson.M{"_id": id, "tenant_id": tenantFilter(tenantID), "state": Active}
+		result, err := col.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return finished, err
+		}
+		if result.ModifiedCount > 0 {
+			finished = append(finished, id)
+		}
+	}
+	return finished, nil
 }
 
 // FindActive ищет активное событие указанного типа
 // Если такого события нет, вернёт nil без ошибки
 // Используется для проверки, не запущено ли уже событие этого типа
-func (r *EventRepository) FindActive(ctx context.Context, eventType string) (*Event, error) {
+func (r *EventRepository) FindActive(ctx context.Context, eventType string) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.FindActive", eventTypeAttr(eventType))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var event Event
 	// Ищем событие с нужным типом и состоянием "активное"
-	err := r.collection.FindOne(ctx, bson.M{"type": eventType, "state": Active}).Decode(&event)
+	filter := bson.M{"tenant_id": tenantFilter(tenantID), "type": eventType, "state": Active}
+	err = col.FindOne(ctx, filter).Decode(&event)
 	if err == mongo.ErrNoDocuments {
 		// Если ничего не нашлось — это нормально, просто вернём nil
 		return nil, nil
@@ -43,16 +237,24 @@ func (r *EventRepository) FindActive(ctx context.Context, eventType string) (*Ev
 }
 
 // Create создаёт новое событие в базе данных
-// Автоматически устанавливает состояние "активное" и время начала
-func (r *EventRepository) Create(ctx context.Context, eventType string) (*Event, error) {
-	// Создаём событие со всеми необходимыми полями
-	event := &Event{
-		Type:      eventType,
-		State:     Active,
-		StartedAt: time.Now(),
+// Из переданного события берутся тип, атрибуты и родитель,
+// состояние "активное" и время начала устанавливаются автоматически
+func (r *EventRepository) Create(ctx context.Context, event *Event) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.Create", eventTypeAttr(event.Type))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
 	}
+
+	event.TenantID = tenantID
+	event.State = Active
+	event.StartedAt = time.Now()
+	event.UpdatedAt = event.StartedAt
+	event.Version = 1
 	// Сохраняем событие в базу данных
-	result, err := r.collection.InsertOne(ctx, event)
+	result, err := col.InsertOne(ctx, event)
 	if err != nil {
 		return nil, err
 	}
@@ -67,19 +269,46 @@ func (r *EventRepository) Create(ctx context.Context, eventType string) (*Event,
 
 // Finish завершает активное событие указанного типа
 // Находит его, меняет состояние на "завершено" и проставляет время окончания
-// Если такого события нет, вернёт ошибку
-func (r *EventRepository) Finish(ctx context.Context, eventType string) (*Event, error) {
+// Переданные атрибуты добавляются к атрибутам события (существующие ключи перезаписываются),
+// а params.FinishedBy, если заполнен, запоминается как автор завершения
+// С params.ID завершается только это событие и только в версии params.Version — иначе *ConflictError;
+// без него, если активного события нет, вернёт mongo.ErrNoDocuments
+func (r *EventRepository) Finish(ctx context.Context, params FinishParams) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.Finish", eventTypeAttr(params.Type))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	// Ищем активное событие нужного типа
-	filter := bson.M{"type": eventType, "state": Active}
-	// Обновляем его: меняем состояние и проставляем время завершения
-	update := bson.M{"$set": bson.M{"state": Finished, "finished_at": now}}
+	filter := bson.M{"tenant_id": tenantFilter(tenantID), "type": params.Type, "state": Active}
+	if !params.ID.IsZero() {
+		// Условное обновление: событие, изменённое после чтения, не завершается молча
+		filter["_id"] = params.ID
+		filter["version"] = versionFilter(params.Version)
+	}
+	// Обновляем его: меняем состояние, проставляем время завершения и увеличиваем версию
+	set := bson.M{"state": Finished, "finished_at": now, "updated_at": now}
+	if params.FinishedBy != "" {
+		set["finished_by"] = params.FinishedBy
+	}
+	for key, value := range params.Attributes {
+		set["attributes."+key] = value
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
 	// Настройки: вернуть обновлённый документ
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
 	var updated Event
 	// Выполняем операцию поиска и обновления за один раз
-	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	err = col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err == mongo.ErrNoDocuments && !params.ID.IsZero() {
+		// Событие успели изменить, завершить или удалить после того, как его прочитал сервис
+		return nil, &ConflictError{ID: params.ID, Version: params.Version}
+	}
 	if err == mongo.ErrNoDocuments {
 		// Если события не нашлось — значит его и не было
 		return nil, mongo.ErrNoDocuments
@@ -90,6 +319,70 @@ func (r *EventRepository) Finish(ctx context.Context, eventType string) (*Event,
 	return &updated, nil
 }
 
+// versionFilter возвращает условие на поле version
+// Документы без поля version сохранены до его появления и имеют версию 0
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+// UpdateAttributes заменяет атрибуты события, если его версия всё ещё равна version,
+// и увеличивает версию
+// Если события с такой версией нет (его удалили или успели изменить), вернёт *ConflictError
+func (r *EventRepository) UpdateAttributes(ctx context.Context, id primitive.ObjectID, version int64, attributes map[string]interface{}) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.UpdateAttributes", eventIDAttr(id.Hex()))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": id, "tenant_id": tenantFilter(tenantID), "version": versionFilter(version)}
+	set := bson.M{"updated_at": time.Now()}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if len(attributes) > 0 {
+		set["attributes"] = attributes
+	} else {
+		// Пустые атрибуты не хранятся — как у события, запущенного без атрибутов
+		update["$unset"] = bson.M{"attributes": ""}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated Event
+	err = col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, &ConflictError{ID: id, Version: version}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete удаляет событие, если его версия всё ещё равна version
+// Если события с такой версией нет, вернёт *ConflictError
+func (r *EventRepository) Delete(ctx context.Context, id primitive.ObjectID, version int64) (err error) {
+	ctx, span := startSpan(ctx, "EventRepository.Delete", eventIDAttr(id.Hex()))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := col.DeleteOne(ctx, bson.M{"_id": id, "tenant_id": tenantFilter(tenantID), "version": versionFilter(version)})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return &ConflictError{ID: id, Version: version}
+	}
+	return nil
+}
+
 // List возвращает события из базы данных с учетом фильтров
 // Параметры:
 //   - offset: смещение от начала списка (0 = с самого начала)
@@ -98,32 +391,54 @@ func (r *EventRepository) Finish(ctx context.Context, eventType string) (*Event,
 //
 // События отсортированы по времени начала в порядке убывания (descending)
 func (r *EventRepository) List(ctx context.Context, offset int, limit int, eventType string) ([]Event, error) {
+	return r.Find(ctx, ListFilter{Offset: offset, Limit: limit, Type: eventType})
+}
+
+// Find возвращает события, подходящие под фильтр
+// События отсортированы по времени начала в порядке убывания (descending)
+func (r *EventRepository) Find(ctx context.Context, f ListFilter) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.Find", eventTypeAttr(f.Type))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Строим фильтр для поиска
-	filter := bson.M{}
-	if eventType != "" {
-		filter["type"] = eventType
+	filter := bson.M{"tenant_id": tenantFilter(tenantID)}
+	typeFilter := bson.M{}
+	if f.Type != "" {
+		typeFilter["$eq"] = f.Type
+	}
+	if len(f.TypePatterns) > 0 {
+		typeFilter["$regex"] = auth.TypePatternRegexp(f.TypePatterns)
+	}
+	if len(typeFilter) > 0 {
+		filter["type"] = typeFilter
 	}
 
 	// Настройки: сортировка по полю started_at по убыванию (descending)
 	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
 
 	// Применяем offset и limit если они указаны
-	if offset > 0 {
-		opts.SetSkip(int64(offset))
+	if f.Offset > 0 {
+		opts.SetSkip(int64(f.Offset))
 	}
-	if limit > 0 {
-		opts.SetLimit(int64(limit))
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
 	}
 
 	// Получаем события из базы с учетом фильтров
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := col.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 	// Не забываем закрыть курсор, когда закончим с ним работать
 	defer cursor.Close(ctx)
 
-	var events []Event
+	// Пустой список — [], а не null в ответе API
+	events := []Event{}
 	// Читаем все найденные события в массив
 	err = cursor.All(ctx, &events)
 	if err != nil {
@@ -131,3 +446,300 @@ func (r *EventRepository) List(ctx context.Context, offset int, limit int, event
 	}
 	return events, nil
 }
+
+// InsertMany записывает пачку готовых событий в базу одним запросом
+// Используется при массовом импорте исторических событий
+// Идентификаторы, созданные MongoDB, проставляются обратно в events
+func (r *EventRepository) InsertMany(ctx context.Context, events []Event) (err error) {
+	ctx, span := startSpan(ctx, "EventRepository.InsertMany", attribute.Int("event.count", len(events)))
+	defer endSpan(span, &err)
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]interface{}, len(events))
+	for i := range events {
+		events[i].TenantID = tenantID
+		if events[i].Version == 0 {
+			events[i].Version = 1
+		}
+		if events[i].UpdatedAt.IsZero() {
+			events[i].UpdatedAt = events[i].ModifiedAt()
+		}
+		docs[i] = events[i]
+	}
+
+	result, err := col.InsertMany(ctx, docs)
+	if err != nil {
+		return err
+	}
+	for i, id := range result.InsertedIDs {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			events[i].ID = oid
+		}
+	}
+	return nil
+}
+
+// CountActive возвращает число активных событий каждого типа по всем арендаторам
+// Не ограничен арендатором из контекста: нужен для сводных метрик, а не для ответов клиентам
+func (r *EventRepository) CountActive(ctx context.Context) (_ map[string]int64, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.CountActive")
+	defer endSpan(span, &err)
+
+	collections, err := r.collections.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"state": Active}}},
+		{{Key: "$group", Value: bson.M{"_id": "$type", "count": bson.M{"$sum": 1}}}},
+	}
+	counts := make(map[string]int64)
+	for _, col := range collections {
+		cursor, err := col.Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		var groups []struct {
+			Type  string `bson:"_id"`
+			Count int64  `bson:"count"`
+		}
+		err = cursor.All(ctx, &groups)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			counts[g.Type] += g.Count
+		}
+	}
+	return counts, nil
+}
+
+// Stats возвращает сводку по событиям каждого типа арендатора из контекста
+// Считается одной агрегацией в MongoDB; типы отсортированы по имени
+func (r *EventRepository) Stats(ctx context.Context, f StatsFilter) (_ []TypeStats, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.Stats", attribute.Int("event.types", len(f.Types)))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{"tenant_id": tenantFilter(tenantID)}
+	typeFilter := bson.M{}
+	if len(f.Types) > 0 {
+		typeFilter["$in"] = f.Types
+	}
+	if len(f.TypePatterns) > 0 {
+		typeFilter["$regex"] = auth.TypePatternRegexp(f.TypePatterns)
+	}
+	if len(typeFilter) > 0 {
+		match["type"] = typeFilter
+	}
+	if !f.Since.IsZero() {
+		match["started_at"] = bson.M{"$gte": f.Since}
+	}
+
+	// Длительность считается только у завершённых событий: у активных она ещё растёт,
+	// а $avg пропускает null
+	finished := bson.M{"$eq": bson.A{"$state", Finished}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$type",
+			"total":    bson.M{"$sum": 1},
+			"active":   bson.M{"$sum": bson.M{"$cond": bson.A{finished, 0, 1}}},
+			"finished": bson.M{"$sum": bson.M{"$cond": bson.A{finished, 1, 0}}},
+			"avg_duration_ms": bson.M{"$avg": bson.M{"$cond": bson.A{
+				finished, bson.M{"$subtract": bson.A{"$finished_at", "$started_at"}}, nil,
+			}}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Type          string  `bson:"_id"`
+		Total         int64   `bson:"total"`
+		Active        int64   `bson:"active"`
+		Finished      int64   `bson:"finished"`
+		AvgDurationMs float64 `bson:"avg_duration_ms"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	stats := make([]TypeStats, len(groups))
+	for i, g := range groups {
+		stats[i] = TypeStats{
+			Type:            g.Type,
+			Total:           g.Total,
+			Active:          g.Active,
+			Finished:        g.Finished,
+			AverageDuration: time.Duration(g.AvgDurationMs * float64(time.Millisecond)),
+		}
+	}
+	return stats, nil
+}
+
+// Tenants возвращает арендаторов, у которых есть события, по всем коллекциям
+// Не ограничен арендатором из контекста: нужен фоновым задачам, которые обходят всех арендаторов
+func (r *EventRepository) Tenants(ctx context.Context) (_ []string, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.Tenants")
+	defer endSpan(span, &err)
+
+	collections, err := r.collections.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var tenants []string
+	for _, col := range collections {
+		values, err := col.Distinct(ctx, "tenant_id", bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			// Документы без tenant_id принадлежат арендатору Default
+			id, _ := value.(string)
+			if id == "" {
+				id = tenant.Default
+			}
+			if !seen[id] {
+				seen[id] = true
+				tenants = append(tenants, id)
+			}
+		}
+	}
+	return tenants, nil
+}
+
+// FindExpired возвращает завершённые события арендатора из контекста, завершённые (и восстановленные
+// из архива, если это было) раньше f.Before, самые давние первыми, не больше f.Limit
+func (r *EventRepository) FindExpired(ctx context.Context, f ExpiredFilter) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.FindExpired", attribute.Int("event.types", len(f.Types)))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Восстановленные из архива события хранятся заново от момента восстановления
+	filter := bson.M{
+		"tenant_id":   tenantFilter(tenantID),
+		"state":       Finished,
+		"finished_at": bson.M{"$lt": f.Before},
+		"restored_at": bson.M{"$not": bson.M{"$gte": f.Before}},
+	}
+	typeFilter := bson.M{}
+	if len(f.Types) > 0 {
+		typeFilter["$in"] = f.Types
+	}
+	if len(f.ExcludeTypes) > 0 {
+		typeFilter["$nin"] = f.ExcludeTypes
+	}
+	if len(typeFilter) > 0 {
+		filter["type"] = typeFilter
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "finished_at", Value: 1}})
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Expire удаляет события после архивации или по истечении срока хранения
+// Событие удаляется, только если его версия не изменилась с момента чтения: изменённое
+// тем временем событие остаётся на месте и попадёт в следующий проход уже в новой версии
+// События удаляются по одному, чтобы точно знать, какие из них удалены
+// Возвращает идентификаторы удалённых событий
+func (r *EventRepository) Expire(ctx context.Context, events []Event) (_ []primitive.ObjectID, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.Expire", attribute.Int("event.count", len(events)))
+	defer endSpan(span, &err)
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []primitive.ObjectID
+	for i := range events {
+		filter := bson.M{"_id": events[i].ID, "tenant_id": tenantFilter(tenantID), "version": versionFilter(events[i].Version)}
+		result, err := col.DeleteOne(ctx, filter)
+		if err != nil {
+			return removed, err
+		}
+		if result.DeletedCount > 0 {
+			removed = append(removed, events[i].ID)
+		}
+	}
+	return removed, nil
+}
+
+// Restore возвращает события из архива в коллекцию арендатора из контекста
+// События сохраняются с прежними идентификаторами и только вставляются: событие, которое уже есть
+// в коллекции (восстановлено раньше или так и не удалено при архивации), не перезаписывается,
+// поэтому восстановление не затирает живые данные и повторное восстановление ничего не дублирует
+// Возвращает идентификаторы вставленных событий
+func (r *EventRepository) Restore(ctx context.Context, events []Event) (_ []primitive.ObjectID, err error) {
+	ctx, span := startSpan(ctx, "EventRepository.Restore", attribute.Int("event.count", len(events)))
+	defer endSpan(span, &err)
+
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Фильтр только по _id: событие с тем же идентификатором у другого арендатора в общей коллекции
+	// тоже нельзя перезаписывать
+	models := make([]mongo.WriteModel, len(events))
+	for i := range events {
+		events[i].TenantID = tenantID
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": events[i].ID}).
+			SetUpdate(bson.M{"$setOnInsert": events[i]}).
+			SetUpsert(true)
+	}
+	result, err := col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return nil, err
+	}
+	inserted := make([]primitive.ObjectID, 0, len(result.UpsertedIDs))
+	for i := range events {
+		if _, ok := result.UpsertedIDs[int64(i)]; ok {
+			inserted = append(inserted, events[i].ID)
+		}
+	}
+	return inserted, nil
+}
@@ -2,10 +2,12 @@ package event
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"event-service/internal/db"
+	"event-service/pkg/tenant"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -35,7 +37,11 @@ func TestNewEventRepository(t *testing.T) {
 	if repo == nil {
 		t.Fatal("NewEventRepository returned nil")
 	}
-	if repo.collection != collection {
+	col, tenantID, err := repo.collection(ctx)
+	if err != nil {
+		t.Fatalf("collection failed: %v", err)
+	}
+	if col != collection || tenantID != tenant.Default {
 		t.Error("Repository collection was not set correctly")
 	}
 }
@@ -63,7 +69,7 @@ func TestEventRepository_FindActive_Found(t *testing.T) {
 	eventType := "meeting"
 
 	// Создаём событие
-	created, err := repo.Create(ctx, eventType)
+	created, err := repo.Create(ctx, &Event{Type: eventType})
 	if err != nil {
 		t.Fatalf("Create failed: %v", err)
 	}
@@ -95,7 +101,7 @@ func TestEventRepository_Create(t *testing.T) {
 	ctx := context.Background()
 	eventType := "meeting"
 
-	event, err := repo.Create(ctx, eventType)
+	event, err := repo.Create(ctx, &Event{Type: eventType})
 	if err != nil {
 		t.Fatalf("Create failed: %v", err)
 	}
@@ -125,13 +131,13 @@ func TestEventRepository_Finish_Success(t *testing.T) {
 	eventType := "meeting"
 
 	// Создаём событие
-	created, err := repo.Create(ctx, eventType)
+	created, err := repo.Create(ctx, &Event{Type: eventType})
 	if err != nil {
 		t.Fatalf("Create failed: %v", err)
 	}
 
 	// Завершаем событие
-	finished, err := repo.Finish(ctx, eventType)
+	finished, err := repo.Finish(ctx, FinishParams{Type: eventType})
 	if err != nil {
 		t.Fatalf("Finish failed: %v", err)
 	}
@@ -157,7 +163,7 @@ func TestEventRepository_Finish_NotFound(t *testing.T) {
 	ctx := context.Background()
 	eventType := "nonexistent"
 
-	event, err := repo.Finish(ctx, eventType)
+	event, err := repo.Finish(ctx, FinishParams{Type: eventType})
 	if err == nil {
 		t.Fatal("Finish should return error when event not found")
 	}
@@ -177,18 +183,18 @@ func TestEventRepository_Finish_AlreadyFinished(t *testing.T) {
 	eventType := "meeting"
 
 	// Создаём и завершаем событие
-	_, err := repo.Create(ctx, eventType)
+	_, err := repo.Create(ctx, &Event{Type: eventType})
 	if err != nil {
 		t.Fatalf("Create failed: %v", err)
 	}
 
-	_, err = repo.Finish(ctx, eventType)
+	_, err = repo.Finish(ctx, FinishParams{Type: eventType})
 	if err != nil {
 		t.Fatalf("First Finish failed: %v", err)
 	}
 
 	// Пытаемся завершить уже завершённое событие
-	event, err := repo.Finish(ctx, eventType)
+	event, err := repo.Finish(ctx, FinishParams{Type: eventType})
 	if err == nil {
 		t.Fatal("Finish should return error when event already finished")
 	}
@@ -226,21 +232,21 @@ func TestEventRepository_List_WithEvents(t *testing.T) {
 	type2 := "call"
 	type3 := "task"
 
-	_, err := repo.Create(ctx, type1)
+	_, err := repo.Create(ctx, &Event{Type: type1})
 	if err != nil {
 		t.Fatalf("Create first failed: %v", err)
 	}
 
 	time.Sleep(10 * time.Millisecond)
 
-	_, err = repo.Create(ctx, type2)
+	_, err = repo.Create(ctx, &Event{Type: type2})
 	if err != nil {
 		t.Fatalf("Create second failed: %v", err)
 	}
 
 	time.Sleep(10 * time.Millisecond)
 
-	_, err = repo.Create(ctx, type3)
+	_, err = repo.Create(ctx, &Event{Type: type3})
 	if err != nil {
 		t.Fatalf("Create third failed: %v", err)
 	}
@@ -281,21 +287,21 @@ func TestEventRepository_List_SortedByStartedAt(t *testing.T) {
 
 	// Создаём события в разное время
 	time1 := time.Now()
-	_, err := repo.Create(ctx, "first")
+	_, err := repo.Create(ctx, &Event{Type: "first"})
 	if err != nil {
 		t.Fatalf("Create first failed: %v", err)
 	}
 
 	time.Sleep(50 * time.Millisecond)
 
-	_, err = repo.Create(ctx, "second")
+	_, err = repo.Create(ctx, &Event{Type: "second"})
 	if err != nil {
 		t.Fatalf("Create second failed: %v", err)
 	}
 
 	time.Sleep(50 * time.Millisecond)
 
-	_, err = repo.Create(ctx, "third")
+	_, err = repo.Create(ctx, &Event{Type: "third"})
 	if err != nil {
 		t.Fatalf("Create third failed: %v", err)
 	}
@@ -334,7 +340,7 @@ func TestEventRepository_Create_UniqueIDs(t *testing.T) {
 	// Создаём несколько событий
 	ids := make(map[primitive.ObjectID]bool)
 	for i := 0; i < 10; i++ {
-		event, err := repo.Create(ctx, "test")
+		event, err := repo.Create(ctx, &Event{Type: "test"})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
@@ -358,7 +364,7 @@ func TestEventRepository_List_WithOffset(t *testing.T) {
 
 	// Создаём несколько событий
 	for i := 0; i < 5; i++ {
-		_, err := repo.Create(ctx, "type"+string(rune('0'+i)))
+		_, err := repo.Create(ctx, &Event{Type: "type" + string(rune('0'+i))})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
@@ -384,7 +390,7 @@ func TestEventRepository_List_WithLimit(t *testing.T) {
 
 	// Создаём несколько событий
 	for i := 0; i < 5; i++ {
-		_, err := repo.Create(ctx, "type"+string(rune('0'+i)))
+		_, err := repo.Create(ctx, &Event{Type: "type" + string(rune('0'+i))})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
@@ -411,7 +417,7 @@ func TestEventRepository_List_WithTypeFilter(t *testing.T) {
 	// Создаём события разных типов
 	types := []string{"meeting", "call", "meeting", "task"}
 	for _, eventType := range types {
-		_, err := repo.Create(ctx, eventType)
+		_, err := repo.Create(ctx, &Event{Type: eventType})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
@@ -443,7 +449,7 @@ func TestEventRepository_List_WithOffsetAndLimit(t *testing.T) {
 
 	// Создаём несколько событий
 	for i := 0; i < 10; i++ {
-		_, err := repo.Create(ctx, "type")
+		_, err := repo.Create(ctx, &Event{Type: "type"})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
@@ -486,7 +492,7 @@ func TestEventRepository_Create_WithError(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	event, err := repo.Create(ctx, "test")
+	event, err := repo.Create(ctx, &Event{Type: "test"})
 	if err == nil {
 		t.Error("Expected error with cancelled context")
 	}
@@ -503,7 +509,7 @@ func TestEventRepository_Finish_WithError(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	event, err := repo.Finish(ctx, "test")
+	event, err := repo.Finish(ctx, FinishParams{Type: "test"})
 	if err == nil {
 		t.Error("Expected error with cancelled context")
 	}
@@ -528,3 +534,174 @@ func TestEventRepository_List_WithError(t *testing.T) {
 		t.Error("Expected nil events when error occurs")
 	}
 }
+
+func TestEventRepository_InsertMany(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	finishedAt := time.Now()
+	events := []Event{
+		{Type: "meeting", State: Finished, StartedAt: finishedAt.Add(-time.Hour), FinishedAt: &finishedAt},
+		{Type: "call", State: Active, StartedAt: finishedAt},
+	}
+
+	if err := repo.InsertMany(ctx, events); err != nil {
+		t.Fatalf("InsertMany failed: %v", err)
+	}
+	for _, e := range events {
+		if e.ID.IsZero() {
+			t.Error("InsertMany should set IDs of inserted events")
+		}
+	}
+
+	stored, err := repo.List(ctx, 0, 0, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Errorf("Expected 2 events, got %d", len(stored))
+	}
+
+	// Пустая пачка — не ошибка
+	if err := repo.InsertMany(ctx, nil); err != nil {
+		t.Errorf("InsertMany with empty slice should not fail: %v", err)
+	}
+}
+
+func TestEventRepository_FindChildrenAndFinishByIDs(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("EnsureIndexes failed: %v", err)
+	}
+
+	parent, _ := repo.Create(ctx, &Event{Type: "meeting"})
+	first, _ := repo.Create(ctx, &Event{Type: "presentation", ParentID: &parent.ID})
+	second, _ := repo.Create(ctx, &Event{Type: "qa", ParentID: &parent.ID})
+	repo.Create(ctx, &Event{Type: "other"})
+
+	children, err := repo.FindChildren(ctx, []primitive.ObjectID{parent.ID})
+	if err != nil {
+		t.Fatalf("FindChildren failed: %v", err)
+	}
+	if len(children) != 2 || children[0].ID != first.ID || children[1].ID != second.ID {
+		t.Fatalf("Expected presentation and qa in start order, got %+v", children)
+	}
+
+	found, err := repo.FindByID(ctx, first.ID)
+	if err != nil || found == nil || found.ParentID == nil || *found.ParentID != parent.ID {
+		t.Fatalf("FindByID returned %+v, %v", found, err)
+	}
+
+	finishedAt := time.Now()
+	finished, err := repo.FinishByIDs(ctx, []primitive.ObjectID{first.ID, second.ID}, finishedAt, "")
+	if err != nil || len(finished) != 2 {
+		t.Fatalf("FinishByIDs = %v, %v; expected 2 events", finished, err)
+	}
+	// Повторный вызов не трогает уже завершённые события
+	if finished, _ := repo.FinishByIDs(ctx, []primitive.ObjectID{first.ID}, time.Now(), ""); len(finished) != 0 {
+		t.Errorf("Expected nothing finished on second call, got %v", finished)
+	}
+}
+
+func TestEventRepository_FindByIDsAndStats(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	started := time.Now().Add(-time.Hour).UTC().Truncate(time.Millisecond)
+	finishedShort, finishedLong := started.Add(10*time.Second), started.Add(30*time.Second)
+	events := []Event{
+		{Type: "meeting", State: Finished, StartedAt: started, FinishedAt: &finishedShort},
+		{Type: "meeting", State: Finished, StartedAt: started, FinishedAt: &finishedLong},
+		{Type: "meeting", State: Active, StartedAt: started},
+		{Type: "call", State: Active, StartedAt: started},
+	}
+	if err := repo.InsertMany(ctx, events); err != nil {
+		t.Fatalf("InsertMany failed: %v", err)
+	}
+
+	found, err := repo.FindByIDs(ctx, []primitive.ObjectID{events[0].ID, events[3].ID, primitive.NewObjectID()})
+	if err != nil {
+		t.Fatalf("FindByIDs failed: %v", err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", found)
+	}
+
+	stats, err := repo.Stats(ctx, StatsFilter{})
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	expected := []TypeStats{
+		{Type: "call", Total: 1, Active: 1},
+		{Type: "meeting", Total: 3, Active: 1, Finished: 2, AverageDuration: 20 * time.Second},
+	}
+	if len(stats) != len(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, stats)
+	}
+	for i := range expected {
+		if stats[i] != expected[i] {
+			t.Errorf("stats[%d] = %+v, expected %+v", i, stats[i], expected[i])
+		}
+	}
+
+	// Фильтр по типу и по времени начала
+	if stats, _ := repo.Stats(ctx, StatsFilter{Types: []string{"call"}}); len(stats) != 1 || stats[0].Type != "call" {
+		t.Errorf("Expected only call, got %+v", stats)
+	}
+	if stats, _ := repo.Stats(ctx, StatsFilter{Since: time.Now()}); len(stats) != 0 {
+		t.Errorf("Expected no stats for future since, got %+v", stats)
+	}
+}
+
+func TestEventRepository_Versions(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	created, err := repo.Create(ctx, &Event{Type: "meeting", Attributes: map[string]interface{}{"room": "A"}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Version != 1 || created.UpdatedAt.IsZero() {
+		t.Fatalf("New event should have version 1 and updated_at, got %d %v", created.Version, created.UpdatedAt)
+	}
+
+	updated, err := repo.UpdateAttributes(ctx, created.ID, 1, map[string]interface{}{"room": "B"})
+	if err != nil || updated == nil {
+		t.Fatalf("UpdateAttributes = %v, %v", updated, err)
+	}
+	if updated.Version != 2 || updated.Attributes["room"] != "B" {
+		t.Errorf("Expected version 2 with room B, got %d %v", updated.Version, updated.Attributes)
+	}
+	// Устаревшая версия не изменяет событие
+	var conflict *ConflictError
+	if stale, err := repo.UpdateAttributes(ctx, created.ID, 1, nil); !errors.As(err, &conflict) || stale != nil {
+		t.Errorf("Stale update should be a conflict, got %v, %v", stale, err)
+	}
+	if _, err := repo.Finish(ctx, FinishParams{Type: "meeting", ID: created.ID, Version: 1}); !errors.As(err, &conflict) || conflict.Version != 1 {
+		t.Errorf("Stale finish should be a conflict, got %v", err)
+	}
+
+	finished, err := repo.Finish(ctx, FinishParams{Type: "meeting", ID: created.ID, Version: 2})
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if finished.Version != 3 || !finished.UpdatedAt.Equal(*finished.FinishedAt) {
+		t.Errorf("Finish should bump version and updated_at, got %d %v", finished.Version, finished.UpdatedAt)
+	}
+
+	if err := repo.Delete(ctx, created.ID, 2); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Delete with stale version should be a conflict, got %v", err)
+	}
+	if err := repo.Delete(ctx, created.ID, 3); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if found, _ := repo.FindByID(ctx, created.ID); found != nil {
+		t.Errorf("Deleted event is still there: %+v", found)
+	}
+}
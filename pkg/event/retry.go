@@ -0,0 +1,37 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// maxWriteAttempts — сколько раз сервис повторяет изменение события, которое не застало
+// событие в прочитанной версии (см. retryOnConflict)
+const maxWriteAttempts = 3
+
+// retryBackoff — пауза перед повтором; растёт с номером попытки, чтобы одновременные запросы
+// не сталкивались снова и снова
+const retryBackoff = 5 * time.Millisecond
+
+// retryOnConflict выполняет изменение write и повторяет его, пока оно завершается *ConflictError,
+// но не больше maxWriteAttempts раз
+// write должен каждый раз заново читать событие и заново принимать решение по прочитанному:
+// повтор с устаревшими данными снова закончится конфликтом. Если попытки кончились,
+// возвращается последний ConflictError — клиент получит 409 и может повторить запрос сам
+func retryOnConflict(ctx context.Context, write func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if !errors.Is(err, ErrVersionConflict) || attempt == maxWriteAttempts {
+			return err
+		}
+		slog.DebugContext(ctx, "Событие изменено одновременно с другим запросом, повторяем", "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * retryBackoff):
+		}
+	}
+}
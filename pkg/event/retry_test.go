@@ -0,0 +1,115 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRetryOnConflict(t *testing.T) {
+	ctx := context.Background()
+	conflict := &ConflictError{ID: primitive.NewObjectID(), Version: 1}
+
+	calls := 0
+	err := retryOnConflict(ctx, func() error {
+		if calls++; calls < maxWriteAttempts {
+			return conflict
+		}
+		return nil
+	})
+	if err != nil || calls != maxWriteAttempts {
+		t.Errorf("Expected success on attempt %d, got %v after %d calls", maxWriteAttempts, err, calls)
+	}
+
+	calls = 0
+	err = retryOnConflict(ctx, func() error {
+		calls++
+		return conflict
+	})
+	if !errors.Is(err, ErrVersionConflict) || calls != maxWriteAttempts {
+		t.Errorf("Expected conflict after %d calls, got %v after %d", maxWriteAttempts, err, calls)
+	}
+
+	// Остальные ошибки не повторяются
+	calls = 0
+	err = retryOnConflict(ctx, func() error {
+		calls++
+		return ErrEventNotFound
+	})
+	if !errors.Is(err, ErrEventNotFound) || calls != 1 {
+		t.Errorf("Expected a single call for non-conflict errors, got %v after %d", err, calls)
+	}
+
+	// Отменённый запрос не ждёт следующей попытки
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := retryOnConflict(cancelled, func() error { return conflict }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// racingRepository имитирует запросы, которые меняют активное событие между чтением и завершением:
+// первые conflicts вызовов Finish заканчиваются конфликтом версий
+type racingRepository struct {
+	Repository
+	active    Event
+	conflicts int
+	finishes  []FinishParams
+}
+
+func (r *racingRepository) FindActive(ctx context.Context, eventType string) (*Event, error) {
+	active := r.active
+	return &active, nil
+}
+
+func (r *racingRepository) Finish(ctx context.Context, params FinishParams) (*Event, error) {
+	r.finishes = append(r.finishes, params)
+	if params.ID != r.active.ID || params.Version != r.active.Version || r.conflicts > 0 {
+		r.conflicts--
+		r.active.Version++
+		return nil, &ConflictError{ID: params.ID, Version: params.Version}
+	}
+	now := time.Now()
+	finished := r.active
+	finished.State, finished.FinishedAt, finished.Version = Finished, &now, finished.Version+1
+	return &finished, nil
+}
+
+func TestService_FinishRetriesConflicts(t *testing.T) {
+	repo := &racingRepository{active: Event{ID: primitive.NewObjectID(), Type: "meeting", Version: 1}, conflicts: 1}
+	event, err := NewEventService(repo, nil).Finish(context.Background(), FinishParams{Type: "meeting"})
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if len(repo.finishes) != 2 || repo.finishes[0].Version != 1 || repo.finishes[1].Version != 2 {
+		t.Errorf("Expected a retry with the re-read version, got %+v", repo.finishes)
+	}
+	if event.Version != 3 {
+		t.Errorf("Expected version 3, got %d", event.Version)
+	}
+}
+
+// TestHandler_VersionConflict проверяет, что непрекращающийся конфликт версий отдаётся как 409
+func TestHandler_VersionConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &racingRepository{active: Event{ID: primitive.NewObjectID(), Type: "meeting", Version: 1}, conflicts: maxWriteAttempts}
+	handler := NewEventHandler(NewEventService(repo, nil), DefaultMaxLimit)
+	router := gin.New()
+	router.POST("/finish", handler.Finish)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/finish", strings.NewReader(`{"type":"meeting"}`)))
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"code":"version_conflict"`) {
+		t.Errorf("Expected 409 version_conflict, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(repo.finishes) != maxWriteAttempts {
+		t.Errorf("Expected %d attempts, got %d", maxWriteAttempts, len(repo.finishes))
+	}
+}
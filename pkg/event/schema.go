@@ -0,0 +1,275 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"event-service/pkg/problem"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// attributesPath — корень JSON-пути к атрибутам в теле запроса
+// Все пути в FieldError начинаются с него, например "/attributes/card/number"
+const attributesPath = "/attributes"
+
+// FieldError описывает ошибку в конкретном поле запроса
+// Формат общий для всех ответов API об ошибках
+type FieldError = problem.FieldError
+
+// AttributeValidationError возвращается, если атрибуты события не прошли проверку
+// Содержит список ошибок по отдельным полям; errors.Is(err, ErrInvalidAttributes) для неё верно
+type AttributeValidationError struct {
+	Fields []FieldError
+}
+
+func (e *AttributeValidationError) Error() string {
+	return "атрибуты события не прошли проверку: " + joinFields(e.Fields)
+}
+
+func (e *AttributeValidationError) Unwrap() error {
+	return ErrInvalidAttributes
+}
+
+func (e *AttributeValidationError) fieldErrors() []FieldError {
+	return e.Fields
+}
+
+// schemaPrinter форматирует сообщения валидатора JSON Schema
+var schemaPrinter = message.NewPrinter(language.English)
+
+// schemaCache хранит скомпилированные JSON Schema типов событий
+// Ключ — арендатор и имя типа, например "default/payment"
+// Схема перекомпилируется, только если её текст в реестре изменился
+type schemaCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedSchema
+}
+
+// cachedSchema — скомпилированная схема и исходный текст, из которого она получена
+type cachedSchema struct {
+	raw    string
+	schema *jsonschema.Schema
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{entries: make(map[string]cachedSchema)}
+}
+
+// get возвращает скомпилированную схему типа, при необходимости компилируя её заново
+func (c *schemaCache) get(key string, raw json.RawMessage) (*jsonschema.Schema, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && entry.raw == string(raw) {
+		return entry.schema, nil
+	}
+
+	schema, err := compileSchema(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cachedSchema{raw: string(raw), schema: schema}
+	c.mu.Unlock()
+	return schema, nil
+}
+
+// forget удаляет схему типа из кеша (после удаления типа из реестра)
+func (c *schemaCache) forget(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// schemaKey возвращает ключ кеша схем для типа арендатора
+func schemaKey(tenantID, typeName string) string {
+	return tenantID + "/" + typeName
+}
+
+// compileSchema компилирует JSON Schema из её текстового представления
+func compileSchema(raw json.RawMessage) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("схема не является корректным JSON: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("attributes.json", doc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile("attributes.json")
+}
+
+// validateAttributes проверяет атрибуты события по описанию типа
+// Сначала проверяются имена атрибутов, затем список допустимых атрибутов и,
+// если у типа есть JSON Schema, сама схема
+// eventType может быть nil — тогда проверяются только имена атрибутов
+func validateAttributes(eventType *EventType, schemas *schemaCache, attributes map[string]interface{}) error {
+	var fields []FieldError
+
+	for _, name := range sortedKeys(attributes) {
+		// Точка и $ в начале имени ломают запросы MongoDB к вложенным полям
+		if name == "" || strings.Contains(name, ".") || strings.HasPrefix(name, "$") {
+			fields = append(fields, problem.Field(attributesPath+"/"+escapePointer(name), "attribute.invalid_name"))
+		}
+	}
+	if eventType == nil {
+		return fieldsToError(fields)
+	}
+
+	if len(eventType.Attributes) > 0 {
+		for _, name := range sortedKeys(attributes) {
+			path := attributesPath + "/" + escapePointer(name)
+			expected, ok := eventType.Attributes[name]
+			if !ok {
+				fields = append(fields, problem.Field(path, "attribute.not_allowed"))
+				continue
+			}
+			if actual := attributeKindOf(attributes[name]); actual != expected {
+				fields = append(fields, problem.Field(path, "attribute.type_mismatch", expected, actual))
+			}
+		}
+	}
+
+	if len(eventType.Schema) > 0 {
+		schema, err := schemas.get(schemaKey(eventType.TenantID, eventType.Name), eventType.Schema)
+		if err != nil {
+			return err
+		}
+		instance, err := toJSONValue(attributes)
+		if err != nil {
+			return err
+		}
+		if err := schema.Validate(instance); err != nil {
+			var validationErr *jsonschema.ValidationError
+			if !errors.As(err, &validationErr) {
+				return err
+			}
+			fields = append(fields, schemaFieldErrors(validationErr)...)
+		}
+	}
+
+	return fieldsToError(fields)
+}
+
+// fieldsToError превращает список ошибок полей в AttributeValidationError (или nil)
+func fieldsToError(fields []FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return &AttributeValidationError{Fields: fields}
+}
+
+// schemaFieldErrors раскладывает дерево ошибок валидатора на ошибки отдельных полей
+// Для отсутствующих обязательных свойств путь указывает на само свойство
+func schemaFieldErrors(err *jsonschema.ValidationError) []FieldError {
+	if len(err.Causes) > 0 {
+		var fields []FieldError
+		for _, cause := range err.Causes {
+			fields = append(fields, schemaFieldErrors(cause)...)
+		}
+		return fields
+	}
+
+	path := attributesPath
+	for _, token := range err.InstanceLocation {
+		path += "/" + escapePointer(token)
+	}
+
+	if required, ok := err.ErrorKind.(*kind.Required); ok {
+		fields := make([]FieldError, len(required.Missing))
+		for i, name := range required.Missing {
+			fields[i] = problem.Field(path+"/"+escapePointer(name), "field.missing")
+		}
+		return fields
+	}
+	return []FieldError{{Path: path, Message: err.ErrorKind.LocalizedString(schemaPrinter)}}
+}
+
+// toJSONValue приводит атрибуты к виду, который понимает валидатор JSON Schema
+// Значения из MongoDB (primitive.D, int32 и т.д.) проходят через JSON туда и обратно
+func toJSONValue(attributes map[string]interface{}) (interface{}, error) {
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+	data, err := json.Marshal(normalizeBSON(attributes))
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(data))
+}
+
+// normalizeBSON заменяет вложенные документы и массивы MongoDB на обычные map и slice
+// Без этого вложенные объекты сериализуются в JSON как списки пар Key/Value
+func normalizeBSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case primitive.D:
+		m := make(map[string]interface{}, len(value))
+		for _, e := range value {
+			m[e.Key] = normalizeBSON(e.Value)
+		}
+		return m
+	case primitive.M:
+		return normalizeBSON(map[string]interface{}(value))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, e := range value {
+			m[k] = normalizeBSON(e)
+		}
+		return m
+	case primitive.A:
+		return normalizeBSON([]interface{}(value))
+	case []interface{}:
+		s := make([]interface{}, len(value))
+		for i, e := range value {
+			s[i] = normalizeBSON(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// attributeKindOf определяет тип значения атрибута
+func attributeKindOf(v interface{}) AttributeKind {
+	switch normalizeBSON(v).(type) {
+	case string:
+		return AttributeString
+	case bool:
+		return AttributeBoolean
+	case float64, float32, int, int32, int64, json.Number:
+		return AttributeNumber
+	case map[string]interface{}:
+		return AttributeObject
+	case []interface{}:
+		return AttributeArray
+	default:
+		return "null"
+	}
+}
+
+// escapePointer экранирует токен JSON Pointer по RFC 6901
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// sortedKeys возвращает ключи атрибутов по алфавиту, чтобы ошибки шли в стабильном порядке
+func sortedKeys(attributes map[string]interface{}) []string {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,184 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// paymentSchema — схема платежа с вложенным объектом и перечислением
+const paymentSchema = `{
+	"type": "object",
+	"required": ["amount", "currency"],
+	"properties": {
+		"amount": {"type": "number", "minimum": 0},
+		"currency": {"enum": ["RUB", "USD", "EUR"]},
+		"card": {
+			"type": "object",
+			"required": ["number"],
+			"properties": {
+				"number": {"type": "string", "pattern": "^[0-9]{16}$"},
+				"holder": {"type": "string"}
+			}
+		}
+	}
+}`
+
+func paymentType() *EventType {
+	return &EventType{Name: "payment", Schema: json.RawMessage(paymentSchema)}
+}
+
+// fieldPaths возвращает пути из ошибки проверки атрибутов
+func fieldPaths(t *testing.T, err error) []string {
+	t.Helper()
+	var validationErr *AttributeValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *AttributeValidationError, got %v", err)
+	}
+	paths := make([]string, len(validationErr.Fields))
+	for i, f := range validationErr.Fields {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
+func TestValidateAttributes_ValidPayment(t *testing.T) {
+	attributes := map[string]interface{}{
+		"amount":   100.5,
+		"currency": "RUB",
+		"card":     map[string]interface{}{"number": "1234567812345678", "holder": "IVAN"},
+	}
+	if err := validateAttributes(paymentType(), newSchemaCache(), attributes); err != nil {
+		t.Errorf("Valid payment should pass, got %v", err)
+	}
+}
+
+func TestValidateAttributes_MissingRequired(t *testing.T) {
+	err := validateAttributes(paymentType(), newSchemaCache(), nil)
+	paths := fieldPaths(t, err)
+
+	expected := []string{"/attributes/amount", "/attributes/currency"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+}
+
+func TestValidateAttributes_Enum(t *testing.T) {
+	attributes := map[string]interface{}{"amount": 10.0, "currency": "GBP"}
+	paths := fieldPaths(t, validateAttributes(paymentType(), newSchemaCache(), attributes))
+
+	if !reflect.DeepEqual(paths, []string{"/attributes/currency"}) {
+		t.Errorf("Expected enum error on currency, got %v", paths)
+	}
+}
+
+func TestValidateAttributes_NestedObject(t *testing.T) {
+	cache := newSchemaCache()
+
+	// Во вложенном объекте нет обязательного поля
+	attributes := map[string]interface{}{
+		"amount":   10.0,
+		"currency": "USD",
+		"card":     map[string]interface{}{"holder": "IVAN"},
+	}
+	paths := fieldPaths(t, validateAttributes(paymentType(), cache, attributes))
+	if !reflect.DeepEqual(paths, []string{"/attributes/card/number"}) {
+		t.Errorf("Expected missing nested field, got %v", paths)
+	}
+
+	// Вложенное поле не подходит под pattern
+	attributes["card"] = map[string]interface{}{"number": "12"}
+	paths = fieldPaths(t, validateAttributes(paymentType(), cache, attributes))
+	if !reflect.DeepEqual(paths, []string{"/attributes/card/number"}) {
+		t.Errorf("Expected pattern error on nested field, got %v", paths)
+	}
+}
+
+func TestValidateAttributes_BSONValues(t *testing.T) {
+	// Так атрибуты выглядят после чтения из MongoDB
+	attributes := map[string]interface{}{
+		"amount":   int32(10),
+		"currency": "EUR",
+		"card":     primitive.D{{Key: "number", Value: "1234567812345678"}},
+	}
+	if err := validateAttributes(paymentType(), newSchemaCache(), attributes); err != nil {
+		t.Errorf("Values decoded from MongoDB should pass, got %v", err)
+	}
+}
+
+func TestValidateAttributes_AllowedAttributes(t *testing.T) {
+	eventType := &EventType{
+		Name:       "meeting",
+		Attributes: map[string]AttributeKind{"room": AttributeString, "people": AttributeNumber},
+	}
+
+	if err := validateAttributes(eventType, newSchemaCache(), map[string]interface{}{"room": "A1", "people": 3.0}); err != nil {
+		t.Errorf("Allowed attributes should pass, got %v", err)
+	}
+
+	attributes := map[string]interface{}{"room": 42.0, "topic": "planning"}
+	paths := fieldPaths(t, validateAttributes(eventType, newSchemaCache(), attributes))
+	expected := []string{"/attributes/room", "/attributes/topic"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+}
+
+func TestValidateAttributes_BadNames(t *testing.T) {
+	attributes := map[string]interface{}{"a.b": 1.0, "$set": 2.0, "ok": 3.0}
+	paths := fieldPaths(t, validateAttributes(nil, nil, attributes))
+
+	expected := []string{"/attributes/$set", "/attributes/a.b"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+}
+
+func TestSchemaCache_ReusesCompiledSchema(t *testing.T) {
+	cache := newSchemaCache()
+	raw := json.RawMessage(paymentSchema)
+
+	first, err := cache.get("payment", raw)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	second, err := cache.get("payment", raw)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if first != second {
+		t.Error("Schema should be compiled once and reused")
+	}
+
+	// Изменённая схема компилируется заново
+	third, err := cache.get("payment", json.RawMessage(`{"type": "object"}`))
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if third == first {
+		t.Error("Changed schema should be recompiled")
+	}
+
+	cache.forget("payment")
+	if _, ok := cache.entries["payment"]; ok {
+		t.Error("forget should remove schema from cache")
+	}
+}
+
+func TestCompileSchema_Invalid(t *testing.T) {
+	if _, err := compileSchema(json.RawMessage(`{"type": 5}`)); err == nil {
+		t.Error("Expected error for invalid schema")
+	}
+	if _, err := compileSchema(json.RawMessage(`not json`)); err == nil {
+		t.Error("Expected error for malformed JSON")
+	}
+}
+
+func TestEscapePointer(t *testing.T) {
+	if got := escapePointer("a/b~c"); got != "a~1b~0c" {
+		t.Errorf("escapePointer() = %s, expected a~1b~0c", got)
+	}
+}
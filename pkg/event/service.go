@@ -2,8 +2,16 @@ package event
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"event-service/pkg/audit"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // EventService содержит всю бизнес-логику работы с событиями
@@ -11,20 +19,222 @@ import (
 type EventService struct {
 	// repo — это репозиторий, который работает с базой данных
 	// Сервис использует его для всех операций с данными
-	repo *EventRepository
+	repo Repository
+
+	// types — реестр типов событий
+	// Может быть nil — тогда разрешены любые типы, подходящие под формат
+	types *TypeService
+
+	// audit — журнал аудита изменений
+	// Может быть nil — тогда изменения никуда не записываются
+	audit Auditor
+
+	// observer узнаёт о запуске и завершении событий (например, для метрик)
+	// Может быть nil
+	observer Observer
+
+	// quota — суточная квота арендатора на создание событий
+	// Может быть nil — тогда события создаются без ограничения
+	quota Quota
+}
+
+// Repository — операции с событиями, которые нужны сервису
+// Реализуется EventRepository; обёртки (например, с замером времени запросов) реализуют его же
+type Repository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (*Event, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]Event, error)
+	FindChildren(ctx context.Context, parentIDs []primitive.ObjectID) ([]Event, error)
+	FindActive(ctx context.Context, eventType string) (*Event, error)
+	Create(ctx context.Context, event *Event) (*Event, error)
+	Finish(ctx context.Context, params FinishParams) (*Event, error)
+	FinishByIDs(ctx context.Context, ids []primitive.ObjectID, finishedAt time.Time, finishedBy string) ([]primitive.ObjectID, error)
+	List(ctx context.Context, offset int, limit int, eventType string) ([]Event, error)
+	Find(ctx context.Context, filter ListFilter) ([]Event, error)
+	InsertMany(ctx context.Context, events []Event) error
+	Stats(ctx context.Context, filter StatsFilter) ([]TypeStats, error)
+	UpdateAttributes(ctx context.Context, id primitive.ObjectID, version int64, attributes map[string]interface{}) (*Event, error)
+	Delete(ctx context.Context, id primitive.ObjectID, version int64) error
+}
+
+// Observer узнаёт о результатах Start и Finish — например, чтобы считать метрики
+// Вызывается только после успешного сохранения изменений
+type Observer interface {
+	// EventStarted вызывается после Start; deduplicated=true, если вместо нового события
+	// возвращено уже активное событие этого типа
+	EventStarted(ctx context.Context, event *Event, deduplicated bool)
+	// EventFinished вызывается для каждого завершённого события, включая потомков при каскадном завершении
+	EventFinished(ctx context.Context, event *Event)
+}
+
+// Observers передаёт уведомления каждому наблюдателю по очереди
+// Нужен, когда за событиями следят несколько потребителей — например, метрики и поток изменений
+type Observers []Observer
+
+// EventStarted уведомляет всех наблюдателей о запуске события
+func (o Observers) EventStarted(ctx context.Context, event *Event, deduplicated bool) {
+	for _, observer := range o {
+		observer.EventStarted(ctx, event, deduplicated)
+	}
+}
+
+// EventFinished уведомляет всех наблюдателей о завершении события
+func (o Observers) EventFinished(ctx context.Context, event *Event) {
+	for _, observer := range o {
+		observer.EventFinished(ctx, event)
+	}
+}
+
+// Quota расходует суточную квоту арендатора из контекста на создание событий (см. ratelimit.Quota)
+// Take возвращает ошибку, если квоты не хватает; refund возвращает взятое, если событие не создано
+type Quota interface {
+	Take(ctx context.Context, n int64) (refund func(), err error)
+}
+
+// Auditor записывает изменения событий в журнал аудита (см. audit.Log)
+type Auditor interface {
+	Append(ctx context.Context, records ...audit.Record) error
 }
 
 // NewEventService создаёт новый сервис для работы с событиями
-// Нужно просто передать ему репозиторий, который уже знает, как работать с базой
-func NewEventService(repo *EventRepository) *EventService {
-	return &EventService{repo: repo}
+// Нужно передать ему репозиторий, который уже знает, как работать с базой,
+// и реестр типов (или nil, если реестр не используется)
+func NewEventService(repo Repository, types *TypeService) *EventService {
+	return &EventService{repo: repo, types: types}
+}
+
+// SetObserver подключает наблюдателя за запуском и завершением событий
+func (s *EventService) SetObserver(o Observer) {
+	s.observer = o
+}
+
+// SetQuota подключает суточные квоты: каждое событие, созданное Start, расходует единицу квоты арендатора
+// Запуск, вернувший уже активное событие, квоту не тратит; импорт — тоже (см. Import)
+func (s *EventService) SetQuota(q Quota) {
+	s.quota = q
+}
+
+// SetAuditor подключает журнал аудита: после этого каждое изменение событий
+// (запуск, завершение, импорт) записывается в него со снимками до и после
+func (s *EventService) SetAuditor(a Auditor) {
+	s.audit = a
+}
+
+// record записывает изменения в журнал аудита, если он подключён
+func (s *EventService) record(ctx context.Context, records ...audit.Record) {
+	appendAudit(ctx, s.audit, records...)
+}
+
+// appendAudit записывает изменения в журнал a; nil — журнал не подключён
+// Само изменение к этому моменту уже сохранено, поэтому ошибка журнала не возвращается клиенту —
+// запрос выполнен, — а пишется в лог со всеми затронутыми событиями. Журнал с outbox
+// (audit.Log.SetOutbox) возвращает ошибку, только если не удалось сохранить записи и туда
+func appendAudit(ctx context.Context, a Auditor, records ...audit.Record) {
+	if a == nil || len(records) == 0 {
+		return
+	}
+	if err := a.Append(ctx, records...); err != nil {
+		ids := make([]string, len(records))
+		for i := range records {
+			ids[i] = string(records[i].Action) + ":" + records[i].EventID.Hex()
+		}
+		slog.ErrorContext(ctx, "Изменение сохранено, но не записано в журнал аудита", "records", ids, "error", err)
+	}
+}
+
+// auditRecord описывает изменение события before → after для журнала аудита
+// before или after могут быть nil (события ещё не было)
+func auditRecord(action audit.Action, before, after *Event) audit.Record {
+	record := audit.Record{Action: action}
+	if before != nil {
+		record.EventID, record.EventType, record.Before = before.ID, before.Type, before
+	}
+	if after != nil {
+		record.EventID, record.EventType, record.After = after.ID, after.Type, after
+	}
+	return record
+}
+
+// NamingRule возвращает действующее правило именования типов
+// Правило хранится в реестре типов, без реестра действует правило по умолчанию
+func (s *EventService) NamingRule() *NamingRule {
+	if s.types == nil {
+		return DefaultNamingRule()
+	}
+	return s.types.NamingRule()
+}
+
+// ValidateType проверяет имя типа по действующему правилу именования
+// Ошибка оборачивает ErrInvalidTypeName и указывает на поле /type
+func (s *EventService) ValidateType(eventType string) error {
+	if err := s.NamingRule().Validate(eventType); err != nil {
+		return &FieldsError{Err: ErrInvalidTypeName, Fields: []FieldError{{Path: "/type", Message: err.Error()}}}
+	}
+	return nil
+}
+
+// checkKnownType проверяет тип по реестру, если он подключён
+// В строгом режиме незарегистрированный тип приводит к ErrUnknownEventType
+func (s *EventService) checkKnownType(ctx context.Context, eventType string) error {
+	if s.types == nil {
+		return nil
+	}
+	return s.types.CheckKnown(ctx, eventType)
+}
+
+// ValidateAttributes проверяет атрибуты нового события по реестру типов
+// Ошибки в данных возвращаются как *AttributeValidationError с путями до полей
+func (s *EventService) ValidateAttributes(ctx context.Context, eventType string, attributes map[string]interface{}) error {
+	if s.types == nil {
+		return validateAttributes(nil, nil, attributes)
+	}
+	return s.types.ValidateAttributes(ctx, eventType, attributes)
+}
+
+// validateFinishAttributes проверяет по схеме типа атрибуты активного события active (nil — события нет)
+// вместе с атрибутами attributes, которые добавятся к ним при завершении
+func (s *EventService) validateFinishAttributes(ctx context.Context, eventType string, active *Event, attributes map[string]interface{}) error {
+	merged := make(map[string]interface{}, len(attributes))
+	if active != nil {
+		for key, value := range active.Attributes {
+			merged[key] = value
+		}
+	}
+	for key, value := range attributes {
+		merged[key] = value
+	}
+	return s.ValidateAttributes(ctx, eventType, merged)
 }
 
 // Start запускает новое событие указанного типа
 // Но делает это умно: сначала проверяет, нет ли уже активного события такого типа
 // Если есть — ничего не делает, просто возвращает существующее событие
 // Если нет — создаёт новое
-func (s *EventService) Start(ctx context.Context, eventType string) (*Event, error) {
+// Если указан родитель, он должен существовать и быть активным,
+// иначе вернётся ErrParentNotFound или ErrParentNotActive
+func (s *EventService) Start(ctx context.Context, params StartParams) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventService.Start", eventTypeAttr(params.Type))
+	defer endSpan(span, &err)
+
+	eventType := params.Type
+
+	// В строгом режиме запускать можно только зарегистрированные типы
+	if err := s.checkKnownType(ctx, eventType); err != nil {
+		return nil, err
+	}
+
+	// Атрибуты проверяются по JSON Schema типа здесь, а не в обработчиках,
+	// чтобы ни один API не мог сохранить событие в обход схемы
+	if err := s.ValidateAttributes(ctx, eventType, params.Attributes); err != nil {
+		return nil, err
+	}
+
+	// Вложенное событие можно запустить только внутри активного родителя
+	if params.ParentID != nil {
+		if err := s.checkParent(ctx, *params.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Сначала проверяем, нет ли уже активного события этого типа
 	active, err := s.repo.FindActive(ctx, eventType)
 	if err != nil {
@@ -35,38 +245,381 @@ func (s *EventService) Start(ctx context.Context, eventType string) (*Event, err
 	// Если активное событие уже есть — просто возвращаем его
 	// Не создаём дубликат, как и требуется в ТЗ
 	if active != nil {
+		slog.DebugContext(ctx, "Событие этого типа уже активно, возвращаем его", "type", eventType, "event_id", active.ID.Hex())
+		if s.observer != nil {
+			s.observer.EventStarted(ctx, active, true)
+		}
 		return active, nil
 	}
 
-	// Если активного события нет — создаём новое
-	return s.repo.Create(ctx, eventType)
+	// Если активного события нет — создаём новое; оно расходует квоту арендатора
+	refund := func() {}
+	if s.quota != nil {
+		if refund, err = s.quota.Take(ctx, 1); err != nil {
+			return nil, err
+		}
+	}
+	event, err := s.repo.Create(ctx, &Event{
+		Type:       eventType,
+		Attributes: params.Attributes,
+		ParentID:   params.ParentID,
+		StartedBy:  params.StartedBy,
+	})
+	if err != nil {
+		refund()
+		return nil, err
+	}
+	slog.DebugContext(ctx, "Событие запущено", "type", eventType, "event_id", event.ID.Hex())
+	// Возврат уже активного события — не изменение, поэтому в журнал попадает только новое
+	if s.observer != nil {
+		s.observer.EventStarted(ctx, event, false)
+	}
+	s.record(ctx, auditRecord(audit.ActionStart, nil, event))
+	return event, nil
+}
+
+// checkParent проверяет, что родительское событие существует и ещё не завершено
+func (s *EventService) checkParent(ctx context.Context, parentID primitive.ObjectID) error {
+	parent, err := s.repo.FindByID(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return ErrParentNotFound
+	}
+	if parent.State != Active {
+		return ErrParentNotActive
+	}
+	return nil
 }
 
 // Finish завершает активное событие указанного типа
-// Если такого события нет — вернёт ошибку
+// Если такого события нет — вернёт ErrEventNotFound
 // Если есть — завершит его и вернёт обновлённое событие
-func (s *EventService) Finish(ctx context.Context, eventType string) (*Event, error) {
-	// Просто просим репозиторий завершить событие
-	// Репозиторий сам вернёт ошибку, если события не найдётся
-	event, err := s.repo.Finish(ctx, eventType)
-	if err == mongo.ErrNoDocuments {
-		// Если события нет — возвращаем ошибку, которую потом обработает handler
-		return nil, mongo.ErrNoDocuments
+// С параметром Cascade вместе с событием завершаются все его активные потомки; само событие
+// записывается в журнал и публикуется до каскада, поэтому и при сбое каскада о его завершении известно
+// Событие завершается в той версии, по которой проверены атрибуты: если его изменили одновременно
+// с завершением, завершение повторяется с новыми данными (retryOnConflict)
+func (s *EventService) Finish(ctx context.Context, params FinishParams) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventService.Finish", eventTypeAttr(params.Type), attribute.Bool("event.cascade", params.Cascade))
+	defer endSpan(span, &err)
+
+	// Имена новых атрибутов проверяются до чтения события: некорректный запрос — 400, даже если события нет
+	if err := validateAttributes(nil, nil, params.Attributes); err != nil {
+		return nil, err
 	}
+
+	// before — событие до завершения: для журнала аудита и для проверки атрибутов
+	var before, event *Event
+	err = retryOnConflict(ctx, func() error {
+		active, err := s.repo.FindActive(ctx, params.Type)
+		if err != nil {
+			return err
+		}
+		if active == nil {
+			// Если события нет — возвращаем ошибку, которую потом обработает handler
+			return fmt.Errorf("%w: нет активного события типа %q", ErrEventNotFound, params.Type)
+		}
+		if len(params.Attributes) > 0 {
+			if err := s.validateFinishAttributes(ctx, params.Type, active, params.Attributes); err != nil {
+				return err
+			}
+		}
+
+		// Репозиторий завершит событие, только если оно не изменилось после чтения
+		conditional := params
+		conditional.ID, conditional.Version = active.ID, active.Version
+		finished, err := s.repo.Finish(ctx, conditional)
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("%w: нет активного события типа %q", ErrEventNotFound, params.Type)
+		}
+		if err != nil {
+			return err
+		}
+		before, event = active, finished
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	if s.observer != nil {
+		s.observer.EventFinished(ctx, event)
+	}
+	s.record(ctx, auditRecord(audit.ActionFinish, before, event))
+
+	cascaded := 0
+	if params.Cascade {
+		if cascaded, err = s.finishDescendants(ctx, event, params.FinishedBy); err != nil {
+			return nil, fmt.Errorf("событие %s завершено, но не все вложенные события: %w", event.ID.Hex(), err)
+		}
+	}
+	slog.DebugContext(ctx, "Событие завершено", "type", event.Type, "event_id", event.ID.Hex(), "cascaded", cascaded)
 	return event, nil
 }
 
+// finishDescendants завершает все активные события, вложенные в parent на любой глубине,
+// с тем же временем окончания, что у parent, записывает их в журнал и сообщает о них наблюдателю
+// Дерево обходится по уровням: один запрос на уровень и одно обновление на событие
+// В журнал и наблюдателю попадают только события, которые завершил именно этот вызов (даже если
+// завершение прервалось): завершённые тем временем кем-то ещё уже описаны тем, кто их завершил
+// Возвращает, сколько событий завершено
+func (s *EventService) finishDescendants(ctx context.Context, parent *Event, finishedBy string) (int, error) {
+	descendants, err := s.descendants(ctx, parent.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []primitive.ObjectID
+	for _, e := range descendants {
+		if e.State == Active {
+			ids = append(ids, e.ID)
+		}
+	}
+	finishedIDs, err := s.repo.FinishByIDs(ctx, ids, *parent.FinishedAt, finishedBy)
+	finished := make(map[primitive.ObjectID]bool, len(finishedIDs))
+	for _, id := range finishedIDs {
+		finished[id] = true
+	}
+
+	records := make([]audit.Record, 0, len(finishedIDs))
+	for i := range descendants {
+		if !finished[descendants[i].ID] {
+			continue
+		}
+		after := descendants[i]
+		after.State, after.FinishedAt, after.FinishedBy = Finished, parent.FinishedAt, finishedBy
+		after.UpdatedAt, after.Version = *parent.FinishedAt, after.Version+1
+		records = append(records, auditRecord(audit.ActionFinish, &descendants[i], &after))
+		if s.observer != nil {
+			s.observer.EventFinished(ctx, &after)
+		}
+	}
+	s.record(ctx, records...)
+	return len(finishedIDs), err
+}
+
+// descendants возвращает всех потомков события, обходя дерево по уровням
+// Каждое событие попадает в результат один раз, даже если данные в базе испорчены циклом
+func (s *EventService) descendants(ctx context.Context, id primitive.ObjectID) ([]Event, error) {
+	seen := map[primitive.ObjectID]bool{id: true}
+	level := []primitive.ObjectID{id}
+
+	var result []Event
+	for len(level) > 0 {
+		children, err := s.repo.FindChildren(ctx, level)
+		if err != nil {
+			return nil, err
+		}
+		level = level[:0]
+		for _, child := range children {
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			result = append(result, child)
+			level = append(level, child.ID)
+		}
+	}
+	return result, nil
+}
+
+// Tree возвращает событие со всеми вложенными событиями и суммарными длительностями
+// Если события нет, вернёт nil без ошибки
+func (s *EventService) Tree(ctx context.Context, id primitive.ObjectID) (_ *EventTree, err error) {
+	ctx, span := startSpan(ctx, "EventService.Tree", eventIDAttr(id.Hex()))
+	defer endSpan(span, &err)
+
+	root, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, nil
+	}
+
+	descendants, err := s.descendants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return buildEventTree(*root, descendants, time.Now()), nil
+}
+
+// Get возвращает событие по идентификатору
+// Если события нет, вернёт ErrEventNotFound
+func (s *EventService) Get(ctx context.Context, id primitive.ObjectID) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventService.Get", eventIDAttr(id.Hex()))
+	defer endSpan(span, &err)
+
+	event, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, fmt.Errorf("%w: %s", ErrEventNotFound, id.Hex())
+	}
+	return event, nil
+}
+
+// Update изменяет атрибуты события и возвращает изменённое событие
+// Итоговый набор атрибутов проверяется по схеме типа. Изменение сохраняется, только если версия
+// события не изменилась с момента чтения: с IfVersion иначе вернётся ErrPreconditionFailed,
+// без него событие перечитывается и изменения применяются заново (retryOnConflict)
+func (s *EventService) Update(ctx context.Context, params UpdateParams) (_ *Event, err error) {
+	ctx, span := startSpan(ctx, "EventService.Update", eventIDAttr(params.ID.Hex()))
+	defer endSpan(span, &err)
+
+	if err := validateAttributes(nil, nil, params.Attributes); err != nil {
+		return nil, err
+	}
+
+	var before, updated *Event
+	err = retryOnConflict(ctx, func() error {
+		current, err := s.current(ctx, params.ID, params.IfVersion)
+		if err != nil {
+			return err
+		}
+		attributes := mergeAttributes(current.Attributes, params.Attributes)
+		if err := s.ValidateAttributes(ctx, current.Type, attributes); err != nil {
+			return err
+		}
+		result, err := s.repo.UpdateAttributes(ctx, current.ID, current.Version, attributes)
+		if err != nil {
+			return ifVersionConflict(err, params.IfVersion)
+		}
+		before, updated = current, result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Атрибуты события изменены", "type", updated.Type, "event_id", updated.ID.Hex(), "version", updated.Version)
+	s.record(ctx, auditRecord(audit.ActionUpdate, before, updated))
+	return updated, nil
+}
+
+// Delete удаляет событие
+// Событие с вложенными событиями не удаляется (ErrEventHasChildren); IfVersion работает как в Update
+func (s *EventService) Delete(ctx context.Context, params DeleteParams) (err error) {
+	ctx, span := startSpan(ctx, "EventService.Delete", eventIDAttr(params.ID.Hex()))
+	defer endSpan(span, &err)
+
+	var deleted *Event
+	err = retryOnConflict(ctx, func() error {
+		current, err := s.current(ctx, params.ID, params.IfVersion)
+		if err != nil {
+			return err
+		}
+		children, err := s.repo.FindChildren(ctx, []primitive.ObjectID{current.ID})
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return fmt.Errorf("%w: %d", ErrEventHasChildren, len(children))
+		}
+		if err := s.repo.Delete(ctx, current.ID, current.Version); err != nil {
+			return ifVersionConflict(err, params.IfVersion)
+		}
+		deleted = current
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.DebugContext(ctx, "Событие удалено", "type", deleted.Type, "event_id", deleted.ID.Hex())
+	s.record(ctx, auditRecord(audit.ActionDelete, deleted, nil))
+	return nil
+}
+
+// ifVersionConflict превращает конфликт версий в ErrPreconditionFailed, если клиент требовал версию ifVersion:
+// повтор с новой версией нарушил бы его условие (If-Match)
+func ifVersionConflict(err error, ifVersion *int64) error {
+	if ifVersion != nil && errors.Is(err, ErrVersionConflict) {
+		return fmt.Errorf("%w: %v", ErrPreconditionFailed, err)
+	}
+	return err
+}
+
+// current читает событие перед изменением и проверяет, что его версия равна ifVersion (если он задан)
+func (s *EventService) current(ctx context.Context, id primitive.ObjectID, ifVersion *int64) (*Event, error) {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("%w: %s", ErrEventNotFound, id.Hex())
+	}
+	if ifVersion != nil && current.Version != *ifVersion {
+		return nil, ErrPreconditionFailed
+	}
+	return current, nil
+}
+
+// mergeAttributes применяет изменения patch к атрибутам attributes и возвращает новый набор
+// Атрибут со значением nil удаляется, остальные добавляются или заменяются целиком
+func mergeAttributes(attributes, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(attributes)+len(patch))
+	for key, value := range attributes {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
 // List возвращает список событий с учетом фильтров
 // Параметры:
 //   - offset: смещение от начала списка (0 = с самого начала)
-//   - limit: максимальное количество событий (0 = без ограничения, максимум 100)
+//   - limit: максимальное количество событий (0 = без ограничения; наибольшее значение проверяет обработчик)
 //   - eventType: фильтр по типу события (пустая строка = без фильтра)
 //
 // События отсортированы по времени начала в порядке убывания (descending)
-func (s *EventService) List(ctx context.Context, offset int, limit int, eventType string) ([]Event, error) {
+func (s *EventService) List(ctx context.Context, offset int, limit int, eventType string) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "EventService.List", eventTypeAttr(eventType))
+	defer endSpan(span, &err)
+
 	// Просим репозиторий вернуть события с учетом фильтров
 	return s.repo.List(ctx, offset, limit, eventType)
 }
+
+// Find возвращает события, подходящие под фильтр
+// В отличие от List умеет ограничивать выборку шаблонами типов
+func (s *EventService) Find(ctx context.Context, filter ListFilter) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "EventService.Find", eventTypeAttr(filter.Type))
+	defer endSpan(span, &err)
+
+	return s.repo.Find(ctx, filter)
+}
+
+// GetMany возвращает события с указанными идентификаторами одним запросом
+// Несуществующие идентификаторы пропускаются, порядок событий не определён
+func (s *EventService) GetMany(ctx context.Context, ids []primitive.ObjectID) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "EventService.GetMany", attribute.Int("event.count", len(ids)))
+	defer endSpan(span, &err)
+
+	return s.repo.FindByIDs(ctx, ids)
+}
+
+// Children возвращает непосредственно вложенные события всех указанных родителей одним запросом
+// События отсортированы по времени начала в порядке возрастания
+func (s *EventService) Children(ctx context.Context, parentIDs []primitive.ObjectID) (_ []Event, err error) {
+	ctx, span := startSpan(ctx, "EventService.Children", attribute.Int("event.parents", len(parentIDs)))
+	defer endSpan(span, &err)
+
+	return s.repo.FindChildren(ctx, parentIDs)
+}
+
+// Stats возвращает сводку по событиям каждого типа: сколько их, сколько активно
+// и сколько в среднем длятся завершённые
+func (s *EventService) Stats(ctx context.Context, filter StatsFilter) (_ []TypeStats, err error) {
+	ctx, span := startSpan(ctx, "EventService.Stats", attribute.Int("event.types", len(filter.Types)))
+	defer endSpan(span, &err)
+
+	return s.repo.Stats(ctx, filter)
+}
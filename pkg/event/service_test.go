@@ -2,11 +2,15 @@ package event
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 
 	"event-service/internal/db"
+	"event-service/pkg/ratelimit"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -57,7 +61,7 @@ func TestNewEventService(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 
 	if service == nil {
 		t.Fatal("NewEventService returned nil")
@@ -71,12 +75,12 @@ func TestEventService_Start_NoActiveEvent(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 	eventType := "meeting"
 
 	// Создаём новое событие
-	event, err := service.Start(ctx, eventType)
+	event, err := service.Start(ctx, StartParams{Type: eventType})
 	if err != nil {
 		t.Fatalf("Start failed: %v", err)
 	}
@@ -102,12 +106,12 @@ func TestEventService_Start_ActiveEventExists(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 	eventType := "meeting"
 
 	// Создаём первое событие
-	firstEvent, err := service.Start(ctx, eventType)
+	firstEvent, err := service.Start(ctx, StartParams{Type: eventType})
 	if err != nil {
 		t.Fatalf("First Start failed: %v", err)
 	}
@@ -115,7 +119,7 @@ func TestEventService_Start_ActiveEventExists(t *testing.T) {
 	firstStartedAt := firstEvent.StartedAt
 
 	// Пытаемся создать ещё одно событие того же типа
-	secondEvent, err := service.Start(ctx, eventType)
+	secondEvent, err := service.Start(ctx, StartParams{Type: eventType})
 	if err != nil {
 		t.Fatalf("Second Start failed: %v", err)
 	}
@@ -134,22 +138,94 @@ func TestEventService_Start_ActiveEventExists(t *testing.T) {
 	}
 }
 
+// startRepository хранит активные события в памяти; Create с failCreate возвращает ошибку
+type startRepository struct {
+	Repository
+	active     map[string]*Event
+	failCreate bool
+}
+
+func (r *startRepository) FindActive(ctx context.Context, eventType string) (*Event, error) {
+	return r.active[eventType], nil
+}
+
+func (r *startRepository) Create(ctx context.Context, event *Event) (*Event, error) {
+	if r.failCreate {
+		return nil, errors.New("нет соединения")
+	}
+	created := *event
+	created.ID, created.State, created.StartedAt = primitive.NewObjectID(), Active, time.Now()
+	r.active[created.Type] = &created
+	return &created, nil
+}
+
+func TestEventService_Start_Quota(t *testing.T) {
+	repo := &startRepository{active: map[string]*Event{}}
+	service := NewEventService(repo, nil)
+	service.SetQuota(ratelimit.NewQuota(ratelimit.NewMemoryStore(), ratelimit.Quotas{ratelimit.DefaultRoute: 2}))
+	ctx := context.Background()
+
+	// Неудачное создание возвращает квоту
+	repo.failCreate = true
+	if _, err := service.Start(ctx, StartParams{Type: "meeting"}); err == nil {
+		t.Fatal("Expected a storage error")
+	}
+	repo.failCreate = false
+
+	// Возврат уже активного события квоту не тратит
+	for i := 0; i < 3; i++ {
+		if _, err := service.Start(ctx, StartParams{Type: "meeting"}); err != nil {
+			t.Fatalf("Start %d failed: %v", i+1, err)
+		}
+	}
+	if _, err := service.Start(ctx, StartParams{Type: "call"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	_, err := service.Start(ctx, StartParams{Type: "review"})
+	if !errors.Is(err, ratelimit.ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if repo.active["review"] != nil {
+		t.Error("Event should not be created over the quota")
+	}
+	// Активное событие по-прежнему возвращается и при исчерпанной квоте
+	if _, err := service.Start(ctx, StartParams{Type: "meeting"}); err != nil {
+		t.Errorf("Deduplicated start should not need the quota, got %v", err)
+	}
+}
+
+func TestEventService_Start_InvalidAttributes(t *testing.T) {
+	repo := &startRepository{active: map[string]*Event{}}
+	service := NewEventService(repo, nil)
+
+	// Сервис сам проверяет атрибуты, поэтому их не обойти ни через один API
+	_, err := service.Start(context.Background(), StartParams{Type: "meeting", Attributes: map[string]interface{}{"$where": "1"}})
+	var validationErr *AttributeValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *AttributeValidationError, got %v", err)
+	}
+	if repo.active["meeting"] != nil {
+		t.Error("Event with invalid attributes should not be created")
+	}
+}
+
 func TestEventService_Finish_Success(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 	eventType := "meeting"
 
 	// Создаём событие
-	startEvent, err := service.Start(ctx, eventType)
+	startEvent, err := service.Start(ctx, StartParams{Type: eventType})
 	if err != nil {
 		t.Fatalf("Start failed: %v", err)
 	}
 
 	// Завершаем событие
-	finishedEvent, err := service.Finish(ctx, eventType)
+	finishedEvent, err := service.Finish(ctx, FinishParams{Type: eventType})
 	if err != nil {
 		t.Fatalf("Finish failed: %v", err)
 	}
@@ -172,17 +248,17 @@ func TestEventService_Finish_NotFound(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 	eventType := "nonexistent"
 
 	// Пытаемся завершить несуществующее событие
-	event, err := service.Finish(ctx, eventType)
+	event, err := service.Finish(ctx, FinishParams{Type: eventType})
 	if err == nil {
 		t.Fatal("Expected error when finishing non-existent event")
 	}
-	if err != mongo.ErrNoDocuments {
-		t.Errorf("Expected mongo.ErrNoDocuments, got %v", err)
+	if !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected ErrEventNotFound, got %v", err)
 	}
 	if event != nil {
 		t.Error("Expected nil event when error occurs")
@@ -193,7 +269,7 @@ func TestEventService_List_Empty(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 
 	// Получаем список событий (должен быть пустым)
@@ -210,7 +286,7 @@ func TestEventService_List_WithEvents(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 
 	// Создаём несколько событий
@@ -218,21 +294,21 @@ func TestEventService_List_WithEvents(t *testing.T) {
 	secondType := "call"
 	thirdType := "task"
 
-	_, err := service.Start(ctx, firstType)
+	_, err := service.Start(ctx, StartParams{Type: firstType})
 	if err != nil {
 		t.Fatalf("Start first failed: %v", err)
 	}
 
 	time.Sleep(10 * time.Millisecond) // Небольшая задержка для разных времён
 
-	_, err = service.Start(ctx, secondType)
+	_, err = service.Start(ctx, StartParams{Type: secondType})
 	if err != nil {
 		t.Fatalf("Start second failed: %v", err)
 	}
 
 	time.Sleep(10 * time.Millisecond)
 
-	_, err = service.Start(ctx, thirdType)
+	_, err = service.Start(ctx, StartParams{Type: thirdType})
 	if err != nil {
 		t.Fatalf("Start third failed: %v", err)
 	}
@@ -259,14 +335,14 @@ func TestEventService_Start_ErrorHandling(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 
 	// Используем отменённый контекст для проверки обработки ошибок
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Отменяем контекст сразу
 
 	// Попытка создать событие с отменённым контекстом должна вернуть ошибку
-	event, err := service.Start(ctx, "test")
+	event, err := service.Start(ctx, StartParams{Type: "test"})
 	if err == nil {
 		t.Error("Expected error with cancelled context")
 	}
@@ -279,22 +355,22 @@ func TestEventService_Finish_DifferentTypes(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 
 	// Создаём два события разных типов
-	_, err := service.Start(ctx, "meeting")
+	_, err := service.Start(ctx, StartParams{Type: "meeting"})
 	if err != nil {
 		t.Fatalf("Start meeting failed: %v", err)
 	}
 
-	_, err = service.Start(ctx, "call")
+	_, err = service.Start(ctx, StartParams{Type: "call"})
 	if err != nil {
 		t.Fatalf("Start call failed: %v", err)
 	}
 
 	// Завершаем только одно
-	finished, err := service.Finish(ctx, "meeting")
+	finished, err := service.Finish(ctx, FinishParams{Type: "meeting"})
 	if err != nil {
 		t.Fatalf("Finish meeting failed: %v", err)
 	}
@@ -326,14 +402,14 @@ func TestEventService_Start_WithRepositoryError(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 
 	// Используем отменённый контекст для создания ошибки на уровне репозитория
 	cancelledCtx, cancel := context.WithCancel(context.Background())
 	cancel()
 
 	// Попытка создать событие с отменённым контекстом
-	event, err := service.Start(cancelledCtx, "test")
+	event, err := service.Start(cancelledCtx, StartParams{Type: "test"})
 	if err == nil {
 		t.Error("Expected error with cancelled context")
 	}
@@ -346,14 +422,14 @@ func TestEventService_Finish_WithGenericError(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 
 	// Используем отменённый контекст для создания ошибки
 	cancelledCtx, cancel := context.WithCancel(context.Background())
 	cancel()
 
 	// Попытка завершить с отменённым контекстом должна вернуть ошибку
-	event, err := service.Finish(cancelledCtx, "test")
+	event, err := service.Finish(cancelledCtx, FinishParams{Type: "test"})
 	if err == nil {
 		t.Error("Expected error with cancelled context")
 	}
@@ -367,7 +443,7 @@ func TestEventService_List_WithContextError(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 
 	// Используем отменённый контекст
 	cancelledCtx, cancel := context.WithCancel(context.Background())
@@ -387,13 +463,13 @@ func TestEventService_List_WithOffset(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 
 	// Создаём несколько событий разных типов (чтобы каждое было уникальным)
 	for i := 0; i < 5; i++ {
 		eventType := "type" + string(rune('0'+i))
-		_, err := service.Start(ctx, eventType)
+		_, err := service.Start(ctx, StartParams{Type: eventType})
 		if err != nil {
 			t.Fatalf("Start failed: %v", err)
 		}
@@ -415,13 +491,13 @@ func TestEventService_List_WithLimit(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 
 	// Создаём несколько событий разных типов
 	for i := 0; i < 5; i++ {
 		eventType := "type" + string(rune('0'+i))
-		_, err := service.Start(ctx, eventType)
+		_, err := service.Start(ctx, StartParams{Type: eventType})
 		if err != nil {
 			t.Fatalf("Start failed: %v", err)
 		}
@@ -443,18 +519,18 @@ func TestEventService_List_WithTypeFilter(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 
 	// Создаём события разных типов
 	types := []string{"meeting", "call", "meeting", "task"}
 	for _, eventType := range types {
 		// Сначала создаём событие, потом завершаем его, чтобы можно было создать новое того же типа
-		_, err := service.Start(ctx, eventType)
+		_, err := service.Start(ctx, StartParams{Type: eventType})
 		if err != nil {
 			t.Fatalf("Start failed: %v", err)
 		}
-		_, err = service.Finish(ctx, eventType)
+		_, err = service.Finish(ctx, FinishParams{Type: eventType})
 		if err != nil {
 			t.Fatalf("Finish failed: %v", err)
 		}
@@ -482,13 +558,13 @@ func TestEventService_List_WithOffsetAndLimit(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
 
-	service := NewEventService(repo)
+	service := NewEventService(repo, nil)
 	ctx := context.Background()
 
 	// Создаём события разных типов (чтобы создать 10 уникальных событий)
 	eventTypes := []string{"type0", "type1", "type2", "type3", "type4", "type5", "type6", "type7", "type8", "type9"}
 	for _, eventType := range eventTypes {
-		_, err := service.Start(ctx, eventType)
+		_, err := service.Start(ctx, StartParams{Type: eventType})
 		if err != nil {
 			t.Fatalf("Start failed: %v", err)
 		}
@@ -505,3 +581,266 @@ func TestEventService_List_WithOffsetAndLimit(t *testing.T) {
 		t.Errorf("Expected 5 events with offset 3 and limit 5, got %d", len(events))
 	}
 }
+
+func TestEventService_Start_WithParent(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	service := NewEventService(repo, nil)
+	ctx := context.Background()
+
+	meeting, err := service.Start(ctx, StartParams{Type: "meeting"})
+	if err != nil {
+		t.Fatalf("Start meeting failed: %v", err)
+	}
+
+	qa, err := service.Start(ctx, StartParams{Type: "qa", ParentID: &meeting.ID})
+	if err != nil {
+		t.Fatalf("Start qa failed: %v", err)
+	}
+	if qa.ParentID == nil || *qa.ParentID != meeting.ID {
+		t.Errorf("Expected qa parent %s, got %v", meeting.ID.Hex(), qa.ParentID)
+	}
+
+	missing := primitive.NewObjectID()
+	if _, err := service.Start(ctx, StartParams{Type: "demo", ParentID: &missing}); !errors.Is(err, ErrParentNotFound) {
+		t.Errorf("Expected ErrParentNotFound, got %v", err)
+	}
+
+	if _, err := service.Finish(ctx, FinishParams{Type: "meeting"}); err != nil {
+		t.Fatalf("Finish meeting failed: %v", err)
+	}
+	if _, err := service.Start(ctx, StartParams{Type: "demo", ParentID: &meeting.ID}); !errors.Is(err, ErrParentNotActive) {
+		t.Errorf("Expected ErrParentNotActive, got %v", err)
+	}
+}
+
+func TestEventService_Finish_Cascade(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	service := NewEventService(repo, nil)
+	ctx := context.Background()
+
+	meeting, _ := service.Start(ctx, StartParams{Type: "meeting"})
+	presentation, _ := service.Start(ctx, StartParams{Type: "presentation", ParentID: &meeting.ID})
+	demo, _ := service.Start(ctx, StartParams{Type: "demo", ParentID: &presentation.ID})
+	other, _ := service.Start(ctx, StartParams{Type: "other"})
+
+	finished, err := service.Finish(ctx, FinishParams{Type: "meeting", Cascade: true})
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	for _, id := range []primitive.ObjectID{presentation.ID, demo.ID} {
+		e, err := repo.FindByID(ctx, id)
+		if err != nil || e == nil {
+			t.Fatalf("FindByID failed: %v", err)
+		}
+		if e.State != Finished {
+			t.Errorf("Expected %s to be finished by cascade", e.Type)
+		}
+		if e.FinishedAt == nil || !e.FinishedAt.Equal(finished.FinishedAt.Truncate(time.Millisecond)) {
+			t.Errorf("Expected %s to share parent finish time, got %v", e.Type, e.FinishedAt)
+		}
+	}
+
+	// Событие вне дерева не затрагивается
+	e, _ := repo.FindByID(ctx, other.ID)
+	if e.State != Active {
+		t.Error("Expected unrelated event to stay active")
+	}
+}
+
+// cascadeRepository — дерево событий в памяти; FinishByIDs завершает не больше limit событий
+// (остальные будто завершил кто-то другой) и возвращает err, если он задан
+type cascadeRepository struct {
+	Repository
+	events []Event
+	limit  int
+	err    error
+}
+
+func (r *cascadeRepository) FindActive(ctx context.Context, eventType string) (*Event, error) {
+	for i := range r.events {
+		if r.events[i].Type == eventType && r.events[i].State == Active {
+			found := r.events[i]
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *cascadeRepository) Finish(ctx context.Context, params FinishParams) (*Event, error) {
+	for i := range r.events {
+		if r.events[i].ID == params.ID {
+			now := time.Now()
+			r.events[i].State, r.events[i].FinishedAt, r.events[i].Version = Finished, &now, r.events[i].Version+1
+			finished := r.events[i]
+			return &finished, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *cascadeRepository) FindChildren(ctx context.Context, parentIDs []primitive.ObjectID) ([]Event, error) {
+	var children []Event
+	for _, e := range r.events {
+		if e.ParentID != nil && slices.Contains(parentIDs, *e.ParentID) {
+			children = append(children, e)
+		}
+	}
+	return children, nil
+}
+
+func (r *cascadeRepository) FinishByIDs(ctx context.Context, ids []primitive.ObjectID, finishedAt time.Time, finishedBy string) ([]primitive.ObjectID, error) {
+	if len(ids) > r.limit {
+		ids = ids[:r.limit]
+	}
+	return ids, r.err
+}
+
+func TestEventService_Finish_CascadePartial(t *testing.T) {
+	ctx := context.Background()
+	meeting := Event{ID: primitive.NewObjectID(), Type: "meeting", State: Active, Version: 1}
+	tree := func() []Event {
+		return []Event{
+			meeting,
+			{ID: primitive.NewObjectID(), Type: "presentation", State: Active, ParentID: &meeting.ID, Version: 1},
+			{ID: primitive.NewObjectID(), Type: "qa", State: Active, ParentID: &meeting.ID, Version: 1},
+		}
+	}
+
+	for name, tt := range map[string]struct {
+		err     error
+		wantErr bool
+	}{
+		// qa завершил кто-то другой между чтением дерева и обновлением
+		"finished elsewhere": {},
+		"storage failure":    {err: errors.New("нет соединения"), wantErr: true},
+	} {
+		repo := &cascadeRepository{events: tree(), limit: 1, err: tt.err}
+		service := NewEventService(repo, nil)
+		observer := newCountingObserver()
+		auditor := &recordingAuditor{}
+		service.SetObserver(observer)
+		service.SetAuditor(auditor)
+
+		_, err := service.Finish(ctx, FinishParams{Type: "meeting", Cascade: true})
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		// Родитель и только действительно завершённый потомок — в журнале и у наблюдателя, даже при сбое каскада
+		if observer.finished["meeting"] != 1 || observer.finished["presentation"] != 1 || observer.finished["qa"] != 0 {
+			t.Errorf("%s: unexpected finish notifications %v", name, observer.finished)
+		}
+		if len(auditor.records) != 2 || auditor.records[0].EventType != "meeting" || auditor.records[1].EventType != "presentation" {
+			t.Errorf("%s: unexpected audit records %+v", name, auditor.records)
+		}
+	}
+}
+
+func TestEventService_Finish_WithoutCascade(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	service := NewEventService(repo, nil)
+	ctx := context.Background()
+
+	meeting, _ := service.Start(ctx, StartParams{Type: "meeting"})
+	qa, _ := service.Start(ctx, StartParams{Type: "qa", ParentID: &meeting.ID})
+
+	if _, err := service.Finish(ctx, FinishParams{Type: "meeting"}); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	e, _ := repo.FindByID(ctx, qa.ID)
+	if e.State != Active {
+		t.Error("Expected child to stay active without cascade")
+	}
+}
+
+func TestEventService_Tree(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	service := NewEventService(repo, nil)
+	ctx := context.Background()
+
+	meeting, _ := service.Start(ctx, StartParams{Type: "meeting"})
+	presentation, _ := service.Start(ctx, StartParams{Type: "presentation", ParentID: &meeting.ID})
+	service.Start(ctx, StartParams{Type: "demo", ParentID: &presentation.ID})
+	service.Start(ctx, StartParams{Type: "qa", ParentID: &meeting.ID})
+
+	tree, err := service.Tree(ctx, meeting.ID)
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	if len(tree.Children) != 2 || len(tree.Children[0].Children) != 1 {
+		t.Fatalf("Unexpected tree shape: %+v", tree)
+	}
+	if tree.Children[0].Children[0].Type != "demo" {
+		t.Errorf("Expected demo under presentation, got %s", tree.Children[0].Children[0].Type)
+	}
+
+	missing, err := service.Tree(ctx, primitive.NewObjectID())
+	if err != nil || missing != nil {
+		t.Errorf("Expected nil tree for missing event, got %v, %v", missing, err)
+	}
+}
+
+// countingObserver считает уведомления сервиса по типам событий
+type countingObserver struct {
+	started, deduplicated, finished map[string]int
+}
+
+func newCountingObserver() *countingObserver {
+	return &countingObserver{started: map[string]int{}, deduplicated: map[string]int{}, finished: map[string]int{}}
+}
+
+func (o *countingObserver) EventStarted(ctx context.Context, event *Event, deduplicated bool) {
+	if deduplicated {
+		o.deduplicated[event.Type]++
+		return
+	}
+	o.started[event.Type]++
+}
+
+func (o *countingObserver) EventFinished(ctx context.Context, event *Event) {
+	o.finished[event.Type]++
+}
+
+func TestEventService_Observer(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	observer := newCountingObserver()
+	service := NewEventService(repo, nil)
+	service.SetObserver(observer)
+	ctx := context.Background()
+
+	parent, err := service.Start(ctx, StartParams{Type: "meeting"})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	// Повторный запуск возвращает активное событие — это дедупликация, а не новый запуск
+	if _, err := service.Start(ctx, StartParams{Type: "meeting"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if _, err := service.Start(ctx, StartParams{Type: "meeting.notes", ParentID: &parent.ID}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	// Каскадное завершение сообщает и о потомках
+	if _, err := service.Finish(ctx, FinishParams{Type: "meeting", Cascade: true}); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	// Неудачное завершение не считается
+	service.Finish(ctx, FinishParams{Type: "meeting"})
+
+	if observer.started["meeting"] != 1 || observer.deduplicated["meeting"] != 1 || observer.started["meeting.notes"] != 1 {
+		t.Errorf("Unexpected start notifications: started=%v deduplicated=%v", observer.started, observer.deduplicated)
+	}
+	if observer.finished["meeting"] != 1 || observer.finished["meeting.notes"] != 1 {
+		t.Errorf("Unexpected finish notifications: %v", observer.finished)
+	}
+}
@@ -0,0 +1,226 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"event-service/internal/db"
+	"event-service/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setupTenantRepos создаёт репозитории событий и типов с указанным размещением арендаторов
+func setupTenantRepos(t *testing.T, placement tenant.Placement) (*EventRepository, *TypeRepository, *mongo.Client, func()) {
+	t.Helper()
+
+	mongoURI, cleanupMongo, err := db.StartEmbeddedMongo()
+	if err != nil {
+		t.Fatalf("Не удалось запустить встроенный MongoDB: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		cleanupMongo()
+		t.Fatalf("Не удалось подключиться к MongoDB: %v", err)
+	}
+
+	eventRepo := NewTenantEventRepository(tenant.NewCollections(client, "events_test_db", "events", placement))
+	typeRepo := NewTenantTypeRepository(tenant.NewCollections(client, "events_test_db", "event_types", placement))
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client.Disconnect(ctx)
+		cleanupMongo()
+	}
+	return eventRepo, typeRepo, client, cleanup
+}
+
+// TestTenantIsolation проверяет, что арендаторы не видят и не завершают чужие события
+// при любом способе размещения данных
+func TestTenantIsolation(t *testing.T) {
+	for _, placement := range []tenant.Placement{tenant.PlacementShared, tenant.PlacementCollection, tenant.PlacementDatabase} {
+		t.Run(string(placement), func(t *testing.T) {
+			eventRepo, typeRepo, _, cleanup := setupTenantRepos(t, placement)
+			defer cleanup()
+
+			service := NewEventService(eventRepo, NewTypeService(typeRepo, false, nil))
+			acme := tenant.WithTenant(context.Background(), "acme")
+			globex := tenant.WithTenant(context.Background(), "globex")
+
+			meeting, err := service.Start(acme, StartParams{Type: "meeting"})
+			if err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+
+			// Чужой арендатор не видит событие в списке, дереве и при поиске по id
+			events, err := service.List(globex, 0, 0, "")
+			if err != nil || len(events) != 0 {
+				t.Errorf("Expected no events for globex, got %d (%v)", len(events), err)
+			}
+			if tree, err := service.Tree(globex, meeting.ID); err != nil || tree != nil {
+				t.Errorf("Expected no tree for globex, got %v (%v)", tree, err)
+			}
+
+			// Чужой арендатор не может завершить событие и вложить в него своё
+			if _, err := service.Finish(globex, FinishParams{Type: "meeting"}); !errors.Is(err, ErrEventNotFound) {
+				t.Errorf("Expected ErrEventNotFound on cross-tenant finish, got %v", err)
+			}
+			if _, err := service.Start(globex, StartParams{Type: "qa", ParentID: &meeting.ID}); !errors.Is(err, ErrParentNotFound) {
+				t.Errorf("Expected ErrParentNotFound on cross-tenant parent, got %v", err)
+			}
+
+			// Активное событие у одного арендатора не мешает запустить такое же у другого
+			other, err := service.Start(globex, StartParams{Type: "meeting"})
+			if err != nil {
+				t.Fatalf("Start for globex failed: %v", err)
+			}
+			if other.ID == meeting.ID {
+				t.Error("Expected globex to get its own event, not acme's")
+			}
+
+			// Событие acme по-прежнему активно
+			active, err := eventRepo.FindActive(acme, "meeting")
+			if err != nil || active == nil || active.ID != meeting.ID {
+				t.Errorf("Expected acme event to stay active, got %v (%v)", active, err)
+			}
+		})
+	}
+}
+
+// TestTenantIsolation_Types проверяет, что у каждого арендатора свой реестр типов
+func TestTenantIsolation_Types(t *testing.T) {
+	_, typeRepo, _, cleanup := setupTenantRepos(t, tenant.PlacementShared)
+	defer cleanup()
+
+	types := NewTypeService(typeRepo, true, nil)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	if err := types.Create(acme, &EventType{Name: "meeting", Description: "acme"}); err != nil {
+		t.Fatalf("Create for acme failed: %v", err)
+	}
+	// Одинаковое имя у разных арендаторов не конфликтует
+	if err := types.Create(globex, &EventType{Name: "meeting", Description: "globex"}); err != nil {
+		t.Fatalf("Create for globex failed: %v", err)
+	}
+	if err := types.Create(globex, &EventType{Name: "call"}); err != nil {
+		t.Fatalf("Create for globex failed: %v", err)
+	}
+
+	if err := types.CheckKnown(acme, "call"); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Expected globex type to be unknown for acme, got %v", err)
+	}
+	got, err := types.Get(acme, "meeting")
+	if err != nil || got == nil || got.Description != "acme" {
+		t.Errorf("Expected acme's own meeting type, got %+v (%v)", got, err)
+	}
+	if err := types.Delete(acme, "call"); err != ErrEventTypeNotFound {
+		t.Errorf("Expected ErrEventTypeNotFound when deleting globex type as acme, got %v", err)
+	}
+}
+
+// TestTenantIsolation_LegacyDocuments проверяет, что события без tenant_id
+// принадлежат арендатору по умолчанию и не видны остальным
+func TestTenantIsolation_LegacyDocuments(t *testing.T) {
+	eventRepo, _, client, cleanup := setupTenantRepos(t, tenant.PlacementShared)
+	defer cleanup()
+
+	ctx := context.Background()
+	collection := client.Database("events_test_db").Collection("events")
+	collection.Drop(ctx)
+	if _, err := collection.InsertOne(ctx, bson.M{"type": "legacy", "state": Active, "started_at": time.Now()}); err != nil {
+		t.Fatalf("InsertOne failed: %v", err)
+	}
+
+	if active, _ := eventRepo.FindActive(ctx, "legacy"); active == nil {
+		t.Error("Expected legacy event to belong to the default tenant")
+	}
+	if active, _ := eventRepo.FindActive(tenant.WithTenant(ctx, "acme"), "legacy"); active != nil {
+		t.Error("Legacy event must not be visible to other tenants")
+	}
+}
+
+// TestTenantIsolation_HTTP проверяет изоляцию через заголовок X-Tenant-ID
+func TestTenantIsolation_HTTP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventRepo, _, _, cleanup := setupTenantRepos(t, tenant.PlacementCollection)
+	defer cleanup()
+
+	handler := NewEventHandler(NewEventService(eventRepo, nil), DefaultMaxLimit)
+	router := gin.New()
+	router.Use(tenant.Middleware("", true))
+	router.GET("/", handler.List)
+	router.POST("/start", handler.Start)
+	router.POST("/finish", handler.Finish)
+
+	do := func(method, path, tenantID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if tenantID != "" {
+			req.Header.Set(tenant.DefaultHeader, tenantID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/start", "acme", `{"type":"meeting"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/start", "", `{"type":"meeting"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without tenant, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/finish", "globex", `{"type":"meeting"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 on cross-tenant finish, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/", "globex", ""); strings.TrimSpace(w.Body.String()) != "null" && strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("Expected empty list for globex, got %s", w.Body.String())
+	}
+	if w := do(http.MethodPost, "/finish", "acme", `{"type":"meeting"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for own finish, got %d", w.Code)
+	}
+}
+
+// TestCountActive_AllTenants проверяет, что сводный подсчёт активных событий видит всех арендаторов
+func TestCountActive_AllTenants(t *testing.T) {
+	for _, placement := range []tenant.Placement{tenant.PlacementShared, tenant.PlacementCollection, tenant.PlacementDatabase} {
+		t.Run(string(placement), func(t *testing.T) {
+			eventRepo, _, _, cleanup := setupTenantRepos(t, placement)
+			defer cleanup()
+
+			service := NewEventService(eventRepo, nil)
+			for _, tenantID := range []string{tenant.Default, "acme", "globex"} {
+				ctx := tenant.WithTenant(context.Background(), tenantID)
+				for _, eventType := range []string{"meeting", "call"} {
+					if _, err := service.Start(ctx, StartParams{Type: eventType}); err != nil {
+						t.Fatalf("Start failed: %v", err)
+					}
+				}
+			}
+			if _, err := service.Finish(tenant.WithTenant(context.Background(), "acme"), FinishParams{Type: "call"}); err != nil {
+				t.Fatalf("Finish failed: %v", err)
+			}
+
+			counts, err := eventRepo.CountActive(context.Background())
+			if err != nil {
+				t.Fatalf("CountActive failed: %v", err)
+			}
+			if counts["meeting"] != 3 || counts["call"] != 2 || len(counts) != 2 {
+				t.Errorf("Unexpected counts: %v", counts)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName — имя трассировщика, под которым видны спаны этого пакета
+const tracerName = "event-service/pkg/event"
+
+// startSpan начинает спан операции name внутри спана из ctx
+// Трассировщик берётся из глобального TracerProvider при каждом вызове:
+// пока трассировка не настроена (tracing.Setup), спаны ничего не стоят и никуда не попадают
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan завершает спан и отмечает его ошибкой, если *err не nil
+// Вызывается через defer с указателем на возвращаемую ошибку
+// «Не найдено» (mongo.ErrNoDocuments, ErrEventNotFound) — обычный ответ, а не сбой, поэтому ошибкой не считается
+func endSpan(span trace.Span, err *error) {
+	if *err != nil && !errors.Is(*err, mongo.ErrNoDocuments) && !errors.Is(*err, ErrEventNotFound) {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// traceHandler начинает спан обработчика и подменяет контекст запроса, чтобы спаны
+// сервиса и репозитория стали его дочерними; возвращает функцию завершения для defer
+// Ошибкой считаются только ответы 5xx — ошибки клиента сбоем сервиса не являются
+func traceHandler(c *gin.Context, name string) func() {
+	ctx, span := startSpan(c.Request.Context(), name)
+	c.Request = c.Request.WithContext(ctx)
+	return func() {
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+		span.End()
+	}
+}
+
+// eventTypeAttr — атрибут спана с типом события
+func eventTypeAttr(eventType string) attribute.KeyValue {
+	return attribute.String("event.type", eventType)
+}
+
+// eventIDAttr — атрибут спана с идентификатором события
+func eventIDAttr(id string) attribute.KeyValue {
+	return attribute.String("event.id", id)
+}
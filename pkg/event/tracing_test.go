@@ -0,0 +1,160 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"event-service/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTracer подменяет глобальный TracerProvider на записывающий спаны в память
+func setupTracer(t *testing.T) (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter, provider
+}
+
+// spanByName находит записанный спан по имени
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("Span %q not found", name)
+	return tracetest.SpanStub{}
+}
+
+// assertChild проверяет, что спан child вложен в parent
+func assertChild(t *testing.T, parent, child tracetest.SpanStub) {
+	t.Helper()
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+		t.Errorf("Span %q should be a child of %q", child.Name, parent.Name)
+	}
+}
+
+// failingRepository отвечает ошибкой на поиск активного события
+type failingRepository struct {
+	Repository
+}
+
+func (r *failingRepository) FindActive(ctx context.Context, eventType string) (*Event, error) {
+	return nil, errors.New("нет соединения")
+}
+
+func TestTracing_HandlerAndServiceSpans(t *testing.T) {
+	exporter, _ := setupTracer(t)
+	gin.SetMode(gin.TestMode)
+
+	handler := NewEventHandler(NewEventService(&failingRepository{}, nil), DefaultMaxLimit)
+	router := gin.New()
+	router.POST("/start", handler.Start)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(`{"type":"meeting"}`)))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", w.Code)
+	}
+
+	spans := exporter.GetSpans()
+	handlerSpan := spanByName(t, spans, "EventHandler.Start")
+	serviceSpan := spanByName(t, spans, "EventService.Start")
+	assertChild(t, handlerSpan, serviceSpan)
+
+	if serviceSpan.Status.Code != codes.Error || len(serviceSpan.Events) == 0 {
+		t.Errorf("Service span should record the error: %+v", serviceSpan.Status)
+	}
+	if handlerSpan.Status.Code != codes.Error {
+		t.Errorf("Handler span should be marked as error for 500, got %+v", handlerSpan.Status)
+	}
+	for _, attr := range serviceSpan.Attributes {
+		if attr.Key == "event.type" && attr.Value.AsString() != "meeting" {
+			t.Errorf("Unexpected event.type attribute: %s", attr.Value.AsString())
+		}
+	}
+}
+
+func TestTracing_ClientErrorIsNotSpanError(t *testing.T) {
+	exporter, _ := setupTracer(t)
+	gin.SetMode(gin.TestMode)
+
+	handler := NewEventHandler(NewEventService(&failingRepository{}, nil), DefaultMaxLimit)
+	router := gin.New()
+	router.POST("/start", handler.Start)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(`{}`)))
+
+	if span := spanByName(t, exporter.GetSpans(), "EventHandler.Start"); span.Status.Code == codes.Error {
+		t.Error("Handler span should not be an error for 400")
+	}
+}
+
+// TestTracing_RepositoryAndMongoSpans проверяет всю цепочку: сервис → репозиторий → команды MongoDB
+func TestTracing_RepositoryAndMongoSpans(t *testing.T) {
+	exporter, provider := setupTracer(t)
+
+	mongoURI, cleanupMongo, err := db.StartEmbeddedMongo()
+	if err != nil {
+		t.Fatalf("Не удалось запустить встроенный MongoDB: %v", err)
+	}
+	defer cleanupMongo()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	monitor := otelmongo.NewMonitor(otelmongo.WithTracerProvider(provider))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI).SetMonitor(monitor))
+	if err != nil {
+		t.Fatalf("Не удалось подключиться к MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	collection := client.Database("events_test_db").Collection("events_tracing")
+	collection.Drop(ctx)
+	repo := NewEventRepository(collection)
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	exporter.Reset()
+
+	service := NewEventService(repo, nil)
+	if _, err := service.Start(ctx, StartParams{Type: "meeting"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	serviceSpan := spanByName(t, spans, "EventService.Start")
+	findActive := spanByName(t, spans, "EventRepository.FindActive")
+	create := spanByName(t, spans, "EventRepository.Create")
+	assertChild(t, serviceSpan, findActive)
+	assertChild(t, serviceSpan, create)
+
+	// Команды драйвера вложены в спаны операций репозитория
+	assertChild(t, findActive, spanByName(t, spans, "events_tracing.find"))
+	assertChild(t, create, spanByName(t, spans, "events_tracing.insert"))
+
+	// Ничего не найдено при завершении — не сбой
+	if _, err := service.Finish(ctx, FinishParams{Type: "call"}); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("Expected ErrEventNotFound, got %v", err)
+	}
+	if span := spanByName(t, exporter.GetSpans(), "EventRepository.Finish"); span.Status.Code == codes.Error {
+		t.Error("Not found should not mark the span as error")
+	}
+}
@@ -0,0 +1,80 @@
+package event
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventTree — событие вместе со всеми вложенными в него событиями
+// Используется в ответе GET /v1/events/{id}/tree
+type EventTree struct {
+	EventResponse
+
+	// DurationSeconds — длительность самого события в секундах
+	// У активного события считается до текущего момента
+	DurationSeconds float64 `json:"durationSeconds"`
+
+	// ChildrenDurationSeconds — суммарная длительность всех вложенных событий на любой глубине
+	ChildrenDurationSeconds float64 `json:"childrenDurationSeconds"`
+
+	// Children — непосредственно вложенные события, отсортированные по времени начала
+	Children []*EventTree `json:"children"`
+}
+
+// buildEventTree собирает дерево из корневого события и всех его потомков
+// Потомки могут идти в любом порядке: они раскладываются по родителям по полю ParentID
+// now нужен, чтобы посчитать длительность ещё не завершённых событий
+func buildEventTree(root Event, descendants []Event, now time.Time) *EventTree {
+	byParent := make(map[primitive.ObjectID][]Event)
+	for _, e := range descendants {
+		if e.ParentID != nil {
+			byParent[*e.ParentID] = append(byParent[*e.ParentID], e)
+		}
+	}
+	return buildEventNode(root, byParent, now)
+}
+
+// buildEventNode строит узел дерева и рекурсивно — его поддеревья
+func buildEventNode(e Event, byParent map[primitive.ObjectID][]Event, now time.Time) *EventTree {
+	node := &EventTree{
+		EventResponse:   e.ToResponse(),
+		DurationSeconds: e.Duration(now).Seconds(),
+		Children:        []*EventTree{},
+	}
+	for _, child := range byParent[e.ID] {
+		childNode := buildEventNode(child, byParent, now)
+		node.ChildrenDurationSeconds += childNode.DurationSeconds + childNode.ChildrenDurationSeconds
+		node.Children = append(node.Children, childNode)
+	}
+	return node
+}
+
+// Duration возвращает длительность события
+// Для активного события — время, прошедшее с начала до now
+func (e *Event) Duration(now time.Time) time.Duration {
+	end := now
+	if e.FinishedAt != nil {
+		end = *e.FinishedAt
+	}
+	if end.Before(e.StartedAt) {
+		return 0
+	}
+	return end.Sub(e.StartedAt)
+}
+
+// prune убирает из дерева вложенные события, тип которых не разрешён allowed,
+// вместе с их потомками, и пересчитывает суммарные длительности
+func (t *EventTree) prune(allowed func(eventType string) bool) {
+	kept := t.Children[:0]
+	t.ChildrenDurationSeconds = 0
+	for _, child := range t.Children {
+		if !allowed(child.Type) {
+			continue
+		}
+		child.prune(allowed)
+		t.ChildrenDurationSeconds += child.DurationSeconds + child.ChildrenDurationSeconds
+		kept = append(kept, child)
+	}
+	t.Children = kept
+}
@@ -0,0 +1,103 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBuildEventTree(t *testing.T) {
+	start := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	now := start.Add(2 * time.Hour)
+	at := func(minutes int) *time.Time {
+		ts := start.Add(time.Duration(minutes) * time.Minute)
+		return &ts
+	}
+
+	meetingID := primitive.NewObjectID()
+	presentationID := primitive.NewObjectID()
+	qaID := primitive.NewObjectID()
+	demoID := primitive.NewObjectID()
+
+	meeting := Event{ID: meetingID, Type: "meeting", State: Finished, StartedAt: start, FinishedAt: at(90)}
+	descendants := []Event{
+		// Порядок потомков не важен — дерево собирается по ParentID
+		{ID: demoID, Type: "demo", State: Finished, StartedAt: *at(10), FinishedAt: at(20), ParentID: &presentationID},
+		{ID: presentationID, Type: "presentation", State: Finished, StartedAt: start, FinishedAt: at(60), ParentID: &meetingID},
+		{ID: qaID, Type: "qa", State: Active, StartedAt: *at(60), ParentID: &meetingID},
+	}
+
+	tree := buildEventTree(meeting, descendants, now)
+
+	if tree.ID != meetingID.Hex() || tree.DurationSeconds != 90*60 {
+		t.Errorf("Unexpected root: id=%s duration=%v", tree.ID, tree.DurationSeconds)
+	}
+	if len(tree.Children) != 2 {
+		t.Fatalf("Expected 2 children, got %d", len(tree.Children))
+	}
+
+	presentation := tree.Children[0]
+	if presentation.Type != "presentation" || presentation.ParentID != meetingID.Hex() {
+		t.Errorf("Unexpected first child: %+v", presentation.EventResponse)
+	}
+	if len(presentation.Children) != 1 || presentation.ChildrenDurationSeconds != 10*60 {
+		t.Errorf("Expected demo inside presentation with 600s, got %d children and %vs",
+			len(presentation.Children), presentation.ChildrenDurationSeconds)
+	}
+
+	// Активное событие qa длится до now: с 11:00 до 12:00
+	qa := tree.Children[1]
+	if qa.DurationSeconds != 60*60 {
+		t.Errorf("Expected active qa duration 3600s, got %v", qa.DurationSeconds)
+	}
+	if qa.Children == nil || len(qa.Children) != 0 {
+		t.Errorf("Expected empty (not nil) children for leaf, got %v", qa.Children)
+	}
+
+	// presentation 60 мин + demo 10 мин + qa 60 мин
+	if tree.ChildrenDurationSeconds != 130*60 {
+		t.Errorf("Expected aggregated children duration 7800s, got %v", tree.ChildrenDurationSeconds)
+	}
+}
+
+func TestEventDuration(t *testing.T) {
+	start := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	finished := start.Add(time.Minute)
+	beforeStart := start.Add(-time.Minute)
+
+	if d := (&Event{StartedAt: start, FinishedAt: &finished}).Duration(start.Add(time.Hour)); d != time.Minute {
+		t.Errorf("Expected 1m for finished event, got %v", d)
+	}
+	if d := (&Event{StartedAt: start}).Duration(start.Add(time.Hour)); d != time.Hour {
+		t.Errorf("Expected 1h for active event, got %v", d)
+	}
+	if d := (&Event{StartedAt: start, FinishedAt: &beforeStart}).Duration(start); d != 0 {
+		t.Errorf("Expected 0 for inconsistent times, got %v", d)
+	}
+}
+
+func TestEventTree_Prune(t *testing.T) {
+	start := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	finished := start.Add(10 * time.Minute)
+
+	rootID := primitive.NewObjectID()
+	secretID := primitive.NewObjectID()
+	root := Event{ID: rootID, Type: "meeting", State: Finished, StartedAt: start, FinishedAt: &finished}
+	descendants := []Event{
+		{ID: primitive.NewObjectID(), Type: "qa", State: Finished, StartedAt: start, FinishedAt: &finished, ParentID: &rootID},
+		{ID: secretID, Type: "secret", State: Finished, StartedAt: start, FinishedAt: &finished, ParentID: &rootID},
+		{ID: primitive.NewObjectID(), Type: "qa", State: Finished, StartedAt: start, FinishedAt: &finished, ParentID: &secretID},
+	}
+
+	tree := buildEventTree(root, descendants, finished)
+	tree.prune(func(eventType string) bool { return eventType != "secret" })
+
+	// Вместе с запрещённым узлом уходит и всё его поддерево
+	if len(tree.Children) != 1 || tree.Children[0].Type != "qa" {
+		t.Fatalf("Expected only qa child after prune, got %+v", tree.Children)
+	}
+	if tree.ChildrenDurationSeconds != 600 {
+		t.Errorf("Expected durations to be recalculated, got %v", tree.ChildrenDurationSeconds)
+	}
+}
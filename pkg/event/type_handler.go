@@ -0,0 +1,92 @@
+package event
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TypeHandler обрабатывает HTTP-запросы к реестру типов событий (/v1/types)
+type TypeHandler struct {
+	// service — бизнес-логика реестра типов
+	service *TypeService
+}
+
+// NewTypeHandler создаёт обработчик запросов к реестру типов
+func NewTypeHandler(service *TypeService) *TypeHandler {
+	return &TypeHandler{service: service}
+}
+
+// List возвращает все зарегистрированные типы событий
+func (h *TypeHandler) List(c *gin.Context) {
+	types, err := h.service.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err, failedTypeList)
+		return
+	}
+	c.JSON(http.StatusOK, types)
+}
+
+// Get возвращает описание одного типа по имени из пути запроса
+func (h *TypeHandler) Get(c *gin.Context) {
+	eventType, err := h.service.Get(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		respondError(c, err, failedTypeGet)
+		return
+	}
+	if eventType == nil {
+		respondError(c, ErrEventTypeNotFound, failedTypeGet)
+		return
+	}
+	c.JSON(http.StatusOK, eventType)
+}
+
+// Create регистрирует новый тип события
+// Возвращает 201 с сохранённым описанием или 409, если тип уже зарегистрирован
+func (h *TypeHandler) Create(c *gin.Context) {
+	var req TypeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err, failedTypeCreate)
+		return
+	}
+
+	eventType := req.toEventType()
+	if err := h.service.Create(c.Request.Context(), eventType); err != nil {
+		respondError(c, err, failedTypeCreate)
+		return
+	}
+	c.JSON(http.StatusCreated, eventType)
+}
+
+// Update заменяет настройки зарегистрированного типа
+// Имя типа берётся из пути запроса, поле name в теле игнорируется
+func (h *TypeHandler) Update(c *gin.Context) {
+	var req TypeRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondError(c, err, failedTypeUpdate)
+		return
+	}
+	req.Name = c.Param("name")
+
+	updated, err := h.service.Update(c.Request.Context(), req.toEventType())
+	if err != nil {
+		respondError(c, err, failedTypeUpdate)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// Delete удаляет тип из реестра, события этого типа при этом сохраняются
+func (h *TypeHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("name")); err != nil {
+		respondError(c, err, failedTypeDelete)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// NamingRule возвращает действующее правило именования типов событий
+// Клиенты могут проверить имя заранее, не отправляя событие
+func (h *TypeHandler) NamingRule(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.NamingRule())
+}
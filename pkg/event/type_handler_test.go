@@ -0,0 +1,252 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"event-service/pkg/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTypeRouter создаёт роутер с эндпоинтами реестра типов и запуска событий
+func setupTypeRouter(t *testing.T, strict bool) (*gin.Engine, func()) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	typeRepo, eventRepo, cleanup := setupTestTypeRepo(t)
+	types := NewTypeService(typeRepo, strict, nil)
+	typeHandler := NewTypeHandler(types)
+	eventHandler := NewEventHandler(NewEventService(eventRepo, types), DefaultMaxLimit)
+
+	router := gin.New()
+	router.GET("/types", typeHandler.List)
+	router.POST("/types", typeHandler.Create)
+	router.GET("/types/:name", typeHandler.Get)
+	router.PUT("/types/:name", typeHandler.Update)
+	router.DELETE("/types/:name", typeHandler.Delete)
+	router.POST("/start", eventHandler.Start)
+	router.POST("/finish", eventHandler.Finish)
+
+	return router, cleanup
+}
+
+func doJSON(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Buffer
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(data)
+	} else {
+		reader = bytes.NewBuffer(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTypeHandler_CRUD(t *testing.T) {
+	router, cleanup := setupTypeRouter(t, false)
+	defer cleanup()
+
+	w := doJSON(router, http.MethodPost, "/types", TypeRequest{Name: "meeting", Description: "Встреча", RetentionDays: 30})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(router, http.MethodPost, "/types", TypeRequest{Name: "meeting"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for duplicate type, got %d", w.Code)
+	}
+
+	w = doJSON(router, http.MethodGet, "/types/meeting", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var eventType EventType
+	if err := json.Unmarshal(w.Body.Bytes(), &eventType); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if eventType.Name != "meeting" || eventType.RetentionDays != 30 {
+		t.Errorf("Unexpected type: %+v", eventType)
+	}
+
+	w = doJSON(router, http.MethodPut, "/types/meeting", TypeRequest{Description: "Совещание", RetentionDays: 60})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(router, http.MethodGet, "/types", nil)
+	var types []EventType
+	if err := json.Unmarshal(w.Body.Bytes(), &types); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(types) != 1 || types[0].RetentionDays != 60 {
+		t.Errorf("Unexpected types: %+v", types)
+	}
+
+	w = doJSON(router, http.MethodDelete, "/types/meeting", nil)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+
+	w = doJSON(router, http.MethodGet, "/types/meeting", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestTypeHandler_Create_Invalid(t *testing.T) {
+	router, cleanup := setupTypeRouter(t, false)
+	defer cleanup()
+
+	w := doJSON(router, http.MethodPost, "/types", TypeRequest{Name: "Bad Name"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	w = doJSON(router, http.MethodPut, "/types/missing", TypeRequest{})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandler_Start_StrictMode(t *testing.T) {
+	router, cleanup := setupTypeRouter(t, true)
+	defer cleanup()
+
+	// Опечатка в типе не должна создавать новый тип
+	w := doJSON(router, http.MethodPost, "/start", StartRequest{Type: "meating"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown type, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	doJSON(router, http.MethodPost, "/types", TypeRequest{Name: "meeting"})
+	w = doJSON(router, http.MethodPost, "/start", StartRequest{Type: "meeting"})
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for registered type, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_Start_AttributesValidatedBySchema(t *testing.T) {
+	router, cleanup := setupTypeRouter(t, false)
+	defer cleanup()
+
+	w := doJSON(router, http.MethodPost, "/types", map[string]interface{}{
+		"name":   "payment",
+		"schema": json.RawMessage(paymentSchema),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(router, http.MethodPost, "/start", StartRequest{
+		Type:       "payment",
+		Attributes: map[string]interface{}{"amount": 10, "currency": "GBP"},
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var errorResp problem.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if errorResp.Code != "invalid_attributes" || len(errorResp.Errors) != 1 || errorResp.Errors[0].Path != "/attributes/currency" {
+		t.Errorf("Expected field error on /attributes/currency, got %+v", errorResp.Errors)
+	}
+
+	w = doJSON(router, http.MethodPost, "/start", StartRequest{
+		Type:       "payment",
+		Attributes: map[string]interface{}{"amount": 10, "currency": "RUB"},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var resp EventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if resp.Attributes["currency"] != "RUB" {
+		t.Errorf("Expected attributes in response, got %+v", resp.Attributes)
+	}
+
+	// При завершении схеме должен соответствовать итоговый набор атрибутов
+	w = doJSON(router, http.MethodPost, "/finish", StartRequest{
+		Type:       "payment",
+		Attributes: map[string]interface{}{"currency": "XXX"},
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid finish attributes, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(router, http.MethodPost, "/finish", StartRequest{
+		Type:       "payment",
+		Attributes: map[string]interface{}{"status": "paid"},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if resp.Attributes["status"] != "paid" || resp.Attributes["currency"] != "RUB" {
+		t.Errorf("Finish attributes should be merged, got %+v", resp.Attributes)
+	}
+}
+
+func TestTypeHandler_NamingRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rule, err := NewNamingRule(DefaultTypePattern, 64, ".")
+	if err != nil {
+		t.Fatalf("NewNamingRule failed: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/naming-rule", NewTypeHandler(NewTypeService(nil, false, rule)).NamingRule)
+
+	w := doJSON(router, http.MethodGet, "/naming-rule", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var got NamingRule
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if got.Pattern != DefaultTypePattern || got.MaxLength != 64 || got.Separators != "." {
+		t.Errorf("Unexpected naming rule: %+v", got)
+	}
+}
+
+func TestTypeHandler_NamespacedTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rule, err := NewNamingRule(DefaultTypePattern, 0, ".")
+	if err != nil {
+		t.Fatalf("NewNamingRule failed: %v", err)
+	}
+
+	typeRepo, eventRepo, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+	types := NewTypeService(typeRepo, false, rule)
+
+	router := gin.New()
+	router.POST("/types", NewTypeHandler(types).Create)
+	router.POST("/start", NewEventHandler(NewEventService(eventRepo, types), DefaultMaxLimit).Start)
+
+	if w := doJSON(router, http.MethodPost, "/types", TypeRequest{Name: "billing.payment"}); w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 for namespaced type, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/types", TypeRequest{Name: "billing..payment"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for empty segment, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/start", StartRequest{Type: "billing.payment"}); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 on start, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/start", StartRequest{Type: "billing-payment"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for wrong separator, got %d", w.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package event
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttributeKind — допустимый тип значения атрибута события
+type AttributeKind string
+
+const (
+	AttributeString  AttributeKind = "string"
+	AttributeNumber  AttributeKind = "number"
+	AttributeBoolean AttributeKind = "boolean"
+	AttributeObject  AttributeKind = "object"
+	AttributeArray   AttributeKind = "array"
+)
+
+// valid проверяет, что тип атрибута нам известен
+func (k AttributeKind) valid() bool {
+	switch k {
+	case AttributeString, AttributeNumber, AttributeBoolean, AttributeObject, AttributeArray:
+		return true
+	default:
+		return false
+	}
+}
+
+// EventType — зарегистрированный тип события и его настройки
+// Реестр типов защищает от опечаток: в строгом режиме событие можно запустить
+// только для типа, который заранее описан в реестре
+type EventType struct {
+	// ID — уникальный идентификатор записи в базе данных
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+
+	// TenantID — арендатор, в реестре которого описан тип
+	TenantID string `bson:"tenant_id" json:"-"`
+
+	// Name — имя типа, то самое значение поля "type" у событий
+	Name string `bson:"name" json:"name"`
+
+	// Description — человекочитаемое описание типа
+	Description string `bson:"description" json:"description"`
+
+	// Attributes — допустимые атрибуты событий этого типа и типы их значений
+	// Пустой список означает, что атрибуты не ограничены
+	Attributes map[string]AttributeKind `bson:"attributes,omitempty" json:"attributes,omitempty"`
+
+	// Schema — необязательная JSON Schema для атрибутов событий этого типа
+	// Хранится как исходные JSON-байты, потому что ключи вида "$ref" нельзя сохранить в документе MongoDB
+	Schema json.RawMessage `bson:"schema,omitempty" json:"schema,omitempty"`
+
+	// RetentionDays — сколько дней хранить завершённые события (0 = общий срок из настроек retention)
+	// Завершённые события старше срока переносятся в архив или удаляются (см. ArchiveService)
+	RetentionDays int `bson:"retention_days" json:"retentionDays"`
+
+	// MaxDurationSeconds — максимальная длительность события в секундах (0 = без ограничения)
+	MaxDurationSeconds int64 `bson:"max_duration_seconds" json:"maxDurationSeconds"`
+
+	// CreatedAt и UpdatedAt — когда тип был создан и последний раз изменён
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// Retention возвращает срок хранения завершённых событий типа (0 = общий срок из настроек)
+func (t *EventType) Retention() time.Duration {
+	return time.Duration(t.RetentionDays) * 24 * time.Hour
+}
+
+// MaxDuration возвращает максимальную длительность события (0 = без ограничения)
+func (t *EventType) MaxDuration() time.Duration {
+	return time.Duration(t.MaxDurationSeconds) * time.Second
+}
+
+// TypeRequest — тело запроса на создание или изменение типа события
+type TypeRequest struct {
+	// Name обязателен при создании, при изменении берётся из пути запроса
+	Name               string                   `json:"name"`
+	Description        string                   `json:"description"`
+	Attributes         map[string]AttributeKind `json:"attributes"`
+	Schema             json.RawMessage          `json:"schema"`
+	RetentionDays      int                      `json:"retentionDays"`
+	MaxDurationSeconds int64                    `json:"maxDurationSeconds"`
+}
+
+// toEventType превращает запрос в модель типа события
+func (r *TypeRequest) toEventType() *EventType {
+	return &EventType{
+		Name:               r.Name,
+		Description:        r.Description,
+		Attributes:         r.Attributes,
+		Schema:             r.Schema,
+		RetentionDays:      r.RetentionDays,
+		MaxDurationSeconds: r.MaxDurationSeconds,
+	}
+}
@@ -0,0 +1,179 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TypeRepository хранит реестр типов событий в MongoDB
+// У каждого арендатора свой реестр: одно и то же имя типа может быть описано по-разному
+type TypeRepository struct {
+	// collections выбирает коллекцию MongoDB для арендатора из контекста
+	collections *tenant.Collections
+
+	// indexed — коллекции арендаторов, для которых индексы уже созданы
+	indexed sync.Map
+}
+
+// NewTypeRepository создаёт репозиторий для реестра типов событий
+// Все арендаторы хранятся в коллекции col и различаются полем tenant_id
+func NewTypeRepository(col *mongo.Collection) *TypeRepository {
+	return NewTenantTypeRepository(tenant.Shared(col))
+}
+
+// NewTenantTypeRepository создаёт репозиторий реестра типов с размещением арендаторов по collections
+func NewTenantTypeRepository(collections *tenant.Collections) *TypeRepository {
+	return &TypeRepository{collections: collections}
+}
+
+// legacyNameIndex — уникальный индекс только по имени из версий без мультиарендности
+// Он не даёт разным арендаторам завести типы с одинаковыми именами, поэтому удаляется
+const legacyNameIndex = "name_1"
+
+// EnsureIndexes создаёт уникальный индекс по арендатору и имени типа
+// в коллекции арендатора из контекста
+// Вызывается при старте приложения; коллекции остальных арендаторов
+// получают индекс автоматически при первом обращении
+func (r *TypeRepository) EnsureIndexes(ctx context.Context) error {
+	_, _, err := r.collection(ctx)
+	return err
+}
+
+// collection возвращает коллекцию арендатора из контекста и его идентификатор
+// При первом обращении к коллекции создаёт в ней индексы
+func (r *TypeRepository) collection(ctx context.Context) (*mongo.Collection, string, error) {
+	col, tenantID, err := r.collections.For(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := col.Database().Name() + "." + col.Name()
+	if _, ok := r.indexed.Load(key); !ok {
+		// Ошибку игнорируем: старого индекса может и не быть
+		col.Indexes().DropOne(ctx, legacyNameIndex)
+		_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		r.indexed.Store(key, true)
+	}
+	return col, tenantID, nil
+}
+
+// Get ищет тип события по имени
+// Если такого типа нет, вернёт nil без ошибки
+func (r *TypeRepository) Get(ctx context.Context, name string) (*EventType, error) {
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventType EventType
+	err = col.FindOne(ctx, bson.M{"tenant_id": tenantFilter(tenantID), "name": name}).Decode(&eventType)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// У типов, сохранённых до мультиарендности, tenant_id не заполнен
+	eventType.TenantID = tenantID
+	return &eventType, nil
+}
+
+// List возвращает все зарегистрированные типы, отсортированные по имени
+func (r *TypeRepository) List(ctx context.Context) ([]EventType, error) {
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := col.Find(ctx, bson.M{"tenant_id": tenantFilter(tenantID)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	types := []EventType{}
+	if err := cursor.All(ctx, &types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// Create сохраняет новый тип события
+// Если тип с таким именем уже есть, вернёт ErrEventTypeExists
+func (r *TypeRepository) Create(ctx context.Context, eventType *EventType) error {
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	eventType.TenantID = tenantID
+	eventType.CreatedAt = now
+	eventType.UpdatedAt = now
+
+	_, err = col.InsertOne(ctx, eventType)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrEventTypeExists
+	}
+	return err
+}
+
+// Update заменяет настройки существующего типа и возвращает обновлённую запись
+// Если типа нет, вернёт mongo.ErrNoDocuments
+func (r *TypeRepository) Update(ctx context.Context, eventType *EventType) (*EventType, error) {
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{
+		"description":          eventType.Description,
+		"attributes":           eventType.Attributes,
+		"schema":               eventType.Schema,
+		"retention_days":       eventType.RetentionDays,
+		"max_duration_seconds": eventType.MaxDurationSeconds,
+		"updated_at":           time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated EventType
+	filter := bson.M{"tenant_id": tenantFilter(tenantID), "name": eventType.Name}
+	err = col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete удаляет тип события из реестра
+// Сами события этого типа остаются в базе
+// Если типа нет, вернёт mongo.ErrNoDocuments
+func (r *TypeRepository) Delete(ctx context.Context, name string) error {
+	col, tenantID, err := r.collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := col.DeleteOne(ctx, bson.M{"tenant_id": tenantFilter(tenantID), "name": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"event-service/internal/db"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setupTestTypeRepo поднимает встроенный MongoDB и возвращает репозитории событий и типов
+// Оба репозитория работают с одной тестовой базой
+func setupTestTypeRepo(t *testing.T) (*TypeRepository, *EventRepository, func()) {
+	t.Helper()
+
+	mongoURI, cleanupMongo, err := db.StartEmbeddedMongo()
+	if err != nil {
+		t.Fatalf("Не удалось запустить встроенный MongoDB: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		cleanupMongo()
+		t.Fatalf("Не удалось подключиться к MongoDB: %v", err)
+	}
+
+	database := client.Database("events_test_db")
+	database.Drop(ctx)
+
+	typeRepo := NewTypeRepository(database.Collection("event_types"))
+	if err := typeRepo.EnsureIndexes(ctx); err != nil {
+		client.Disconnect(ctx)
+		cleanupMongo()
+		t.Fatalf("Не удалось создать индексы: %v", err)
+	}
+	eventRepo := NewEventRepository(database.Collection("events"))
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client.Disconnect(ctx)
+		cleanupMongo()
+	}
+
+	return typeRepo, eventRepo, cleanup
+}
+
+func TestTypeRepository_CreateAndGet(t *testing.T) {
+	repo, _, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	eventType := &EventType{
+		Name:          "meeting",
+		Description:   "Встреча",
+		Attributes:    map[string]AttributeKind{"room": AttributeString},
+		RetentionDays: 90,
+	}
+
+	if err := repo.Create(ctx, eventType); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if eventType.CreatedAt.IsZero() {
+		t.Error("CreatedAt should be set")
+	}
+
+	found, err := repo.Get(ctx, "meeting")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if found == nil {
+		t.Fatal("Get should return registered type")
+	}
+	if found.Description != "Встреча" || found.RetentionDays != 90 {
+		t.Errorf("Unexpected type: %+v", found)
+	}
+	if found.Attributes["room"] != AttributeString {
+		t.Errorf("Expected attribute room of kind string, got %+v", found.Attributes)
+	}
+}
+
+func TestTypeRepository_Get_NotFound(t *testing.T) {
+	repo, _, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	found, err := repo.Get(context.Background(), "missing")
+	if err != nil {
+		t.Errorf("Get should return nil error when not found, got: %v", err)
+	}
+	if found != nil {
+		t.Error("Get should return nil when type is not registered")
+	}
+}
+
+func TestTypeRepository_Create_Duplicate(t *testing.T) {
+	repo, _, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := repo.Create(ctx, &EventType{Name: "meeting"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(ctx, &EventType{Name: "meeting"}); err != ErrEventTypeExists {
+		t.Errorf("Expected ErrEventTypeExists, got %v", err)
+	}
+}
+
+func TestTypeRepository_List(t *testing.T) {
+	repo, _, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, name := range []string{"call", "meeting", "break"} {
+		if err := repo.Create(ctx, &EventType{Name: name}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	types, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(types) != 3 {
+		t.Fatalf("Expected 3 types, got %d", len(types))
+	}
+	// Типы отсортированы по имени
+	if types[0].Name != "break" || types[1].Name != "call" || types[2].Name != "meeting" {
+		t.Errorf("Types are not sorted by name: %v, %v, %v", types[0].Name, types[1].Name, types[2].Name)
+	}
+}
+
+func TestTypeRepository_Update(t *testing.T) {
+	repo, _, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := repo.Create(ctx, &EventType{Name: "meeting", Description: "old"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	updated, err := repo.Update(ctx, &EventType{Name: "meeting", Description: "new", MaxDurationSeconds: 3600})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updated.Description != "new" || updated.MaxDurationSeconds != 3600 {
+		t.Errorf("Unexpected updated type: %+v", updated)
+	}
+
+	if _, err := repo.Update(ctx, &EventType{Name: "missing"}); err != mongo.ErrNoDocuments {
+		t.Errorf("Expected mongo.ErrNoDocuments, got %v", err)
+	}
+}
+
+func TestTypeRepository_Delete(t *testing.T) {
+	repo, _, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := repo.Create(ctx, &EventType{Name: "meeting"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Delete(ctx, "meeting"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(ctx, "meeting"); err != mongo.ErrNoDocuments {
+		t.Errorf("Expected mongo.ErrNoDocuments, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package event
+
+import (
+	"context"
+	"fmt"
+
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TypeService содержит бизнес-логику реестра типов событий
+// Он проверяет описания типов и решает, можно ли запускать события неизвестных типов
+type TypeService struct {
+	// repo — репозиторий с описаниями типов
+	repo *TypeRepository
+
+	// strict — строгий режим: события можно запускать только для зарегистрированных типов
+	// Если выключен, реестр только хранит настройки, а ad-hoc типы разрешены
+	strict bool
+
+	// schemas — кеш скомпилированных JSON Schema атрибутов
+	schemas *schemaCache
+
+	// naming — правило именования типов, общее для API, импорта и реестра
+	naming *NamingRule
+}
+
+// NewTypeService создаёт сервис реестра типов
+// strict включает строгий режим, в котором незарегистрированные типы отклоняются
+// naming задаёт правило именования типов (nil — правило по умолчанию ^[a-z0-9]+$)
+func NewTypeService(repo *TypeRepository, strict bool, naming *NamingRule) *TypeService {
+	if naming == nil {
+		naming = DefaultNamingRule()
+	}
+	return &TypeService{repo: repo, strict: strict, schemas: newSchemaCache(), naming: naming}
+}
+
+// NamingRule возвращает действующее правило именования типов
+func (s *TypeService) NamingRule() *NamingRule {
+	return s.naming
+}
+
+// Strict сообщает, включён ли строгий режим
+func (s *TypeService) Strict() bool {
+	return s.strict
+}
+
+// CheckKnown проверяет, можно ли запускать события указанного типа
+// В строгом режиме незарегистрированный тип приводит к ErrUnknownEventType
+func (s *TypeService) CheckKnown(ctx context.Context, name string) error {
+	if !s.strict {
+		return nil
+	}
+	eventType, err := s.repo.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if eventType == nil {
+		return ErrUnknownEventType
+	}
+	return nil
+}
+
+// ValidateAttributes проверяет атрибуты события по описанию его типа в реестре
+// Если тип не зарегистрирован, проверяются только имена атрибутов
+// Ошибки в данных возвращаются как *AttributeValidationError с путями до полей
+func (s *TypeService) ValidateAttributes(ctx context.Context, name string, attributes map[string]interface{}) error {
+	eventType, err := s.repo.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	return validateAttributes(eventType, s.schemas, attributes)
+}
+
+// Get возвращает описание типа или nil, если тип не зарегистрирован
+func (s *TypeService) Get(ctx context.Context, name string) (*EventType, error) {
+	return s.repo.Get(ctx, name)
+}
+
+// List возвращает все зарегистрированные типы
+func (s *TypeService) List(ctx context.Context) ([]EventType, error) {
+	return s.repo.List(ctx)
+}
+
+// Create регистрирует новый тип события
+func (s *TypeService) Create(ctx context.Context, eventType *EventType) error {
+	if err := s.validateTypeDefinition(eventType); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, eventType)
+}
+
+// Update заменяет настройки зарегистрированного типа
+// Если типа нет, вернёт ErrEventTypeNotFound
+func (s *TypeService) Update(ctx context.Context, eventType *EventType) (*EventType, error) {
+	if err := s.validateTypeDefinition(eventType); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.Update(ctx, eventType)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrEventTypeNotFound
+	}
+	return updated, err
+}
+
+// Delete удаляет тип из реестра
+// Если типа нет, вернёт ErrEventTypeNotFound
+func (s *TypeService) Delete(ctx context.Context, name string) error {
+	err := s.repo.Delete(ctx, name)
+	if err == mongo.ErrNoDocuments {
+		return ErrEventTypeNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.schemas.forget(schemaKey(tenant.ID(ctx), name))
+	return nil
+}
+
+// validateTypeDefinition проверяет описание типа и пробно компилирует его схему,
+// чтобы некорректная схема не попала в реестр
+func (s *TypeService) validateTypeDefinition(eventType *EventType) error {
+	if err := validateTypeDefinition(eventType, s.naming); err != nil {
+		return err
+	}
+	if len(eventType.Schema) == 0 || string(eventType.Schema) == "null" {
+		eventType.Schema = nil
+		return nil
+	}
+	if _, err := compileSchema(eventType.Schema); err != nil {
+		return fmt.Errorf("%w: некорректная JSON Schema: %v", ErrInvalidEventType, err)
+	}
+	return nil
+}
+
+// validateTypeDefinition проверяет описание типа перед сохранением
+// Все ошибки оборачивают ErrInvalidEventType
+func validateTypeDefinition(eventType *EventType, naming *NamingRule) error {
+	if err := naming.Validate(eventType.Name); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEventType, err)
+	}
+	if eventType.RetentionDays < 0 {
+		return fmt.Errorf("%w: retentionDays не может быть отрицательным", ErrInvalidEventType)
+	}
+	if eventType.MaxDurationSeconds < 0 {
+		return fmt.Errorf("%w: maxDurationSeconds не может быть отрицательным", ErrInvalidEventType)
+	}
+	for name, kind := range eventType.Attributes {
+		if name == "" {
+			return fmt.Errorf("%w: имя атрибута не может быть пустым", ErrInvalidEventType)
+		}
+		if !kind.valid() {
+			return fmt.Errorf("%w: неизвестный тип атрибута '%s' у '%s'", ErrInvalidEventType, kind, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateTypeDefinition(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType EventType
+		wantErr   bool
+	}{
+		{"valid", EventType{Name: "meeting", Attributes: map[string]AttributeKind{"room": AttributeString}}, false},
+		{"bad name", EventType{Name: "Meeting"}, true},
+		{"empty name", EventType{Name: ""}, true},
+		{"negative retention", EventType{Name: "meeting", RetentionDays: -1}, true},
+		{"negative max duration", EventType{Name: "meeting", MaxDurationSeconds: -1}, true},
+		{"unknown attribute kind", EventType{Name: "meeting", Attributes: map[string]AttributeKind{"room": "date"}}, true},
+		{"empty attribute name", EventType{Name: "meeting", Attributes: map[string]AttributeKind{"": AttributeString}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTypeDefinition(&tt.eventType, DefaultNamingRule())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateTypeDefinition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidEventType) {
+				t.Errorf("Error should wrap ErrInvalidEventType, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTypeService_CheckKnown_NotStrict(t *testing.T) {
+	// Без строгого режима реестр в базу даже не заглядывает
+	service := NewTypeService(nil, false, nil)
+	if err := service.CheckKnown(context.Background(), "anything"); err != nil {
+		t.Errorf("CheckKnown should allow ad-hoc types when not strict, got %v", err)
+	}
+	if service.Strict() {
+		t.Error("Strict should be false")
+	}
+}
+
+func TestTypeService_CheckKnown_Strict(t *testing.T) {
+	typeRepo, _, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	service := NewTypeService(typeRepo, true, nil)
+
+	if err := service.Create(ctx, &EventType{Name: "meeting"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := service.CheckKnown(ctx, "meeting"); err != nil {
+		t.Errorf("Registered type should be allowed, got %v", err)
+	}
+	if err := service.CheckKnown(ctx, "meating"); err != ErrUnknownEventType {
+		t.Errorf("Expected ErrUnknownEventType, got %v", err)
+	}
+}
+
+func TestEventService_Start_StrictRejectsUnknownType(t *testing.T) {
+	typeRepo, eventRepo, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	types := NewTypeService(typeRepo, true, nil)
+	service := NewEventService(eventRepo, types)
+
+	if err := types.Create(ctx, &EventType{Name: "meeting"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := service.Start(ctx, StartParams{Type: "meeting"}); err != nil {
+		t.Errorf("Start of registered type failed: %v", err)
+	}
+
+	event, err := service.Start(ctx, StartParams{Type: "meating"})
+	if err != ErrUnknownEventType {
+		t.Errorf("Expected ErrUnknownEventType, got %v", err)
+	}
+	if event != nil {
+		t.Error("Expected nil event for unknown type")
+	}
+}
+
+func TestEventService_Import_StrictRejectsUnknownType(t *testing.T) {
+	typeRepo, eventRepo, cleanup := setupTestTypeRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	types := NewTypeService(typeRepo, true, nil)
+	service := NewEventService(eventRepo, types)
+
+	if err := types.Create(ctx, &EventType{Name: "meeting"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	input := `{"type":"meeting","state":"finished","startedAt":"2024-01-01T10:00:00Z","finishedAt":"2024-01-01T11:00:00Z"}
+{"type":"meating","state":"finished","startedAt":"2024-01-01T10:00:00Z","finishedAt":"2024-01-01T11:00:00Z"}
+`
+	report, err := service.Import(ctx, strings.NewReader(input), ImportFormatNDJSON, false)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if report.Imported != 1 || report.RejectedCount != 1 || report.Rejected[0].Line != 2 {
+		t.Errorf("Unknown type should be rejected: %+v", report)
+	}
+}
+
+func TestTypeService_Create_InvalidSchema(t *testing.T) {
+	// До базы дело не доходит — некорректная схема отклоняется при проверке
+	service := NewTypeService(nil, false, nil)
+	err := service.Create(context.Background(), &EventType{Name: "payment", Schema: []byte(`{"type": 5}`)})
+	if !errors.Is(err, ErrInvalidEventType) {
+		t.Errorf("Expected ErrInvalidEventType for invalid schema, got %v", err)
+	}
+}
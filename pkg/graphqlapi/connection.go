@@ -0,0 +1,86 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/tenant"
+)
+
+// Ошибки подключения подписок; текст отправляется клиенту в сообщении connection_error
+var (
+	errAuthenticationRequired = errors.New("требуется аутентификация")
+	errInvalidCredentials     = errors.New("недействительные учётные данные")
+	errAuthenticationFailed   = errors.New("сбой аутентификации")
+	errForbidden              = errors.New("недостаточно прав")
+)
+
+// ConnectionParams — параметры подключения WebSocket для подписок
+// Браузер не может задать заголовки запроса на подключение, поэтому учётные данные и арендатора
+// клиент передаёт в payload сообщения connection_init под именами заголовков HTTP API
+// (Authorization, X-API-Key, X-Tenant-ID); остальные клиенты могут передать их и заголовками
+type ConnectionParams struct {
+	payload map[string]any
+	header  http.Header
+}
+
+// Get возвращает параметр name из payload (регистр имени не важен), а если его там нет — заголовок name
+func (p ConnectionParams) Get(name string) string {
+	for key, value := range p.payload {
+		if s, ok := value.(string); ok && strings.EqualFold(key, name) {
+			return s
+		}
+	}
+	return p.header.Get(name)
+}
+
+// Interceptor готовит контекст подписок по параметрам подключения — например, аутентифицирует клиента
+// Ошибка отклоняет подключение; запросы по HTTP проходят через middleware HTTP API, а не через Interceptor
+type Interceptor func(ctx context.Context, params ConnectionParams) (context.Context, error)
+
+// Authenticate аутентифицирует подключение так же, как auth.Middleware в HTTP API,
+// и требует право read — подписки только читают события
+func Authenticate(authenticators ...auth.Authenticator) Interceptor {
+	return func(ctx context.Context, params ConnectionParams) (context.Context, error) {
+		token := params.Get(auth.APIKeyHeader)
+		if token == "" {
+			token = auth.BearerToken(params.Get("Authorization"))
+		}
+		if token == "" {
+			return nil, errAuthenticationRequired
+		}
+
+		authenticated, err := auth.Authenticate(ctx, token, authenticators...)
+		if errors.Is(err, auth.ErrInvalidCredentials) || errors.Is(err, auth.ErrUnsupportedToken) {
+			return nil, errInvalidCredentials
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Сбой аутентификации подключения GraphQL", "error", err)
+			return nil, errAuthenticationFailed
+		}
+		if !auth.FromContext(authenticated).HasScope(auth.ScopeRead) {
+			return nil, errForbidden
+		}
+		return authenticated, nil
+	}
+}
+
+// Tenant определяет арендатора подключения так же, как tenant.Middleware в HTTP API
+// Арендатор берётся из параметра header (имя заголовка HTTP)
+func Tenant(header string, required bool) Interceptor {
+	if header == "" {
+		header = tenant.DefaultHeader
+	}
+	return func(ctx context.Context, params ConnectionParams) (context.Context, error) {
+		resolved, err := tenant.Resolve(ctx, params.Get(header), required)
+		if errors.Is(err, tenant.ErrRequired) {
+			return nil, fmt.Errorf("%w: %s", err, header)
+		}
+		return resolved, err
+	}
+}
@@ -0,0 +1,203 @@
+// Package graphqlapi — GraphQL API сервиса событий (schema.graphqls)
+//
+// Клиент одним запросом получает события, их вложенные события, описания типов и сводку по типам,
+// не дожидаясь отдельного маршрута REST под каждую комбинацию. Запросы выполняются через
+// event.EventService и event.TypeService, поэтому права клиента, арендаторы и трассировка у GraphQL
+// те же, что у HTTP API. Обращения резолверов к базе данных собираются загрузчиками в пакеты (loaders.go),
+// сложность запроса ограничена, а подписки на запуски и завершения событий работают по WebSocket
+// поверх event.Feed. Код исполнения схемы (generated.go, models_gen.go) создаётся gqlgen: go generate
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"event-service/pkg/event"
+	"event-service/pkg/i18n"
+	"event-service/pkg/logging"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// DefaultComplexityLimit — наибольшая сложность запроса по умолчанию
+// Каждое поле стоит 1; поля-списки умножают стоимость вложенных полей на число элементов
+const DefaultComplexityLimit = 5000
+
+// listComplexity — сколько элементов предполагается в списке, длину которого запрос не задаёт
+// (вложенные события, типы, сводка по типам)
+const listComplexity = 10
+
+// keepAliveInterval — как часто сервер напоминает клиенту подписок, что соединение живо
+const keepAliveInterval = 15 * time.Second
+
+// errPanicked — резолвер запаниковал; паника уже записана в лог
+var errPanicked = errors.New("паника при выполнении запроса GraphQL")
+
+// Handler обслуживает GraphQL API: запросы по HTTP (POST) и подписки по WebSocket (GET)
+type Handler struct {
+	server       *handler.Server
+	interceptors []Interceptor
+
+	// closing закрывается методом Drain и завершает подписки
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewHandler создаёт обработчик GraphQL API
+// types может быть nil — тогда типов в ответах нет; feed должен быть подключён к service как наблюдатель.
+// maxLimit — наибольшее значение limit в events, complexityLimit — наибольшая сложность запроса;
+// interceptors аутентифицируют подключения подписок (запросы по HTTP проходят через middleware)
+func NewHandler(service *event.EventService, types *event.TypeService, feed *event.Feed, maxLimit, complexityLimit int, interceptors ...Interceptor) *Handler {
+	h := &Handler{interceptors: interceptors, closing: make(chan struct{})}
+
+	cfg := Config{Resolvers: &Resolver{service: service, types: types, feed: feed, maxLimit: maxLimit, closing: h.closing}}
+	// limit больше maxLimit резолвер всё равно отклонит, поэтому сложность считается не больше чем для maxLimit
+	cfg.Complexity.Query.Events = func(childComplexity int, offset *int, limit *int, typeArg *string) int {
+		if limit != nil && *limit > 0 && *limit < maxLimit {
+			return 1 + *limit*childComplexity
+		}
+		return 1 + maxLimit*childComplexity
+	}
+	cfg.Complexity.Query.Types = listOf
+	cfg.Complexity.Query.Stats = func(childComplexity int, types []string, since *time.Time) int {
+		if len(types) > 0 {
+			return 1 + len(types)*childComplexity
+		}
+		return listOf(childComplexity)
+	}
+	cfg.Complexity.Event.Children = listOf
+
+	srv := handler.New(NewExecutableSchema(cfg))
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: keepAliveInterval,
+		// Учётные данные передаются явно (connection_init или заголовки), а не cookie,
+		// поэтому подключения с других источников (фронтенд на своём домене) безопасны
+		Upgrader: websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
+		InitFunc: h.init,
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(complexityLimit))
+	srv.SetErrorPresenter(presentError)
+	srv.SetRecoverFunc(recoverPanic)
+
+	// Загрузчики создаются на каждый ответ: на запрос или на очередное изменение в подписке,
+	// чтобы подписка не отдавала данные из кеша, собранного много изменений назад
+	srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		return next(withLoaders(ctx, newLoaders(service, types)))
+	})
+
+	h.server = srv
+	return h
+}
+
+// ServeHTTP выполняет запрос или открывает соединение для подписок
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		h.server.ServeHTTP(w, r)
+		return
+	}
+
+	// Соединение WebSocket живёт, пока его не закроет клиент или Drain;
+	// заголовки запроса на подключение нужны interceptors
+	ctx, cancel := context.WithCancel(context.WithValue(r.Context(), headerKey{}, r.Header))
+	defer cancel()
+	go func() {
+		select {
+		case <-h.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	h.server.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// Drain закрывает соединения подписок
+// http.Server.Shutdown не ждёт соединения WebSocket и не закрывает их — для этого Drain
+// подключается через http.Server.RegisterOnShutdown
+func (h *Handler) Drain() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+type headerKey struct{}
+
+// init готовит контекст подписок по сообщению connection_init
+func (h *Handler) init(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	header, _ := ctx.Value(headerKey{}).(http.Header)
+	params := ConnectionParams{payload: payload, header: header}
+	for _, intercept := range h.interceptors {
+		var err error
+		if ctx, err = intercept(ctx, params); err != nil {
+			return nil, nil, err
+		}
+	}
+	return ctx, nil, nil
+}
+
+// listOf — сложность поля-списка неизвестной длины
+func listOf(childComplexity int) int {
+	return 1 + listComplexity*childComplexity
+}
+
+// presentError переводит ошибку резолвера в ошибку GraphQL
+// Ошибки сервиса получают в extensions тот же код, что и в HTTP API (поле code ответа problem+json),
+// и сообщение на языке клиента; неизвестная ошибка — сбой сервиса: она пишется в лог,
+// а клиент получает internal_error без подробностей. Ошибки разбора и проверки запроса
+// (в том числе превышение сложности) отдаются как есть
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	if gqlErr.Unwrap() == nil {
+		return gqlErr
+	}
+	lang := i18n.FromContext(ctx)
+
+	if p, ok := event.ProblemFor(err); ok {
+		p = p.Localize(lang)
+		gqlErr.Message = p.Title
+		gqlErr.Extensions = map[string]any{"code": p.Code}
+		if p.Detail != "" {
+			gqlErr.Extensions["detail"] = p.Detail
+		}
+		if len(p.Errors) > 0 {
+			gqlErr.Extensions["errors"] = p.Errors
+		}
+		return gqlErr
+	}
+
+	code := "internal_error"
+	switch {
+	case errors.Is(err, errForbidden):
+		code = "forbidden"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// Клиент ушёл, не дождавшись ответа, — это не сбой сервиса
+		return gqlErr
+	case !errors.Is(err, errPanicked):
+		slog.ErrorContext(ctx, "Сбой при выполнении запроса GraphQL", "path", gqlErr.Path.String(), "error", err)
+	}
+	gqlErr.Message = i18n.Text(lang, code)
+	gqlErr.Extensions = map[string]any{"code": code, "requestId": logging.RequestID(ctx)}
+	return gqlErr
+}
+
+// recoverPanic пишет панику резолвера в лог; клиент получает internal_error
+func recoverPanic(ctx context.Context, recovered any) error {
+	slog.ErrorContext(ctx, "Паника при выполнении запроса GraphQL",
+		"panic", recovered,
+		"stack", string(debug.Stack()),
+	)
+	return errPanicked
+}
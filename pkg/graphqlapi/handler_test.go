@@ -0,0 +1,391 @@
+package graphqlapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/event"
+	"event-service/pkg/tenant"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryRepository хранит события в памяти и считает запросы — так видно, что загрузчики собирают их в пакеты
+// Остальные методы не реализованы: встроенный nil-интерфейс вызовет панику, если тест до них дойдёт
+type memoryRepository struct {
+	event.Repository
+
+	mu     sync.Mutex
+	events []event.Event
+	calls  map[string]int
+}
+
+func (r *memoryRepository) called(method string) {
+	if r.calls == nil {
+		r.calls = map[string]int{}
+	}
+	r.calls[method]++
+}
+
+// count возвращает, сколько раз вызывался метод
+func (r *memoryRepository) count(method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[method]
+}
+
+// add сохраняет событие type, вложенное в parent (может быть nil)
+func (r *memoryRepository) add(eventType string, parent *event.Event) *event.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := event.Event{ID: primitive.NewObjectID(), Type: eventType, StartedAt: time.Now().Add(-time.Duration(len(r.events)) * time.Minute)}
+	if parent != nil {
+		e.ParentID = &parent.ID
+	}
+	r.events = append(r.events, e)
+	return &e
+}
+
+func (r *memoryRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.called("FindByIDs")
+	var found []event.Event
+	for _, e := range r.events {
+		for _, id := range ids {
+			if e.ID == id {
+				found = append(found, e)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryRepository) FindChildren(ctx context.Context, parentIDs []primitive.ObjectID) ([]event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.called("FindChildren")
+	var children []event.Event
+	for _, e := range r.events {
+		for _, id := range parentIDs {
+			if e.ParentID != nil && *e.ParentID == id {
+				children = append(children, e)
+			}
+		}
+	}
+	return children, nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, filter event.ListFilter) ([]event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.called("Find")
+	events := []event.Event{}
+	for _, e := range r.events {
+		if (filter.Type == "" || e.Type == filter.Type) && (filter.Limit == 0 || len(events) < filter.Limit) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *memoryRepository) Stats(ctx context.Context, filter event.StatsFilter) ([]event.TypeStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.called("Stats")
+	index := map[string]int{}
+	var stats []event.TypeStats
+	for _, e := range r.events {
+		i, ok := index[e.Type]
+		if !ok {
+			i = len(stats)
+			index[e.Type] = i
+			stats = append(stats, event.TypeStats{Type: e.Type})
+		}
+		stats[i].Total++
+		stats[i].Active++
+	}
+	return stats, nil
+}
+
+func (r *memoryRepository) FindActive(ctx context.Context, eventType string) (*event.Event, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) Create(ctx context.Context, e *event.Event) (*event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.ID = primitive.NewObjectID()
+	e.TenantID = tenant.ID(ctx)
+	e.StartedAt = time.Now()
+	r.events = append(r.events, *e)
+	return e, nil
+}
+
+// tokens — аутентификатор с заранее известными токенами
+type tokens map[string]*auth.Principal
+
+func (t tokens) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if p, ok := t[token]; ok {
+		return p, nil
+	}
+	return nil, auth.ErrInvalidCredentials
+}
+
+// newTestHandler создаёт обработчик поверх репозитория в памяти без реестра типов
+func newTestHandler(t *testing.T, complexityLimit int, interceptors ...Interceptor) (*Handler, *event.EventService, *memoryRepository) {
+	t.Helper()
+	repo := &memoryRepository{}
+	feed := event.NewFeed(0)
+	service := event.NewEventService(repo, nil)
+	service.SetObserver(feed)
+	h := NewHandler(service, nil, feed, event.DefaultMaxLimit, complexityLimit, interceptors...)
+	t.Cleanup(h.Drain)
+	return h, service, repo
+}
+
+// response — ответ GraphQL
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// query выполняет запрос от имени principal (nil — без аутентификации)
+func query(t *testing.T, h http.Handler, principal *auth.Principal, q string) response {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": q})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if principal != nil {
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid response %q: %v", w.Body.String(), err)
+	}
+	return resp
+}
+
+func TestHandler_EventsWithChildrenAndParent(t *testing.T) {
+	h, _, repo := newTestHandler(t, DefaultComplexityLimit)
+	for i := 0; i < 5; i++ {
+		meeting := repo.add("meeting", nil)
+		repo.add("presentation", meeting)
+		repo.add("qa", meeting)
+	}
+
+	resp := query(t, h, nil, `{ events(type: "meeting") { id type state children { type parent { id } } } }`)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %+v", resp.Errors)
+	}
+	var data struct {
+		Events []struct {
+			ID       string
+			State    string
+			Children []struct {
+				Type   string
+				Parent struct{ ID string }
+			}
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Events) != 5 {
+		t.Fatalf("Expected 5 meetings, got %d", len(data.Events))
+	}
+	for _, e := range data.Events {
+		if e.State != "STARTED" || len(e.Children) != 2 {
+			t.Errorf("Expected an active meeting with 2 children, got %+v", e)
+		}
+		for _, child := range e.Children {
+			if child.Parent.ID != e.ID {
+				t.Errorf("Child %s points to parent %s, expected %s", child.Type, child.Parent.ID, e.ID)
+			}
+		}
+	}
+
+	// Дети всех пяти событий и их родители достаются одним запросом каждый, а не по запросу на событие
+	if n := repo.count("FindChildren"); n != 1 {
+		t.Errorf("FindChildren called %d times, expected 1", n)
+	}
+	if n := repo.count("FindByIDs"); n != 1 {
+		t.Errorf("FindByIDs called %d times, expected 1", n)
+	}
+}
+
+func TestHandler_Stats(t *testing.T) {
+	h, _, repo := newTestHandler(t, DefaultComplexityLimit)
+	repo.add("meeting", nil)
+	repo.add("meeting", nil)
+	repo.add("call", nil)
+
+	resp := query(t, h, nil, `{ stats { type total active averageDurationSeconds } }`)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %+v", resp.Errors)
+	}
+	expected := `{"stats":[{"type":"meeting","total":2,"active":2,"averageDurationSeconds":null},{"type":"call","total":1,"active":1,"averageDurationSeconds":null}]}`
+	if string(resp.Data) != expected {
+		t.Errorf("Expected %s, got %s", expected, resp.Data)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	h, _, repo := newTestHandler(t, 500)
+	billing := repo.add("billing", nil)
+	reader := &auth.Principal{Subject: "reader", Scopes: []auth.Scope{auth.ScopeRead}, Types: []string{"meeting"}}
+
+	cases := map[string]struct {
+		principal *auth.Principal
+		query     string
+		code      string
+	}{
+		"invalid id":         {nil, `{ event(id: "42") { id } }`, "invalid_parameter"},
+		"limit out of range": {nil, `{ events(limit: 100000) { id } }`, "invalid_parameter"},
+		"forbidden type":     {reader, `{ events(type: "billing") { id } }`, "forbidden"},
+		// Сложность: 1 + 100 событий × (1 + 10 детей × 1) больше 500
+		"too complex": {nil, `{ events { children { id } } }`, "COMPLEXITY_LIMIT_EXCEEDED"},
+	}
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := query(t, h, tt.principal, tt.query)
+			if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != tt.code {
+				t.Errorf("Expected error %s, got %+v", tt.code, resp.Errors)
+			}
+		})
+	}
+
+	// Событие типа, не разрешённого ключу, выглядит как несуществующее
+	resp := query(t, h, reader, fmt.Sprintf(`{ event(id: %q) { id } }`, billing.ID.Hex()))
+	if len(resp.Errors) > 0 || string(resp.Data) != `{"event":null}` {
+		t.Errorf("Expected a hidden event, got %s %+v", resp.Data, resp.Errors)
+	}
+}
+
+// wsMessage — сообщение протокола graphql-transport-ws
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// dialSubscriptions подключается к подпискам и отправляет connection_init с payload
+// Возвращает соединение и первый ответ сервера; если сервер закрыл соединение, тип ответа — closed
+func dialSubscriptions(t *testing.T, h http.Handler, payload map[string]string) (*websocket.Conn, wsMessage) {
+	t.Helper()
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	init, _ := json.Marshal(payload)
+	if err := conn.WriteJSON(wsMessage{Type: "connection_init", Payload: init}); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg wsMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			t.Fatal(err)
+		}
+		msg.Type = "closed"
+	}
+	return conn, msg
+}
+
+func TestHandler_Subscription(t *testing.T) {
+	keys := tokens{
+		"reader": {Subject: "reader", Tenant: "acme", Scopes: []auth.Scope{auth.ScopeRead}},
+		"writer": {Subject: "writer", Tenant: "acme", Scopes: []auth.Scope{auth.ScopeStart}},
+	}
+	h, service, _ := newTestHandler(t, DefaultComplexityLimit, Authenticate(keys), Tenant("", true))
+
+	// Без ключа, с неизвестным ключом и без права read подключение отклоняется
+	for name, payload := range map[string]map[string]string{
+		"no key":      {},
+		"invalid key": {"X-API-Key": "unknown"},
+		"no scope":    {"Authorization": "Bearer writer"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, msg := dialSubscriptions(t, h, payload); msg.Type != "closed" {
+				t.Errorf("Expected the connection to be rejected, got %+v", msg)
+			}
+		})
+	}
+
+	conn, msg := dialSubscriptions(t, h, map[string]string{"x-api-key": "reader"})
+	if msg.Type != "connection_ack" {
+		t.Fatalf("Expected connection_ack, got %+v", msg)
+	}
+	subscribe, _ := json.Marshal(map[string]string{"query": `subscription { eventChanged { kind event { type startedBy } } }`})
+	if err := conn.WriteJSON(wsMessage{ID: "1", Type: "subscribe", Payload: subscribe}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Подписка оформляется асинхронно, поэтому события запускаются, пока не придёт первое изменение
+	// Событие другого арендатора подписчику не видно
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		acme := tenant.WithTenant(context.Background(), "acme")
+		globex := tenant.WithTenant(context.Background(), "globex")
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			service.Start(globex, event.StartParams{Type: fmt.Sprintf("hidden%d", i)})
+			service.Start(acme, event.StartParams{Type: fmt.Sprintf("meeting%d", i), StartedBy: "writer"})
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type != "ping" && msg.Type != "pong" {
+			break
+		}
+	}
+	if msg.Type != "next" || msg.ID != "1" {
+		t.Fatalf("Expected next, got %+v", msg)
+	}
+	var payload struct {
+		Data struct {
+			EventChanged struct {
+				Kind  string
+				Event struct{ Type, StartedBy string }
+			}
+		}
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	change := payload.Data.EventChanged
+	if change.Kind != "STARTED" || !strings.HasPrefix(change.Event.Type, "meeting") || change.Event.StartedBy != "writer" {
+		t.Errorf("Unexpected change %+v", change)
+	}
+}
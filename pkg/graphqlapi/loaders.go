@@ -0,0 +1,122 @@
+package graphqlapi
+
+import (
+	"context"
+
+	"event-service/pkg/event"
+
+	"github.com/vikstrous/dataloadgen"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// loaders собирают обращения резолверов к сервису в пакеты
+// Без них запрос списка событий с полями parent, children или eventType обращался бы
+// к базе данных отдельно для каждого события списка (проблема N+1)
+// Загрузчики создаются заново для каждого ответа и кешируют результаты только в его пределах
+type loaders struct {
+	events   *dataloadgen.Loader[primitive.ObjectID, *event.Event]
+	children *dataloadgen.Loader[primitive.ObjectID, []event.Event]
+	types    *dataloadgen.Loader[string, *event.EventType]
+	stats    *dataloadgen.Loader[string, *event.TypeStats]
+}
+
+type loadersKey struct{}
+
+// newLoaders создаёт загрузчики поверх сервисов; types может быть nil
+func newLoaders(service *event.EventService, types *event.TypeService) *loaders {
+	return &loaders{
+		// События по идентификатору — одним запросом FindByIDs
+		events: dataloadgen.NewLoader(func(ctx context.Context, ids []primitive.ObjectID) ([]*event.Event, []error) {
+			found, err := service.GetMany(ctx, ids)
+			if err != nil {
+				return nil, fill(len(ids), err)
+			}
+			byID := make(map[primitive.ObjectID]*event.Event, len(found))
+			for i := range found {
+				byID[found[i].ID] = &found[i]
+			}
+			result := make([]*event.Event, len(ids))
+			for i, id := range ids {
+				result[i] = byID[id]
+			}
+			return result, nil
+		}),
+
+		// Вложенные события — целый уровень дерева одним запросом FindChildren
+		children: dataloadgen.NewLoader(func(ctx context.Context, parentIDs []primitive.ObjectID) ([][]event.Event, []error) {
+			found, err := service.Children(ctx, parentIDs)
+			if err != nil {
+				return nil, fill(len(parentIDs), err)
+			}
+			byParent := make(map[primitive.ObjectID][]event.Event, len(parentIDs))
+			for _, child := range found {
+				byParent[*child.ParentID] = append(byParent[*child.ParentID], child)
+			}
+			result := make([][]event.Event, len(parentIDs))
+			for i, id := range parentIDs {
+				result[i] = byParent[id]
+			}
+			return result, nil
+		}),
+
+		// Типы — весь реестр арендатора одним запросом: он небольшой, а запрос по списку имён
+		// ничем не дешевле
+		types: dataloadgen.NewLoader(func(ctx context.Context, names []string) ([]*event.EventType, []error) {
+			result := make([]*event.EventType, len(names))
+			if types == nil {
+				return result, nil
+			}
+			registered, err := types.List(ctx)
+			if err != nil {
+				return nil, fill(len(names), err)
+			}
+			byName := make(map[string]*event.EventType, len(registered))
+			for i := range registered {
+				byName[registered[i].Name] = &registered[i]
+			}
+			for i, name := range names {
+				result[i] = byName[name]
+			}
+			return result, nil
+		}),
+
+		// Сводка по типам — одной агрегацией по всем запрошенным типам
+		stats: dataloadgen.NewLoader(func(ctx context.Context, names []string) ([]*event.TypeStats, []error) {
+			found, err := service.Stats(ctx, event.StatsFilter{Types: names})
+			if err != nil {
+				return nil, fill(len(names), err)
+			}
+			byType := make(map[string]*event.TypeStats, len(found))
+			for i := range found {
+				byType[found[i].Type] = &found[i]
+			}
+			result := make([]*event.TypeStats, len(names))
+			for i, name := range names {
+				// У типа без событий сводка нулевая, а не отсутствует
+				if result[i] = byType[name]; result[i] == nil {
+					result[i] = &event.TypeStats{Type: name}
+				}
+			}
+			return result, nil
+		}),
+	}
+}
+
+// withLoaders кладёт загрузчики в контекст
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+// loadersFrom возвращает загрузчики из контекста
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// fill возвращает ошибку err для каждого из n ключей пакета
+func fill(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
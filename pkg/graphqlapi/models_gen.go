@@ -0,0 +1,126 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphqlapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type Query struct {
+}
+
+type Subscription struct {
+}
+
+type ChangeKind string
+
+const (
+	ChangeKindStarted  ChangeKind = "STARTED"
+	ChangeKindFinished ChangeKind = "FINISHED"
+)
+
+var AllChangeKind = []ChangeKind{
+	ChangeKindStarted,
+	ChangeKindFinished,
+}
+
+func (e ChangeKind) IsValid() bool {
+	switch e {
+	case ChangeKindStarted, ChangeKindFinished:
+		return true
+	}
+	return false
+}
+
+func (e ChangeKind) String() string {
+	return string(e)
+}
+
+func (e *ChangeKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ChangeKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ChangeKind", str)
+	}
+	return nil
+}
+
+func (e ChangeKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ChangeKind) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ChangeKind) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type EventState string
+
+const (
+	EventStateStarted  EventState = "STARTED"
+	EventStateFinished EventState = "FINISHED"
+)
+
+var AllEventState = []EventState{
+	EventStateStarted,
+	EventStateFinished,
+}
+
+func (e EventState) IsValid() bool {
+	switch e {
+	case EventStateStarted, EventStateFinished:
+		return true
+	}
+	return false
+}
+
+func (e EventState) String() string {
+	return string(e)
+}
+
+func (e *EventState) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EventState(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EventState", str)
+	}
+	return nil
+}
+
+func (e EventState) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *EventState) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e EventState) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
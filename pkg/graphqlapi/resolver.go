@@ -0,0 +1,286 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/event"
+	"event-service/pkg/problem"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Resolver отвечает на запросы схемы schema.graphqls через event.EventService и event.TypeService
+// Права те же, что в HTTP API: события и типы, не разрешённые ключу клиента, в ответ не попадают
+type Resolver struct {
+	service *event.EventService
+	// types — реестр типов; nil — реестр не подключён, и типов нет
+	types *event.TypeService
+	feed  *event.Feed
+	// maxLimit — наибольшее значение limit в events, как api.max_page_size в HTTP API
+	maxLimit int
+	// closing закрывается при остановке сервера и завершает подписки
+	closing <-chan struct{}
+}
+
+func (r *Resolver) Event() EventResolver             { return &eventResolver{r} }
+func (r *Resolver) EventChange() EventChangeResolver { return &eventChangeResolver{r} }
+func (r *Resolver) EventType() EventTypeResolver     { return &eventTypeResolver{r} }
+func (r *Resolver) Query() QueryResolver             { return &queryResolver{r} }
+func (r *Resolver) Subscription() SubscriptionResolver {
+	return &subscriptionResolver{r}
+}
+func (r *Resolver) TypeStats() TypeStatsResolver { return &typeStatsResolver{r} }
+
+type queryResolver struct{ *Resolver }
+
+// Events возвращает события, новые первыми; повторяет GET /v1
+// Без limit возвращается не больше maxLimit событий: сложность запроса должна быть ограничена
+func (r *queryResolver) Events(ctx context.Context, offset *int, limit *int, typeArg *string) ([]event.Event, error) {
+	filter := event.ListFilter{Limit: r.maxLimit, TypePatterns: auth.AllowedTypes(ctx)}
+	if offset != nil {
+		if *offset < 0 {
+			return nil, invalidParameter("offset", "expected.non_negative")
+		}
+		filter.Offset = *offset
+	}
+	if limit != nil {
+		if *limit < 1 || *limit > r.maxLimit {
+			return nil, invalidParameter("limit", "expected.range", 1, r.maxLimit)
+		}
+		filter.Limit = *limit
+	}
+	if typeArg != nil {
+		if !auth.Allowed(ctx, auth.ScopeRead, *typeArg) {
+			return nil, errForbidden
+		}
+		filter.Type = *typeArg
+	}
+	return r.service.Find(ctx, filter)
+}
+
+// Event возвращает событие по идентификатору или null
+// Событие чужого типа для ограниченного ключа выглядит как несуществующее
+func (r *queryResolver) Event(ctx context.Context, id string) (*event.Event, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidParameter("id", "expected.event_id")
+	}
+	found, err := loadersFrom(ctx).events.Load(ctx, objectID)
+	if err != nil || found == nil || !auth.Allowed(ctx, auth.ScopeRead, found.Type) {
+		return nil, err
+	}
+	return found, nil
+}
+
+// Types возвращает типы реестра, разрешённые ключу клиента
+func (r *queryResolver) Types(ctx context.Context) ([]event.EventType, error) {
+	if r.types == nil {
+		return []event.EventType{}, nil
+	}
+	registered, err := r.types.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]event.EventType, 0, len(registered))
+	for _, t := range registered {
+		if auth.Allowed(ctx, auth.ScopeRead, t.Name) {
+			allowed = append(allowed, t)
+		}
+	}
+	return allowed, nil
+}
+
+// Type возвращает тип реестра по имени или null
+func (r *queryResolver) Type(ctx context.Context, name string) (*event.EventType, error) {
+	if !auth.Allowed(ctx, auth.ScopeRead, name) {
+		return nil, nil
+	}
+	return loadersFrom(ctx).types.Load(ctx, name)
+}
+
+// Stats возвращает сводку по типам событий, разрешённым ключу клиента
+func (r *queryResolver) Stats(ctx context.Context, types []string, since *time.Time) ([]event.TypeStats, error) {
+	filter := event.StatsFilter{Types: types, TypePatterns: auth.AllowedTypes(ctx)}
+	if since != nil {
+		filter.Since = *since
+	}
+	stats, err := r.service.Stats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = []event.TypeStats{}
+	}
+	return stats, nil
+}
+
+type eventResolver struct{ *Resolver }
+
+func (r *eventResolver) ID(ctx context.Context, obj *event.Event) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+func (r *eventResolver) State(ctx context.Context, obj *event.Event) (EventState, error) {
+	if obj.State == event.Finished {
+		return EventStateFinished, nil
+	}
+	return EventStateStarted, nil
+}
+
+func (r *eventResolver) DurationSeconds(ctx context.Context, obj *event.Event) (float64, error) {
+	return obj.Duration(time.Now()).Seconds(), nil
+}
+
+func (r *eventResolver) StartedBy(ctx context.Context, obj *event.Event) (*string, error) {
+	return optional(obj.StartedBy), nil
+}
+
+func (r *eventResolver) FinishedBy(ctx context.Context, obj *event.Event) (*string, error) {
+	return optional(obj.FinishedBy), nil
+}
+
+// Attributes возвращает атрибуты в том же представлении JSON, что и HTTP API
+func (r *eventResolver) Attributes(ctx context.Context, obj *event.Event) (map[string]any, error) {
+	return obj.ToResponse().Attributes, nil
+}
+
+func (r *eventResolver) Parent(ctx context.Context, obj *event.Event) (*event.Event, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
+	parent, err := loadersFrom(ctx).events.Load(ctx, *obj.ParentID)
+	if err != nil || parent == nil || !auth.Allowed(ctx, auth.ScopeRead, parent.Type) {
+		return nil, err
+	}
+	return parent, nil
+}
+
+// Children возвращает вложенные события, кроме событий типов, не разрешённых ключу клиента
+func (r *eventResolver) Children(ctx context.Context, obj *event.Event) ([]event.Event, error) {
+	children, err := loadersFrom(ctx).children.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]event.Event, 0, len(children))
+	for _, child := range children {
+		if auth.Allowed(ctx, auth.ScopeRead, child.Type) {
+			allowed = append(allowed, child)
+		}
+	}
+	return allowed, nil
+}
+
+func (r *eventResolver) EventType(ctx context.Context, obj *event.Event) (*event.EventType, error) {
+	return loadersFrom(ctx).types.Load(ctx, obj.Type)
+}
+
+type eventTypeResolver struct{ *Resolver }
+
+func (r *eventTypeResolver) Attributes(ctx context.Context, obj *event.EventType) (map[string]any, error) {
+	if len(obj.Attributes) == 0 {
+		return nil, nil
+	}
+	attributes := make(map[string]any, len(obj.Attributes))
+	for name, kind := range obj.Attributes {
+		attributes[name] = string(kind)
+	}
+	return attributes, nil
+}
+
+func (r *eventTypeResolver) Schema(ctx context.Context, obj *event.EventType) (map[string]any, error) {
+	if len(obj.Schema) == 0 {
+		return nil, nil
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(obj.Schema, &schema); err != nil {
+		return nil, fmt.Errorf("схема типа %s не читается: %w", obj.Name, err)
+	}
+	return schema, nil
+}
+
+func (r *eventTypeResolver) Stats(ctx context.Context, obj *event.EventType) (*event.TypeStats, error) {
+	return loadersFrom(ctx).stats.Load(ctx, obj.Name)
+}
+
+type typeStatsResolver struct{ *Resolver }
+
+func (r *typeStatsResolver) AverageDurationSeconds(ctx context.Context, obj *event.TypeStats) (*float64, error) {
+	if obj.Finished == 0 {
+		return nil, nil
+	}
+	seconds := obj.AverageDuration.Seconds()
+	return &seconds, nil
+}
+
+type eventChangeResolver struct{ *Resolver }
+
+func (r *eventChangeResolver) Kind(ctx context.Context, obj *event.Change) (ChangeKind, error) {
+	if obj.Kind == event.ChangeFinished {
+		return ChangeKindFinished, nil
+	}
+	return ChangeKindStarted, nil
+}
+
+type subscriptionResolver struct{ *Resolver }
+
+// EventChanged передаёт запуски и завершения событий арендатора, пока клиент не отменит подписку
+// Подписка отстающего клиента и подписки при остановке сервера завершаются (complete) —
+// клиент должен подписаться заново
+func (r *subscriptionResolver) EventChanged(ctx context.Context, typeArg *string) (<-chan *event.Change, error) {
+	var eventType string
+	if typeArg != nil {
+		eventType = *typeArg
+		if err := r.service.ValidateType(eventType); err != nil {
+			return nil, err
+		}
+		if !auth.Allowed(ctx, auth.ScopeRead, eventType) {
+			return nil, errForbidden
+		}
+	}
+
+	subscription := r.feed.Subscribe(ctx, func(t string) bool {
+		return (eventType == "" || t == eventType) && auth.Allowed(ctx, auth.ScopeRead, t)
+	})
+	changes := make(chan *event.Change)
+	go func() {
+		defer close(changes)
+		defer subscription.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.closing:
+				return
+			case change, ok := <-subscription.Changes():
+				if !ok {
+					slog.InfoContext(ctx, "Подписка GraphQL завершена", "error", subscription.Err())
+					return
+				}
+				select {
+				case changes <- &change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// optional возвращает nil вместо пустой строки
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// invalidParameter — некорректное значение аргумента name; key — сообщение из каталога i18n
+func invalidParameter(name, key string, args ...any) error {
+	return &event.FieldsError{Err: event.ErrInvalidParameter, Fields: []event.FieldError{problem.Field(name, key, args...)}}
+}
@@ -0,0 +1,67 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	eventv1 "event-service/api/event/v1"
+	"event-service/pkg/event"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProto переводит событие в сообщение gRPC API
+// Атрибуты проходят через то же представление JSON, что и в HTTP API, поэтому значения из MongoDB
+// (вложенные документы, ObjectID, даты) выглядят в обоих API одинаково
+func toProto(e *event.Event) (*eventv1.Event, error) {
+	msg := &eventv1.Event{
+		Id:         e.ID.Hex(),
+		Type:       e.Type,
+		State:      stateToProto(e.State),
+		StartedAt:  timestamppb.New(e.StartedAt),
+		StartedBy:  e.StartedBy,
+		FinishedBy: e.FinishedBy,
+	}
+	if e.FinishedAt != nil {
+		msg.FinishedAt = timestamppb.New(*e.FinishedAt)
+	}
+	if e.ParentID != nil {
+		msg.ParentId = e.ParentID.Hex()
+	}
+	if attributes := e.ToResponse().Attributes; len(attributes) > 0 {
+		data, err := json.Marshal(attributes)
+		if err == nil {
+			msg.Attributes = &structpb.Struct{}
+			err = msg.Attributes.UnmarshalJSON(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("атрибуты события %s не представимы в protobuf: %w", e.ID.Hex(), err)
+		}
+	}
+	return msg, nil
+}
+
+// stateToProto переводит состояние события в перечисление gRPC API
+func stateToProto(s event.State) eventv1.State {
+	switch s {
+	case event.Active:
+		return eventv1.State_STATE_STARTED
+	case event.Finished:
+		return eventv1.State_STATE_FINISHED
+	default:
+		return eventv1.State_STATE_UNSPECIFIED
+	}
+}
+
+// changeKindToProto переводит вид изменения в перечисление gRPC API
+func changeKindToProto(k event.ChangeKind) eventv1.EventChange_Kind {
+	switch k {
+	case event.ChangeStarted:
+		return eventv1.EventChange_KIND_STARTED
+	case event.ChangeFinished:
+		return eventv1.EventChange_KIND_FINISHED
+	default:
+		return eventv1.EventChange_KIND_UNSPECIFIED
+	}
+}
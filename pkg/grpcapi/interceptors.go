@@ -0,0 +1,227 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	eventv1 "event-service/api/event/v1"
+	"event-service/pkg/auth"
+	"event-service/pkg/logging"
+	"event-service/pkg/ratelimit"
+	"event-service/pkg/tenant"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Ключи метаданных вызова — те же заголовки, что и в HTTP API, в нижнем регистре
+var (
+	authorizationKey = "authorization"
+	apiKeyKey        = strings.ToLower(auth.APIKeyHeader)
+	requestIDKey     = strings.ToLower(logging.RequestIDHeader)
+)
+
+// Ошибки аутентификации и определения арендатора
+var (
+	errAuthenticationRequired = withDetails(status.New(codes.Unauthenticated, "требуется аутентификация"), "authentication_required", nil)
+	errInvalidCredentials     = withDetails(status.New(codes.Unauthenticated, "недействительные учётные данные"), "invalid_credentials", nil)
+	errTenantForbidden        = withDetails(status.New(codes.PermissionDenied, tenant.ErrForbidden.Error()), "tenant_forbidden", nil)
+)
+
+// Interceptor готовит контекст вызова по его метаданным — например, аутентифицирует клиента
+// Ошибка прерывает вызов и возвращается клиенту; один Interceptor обслуживает и обычные, и потоковые вызовы
+type Interceptor func(ctx context.Context, md metadata.MD) (context.Context, error)
+
+// ServerOptions возвращает настройки grpc.Server, общие для всех вызовов: идентификатор запроса
+// (метаданные x-request-id, как заголовок X-Request-ID в HTTP API), строка лога о каждом вызове,
+// перехват паники в обработчике и затем interceptors по порядку
+func ServerOptions(interceptors ...Interceptor) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+			err = call(ctx, info.FullMethod, interceptors, func(ctx context.Context) error {
+				resp, err = handler(ctx, req)
+				return err
+			})
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return call(stream.Context(), info.FullMethod, interceptors, func(ctx context.Context) error {
+				return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+			})
+		}),
+	}
+}
+
+// call выполняет вызов method: готовит контекст, вызывает run и пишет строку лога
+// Ответы Internal и Unknown пишутся с уровнем error, остальные — info
+func call(ctx context.Context, method string, interceptors []Interceptor, run func(ctx context.Context) error) (err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := first(md, requestIDKey)
+	if !logging.ValidRequestID(id) {
+		id = logging.NewRequestID()
+	}
+	ctx = logging.WithRequestID(ctx, id)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+	start := time.Now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.ErrorContext(ctx, "Паника при обработке gRPC-вызова",
+				"panic", recovered,
+				"stack", string(debug.Stack()),
+			)
+			err = withDetails(status.New(codes.Internal, "внутренняя ошибка сервиса"), "internal_error", nil)
+		}
+		code := status.Code(err)
+		level := slog.LevelInfo
+		if code == codes.Internal || code == codes.Unknown {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "gRPC-вызов",
+			slog.String("method", method),
+			slog.String("code", code.String()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+	}()
+
+	callCtx := ctx
+	for _, intercept := range interceptors {
+		if callCtx, err = intercept(callCtx, md); err != nil {
+			return err
+		}
+	}
+	return run(callCtx)
+}
+
+// serverStream подменяет контекст потока контекстом, подготовленным interceptors
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// Authenticate аутентифицирует клиента так же, как auth.Middleware в HTTP API
+// Токен берётся из метаданных authorization ("Bearer <токен>") или x-api-key;
+// без токена или с недействительным токеном вызов отклоняется с Unauthenticated
+func Authenticate(authenticators ...auth.Authenticator) Interceptor {
+	return func(ctx context.Context, md metadata.MD) (context.Context, error) {
+		token := first(md, apiKeyKey)
+		if token == "" {
+			token = auth.BearerToken(first(md, authorizationKey))
+		}
+		if token == "" {
+			return nil, errAuthenticationRequired
+		}
+
+		authenticated, err := auth.Authenticate(ctx, token, authenticators...)
+		if errors.Is(err, auth.ErrInvalidCredentials) || errors.Is(err, auth.ErrUnsupportedToken) {
+			return nil, errInvalidCredentials
+		}
+		if err != nil {
+			return nil, statusFor(ctx, err)
+		}
+		return authenticated, nil
+	}
+}
+
+// Tenant определяет арендатора вызова так же, как tenant.Middleware в HTTP API
+// Арендатор берётся из метаданных header (имя заголовка HTTP, регистр не важен)
+func Tenant(header string, required bool) Interceptor {
+	if header == "" {
+		header = tenant.DefaultHeader
+	}
+	key := strings.ToLower(header)
+	return func(ctx context.Context, md metadata.MD) (context.Context, error) {
+		resolved, err := tenant.Resolve(ctx, first(md, key), required)
+		switch {
+		case errors.Is(err, tenant.ErrForbidden):
+			return nil, errTenantForbidden
+		case errors.Is(err, tenant.ErrRequired):
+			return nil, withDetails(status.Newf(codes.InvalidArgument, "%s: %s", err, header), "tenant_required", nil)
+		case err != nil:
+			return nil, withDetails(status.New(codes.InvalidArgument, err.Error()), "invalid_tenant", nil)
+		}
+		return resolved, nil
+	}
+}
+
+// httpRoutes сопоставляет методы gRPC с маршрутами HTTP API, которые они повторяют:
+// лимиты rate_limits.rules задаются по маршрутам HTTP и так же действуют на вызовы gRPC
+var httpRoutes = map[string]string{
+	eventv1.EventService_Start_FullMethodName:  "POST /v1/start",
+	eventv1.EventService_Finish_FullMethodName: "POST /v1/finish",
+	eventv1.EventService_List_FullMethodName:   "GET /v1",
+	eventv1.EventService_Get_FullMethodName:    "GET /v1/events/:id",
+}
+
+// errRateLimited — ответ при превышении лимита вызовов
+var errRateLimited = withDetails(status.New(codes.ResourceExhausted, "слишком много запросов"), "rate_limited", nil)
+
+// RateLimit ограничивает частоту вызовов так же, как ratelimit.Middleware в HTTP API
+// Вызов метода, повторяющего маршрут HTTP, расходует то же ведро клиента, что и запрос к маршруту;
+// для остальных методов (Watch) действует правило по умолчанию, ведро у каждого метода своё
+// При превышении — ResourceExhausted с причиной rate_limited и метаданными retry-after (секунды)
+// Должен идти после Authenticate: клиент определяется по API-ключу или токену, без них — по адресу
+func RateLimit(store ratelimit.Store, rules ratelimit.Rules) Interceptor {
+	return func(ctx context.Context, _ metadata.MD) (context.Context, error) {
+		method, _ := grpc.Method(ctx)
+		route, ok := httpRoutes[method]
+		if !ok {
+			route = "GRPC " + method
+		}
+		httpMethod, path, _ := strings.Cut(route, " ")
+		limit, ok := rules.For(httpMethod, path)
+		if !ok {
+			return ctx, nil
+		}
+
+		result, err := store.Take(ctx, ratelimit.Key(route, ratelimit.Client(ctx, peerAddress(ctx))), limit, time.Now())
+		if err != nil {
+			slog.WarnContext(ctx, "Не удалось проверить лимит запросов", "error", err)
+			return ctx, nil
+		}
+		if !result.Allowed {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfterSeconds(result.RetryAfter))))
+			return nil, errRateLimited
+		}
+		return ctx, nil
+	}
+}
+
+// peerAddress возвращает адрес клиента без порта
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+// retryAfterSeconds округляет ожидание вверх до целых секунд, как заголовок Retry-After в HTTP API
+func retryAfterSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
+
+// first возвращает первое значение ключа key из метаданных или пустую строку
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
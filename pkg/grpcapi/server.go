@@ -0,0 +1,254 @@
+// Package grpcapi — gRPC API сервиса событий (api/event/v1/event.proto)
+//
+// Сервер работает поверх того же event.EventService, что и HTTP API, поэтому правила у обоих API общие:
+// проверка типов и атрибутов, права клиента, арендаторы, журнал аудита и метрики.
+// Ошибки сервиса превращаются в статусы gRPC в одном месте (errorStatuses), а поток изменений Watch
+// получает запуски и завершения событий из event.Feed
+package grpcapi
+
+import (
+	"context"
+	"sync"
+
+	eventv1 "event-service/api/event/v1"
+	"event-service/pkg/auth"
+	"event-service/pkg/event"
+	"event-service/pkg/problem"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errForbidden — у клиента нет права на операцию или на тип события
+var errForbidden = withDetails(status.New(codes.PermissionDenied, "недостаточно прав"), "forbidden", nil)
+
+// errShuttingDown завершает открытые потоки Watch при остановке сервера
+var errShuttingDown = withDetails(status.New(codes.Unavailable, "сервер останавливается"), "shutting_down", nil)
+
+// Server реализует eventv1.EventServiceServer поверх event.EventService
+type Server struct {
+	eventv1.UnimplementedEventServiceServer
+
+	service *event.EventService
+	feed    *event.Feed
+	// maxLimit — наибольшее значение limit в List, как api.max_page_size в HTTP API
+	maxLimit int
+
+	// closing закрывается методом Drain и завершает потоки Watch
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServer создаёт gRPC-сервер событий
+// feed должен быть подключён к service как наблюдатель — из него Watch берёт изменения
+func NewServer(service *event.EventService, feed *event.Feed, maxLimit int) *Server {
+	return &Server{service: service, feed: feed, maxLimit: maxLimit, closing: make(chan struct{})}
+}
+
+// Register регистрирует сервис на gRPC-сервере
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	eventv1.RegisterEventServiceServer(registrar, s)
+}
+
+// Drain завершает открытые потоки Watch со статусом Unavailable
+// Без этого grpc.Server.GracefulStop ждал бы бесконечные потоки до таймаута остановки
+func (s *Server) Drain() {
+	s.closeOnce.Do(func() { close(s.closing) })
+}
+
+// Start запускает событие; повторяет POST /v1/start
+func (s *Server) Start(ctx context.Context, req *eventv1.StartRequest) (*eventv1.Event, error) {
+	if req.GetType() == "" {
+		return nil, statusFor(ctx, requiredField("type"))
+	}
+	if err := s.service.ValidateType(req.GetType()); err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	// Ключ может быть ограничен определёнными типами событий
+	if !auth.Allowed(ctx, auth.ScopeStart, req.GetType()) {
+		return nil, errForbidden
+	}
+
+	var attributes map[string]interface{}
+	if req.GetAttributes() != nil {
+		attributes = req.GetAttributes().AsMap()
+	}
+
+	params := event.StartParams{Type: req.GetType(), Attributes: attributes, StartedBy: auth.Subject(ctx)}
+	if req.GetParentId() != "" {
+		parentID, err := primitive.ObjectIDFromHex(req.GetParentId())
+		if err != nil {
+			return nil, statusFor(ctx, &event.FieldsError{Err: event.ErrInvalidRequest, Fields: []event.FieldError{problem.Field("parent_id", "expected.event_id")}})
+		}
+		params.ParentID = &parentID
+	}
+
+	started, err := s.service.Start(ctx, params)
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	return s.respond(ctx, started)
+}
+
+// Finish завершает активное событие; повторяет POST /v1/finish
+func (s *Server) Finish(ctx context.Context, req *eventv1.FinishRequest) (*eventv1.Event, error) {
+	if req.GetType() == "" {
+		return nil, statusFor(ctx, requiredField("type"))
+	}
+	if err := s.service.ValidateType(req.GetType()); err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	if !auth.Allowed(ctx, auth.ScopeFinish, req.GetType()) {
+		return nil, errForbidden
+	}
+
+	// Атрибуты сервис проверяет вместе с уже сохранёнными у события
+	var attributes map[string]interface{}
+	if len(req.GetAttributes().GetFields()) > 0 {
+		attributes = req.GetAttributes().AsMap()
+	}
+
+	finished, err := s.service.Finish(ctx, event.FinishParams{
+		Type:       req.GetType(),
+		Attributes: attributes,
+		Cascade:    req.GetCascade(),
+		FinishedBy: auth.Subject(ctx),
+	})
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	return s.respond(ctx, finished)
+}
+
+// List возвращает события, новые первыми; повторяет GET /v1
+func (s *Server) List(ctx context.Context, req *eventv1.ListRequest) (*eventv1.ListResponse, error) {
+	if req.GetOffset() < 0 {
+		return nil, statusFor(ctx, invalidParameter("offset", "expected.non_negative"))
+	}
+	if req.GetLimit() < 0 || int(req.GetLimit()) > s.maxLimit {
+		return nil, statusFor(ctx, invalidParameter("limit", "expected.range", 0, s.maxLimit))
+	}
+	if err := require(ctx, auth.ScopeRead); err != nil {
+		return nil, err
+	}
+	if req.GetType() != "" && !auth.Allowed(ctx, auth.ScopeRead, req.GetType()) {
+		return nil, errForbidden
+	}
+
+	// Клиент с ограниченным ключом видит только события разрешённых типов
+	events, err := s.service.Find(ctx, event.ListFilter{
+		Offset:       int(req.GetOffset()),
+		Limit:        int(req.GetLimit()),
+		Type:         req.GetType(),
+		TypePatterns: auth.AllowedTypes(ctx),
+	})
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+
+	resp := &eventv1.ListResponse{Events: make([]*eventv1.Event, len(events))}
+	for i := range events {
+		msg, err := toProto(&events[i])
+		if err != nil {
+			return nil, statusFor(ctx, err)
+		}
+		resp.Events[i] = msg
+	}
+	return resp, nil
+}
+
+// Get возвращает событие по идентификатору
+func (s *Server) Get(ctx context.Context, req *eventv1.GetRequest) (*eventv1.Event, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, statusFor(ctx, invalidParameter("id", "expected.event_id"))
+	}
+	if err := require(ctx, auth.ScopeRead); err != nil {
+		return nil, err
+	}
+
+	found, err := s.service.Get(ctx, id)
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	// Событие чужого типа для ограниченного ключа выглядит как несуществующее
+	if !auth.Allowed(ctx, auth.ScopeRead, found.Type) {
+		return nil, statusFor(ctx, event.ErrEventNotFound)
+	}
+	return s.respond(ctx, found)
+}
+
+// Watch передаёт изменения событий арендатора, пока клиент не закроет поток
+// Отстающий клиент отключается со статусом ResourceExhausted и должен подписаться заново
+func (s *Server) Watch(req *eventv1.WatchRequest, stream grpc.ServerStreamingServer[eventv1.EventChange]) error {
+	ctx := stream.Context()
+	if err := require(ctx, auth.ScopeRead); err != nil {
+		return err
+	}
+	if req.GetType() != "" {
+		if err := s.service.ValidateType(req.GetType()); err != nil {
+			return statusFor(ctx, err)
+		}
+		if !auth.Allowed(ctx, auth.ScopeRead, req.GetType()) {
+			return errForbidden
+		}
+	}
+
+	subscription := s.feed.Subscribe(ctx, func(eventType string) bool {
+		return (req.GetType() == "" || eventType == req.GetType()) && auth.Allowed(ctx, auth.ScopeRead, eventType)
+	})
+	defer subscription.Close()
+
+	// Заголовки отправляются сразу: клиент узнаёт, что подписка оформлена, ещё до первого изменения
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return statusFor(ctx, ctx.Err())
+		case <-s.closing:
+			return errShuttingDown
+		case change, ok := <-subscription.Changes():
+			if !ok {
+				return statusFor(ctx, subscription.Err())
+			}
+			msg, err := toProto(&change.Event)
+			if err != nil {
+				return statusFor(ctx, err)
+			}
+			if err := stream.Send(&eventv1.EventChange{Kind: changeKindToProto(change.Kind), Event: msg}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// respond переводит событие в ответ
+func (s *Server) respond(ctx context.Context, e *event.Event) (*eventv1.Event, error) {
+	msg, err := toProto(e)
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	return msg, nil
+}
+
+// require проверяет право scope у клиента; без аутентификации разрешено всё
+func require(ctx context.Context, scope auth.Scope) error {
+	if p := auth.FromContext(ctx); p != nil && !p.HasScope(scope) {
+		return errForbidden
+	}
+	return nil
+}
+
+// requiredField — в запросе не заполнено обязательное поле name
+func requiredField(name string) error {
+	return &event.FieldsError{Err: event.ErrInvalidRequest, Fields: []event.FieldError{problem.Field(name, "field.required")}}
+}
+
+// invalidParameter — некорректное значение поля name; key — сообщение из каталога i18n
+func invalidParameter(name, key string, args ...any) error {
+	return &event.FieldsError{Err: event.ErrInvalidParameter, Fields: []event.FieldError{problem.Field(name, key, args...)}}
+}
@@ -0,0 +1,567 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	eventv1 "event-service/api/event/v1"
+	"event-service/pkg/auth"
+	"event-service/pkg/event"
+	"event-service/pkg/ratelimit"
+	"event-service/pkg/tenant"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// memoryRepository хранит события в памяти — gRPC-сервер проверяется без MongoDB
+// Запуск и завершение, как и в EventRepository, видят только события арендатора из контекста
+type memoryRepository struct {
+	mu     sync.Mutex
+	events []event.Event
+	// err, если задан, возвращается из Create
+	err error
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		if r.events[i].ID == id {
+			e := r.events[i]
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]event.Event, error) {
+	var events []event.Event
+	for _, id := range ids {
+		if e, _ := r.FindByID(ctx, id); e != nil {
+			events = append(events, *e)
+		}
+	}
+	return events, nil
+}
+
+func (r *memoryRepository) FindChildren(ctx context.Context, parentIDs []primitive.ObjectID) ([]event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var children []event.Event
+	for _, e := range r.events {
+		for _, id := range parentIDs {
+			if e.ParentID != nil && *e.ParentID == id {
+				children = append(children, e)
+			}
+		}
+	}
+	return children, nil
+}
+
+func (r *memoryRepository) FindActive(ctx context.Context, eventType string) (*event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		e := r.events[i]
+		if e.TenantID == tenant.ID(ctx) && e.Type == eventType && e.State == event.Active {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) Create(ctx context.Context, e *event.Event) (*event.Event, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.ID = primitive.NewObjectID()
+	e.TenantID = tenant.ID(ctx)
+	e.State = event.Active
+	e.StartedAt = time.Now().UTC().Truncate(time.Millisecond)
+	r.events = append(r.events, *e)
+	return e, nil
+}
+
+func (r *memoryRepository) Finish(ctx context.Context, params event.FinishParams) (*event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		e := &r.events[i]
+		if e.TenantID != tenant.ID(ctx) || e.Type != params.Type || e.State != event.Active {
+			continue
+		}
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		e.State, e.FinishedAt, e.FinishedBy = event.Finished, &now, params.FinishedBy
+		for key, value := range params.Attributes {
+			if e.Attributes == nil {
+				e.Attributes = map[string]interface{}{}
+			}
+			e.Attributes[key] = value
+		}
+		finished := *e
+		return &finished, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryRepository) FinishByIDs(ctx context.Context, ids []primitive.ObjectID, finishedAt time.Time, finishedBy string) ([]primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var finished []primitive.ObjectID
+	for i := range r.events {
+		for _, id := range ids {
+			if r.events[i].ID == id && r.events[i].State == event.Active {
+				r.events[i].State, r.events[i].FinishedAt, r.events[i].FinishedBy = event.Finished, &finishedAt, finishedBy
+				finished = append(finished, id)
+			}
+		}
+	}
+	return finished, nil
+}
+
+func (r *memoryRepository) List(ctx context.Context, offset int, limit int, eventType string) ([]event.Event, error) {
+	return r.Find(ctx, event.ListFilter{Offset: offset, Limit: limit, Type: eventType})
+}
+
+func (r *memoryRepository) Find(ctx context.Context, filter event.ListFilter) ([]event.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := []event.Event{}
+	for _, e := range r.events {
+		if filter.Type == "" || e.Type == filter.Type {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartedAt.After(events[j].StartedAt) })
+	if filter.Offset >= len(events) {
+		return []event.Event{}, nil
+	}
+	events = events[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(events) {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+func (r *memoryRepository) InsertMany(ctx context.Context, events []event.Event) error {
+	return errors.New("не поддерживается")
+}
+
+func (r *memoryRepository) Stats(ctx context.Context, filter event.StatsFilter) ([]event.TypeStats, error) {
+	return nil, errors.New("не поддерживается")
+}
+
+func (r *memoryRepository) UpdateAttributes(ctx context.Context, id primitive.ObjectID, version int64, attributes map[string]interface{}) (*event.Event, error) {
+	return nil, errors.New("не поддерживается")
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id primitive.ObjectID, version int64) error {
+	return errors.New("не поддерживается")
+}
+
+// tokens — аутентификатор с заранее известными токенами
+type tokens map[string]*auth.Principal
+
+func (t tokens) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if p, ok := t[token]; ok {
+		return p, nil
+	}
+	return nil, auth.ErrInvalidCredentials
+}
+
+// testServer — gRPC-сервер на bufconn и клиент к нему
+type testServer struct {
+	client eventv1.EventServiceClient
+	server *Server
+	repo   *memoryRepository
+}
+
+// newTestServer поднимает сервер с interceptors поверх репозитория в памяти
+func newTestServer(t *testing.T, interceptors ...Interceptor) *testServer {
+	t.Helper()
+	repo := &memoryRepository{}
+	feed := event.NewFeed(0)
+	service := event.NewEventService(repo, nil)
+	service.SetObserver(feed)
+	server := NewServer(service, feed, event.DefaultMaxLimit)
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(ServerOptions(interceptors...)...)
+	server.Register(grpcServer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(func() {
+		server.Drain()
+		grpcServer.GracefulStop()
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testServer{client: eventv1.NewEventServiceClient(conn), server: server, repo: repo}
+}
+
+// withMetadata добавляет к контексту вызова метаданные kv (ключ, значение, ...)
+func withMetadata(kv ...string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), kv...)
+}
+
+// reasonOf возвращает код ошибки из errdetails.ErrorInfo и поля с ошибками из errdetails.BadRequest
+func reasonOf(err error) (reason string, fields []string) {
+	for _, detail := range status.Convert(err).Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			reason = d.GetReason()
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	return reason, fields
+}
+
+func TestServer_StartGetFinish(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	attributes, _ := structpb.NewStruct(map[string]interface{}{"room": "42", "seats": 8, "tags": []interface{}{"a"}})
+	started, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "meeting", Attributes: attributes})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if started.GetState() != eventv1.State_STATE_STARTED || started.GetFinishedAt() != nil {
+		t.Errorf("Expected an active event, got %v", started)
+	}
+	if got := started.GetAttributes().AsMap(); got["room"] != "42" || got["seats"] != 8.0 {
+		t.Errorf("Attributes did not survive the round trip: %v", got)
+	}
+
+	// Повторный запуск возвращает уже активное событие
+	again, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "meeting"})
+	if err != nil || again.GetId() != started.GetId() {
+		t.Errorf("Expected the active event %s, got %v, %v", started.GetId(), again, err)
+	}
+
+	child, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "qa", ParentId: started.GetId()})
+	if err != nil || child.GetParentId() != started.GetId() {
+		t.Fatalf("Expected a nested event, got %v, %v", child, err)
+	}
+
+	got, err := ts.client.Get(ctx, &eventv1.GetRequest{Id: started.GetId()})
+	if err != nil || got.GetType() != "meeting" {
+		t.Errorf("Get returned %v, %v", got, err)
+	}
+
+	finished, err := ts.client.Finish(ctx, &eventv1.FinishRequest{Type: "meeting", Cascade: true})
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if finished.GetState() != eventv1.State_STATE_FINISHED || finished.GetFinishedAt() == nil {
+		t.Errorf("Expected a finished event, got %v", finished)
+	}
+
+	list, err := ts.client.List(ctx, &eventv1.ListRequest{})
+	if err != nil || len(list.GetEvents()) != 2 {
+		t.Fatalf("Expected 2 events, got %v, %v", list, err)
+	}
+	for _, e := range list.GetEvents() {
+		if e.GetState() != eventv1.State_STATE_FINISHED {
+			t.Errorf("Cascade should finish %s", e.GetType())
+		}
+	}
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	parent, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "meeting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.client.Finish(ctx, &eventv1.FinishRequest{Type: "meeting"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		call   func() error
+		code   codes.Code
+		reason string
+		field  string
+	}{
+		{"missing type", func() error { _, err := ts.client.Start(ctx, &eventv1.StartRequest{}); return err },
+			codes.InvalidArgument, "invalid_request", "type"},
+		{"bad type", func() error { _, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "Bad Type"}); return err },
+			codes.InvalidArgument, "invalid_type_name", ""},
+		{"bad parent id", func() error {
+			_, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "qa", ParentId: "42"})
+			return err
+		}, codes.InvalidArgument, "invalid_request", "parent_id"},
+		{"missing parent", func() error {
+			_, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "qa", ParentId: primitive.NewObjectID().Hex()})
+			return err
+		}, codes.NotFound, "parent_not_found", ""},
+		{"finished parent", func() error {
+			_, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "qa", ParentId: parent.GetId()})
+			return err
+		}, codes.FailedPrecondition, "parent_not_active", ""},
+		{"nothing to finish", func() error { _, err := ts.client.Finish(ctx, &eventv1.FinishRequest{Type: "call"}); return err },
+			codes.NotFound, "event_not_found", ""},
+		{"bad finish attribute", func() error {
+			// Имена атрибутов проверяет сервис — до поиска события, поэтому это не 404
+			attributes, _ := structpb.NewStruct(map[string]interface{}{"$room": "42"})
+			_, err := ts.client.Finish(ctx, &eventv1.FinishRequest{Type: "call", Attributes: attributes})
+			return err
+		}, codes.InvalidArgument, "invalid_attributes", ""},
+		{"unknown id", func() error {
+			_, err := ts.client.Get(ctx, &eventv1.GetRequest{Id: primitive.NewObjectID().Hex()})
+			return err
+		}, codes.NotFound, "event_not_found", ""},
+		{"malformed id", func() error { _, err := ts.client.Get(ctx, &eventv1.GetRequest{Id: "42"}); return err },
+			codes.InvalidArgument, "invalid_parameter", "id"},
+		{"limit too large", func() error { _, err := ts.client.List(ctx, &eventv1.ListRequest{Limit: 1000}); return err },
+			codes.InvalidArgument, "invalid_parameter", "limit"},
+		{"storage failure", func() error {
+			ts.repo.err = errors.New("нет соединения")
+			defer func() { ts.repo.err = nil }()
+			_, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "call"})
+			return err
+		}, codes.Internal, "internal_error", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("Expected %s, got %s: %v", tt.code, code, err)
+			}
+			reason, fields := reasonOf(err)
+			if reason != tt.reason {
+				t.Errorf("Expected reason %s, got %q", tt.reason, reason)
+			}
+			if tt.field != "" && (len(fields) == 0 || fields[0] != tt.field) {
+				t.Errorf("Expected a violation of %s, got %v", tt.field, fields)
+			}
+		})
+	}
+}
+
+func TestServer_AuthAndTenant(t *testing.T) {
+	ts := newTestServer(t,
+		Authenticate(tokens{
+			"writer":    {Subject: "writer", Scopes: []auth.Scope{auth.ScopeStart, auth.ScopeRead}},
+			"reader":    {Subject: "reader", Scopes: []auth.Scope{auth.ScopeRead}},
+			"billing":   {Subject: "billing", Scopes: []auth.Scope{auth.ScopeRead}, Types: []string{"billing.*"}},
+			"acme-only": {Subject: "acme", Tenant: "acme", Scopes: []auth.Scope{auth.ScopeRead}},
+		}),
+		Tenant("X-Tenant-ID", false),
+	)
+
+	started, err := ts.client.Start(withMetadata("authorization", "Bearer writer"), &eventv1.StartRequest{Type: "meeting"})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if started.GetStartedBy() != "writer" {
+		t.Errorf("Expected startedBy from the token, got %q", started.GetStartedBy())
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		call func(ctx context.Context) error
+		code codes.Code
+	}{
+		{"no token", context.Background(), func(ctx context.Context) error {
+			_, err := ts.client.List(ctx, &eventv1.ListRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"unknown token", withMetadata("x-api-key", "nope"), func(ctx context.Context) error {
+			_, err := ts.client.List(ctx, &eventv1.ListRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"api key", withMetadata("x-api-key", "reader"), func(ctx context.Context) error {
+			_, err := ts.client.List(ctx, &eventv1.ListRequest{})
+			return err
+		}, codes.OK},
+		{"missing scope", withMetadata("authorization", "Bearer reader"), func(ctx context.Context) error {
+			_, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "meeting"})
+			return err
+		}, codes.PermissionDenied},
+		{"type outside the key", withMetadata("authorization", "Bearer billing"), func(ctx context.Context) error {
+			_, err := ts.client.Get(ctx, &eventv1.GetRequest{Id: started.GetId()})
+			return err
+		}, codes.NotFound},
+		{"foreign tenant", withMetadata("authorization", "Bearer acme-only", "x-tenant-id", "globex"), func(ctx context.Context) error {
+			_, err := ts.client.List(ctx, &eventv1.ListRequest{})
+			return err
+		}, codes.PermissionDenied},
+		{"invalid tenant", withMetadata("authorization", "Bearer reader", "x-tenant-id", "Not Valid"), func(ctx context.Context) error {
+			_, err := ts.client.List(ctx, &eventv1.ListRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"watch without token", context.Background(), func(ctx context.Context) error {
+			stream, err := ts.client.Watch(ctx, &eventv1.WatchRequest{})
+			if err != nil {
+				return err
+			}
+			_, err = stream.Recv()
+			return err
+		}, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call(tt.ctx)); code != tt.code {
+				t.Errorf("Expected %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestServer_Watch(t *testing.T) {
+	ts := newTestServer(t, Tenant("X-Tenant-ID", false))
+	acme := withMetadata("x-tenant-id", "acme")
+
+	ctx, cancel := context.WithTimeout(acme, 5*time.Second)
+	defer cancel()
+	stream, err := ts.client.Watch(ctx, &eventv1.WatchRequest{Type: "meeting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Заголовки приходят после оформления подписки — дальше изменения не теряются
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Изменения другого арендатора и другого типа не попадают в поток
+	if _, err := ts.client.Start(withMetadata("x-tenant-id", "globex"), &eventv1.StartRequest{Type: "meeting"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.client.Start(acme, &eventv1.StartRequest{Type: "call"}); err != nil {
+		t.Fatal(err)
+	}
+	started, err := ts.client.Start(acme, &eventv1.StartRequest{Type: "meeting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.client.Finish(acme, &eventv1.FinishRequest{Type: "meeting"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []eventv1.EventChange_Kind{eventv1.EventChange_KIND_STARTED, eventv1.EventChange_KIND_FINISHED} {
+		change, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if change.GetKind() != want || change.GetEvent().GetId() != started.GetId() {
+			t.Errorf("Expected %s of %s, got %v", want, started.GetId(), change)
+		}
+	}
+
+	// При остановке сервера поток завершается, а не держит остановку до таймаута
+	ts.server.Drain()
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable after Drain, got %v", err)
+	}
+}
+
+func TestServer_RequestID(t *testing.T) {
+	ts := newTestServer(t)
+
+	var header metadata.MD
+	if _, err := ts.client.List(withMetadata("x-request-id", "req-42"), &eventv1.ListRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "req-42" {
+		t.Errorf("Expected the request ID to be echoed, got %v", got)
+	}
+
+	header = nil
+	if _, err := ts.client.List(context.Background(), &eventv1.ListRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || len(got[0]) != 32 {
+		t.Errorf("Expected a generated request ID, got %v", got)
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	ts := newTestServer(t,
+		Authenticate(tokens{
+			"first":  {Subject: "first", KeyID: "first", Scopes: []auth.Scope{auth.ScopeStart, auth.ScopeRead}},
+			"second": {Subject: "second", KeyID: "second", Scopes: []auth.Scope{auth.ScopeStart, auth.ScopeRead}},
+		}),
+		RateLimit(store, ratelimit.Rules{"POST /v1/start": {Rate: 0.001, Burst: 1}}),
+	)
+	first := withMetadata("x-api-key", "first")
+
+	if _, err := ts.client.Start(first, &eventv1.StartRequest{Type: "meeting"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	var header metadata.MD
+	_, err := ts.client.Start(first, &eventv1.StartRequest{Type: "meeting"}, grpc.Header(&header))
+	if reason, _ := reasonOf(err); status.Code(err) != codes.ResourceExhausted || reason != "rate_limited" {
+		t.Fatalf("Expected ResourceExhausted rate_limited, got %v", err)
+	}
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] == "0" {
+		t.Errorf("Expected retry-after metadata, got %v", got)
+	}
+
+	// Ведро своё у каждого клиента, а методы без правила не ограничены
+	if _, err := ts.client.Start(withMetadata("x-api-key", "second"), &eventv1.StartRequest{Type: "call"}); err != nil {
+		t.Errorf("Another client should not be limited: %v", err)
+	}
+	if _, err := ts.client.List(first, &eventv1.ListRequest{}); err != nil {
+		t.Errorf("List should not be limited: %v", err)
+	}
+
+	// Ведро то же, что у POST /v1/start в HTTP API
+	result, err := store.Take(context.Background(), ratelimit.Key("POST /v1/start", "key:first"), ratelimit.Limit{Rate: 0.001, Burst: 1}, time.Now())
+	if err != nil || result.Allowed {
+		t.Errorf("Expected the HTTP bucket to be used up, got %+v, %v", result, err)
+	}
+}
+
+func TestServer_Quota(t *testing.T) {
+	ts := newTestServer(t, Tenant("X-Tenant-ID", false))
+	ts.server.service.SetQuota(ratelimit.NewQuota(ratelimit.NewMemoryStore(), ratelimit.Quotas{ratelimit.DefaultRoute: 1}))
+	ctx := context.Background()
+
+	if _, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "meeting"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	// Повторный запуск возвращает активное событие и квоту не расходует
+	if _, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "meeting"}); err != nil {
+		t.Fatalf("Deduplicated start failed: %v", err)
+	}
+
+	_, err := ts.client.Start(ctx, &eventv1.StartRequest{Type: "call"})
+	if reason, _ := reasonOf(err); status.Code(err) != codes.ResourceExhausted || reason != "quota_exceeded" {
+		t.Fatalf("Expected ResourceExhausted quota_exceeded, got %v", err)
+	}
+
+	// Квота у каждого арендатора своя
+	if _, err := ts.client.Start(withMetadata("x-tenant-id", "acme"), &eventv1.StartRequest{Type: "call"}); err != nil {
+		t.Errorf("Another tenant should have its own quota: %v", err)
+	}
+}
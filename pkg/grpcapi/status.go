@@ -0,0 +1,78 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"event-service/pkg/event"
+	"event-service/pkg/ratelimit"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain — домен ошибок в errdetails.ErrorInfo
+const errorDomain = "event-service"
+
+// errorStatuses сопоставляет ошибки сервиса с кодами gRPC
+// reason передаётся в errdetails.ErrorInfo и для ошибок, которые есть в HTTP API, совпадает
+// с их кодом (поле code ответа problem+json), чтобы клиенты обоих API различали ошибки одинаково
+// Ошибки проверяются по порядку через errors.Is
+var errorStatuses = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{event.ErrInvalidRequest, codes.InvalidArgument, "invalid_request"},
+	{event.ErrInvalidParameter, codes.InvalidArgument, "invalid_parameter"},
+	{event.ErrInvalidTypeName, codes.InvalidArgument, "invalid_type_name"},
+	{event.ErrInvalidAttributes, codes.InvalidArgument, "invalid_attributes"},
+	{event.ErrUnknownEventType, codes.InvalidArgument, "unknown_event_type"},
+	{event.ErrEventNotFound, codes.NotFound, "event_not_found"},
+	{event.ErrParentNotFound, codes.NotFound, "parent_not_found"},
+	{event.ErrParentNotActive, codes.FailedPrecondition, "parent_not_active"},
+	{event.ErrVersionConflict, codes.Aborted, "version_conflict"},
+	{event.ErrSubscriberLagged, codes.ResourceExhausted, "subscriber_lagged"},
+	{ratelimit.ErrQuotaExceeded, codes.ResourceExhausted, "quota_exceeded"},
+}
+
+// statusFor превращает ошибку сервиса в статус gRPC
+// Неизвестная ошибка — сбой сервиса: она пишется в лог, а клиент получает Internal без подробностей
+func statusFor(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	for _, known := range errorStatuses {
+		if errors.Is(err, known.err) {
+			return withDetails(status.New(known.code, err.Error()), known.reason, event.FieldErrors(err))
+		}
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	slog.ErrorContext(ctx, "Сбой при выполнении gRPC-вызова", "error", err)
+	return withDetails(status.New(codes.Internal, "внутренняя ошибка сервиса"), "internal_error", nil)
+}
+
+// withDetails добавляет к статусу код ошибки и ошибки отдельных полей
+func withDetails(st *status.Status, reason string, fields []event.FieldError) error {
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}}
+	if len(fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+		for i, f := range fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Path, Description: f.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusResponse — ответ /livez и /readyz: статус и, если сервис не готов, причины
+type statusResponse struct {
+	Status string `json:"status"`
+	// Failed — компоненты, не прошедшие проверку, с описанием ошибки
+	Failed []string `json:"failed,omitempty"`
+}
+
+// Live отвечает 200, пока процесс обслуживает запросы (GET /livez)
+func (m *Monitor) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, statusResponse{Status: StatusUp})
+}
+
+// Ready отвечает 200, если все компоненты готовы, иначе 503 (GET /readyz)
+// После сигнала завершения сразу отвечает 503, не выполняя проверок
+func (m *Monitor) Ready(c *gin.Context) {
+	if m.ShuttingDown() {
+		c.JSON(http.StatusServiceUnavailable, statusResponse{Status: StatusShuttingDown})
+		return
+	}
+
+	report := m.Run(c.Request.Context())
+	if report.Ready() {
+		c.JSON(http.StatusOK, statusResponse{Status: report.Status})
+		return
+	}
+
+	var failed []string
+	for name, component := range report.Components {
+		if component.Status != StatusUp {
+			failed = append(failed, name+": "+component.Error)
+		}
+	}
+	sort.Strings(failed)
+	c.JSON(http.StatusServiceUnavailable, statusResponse{Status: report.Status, Failed: failed})
+}
+
+// Health возвращает подробный отчёт по всем компонентам (GET /health)
+// Код ответа как у /readyz: 200, если сервис готов, иначе 503
+func (m *Monitor) Health(c *gin.Context) {
+	report := m.Run(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,170 @@
+// Package health проверяет состояние сервиса для оркестратора и мониторинга
+//
+// /livez отвечает, пока процесс жив и обслуживает запросы, и ничего не проверяет:
+// перезапуск процесса не поможет, если недоступна база данных.
+// /readyz проверяет зависимости (MongoDB, подготовку хранилищ, фоновые задачи) и отвечает 503,
+// пока хотя бы одна не готова или сервис останавливается — оркестратор перестаёт направлять запросы.
+// /health возвращает подробный отчёт по каждому компоненту вместе с временем проверки
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Статусы сервиса и компонентов в ответах
+const (
+	StatusUp           = "up"
+	StatusDown         = "down"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check проверяет один компонент и возвращает ошибку, если он не готов обслуживать запросы
+// Проверка должна завершаться при отмене ctx
+type Check func(ctx context.Context) error
+
+// Component — результат проверки одного компонента
+type Component struct {
+	Status string `json:"status"`
+	// LatencyMs — время проверки в миллисекундах
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report — состояние сервиса и всех его компонентов
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// Ready сообщает, готов ли сервис принимать запросы
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+// check — зарегистрированная проверка
+type check struct {
+	name string
+	run  Check
+}
+
+// Monitor хранит проверки компонентов и признак остановки сервиса
+type Monitor struct {
+	// timeout — сколько ждать каждую проверку
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+
+	shuttingDown atomic.Bool
+}
+
+// NewMonitor создаёт монитор; каждая проверка получает контекст с таймаутом timeout
+func NewMonitor(timeout time.Duration) *Monitor {
+	return &Monitor{timeout: timeout}
+}
+
+// Register добавляет проверку компонента name
+func (m *Monitor) Register(name string, run Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks = append(m.checks, check{name: name, run: run})
+}
+
+// SetShuttingDown снимает готовность: с этого момента /readyz отвечает 503,
+// хотя сервер ещё обслуживает запросы
+func (m *Monitor) SetShuttingDown() {
+	m.shuttingDown.Store(true)
+}
+
+// ShuttingDown сообщает, получен ли сигнал завершения
+func (m *Monitor) ShuttingDown() bool {
+	return m.shuttingDown.Load()
+}
+
+// Run выполняет все проверки параллельно и собирает отчёт
+// Сервис готов, если все компоненты в порядке и он не останавливается
+func (m *Monitor) Run(ctx context.Context) Report {
+	m.mu.RLock()
+	checks := append([]check(nil), m.checks...)
+	m.mu.RUnlock()
+
+	results := make([]Component, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = m.runCheck(ctx, c.run)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(checks))}
+	for i, c := range checks {
+		report.Components[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	if m.ShuttingDown() {
+		report.Status = StatusShuttingDown
+	}
+	return report
+}
+
+// runCheck выполняет одну проверку с таймаутом и замеряет её время
+func (m *Monitor) runCheck(ctx context.Context, run Check) Component {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := run(ctx)
+	result := Component{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// MongoCheck проверяет доступность MongoDB запросом ping к основному узлу
+func MongoCheck(client *mongo.Client) Check {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	}
+}
+
+// Flag — компонент, который становится готовым один раз и навсегда,
+// например после создания индексов при запуске
+type Flag struct {
+	done    atomic.Bool
+	pending error
+}
+
+// NewFlag создаёт неготовый компонент; до вызова Set проверка возвращает ошибку с текстом pending
+func NewFlag(pending string) *Flag {
+	return &Flag{pending: errors.New(pending)}
+}
+
+// Set отмечает компонент готовым
+func (f *Flag) Set() {
+	f.done.Store(true)
+}
+
+// Check — проверка компонента для Register
+func (f *Flag) Check(ctx context.Context) error {
+	if !f.done.Load() {
+		return f.pending
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func up(ctx context.Context) error { return nil }
+
+func down(ctx context.Context) error { return errors.New("нет соединения") }
+
+// hang ждёт отмены контекста — как зависшая зависимость
+func hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// setupHealthRouter создаёт роутер с эндпоинтами монитора
+func setupHealthRouter(m *Monitor) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/livez", m.Live)
+	router.GET("/readyz", m.Ready)
+	router.GET("/health", m.Health)
+	return router
+}
+
+func doRequest(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestMonitor_Run(t *testing.T) {
+	m := NewMonitor(50 * time.Millisecond)
+	m.Register("mongo", up)
+	m.Register("workers", up)
+
+	if report := m.Run(context.Background()); !report.Ready() || len(report.Components) != 2 {
+		t.Fatalf("Expected all components up, got %+v", report)
+	}
+
+	m.Register("slow", hang)
+	m.Register("broken", down)
+	started := time.Now()
+	report := m.Run(context.Background())
+	if report.Ready() || report.Status != StatusDown {
+		t.Errorf("Expected status down, got %+v", report)
+	}
+	if time.Since(started) > time.Second {
+		t.Error("Checks should be limited by the timeout")
+	}
+	if c := report.Components["slow"]; c.Status != StatusDown || c.Error == "" || c.LatencyMs < 50 {
+		t.Errorf("Hanging check should time out: %+v", c)
+	}
+	if c := report.Components["broken"]; c.Error != "нет соединения" {
+		t.Errorf("Check error should be reported: %+v", c)
+	}
+	if c := report.Components["mongo"]; c.Status != StatusUp {
+		t.Errorf("Healthy component should stay up: %+v", c)
+	}
+}
+
+func TestFlag(t *testing.T) {
+	f := NewFlag("индексы ещё не созданы")
+	if err := f.Check(context.Background()); err == nil || err.Error() != "индексы ещё не созданы" {
+		t.Errorf("Expected pending error, got %v", err)
+	}
+	f.Set()
+	if err := f.Check(context.Background()); err != nil {
+		t.Errorf("Expected nil after Set, got %v", err)
+	}
+}
+
+func TestMongoCheck_Unreachable(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+
+	if err := MongoCheck(client)(context.Background()); err == nil {
+		t.Error("Expected error for unreachable MongoDB")
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	flag := NewFlag("индексы ещё не созданы")
+	m := NewMonitor(time.Second)
+	m.Register("mongo", up)
+	m.Register("migrations", flag.Check)
+	router := setupHealthRouter(m)
+
+	if w := doRequest(router, "/livez"); w.Code != http.StatusOK {
+		t.Errorf("/livez should always be 200, got %d", w.Code)
+	}
+
+	// Пока хранилища не подготовлены, сервис не готов, но жив
+	w := doRequest(router, "/readyz")
+	var ready statusResponse
+	json.Unmarshal(w.Body.Bytes(), &ready)
+	if w.Code != http.StatusServiceUnavailable || len(ready.Failed) != 1 || ready.Failed[0] != "migrations: индексы ещё не созданы" {
+		t.Errorf("Expected 503 naming the failed component, got %d %s", w.Code, w.Body.String())
+	}
+
+	flag.Set()
+	if w := doRequest(router, "/readyz"); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 when all components are up, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(router, "/health")
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || report.Status != StatusUp || report.Components["mongo"].Status != StatusUp {
+		t.Errorf("Unexpected /health response: %d %s", w.Code, w.Body.String())
+	}
+
+	// После сигнала завершения готовность снимается, а процесс остаётся живым
+	m.SetShuttingDown()
+	w = doRequest(router, "/readyz")
+	json.Unmarshal(w.Body.Bytes(), &ready)
+	if w.Code != http.StatusServiceUnavailable || ready.Status != StatusShuttingDown {
+		t.Errorf("Expected 503 shutting_down, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(router, "/health"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("/health should report shutdown, got %d", w.Code)
+	}
+	if w := doRequest(router, "/livez"); w.Code != http.StatusOK {
+		t.Errorf("/livez should stay 200 during shutdown, got %d", w.Code)
+	}
+}
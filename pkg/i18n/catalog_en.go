@@ -0,0 +1,72 @@
+package i18n
+
+// en — английский каталог; ключи те же, что в ru
+var en = map[string]string{
+	// Заголовки ошибок API, ключ — код ошибки
+	"invalid_request":         "Malformed request body",
+	"invalid_parameter":       "Invalid query parameter",
+	"invalid_type_name":       "Event type does not match the naming rule",
+	"invalid_attributes":      "Event attributes do not match the type schema",
+	"invalid_event_type":      "Invalid event type definition",
+	"unknown_event_type":      "Event type is not registered",
+	"event_type_not_found":    "Event type not found",
+	"event_type_exists":       "Event type is already registered",
+	"event_not_found":         "Event not found",
+	"parent_not_found":        "Parent event not found",
+	"parent_not_active":       "Parent event is already finished",
+	"precondition_failed":     "Event has changed since it was read",
+	"event_has_children":      "Event has nested events",
+	"version_conflict":        "Event was modified by a concurrent request, retry the request",
+	"archive_not_found":       "Archive not found",
+	"start_failed":            "Failed to start the event",
+	"finish_failed":           "Failed to finish the event",
+	"tree_failed":             "Failed to load the event tree",
+	"get_failed":              "Failed to load the event",
+	"update_failed":           "Failed to update the event",
+	"delete_failed":           "Failed to delete the event",
+	"list_failed":             "Failed to list events",
+	"import_failed":           "Failed to import events",
+	"type_list_failed":        "Failed to list event types",
+	"type_get_failed":         "Failed to load the event type",
+	"type_create_failed":      "Failed to register the event type",
+	"type_update_failed":      "Failed to update the event type",
+	"type_delete_failed":      "Failed to delete the event type",
+	"archive_list_failed":     "Failed to list archives",
+	"archive_find_failed":     "Failed to get archived events",
+	"archive_restore_failed":  "Failed to restore the archive",
+	"authentication_required": "Authentication required",
+	"invalid_credentials":     "Invalid credentials",
+	"authentication_failed":   "Failed to verify credentials",
+	"forbidden":               "Insufficient permissions for this operation",
+	"invalid_key_request":     "Invalid key parameters",
+	"key_not_found":           "Active key not found",
+	"key_list_failed":         "Failed to list keys",
+	"key_create_failed":       "Failed to create the key",
+	"key_rotate_failed":       "Failed to rotate the key",
+	"key_revoke_failed":       "Failed to revoke the key",
+	"audit_list_failed":       "Failed to load the audit log",
+	"audit_verify_failed":     "Failed to verify the audit log",
+	"tenant_forbidden":        "No access to the requested tenant's data",
+	"tenant_required":         "Tenant is not specified",
+	"invalid_tenant":          "Invalid tenant identifier",
+	"rate_limited":            "Too many requests, try again later",
+	"quota_exceeded":          "Daily event quota exceeded",
+	"route_not_found":         "Route not found",
+	"internal_error":          "Internal server error",
+
+	// Сообщения об ошибках в отдельных полях и параметрах
+	"field.required":          "required field is missing or empty",
+	"field.missing":           "required field is missing",
+	"attribute.invalid_name":  "attribute name must not be empty, contain '.' or start with '$'",
+	"attribute.not_allowed":   "attribute is not allowed for this event type",
+	"attribute.type_mismatch": "expected type %s, got %s",
+	"expected.event_id":       "expected an event identifier",
+	"expected.key_id":         "expected a key identifier",
+	"expected.non_negative":   "expected a non-negative number",
+	"expected.range":          "expected a number from %d to %d",
+	"expected.import_format":  "expected ndjson or csv",
+	"expected.boolean":        "expected true or false",
+	"expected.timestamp":      "expected an RFC 3339 timestamp",
+	"expected.archive_name":   "expected an archive name like 20060102T150405Z",
+	"header.required":         "Header %s is required",
+}
@@ -0,0 +1,72 @@
+package i18n
+
+// ru — исходный каталог: все ключи сообщений сервиса и их тексты на русском
+var ru = map[string]string{
+	// Заголовки ошибок API, ключ — код ошибки
+	"invalid_request":         "Некорректное тело запроса",
+	"invalid_parameter":       "Некорректный параметр запроса",
+	"invalid_type_name":       "Тип события не соответствует правилу именования",
+	"invalid_attributes":      "Атрибуты события не соответствуют схеме типа",
+	"invalid_event_type":      "Некорректное описание типа события",
+	"unknown_event_type":      "Тип события не зарегистрирован",
+	"event_type_not_found":    "Тип события не найден",
+	"event_type_exists":       "Тип события уже зарегистрирован",
+	"event_not_found":         "Событие не найдено",
+	"parent_not_found":        "Родительское событие не найдено",
+	"parent_not_active":       "Родительское событие уже завершено",
+	"precondition_failed":     "Событие изменилось с момента чтения",
+	"event_has_children":      "У события есть вложенные события",
+	"version_conflict":        "Событие изменено одновременно с другим запросом, повторите запрос",
+	"archive_not_found":       "Архив не найден",
+	"start_failed":            "Не удалось создать событие",
+	"finish_failed":           "Не удалось завершить событие",
+	"tree_failed":             "Не удалось получить дерево событий",
+	"get_failed":              "Не удалось получить событие",
+	"update_failed":           "Не удалось изменить событие",
+	"delete_failed":           "Не удалось удалить событие",
+	"list_failed":             "Не удалось получить список событий",
+	"import_failed":           "Не удалось импортировать события",
+	"type_list_failed":        "Не удалось получить список типов событий",
+	"type_get_failed":         "Не удалось получить тип события",
+	"type_create_failed":      "Не удалось зарегистрировать тип события",
+	"type_update_failed":      "Не удалось изменить тип события",
+	"type_delete_failed":      "Не удалось удалить тип события",
+	"archive_list_failed":     "Не удалось получить список архивов",
+	"archive_find_failed":     "Не удалось получить архивные события",
+	"archive_restore_failed":  "Не удалось восстановить архив",
+	"authentication_required": "Требуется аутентификация",
+	"invalid_credentials":     "Недействительные учётные данные",
+	"authentication_failed":   "Не удалось проверить учётные данные",
+	"forbidden":               "Недостаточно прав для этой операции",
+	"invalid_key_request":     "Некорректные параметры ключа",
+	"key_not_found":           "Действующий ключ не найден",
+	"key_list_failed":         "Не удалось получить список ключей",
+	"key_create_failed":       "Не удалось создать ключ",
+	"key_rotate_failed":       "Не удалось перевыпустить ключ",
+	"key_revoke_failed":       "Не удалось отозвать ключ",
+	"audit_list_failed":       "Не удалось получить журнал аудита",
+	"audit_verify_failed":     "Не удалось проверить журнал аудита",
+	"tenant_forbidden":        "Нет доступа к данным указанного арендатора",
+	"tenant_required":         "Не указан арендатор",
+	"invalid_tenant":          "Некорректный идентификатор арендатора",
+	"rate_limited":            "Слишком много запросов, повторите позже",
+	"quota_exceeded":          "Исчерпана суточная квота на создание событий",
+	"route_not_found":         "Маршрут не найден",
+	"internal_error":          "Внутренняя ошибка сервера",
+
+	// Сообщения об ошибках в отдельных полях и параметрах
+	"field.required":          "обязательное поле отсутствует или пусто",
+	"field.missing":           "обязательное поле отсутствует",
+	"attribute.invalid_name":  "имя атрибута не может быть пустым, содержать '.' или начинаться с '$'",
+	"attribute.not_allowed":   "атрибут не разрешён для этого типа события",
+	"attribute.type_mismatch": "ожидался тип %s, получен %s",
+	"expected.event_id":       "ожидается идентификатор события",
+	"expected.key_id":         "ожидается идентификатор ключа",
+	"expected.non_negative":   "ожидается неотрицательное число",
+	"expected.range":          "ожидается число от %d до %d",
+	"expected.import_format":  "ожидается ndjson или csv",
+	"expected.boolean":        "ожидается true или false",
+	"expected.timestamp":      "ожидается время в формате RFC 3339",
+	"expected.archive_name":   "ожидается имя архива вида 20060102T150405Z",
+	"header.required":         "Заголовок %s обязателен",
+}
@@ -0,0 +1,117 @@
+// Package i18n выбирает язык ответов по заголовку Accept-Language и переводит тексты ответов
+//
+// Тексты хранятся в каталогах, по одному на язык. Ключ сообщения стабилен и не зависит от языка:
+// для заголовков ошибок ключ — код ошибки (например, "event_not_found"), для сообщений о полях —
+// ключ вида "expected.event_id". Исходный язык — русский; остальные каталоги содержат те же ключи
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Поддерживаемые языки
+const (
+	Russian = "ru"
+	English = "en"
+)
+
+// catalogs — каталоги сообщений по языкам
+var catalogs = map[string]map[string]string{
+	Russian: ru,
+	English: en,
+}
+
+// contextKey — ключ языка запроса в context.Context
+type contextKey struct{}
+
+// Languages возвращает поддерживаемые языки по алфавиту
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// ValidateLanguage проверяет, что для языка lang есть каталог
+func ValidateLanguage(lang string) error {
+	if _, ok := catalogs[lang]; !ok {
+		return fmt.Errorf("неизвестный язык %q, поддерживаются: %s", lang, strings.Join(Languages(), ", "))
+	}
+	return nil
+}
+
+// Has сообщает, есть ли в каталоге языка lang сообщение с ключом key
+func Has(lang, key string) bool {
+	_, ok := catalogs[lang][key]
+	return ok
+}
+
+// Text возвращает сообщение key на языке lang; args подставляются в него как в fmt.Sprintf
+// Если в каталоге lang сообщения нет, берётся русское, а если нет и его — сам ключ
+func Text(lang, key string, args ...any) string {
+	text, ok := catalogs[lang][key]
+	if !ok {
+		text, ok = ru[key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Negotiate выбирает язык по значению заголовка Accept-Language: первый поддерживаемый
+// в порядке предпочтения клиента (q), сравнивая только основной язык (en-US подходит для en)
+// Если заголовка нет, он некорректен или ни один язык не поддерживается, возвращается fallback
+func Negotiate(acceptLanguage, fallback string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return fallback
+	}
+	for _, tag := range tags {
+		base, confidence := tag.Base()
+		if confidence != language.Exact {
+			continue
+		}
+		if _, ok := catalogs[base.String()]; ok {
+			return base.String()
+		}
+	}
+	return fallback
+}
+
+// WithLanguage возвращает контекст с языком ответа lang
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// FromContext возвращает язык ответа из контекста; если он не выбран — русский
+func FromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(contextKey{}).(string); ok {
+		return lang
+	}
+	return Russian
+}
+
+// Middleware выбирает язык ответа по заголовку Accept-Language и кладёт его в контекст запроса
+// fallback — язык для клиентов, которые не указали ни одного поддерживаемого; пусто — русский
+func Middleware(fallback string) gin.HandlerFunc {
+	if fallback == "" {
+		fallback = Russian
+	}
+	return func(c *gin.Context) {
+		lang := Negotiate(c.GetHeader("Accept-Language"), fallback)
+		c.Request = c.Request.WithContext(WithLanguage(c.Request.Context(), lang))
+		c.Next()
+	}
+}
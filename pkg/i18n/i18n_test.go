@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// verbs находит подстановки fmt в сообщении
+var verbs = regexp.MustCompile(`%[a-z]`)
+
+// TestCatalogsComplete проверяет, что каждый ключ переведён на каждый язык с теми же подстановками
+func TestCatalogsComplete(t *testing.T) {
+	for _, lang := range Languages() {
+		catalog := catalogs[lang]
+		for key, source := range ru {
+			text, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing translation for %q", lang, key)
+				continue
+			}
+			if got, want := verbs.FindAllString(text, -1), verbs.FindAllString(source, -1); len(got) != len(want) {
+				t.Errorf("%s: %q has verbs %v, source has %v", lang, key, got, want)
+			}
+		}
+		for key := range catalog {
+			if _, ok := ru[key]; !ok {
+				t.Errorf("%s: key %q is not in the source catalog", lang, key)
+			}
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header, fallback, want string
+	}{
+		{"", Russian, Russian},
+		{"", English, English},
+		{"en-US,en;q=0.9,ru;q=0.8", Russian, English},
+		{"ru-RU", English, Russian},
+		{"de, en;q=0.5", Russian, English},
+		{"ru;q=0, en", Russian, English},
+		{"de, fr", English, English},
+		{"*", English, English},
+		{"не язык;;", English, English},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header, tt.fallback); got != tt.want {
+			t.Errorf("Negotiate(%q, %q) = %q, want %q", tt.header, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	if got := Text(English, "expected.range", 0, 100); got != "expected a number from 0 to 100" {
+		t.Errorf("Unexpected English text: %q", got)
+	}
+	if got := Text("de", "event_not_found"); got != ru["event_not_found"] {
+		t.Errorf("Unknown language should fall back to Russian, got %q", got)
+	}
+	if got := Text(English, "no.such.key"); got != "no.such.key" {
+		t.Errorf("Unknown key should be returned as is, got %q", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(English))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, FromContext(c.Request.Context()))
+	})
+
+	for header, want := range map[string]string{"": English, "ru": Russian, "fr": English} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("Accept-Language", header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Errorf("Accept-Language %q: expected %s, got %s", header, want, w.Body.String())
+		}
+	}
+
+	if lang := FromContext(context.Background()); lang != Russian {
+		t.Errorf("Expected Russian without middleware, got %s", lang)
+	}
+}
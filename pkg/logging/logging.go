@@ -0,0 +1,118 @@
+// Package logging настраивает структурированные логи сервиса (log/slog) и идентификаторы запросов
+//
+// Каждая строка лога — отдельный JSON-объект (или key=value в формате text), поэтому логи можно
+// разбирать и фильтровать по полям. Записи, сделанные с контекстом запроса (slog.InfoContext и т.п.),
+// автоматически получают поле request_id, а при включённой трассировке — ещё и trace_id и span_id:
+// по ним все строки одного запроса находятся вместе, от обработчика до репозитория
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Форматы логов
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// ParseLevel разбирает уровень логов: debug, info, warn или error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("неизвестный уровень логов %q (ожидается debug, info, warn или error)", s)
+	}
+}
+
+// ValidateFormat проверяет название формата логов
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatText:
+		return nil
+	default:
+		return fmt.Errorf("неизвестный формат логов %q (ожидается json или text)", format)
+	}
+}
+
+// Options — настройки логов
+type Options struct {
+	// Level — минимальный уровень записей: debug, info, warn или error
+	Level string
+	// Format — json (по умолчанию) или text
+	Format string
+	// Output — куда писать логи (nil — os.Stderr)
+	Output io.Writer
+}
+
+// New создаёт логгер по настройкам
+func New(opts Options) (*slog.Logger, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch opts.Format {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	case FormatText:
+		handler = slog.NewTextHandler(out, handlerOpts)
+	default:
+		return nil, ValidateFormat(opts.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Setup создаёт логгер и делает его логгером по умолчанию
+// Стандартный пакет log после этого тоже пишет через него (с уровнем info)
+func Setup(opts Options) error {
+	logger, err := New(opts)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// contextHandler добавляет к записи поля запроса из контекста: request_id, trace_id и span_id
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
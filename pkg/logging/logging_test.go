@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// decodeLines разбирает вывод JSON-логгера на записи
+func decodeLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Log line is not JSON: %q", line)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestParseLevel(t *testing.T) {
+	for input, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		if got, err := ParseLevel(input); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; expected %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
+
+func TestNew_LevelAndFormat(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(Options{Level: "warn", Format: FormatJSON, Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("скрыто")
+	logger.Warn("видно", "key", "value")
+
+	records := decodeLines(t, &out)
+	if len(records) != 1 {
+		t.Fatalf("Expected only the warn record, got %d", len(records))
+	}
+	if records[0]["msg"] != "видно" || records[0]["level"] != "WARN" || records[0]["key"] != "value" {
+		t.Errorf("Unexpected record: %v", records[0])
+	}
+
+	out.Reset()
+	logger, err = New(Options{Level: "info", Format: FormatText, Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("текст", "key", "value")
+	if !strings.Contains(out.String(), "key=value") {
+		t.Errorf("Expected key=value in text output, got %q", out.String())
+	}
+
+	if _, err := New(Options{Level: "info", Format: "xml"}); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestNew_ContextFields(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(Options{Level: "debug", Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	// Поля контекста добавляются и в логгер с собственными атрибутами
+	logger.With("component", "test").DebugContext(ctx, "с контекстом")
+	logger.Debug("без контекста")
+
+	records := decodeLines(t, &out)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0]["request_id"] != "req-1" || records[0]["trace_id"] != traceID.String() ||
+		records[0]["span_id"] != spanID.String() || records[0]["component"] != "test" {
+		t.Errorf("Expected request and trace IDs in record: %v", records[0])
+	}
+	if _, ok := records[1]["request_id"]; ok {
+		t.Errorf("Record without context should not have request_id: %v", records[1])
+	}
+}
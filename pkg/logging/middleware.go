@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"event-service/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// internalError повторяет формат ошибок API (RFC 7807, см. пакет problem) для ответа на панику
+// Пакет problem сам пишет в лог через этот пакет, поэтому формат не импортируется, а повторяется;
+// заголовок, как и у остальных ошибок, переводится на язык запроса (см. i18n.Middleware)
+type internalError struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// RequestIDMiddleware присваивает каждому запросу идентификатор и кладёт его в контекст запроса
+// Идентификатор берётся из заголовка X-Request-ID, если клиент или балансировщик его передал,
+// иначе создаётся новый; в любом случае он возвращается в заголовке X-Request-ID ответа
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !ValidRequestID(id) {
+			id = NewRequestID()
+		}
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// AccessLog пишет строку лога о каждом выполненном запросе: метод, путь, маршрут, статус и длительность
+// Ответы 5xx пишутся с уровнем error, остальные — info
+// Запросы к quiet (например, /livez и /metrics, которые опрашиваются постоянно) пишутся только с уровнем debug
+// client_ip — c.ClientIP(): X-Forwarded-For учитывается только от прокси,
+// которым доверяет роутер (gin.Engine.SetTrustedProxies)
+func AccessLog(quiet ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(quiet))
+	for _, path := range quiet {
+		skip[path] = true
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case skip[c.Request.URL.Path]:
+			level = slog.LevelDebug
+		}
+		slog.LogAttrs(c.Request.Context(), level, "HTTP-запрос",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// Recovery перехватывает панику в обработчике: пишет её в лог со стеком вызовов
+// и отвечает клиенту 500 с идентификатором запроса, по которому запись можно найти
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if recovered == http.ErrAbortHandler {
+					// Намеренный обрыв ответа — http.Server обработает его сам
+					panic(recovered)
+				}
+				ctx := c.Request.Context()
+				slog.ErrorContext(ctx, "Паника при обработке запроса",
+					"panic", recovered,
+					"stack", string(debug.Stack()),
+				)
+				lang := i18n.FromContext(ctx)
+				c.Header("Content-Type", "application/problem+json")
+				c.Header("Content-Language", lang)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, internalError{
+					Type:      "urn:event-service:problem:internal_error",
+					Title:     i18n.Text(lang, "internal_error"),
+					Status:    http.StatusInternalServerError,
+					Instance:  c.Request.URL.Path,
+					Code:      "internal_error",
+					RequestID: RequestID(ctx),
+				})
+			}
+		}()
+		c.Next()
+	}
+}
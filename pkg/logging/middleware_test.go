@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// captureDefault подменяет логгер по умолчанию на пишущий в буфер
+func captureDefault(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+	var out bytes.Buffer
+	logger, err := New(Options{Level: level, Output: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &out
+}
+
+func newRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), AccessLog("/livez"), Recovery())
+	r.GET("/id", func(c *gin.Context) {
+		c.String(http.StatusOK, RequestID(c.Request.Context()))
+	})
+	r.GET("/livez", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/panic", func(c *gin.Context) { panic("сбой") })
+	return r
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	router := newRouter()
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"propagated", "3f2c9a7e-1b4d-4c8a-9e21-7d5b0c6f8a13", true},
+		{"generated", "", false},
+		{"with spaces", "a b", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"control characters", "abc\x01", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/id", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if id == "" || w.Body.String() != id {
+				t.Fatalf("Context and header should carry the same ID: header %q, context %q", id, w.Body.String())
+			}
+			if tt.keep && id != tt.incoming {
+				t.Errorf("Expected incoming ID %q to be kept, got %q", tt.incoming, id)
+			}
+			if !tt.keep && (id == tt.incoming || len(id) != 32) {
+				t.Errorf("Expected a new 32-char ID, got %q", id)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	out := captureDefault(t, "info")
+	router := newRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	// Проверки состояния пишутся только на уровне debug
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	records := decodeLines(t, out)
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 access log record, got %d: %s", len(records), out.String())
+	}
+	record := records[0]
+	if record["request_id"] != "req-42" || record["route"] != "/id" || record["status"] != float64(200) || record["method"] != "GET" {
+		t.Errorf("Unexpected access log record: %v", record)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	out := captureDefault(t, "info")
+	router := newRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(RequestIDHeader, "req-panic")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", w.Code)
+	}
+	var body internalError
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.RequestID != "req-panic" || body.Code != "internal_error" {
+		t.Errorf("Expected internal_error with requestId in error body, got %s", w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected problem+json content type, got %q", ct)
+	}
+
+	records := decodeLines(t, out)
+	if len(records) != 2 || records[0]["panic"] != "сбой" || records[0]["level"] != "ERROR" || records[0]["request_id"] != "req-panic" {
+		t.Fatalf("Expected panic and access log records, got %s", out.String())
+	}
+	if records[1]["status"] != float64(500) || records[1]["level"] != "ERROR" {
+		t.Errorf("Access log should record 500 as error: %v", records[1])
+	}
+}
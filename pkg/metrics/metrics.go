@@ -0,0 +1,133 @@
+// Package metrics собирает метрики Prometheus и отдаёт их на /metrics
+//
+// HTTP-запросы считаются в middleware, время запросов к MongoDB — в обёртке над репозиторием событий,
+// запуски и завершения событий — через наблюдателя сервиса (event.Observer).
+// Число активных событий не хранится в процессе, а считается в базе при каждом сборе метрик:
+// так оно верно после перезапуска и одинаково на всех репликах
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"event-service/pkg/event"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics — все метрики сервиса в собственном реестре
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	eventsStarted      *prometheus.CounterVec
+	eventsFinished     *prometheus.CounterVec
+	eventsDeduplicated *prometheus.CounterVec
+
+	mongoDuration *prometheus.HistogramVec
+}
+
+// New создаёт метрики и регистрирует их вместе со стандартными метриками Go и процесса
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Число HTTP-запросов по методу, маршруту и коду ответа",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Время обработки HTTP-запросов по методу, маршруту и коду ответа",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		eventsStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "events_started_total",
+			Help: "Число запущенных событий по типу",
+		}, []string{"type"}),
+		eventsFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "events_finished_total",
+			Help: "Число завершённых событий по типу, включая каскадное завершение потомков",
+		}, []string{"type"}),
+		eventsDeduplicated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "events_deduplicated_total",
+			Help: "Число запросов на запуск, вернувших уже активное событие, по типу",
+		}, []string{"type"}),
+		mongoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mongo_operation_duration_seconds",
+			Help:    "Время операций репозитория событий с MongoDB по операции и результату",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "result"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.eventsStarted, m.eventsFinished, m.eventsDeduplicated,
+		m.mongoDuration,
+	)
+	return m
+}
+
+// Handler отдаёт метрики в формате Prometheus (GET /metrics)
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// EventStarted считает запуск события или, если вернулось уже активное, повторный запрос (event.Observer)
+func (m *Metrics) EventStarted(ctx context.Context, e *event.Event, deduplicated bool) {
+	if deduplicated {
+		m.eventsDeduplicated.WithLabelValues(e.Type).Inc()
+		return
+	}
+	m.eventsStarted.WithLabelValues(e.Type).Inc()
+}
+
+// EventFinished считает завершение события (event.Observer)
+func (m *Metrics) EventFinished(ctx context.Context, e *event.Event) {
+	m.eventsFinished.WithLabelValues(e.Type).Inc()
+}
+
+// CountActiveFunc считает активные события по типам (см. event.EventRepository.CountActive)
+type CountActiveFunc func(ctx context.Context) (map[string]int64, error)
+
+// RegisterActiveEvents добавляет gauge events_active: при каждом сборе метрик
+// активные события считаются функцией count не дольше timeout
+func (m *Metrics) RegisterActiveEvents(count CountActiveFunc, timeout time.Duration) error {
+	return m.registry.Register(&activeCollector{
+		count:   count,
+		timeout: timeout,
+		desc:    prometheus.NewDesc("events_active", "Число активных событий по типу", []string{"type"}, nil),
+	})
+}
+
+// activeCollector выдаёт число активных событий, посчитанное в момент сбора метрик
+type activeCollector struct {
+	count   CountActiveFunc
+	timeout time.Duration
+	desc    *prometheus.Desc
+}
+
+func (c *activeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *activeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		// Без данных метрика пропадает из ответа — это заметнее, чем устаревшее значение
+		slog.Warn("Не удалось посчитать активные события для метрик", "error", err)
+		return
+	}
+	for eventType, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), eventType)
+	}
+}
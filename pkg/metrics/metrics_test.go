@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"event-service/pkg/event"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// scrape возвращает текст ответа /metrics
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+	return string(body)
+}
+
+func TestObserver(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+	meeting := &event.Event{Type: "meeting"}
+	call := &event.Event{Type: "call"}
+
+	m.EventStarted(ctx, meeting, false)
+	m.EventStarted(ctx, meeting, true)
+	m.EventStarted(ctx, meeting, true)
+	m.EventStarted(ctx, call, false)
+	m.EventFinished(ctx, meeting)
+
+	if v := testutil.ToFloat64(m.eventsStarted.WithLabelValues("meeting")); v != 1 {
+		t.Errorf("events_started_total{type=meeting} = %v, expected 1", v)
+	}
+	if v := testutil.ToFloat64(m.eventsDeduplicated.WithLabelValues("meeting")); v != 2 {
+		t.Errorf("events_deduplicated_total{type=meeting} = %v, expected 2", v)
+	}
+	if v := testutil.ToFloat64(m.eventsStarted.WithLabelValues("call")); v != 1 {
+		t.Errorf("events_started_total{type=call} = %v, expected 1", v)
+	}
+	if v := testutil.ToFloat64(m.eventsFinished.WithLabelValues("meeting")); v != 1 {
+		t.Errorf("events_finished_total{type=meeting} = %v, expected 1", v)
+	}
+
+	body := scrape(t, m)
+	for _, line := range []string{
+		`events_started_total{type="meeting"} 1`,
+		`events_deduplicated_total{type="meeting"} 2`,
+		`events_finished_total{type="meeting"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected %q in /metrics output", line)
+		}
+	}
+}
+
+func TestActiveEvents(t *testing.T) {
+	m := New()
+	counts := map[string]int64{"meeting": 2, "call": 1}
+	var fail bool
+	err := m.RegisterActiveEvents(func(ctx context.Context) (map[string]int64, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Count should be limited by the timeout")
+		}
+		if fail {
+			return nil, errors.New("нет соединения")
+		}
+		return counts, nil
+	}, time.Second)
+	if err != nil {
+		t.Fatalf("RegisterActiveEvents failed: %v", err)
+	}
+
+	body := scrape(t, m)
+	if !strings.Contains(body, `events_active{type="meeting"} 2`) || !strings.Contains(body, `events_active{type="call"} 1`) {
+		t.Errorf("Expected active gauges in /metrics output:\n%s", body)
+	}
+
+	// Значение считается заново при каждом сборе
+	counts["meeting"] = 0
+	if body := scrape(t, m); !strings.Contains(body, `events_active{type="meeting"} 0`) {
+		t.Error("Active gauge should reflect the current count")
+	}
+
+	// При ошибке метрика пропадает, остальные метрики отдаются
+	fail = true
+	if body := scrape(t, m); strings.Contains(body, "events_active{") || !strings.Contains(body, "go_goroutines") {
+		t.Error("Active gauge should be omitted when counting fails")
+	}
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute — метка маршрута для запросов, не попавших ни в один маршрут (404)
+// Путь запроса в метку не попадает: иначе каждый случайный адрес создавал бы новую серию
+const unmatchedRoute = "unmatched"
+
+// Middleware считает HTTP-запросы и время их обработки по методу, шаблону маршрута и коду ответа
+// Подключается первым, чтобы учитывать и запросы, отклонённые аутентификацией или лимитами
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(started).Seconds())
+	}
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := New()
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/v1/events/:id/tree", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/v1/start", func(c *gin.Context) { c.Status(http.StatusTooManyRequests) })
+
+	do := func(method, path string) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	}
+	do(http.MethodGet, "/v1/events/1/tree")
+	do(http.MethodGet, "/v1/events/2/tree")
+	do(http.MethodPost, "/v1/start")
+	do(http.MethodGet, "/no/such/path")
+
+	tests := []struct {
+		method, route, status string
+		want                  float64
+	}{
+		// Запросы группируются по шаблону маршрута, а не по пути
+		{http.MethodGet, "/v1/events/:id/tree", "200", 2},
+		{http.MethodPost, "/v1/start", "429", 1},
+		{http.MethodGet, unmatchedRoute, "404", 1},
+	}
+	for _, tt := range tests {
+		if v := testutil.ToFloat64(m.httpRequests.WithLabelValues(tt.method, tt.route, tt.status)); v != tt.want {
+			t.Errorf("http_requests_total{%s %s %s} = %v, expected %v", tt.method, tt.route, tt.status, v, tt.want)
+		}
+	}
+	if n := testutil.CollectAndCount(m.httpDuration); n != 3 {
+		t.Errorf("Expected 3 latency histograms, got %d", n)
+	}
+}
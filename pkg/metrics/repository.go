@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"event-service/pkg/event"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Repository оборачивает репозиторий событий и замеряет время каждой операции с MongoDB
+// Результат операции (ok или error) попадает в метку result
+type Repository struct {
+	next     event.Repository
+	duration *prometheus.HistogramVec
+}
+
+// WrapRepository возвращает репозиторий, который пишет время операций next в метрики
+func (m *Metrics) WrapRepository(next event.Repository) *Repository {
+	return &Repository{next: next, duration: m.mongoDuration}
+}
+
+// observe записывает время операции; вызывается через defer с указателем на возвращаемую ошибку
+func (r *Repository) observe(operation string, started time.Time, err *error) {
+	result := "ok"
+	if *err != nil {
+		result = "error"
+	}
+	r.duration.WithLabelValues(operation, result).Observe(time.Since(started).Seconds())
+}
+
+func (r *Repository) FindByID(ctx context.Context, id primitive.ObjectID) (e *event.Event, err error) {
+	defer r.observe("find_by_id", time.Now(), &err)
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *Repository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (events []event.Event, err error) {
+	defer r.observe("find_by_ids", time.Now(), &err)
+	return r.next.FindByIDs(ctx, ids)
+}
+
+func (r *Repository) FindChildren(ctx context.Context, parentIDs []primitive.ObjectID) (events []event.Event, err error) {
+	defer r.observe("find_children", time.Now(), &err)
+	return r.next.FindChildren(ctx, parentIDs)
+}
+
+func (r *Repository) FindActive(ctx context.Context, eventType string) (e *event.Event, err error) {
+	defer r.observe("find_active", time.Now(), &err)
+	return r.next.FindActive(ctx, eventType)
+}
+
+func (r *Repository) Create(ctx context.Context, e *event.Event) (created *event.Event, err error) {
+	defer r.observe("create", time.Now(), &err)
+	return r.next.Create(ctx, e)
+}
+
+func (r *Repository) Finish(ctx context.Context, params event.FinishParams) (e *event.Event, err error) {
+	defer r.observe("finish", time.Now(), &err)
+	return r.next.Finish(ctx, params)
+}
+
+func (r *Repository) FinishByIDs(ctx context.Context, ids []primitive.ObjectID, finishedAt time.Time, finishedBy string) (finished []primitive.ObjectID, err error) {
+	defer r.observe("finish_by_ids", time.Now(), &err)
+	return r.next.FinishByIDs(ctx, ids, finishedAt, finishedBy)
+}
+
+func (r *Repository) List(ctx context.Context, offset int, limit int, eventType string) (events []event.Event, err error) {
+	defer r.observe("list", time.Now(), &err)
+	return r.next.List(ctx, offset, limit, eventType)
+}
+
+func (r *Repository) Find(ctx context.Context, filter event.ListFilter) (events []event.Event, err error) {
+	defer r.observe("find", time.Now(), &err)
+	return r.next.Find(ctx, filter)
+}
+
+func (r *Repository) InsertMany(ctx context.Context, events []event.Event) (err error) {
+	defer r.observe("insert_many", time.Now(), &err)
+	return r.next.InsertMany(ctx, events)
+}
+
+func (r *Repository) Stats(ctx context.Context, filter event.StatsFilter) (stats []event.TypeStats, err error) {
+	defer r.observe("stats", time.Now(), &err)
+	return r.next.Stats(ctx, filter)
+}
+
+func (r *Repository) UpdateAttributes(ctx context.Context, id primitive.ObjectID, version int64, attributes map[string]interface{}) (e *event.Event, err error) {
+	defer r.observe("update_attributes", time.Now(), &err)
+	return r.next.UpdateAttributes(ctx, id, version, attributes)
+}
+
+func (r *Repository) Delete(ctx context.Context, id primitive.ObjectID, version int64) (err error) {
+	defer r.observe("delete", time.Now(), &err)
+	return r.next.Delete(ctx, id, version)
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"event-service/pkg/event"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stubRepository отвечает на FindActive и Create без базы данных
+// Остальные методы не реализованы: встроенный nil-интерфейс вызовет панику, если тест до них дойдёт
+type stubRepository struct {
+	event.Repository
+	err error
+}
+
+func (r *stubRepository) FindActive(ctx context.Context, eventType string) (*event.Event, error) {
+	return nil, r.err
+}
+
+func (r *stubRepository) Create(ctx context.Context, e *event.Event) (*event.Event, error) {
+	return e, r.err
+}
+
+func TestWrapRepository(t *testing.T) {
+	m := New()
+	stub := &stubRepository{}
+	service := event.NewEventService(m.WrapRepository(stub), nil)
+	service.SetObserver(m)
+
+	if _, err := service.Start(context.Background(), event.StartParams{Type: "meeting"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	stub.err = errors.New("нет соединения")
+	if _, err := service.Start(context.Background(), event.StartParams{Type: "meeting"}); err == nil {
+		t.Fatal("Expected error from repository")
+	}
+
+	// Каждая операция попадает в гистограмму со своим результатом
+	for _, tt := range []struct {
+		operation, result string
+		want              uint64
+	}{
+		{"find_active", "ok", 1},
+		{"create", "ok", 1},
+		{"find_active", "error", 1},
+		{"create", "error", 0},
+	} {
+		if got := histogramCount(t, m, tt.operation, tt.result); got != tt.want {
+			t.Errorf("mongo_operation_duration_seconds{%s,%s} count = %d, expected %d", tt.operation, tt.result, got, tt.want)
+		}
+	}
+
+	// Наблюдатель считает только успешный запуск
+	if v := testutil.ToFloat64(m.eventsStarted.WithLabelValues("meeting")); v != 1 {
+		t.Errorf("events_started_total{type=meeting} = %v, expected 1", v)
+	}
+}
+
+// histogramCount возвращает число наблюдений в гистограмме операции с MongoDB
+func histogramCount(t *testing.T, m *Metrics, operation, result string) uint64 {
+	t.Helper()
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "mongo_operation_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["operation"] == operation && labels["result"] == result {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
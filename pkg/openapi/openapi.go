@@ -0,0 +1,74 @@
+// Package openapi встраивает в сервис спецификацию OpenAPI 3 его HTTP API,
+// отдаёт её вместе со страницей Swagger UI и проверяет по ней запросы и ответы
+//
+// Спецификация — контракт API: файл openapi.yaml лежит рядом с кодом и встраивается в бинарник,
+// поэтому сервис всегда отдаёт описание именно той версии API, которую реализует
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+// SpecPath и DocsPath — пути, по которым отдаются спецификация и Swagger UI
+const (
+	SpecPath = "/v1/openapi.yaml"
+	DocsPath = "/v1/docs"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Spec возвращает исходный текст спецификации (YAML)
+func Spec() []byte {
+	return spec
+}
+
+// Load разбирает встроенную спецификацию и проверяет, что она корректна
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать спецификацию OpenAPI: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("спецификация OpenAPI некорректна: %w", err)
+	}
+	return doc, nil
+}
+
+// SpecHandler отдаёт спецификацию (GET /v1/openapi.yaml)
+func SpecHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", spec)
+}
+
+// DocsHandler отдаёт страницу Swagger UI со встроенной спецификацией (GET /v1/docs)
+// Сам Swagger UI загружается браузером с CDN — в бинарник он не встраивается
+func DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+// docsPage — страница Swagger UI; спецификация берётся с SpecPath того же сервера
+const docsPage = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+  <meta charset="utf-8">
+  <title>Event Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: "` + SpecPath + `", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
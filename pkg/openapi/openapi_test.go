@@ -0,0 +1,149 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"event-service/pkg/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/v1", "/v1/start", "/v1/finish", "/v1/events/{id}", "/v1/events/{id}/tree", "/v1/archives", "/v1/archives/events"} {
+		if doc.Paths.Find(path) == nil {
+			t.Errorf("Expected path %s in the spec", path)
+		}
+	}
+}
+
+func TestSpecAndDocsHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(SpecPath, SpecHandler)
+	router.GET(DocsPath, DocsHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "openapi: 3.") {
+		t.Errorf("Expected the spec, got %d %.40q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, DocsPath, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `url: "`+SpecPath+`"`) {
+		t.Errorf("Expected Swagger UI pointing to the spec, got %d", w.Code)
+	}
+}
+
+// newValidatedRouter — роутер с проверкой в режиме mode; обработчики отвечают body со статусом status
+func newValidatedRouter(t *testing.T, mode string, status int, body string) (*gin.Engine, *[]error) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	validator, err := NewValidator(mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var drift []error
+	validator.OnResponseError(func(c *gin.Context, err error) { drift = append(drift, err) })
+
+	respond := func(c *gin.Context) { c.Data(status, "application/json", []byte(body)) }
+	router := gin.New()
+	router.Use(validator.Middleware())
+	router.GET("/v1", respond)
+	router.POST("/v1/start", respond)
+	router.POST("/v1/import", respond)
+	router.GET("/livez", respond)
+	router.GET("/metrics", respond)
+	return router, &drift
+}
+
+func TestValidator_Requests(t *testing.T) {
+	router, _ := newValidatedRouter(t, ValidateRequests, http.StatusOK, `[]`)
+
+	tests := []struct {
+		name, method, path, contentType, body string
+		status                                int
+		code, field                           string
+	}{
+		{"missing type", http.MethodPost, "/v1/start", "application/json", `{}`, http.StatusBadRequest, "invalid_request", "/type"},
+		{"wrong type", http.MethodPost, "/v1/start", "application/json", `{"type":5}`, http.StatusBadRequest, "invalid_request", "/type"},
+		{"bad parent", http.MethodPost, "/v1/start", "application/json", `{"type":"meeting","parentId":"42"}`, http.StatusBadRequest, "invalid_request", "/parentId"},
+		{"valid start", http.MethodPost, "/v1/start", "application/json", `{"type":"meeting"}`, http.StatusOK, "", ""},
+		{"negative limit", http.MethodGet, "/v1?limit=-1", "", ``, http.StatusBadRequest, "invalid_parameter", "limit"},
+		{"not a number", http.MethodGet, "/v1?offset=x", "", ``, http.StatusBadRequest, "invalid_parameter", "offset"},
+		{"import stream is not parsed", http.MethodPost, "/v1/import", "application/x-ndjson", "{\"type\":\"a\"}\nnot json\n", http.StatusOK, "", ""},
+		{"path outside the spec", http.MethodGet, "/metrics", "", ``, http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.code == "" {
+				return
+			}
+			var p problem.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("Response is not a problem document: %s", w.Body.String())
+			}
+			if p.Code != tt.code || len(p.Errors) == 0 || p.Errors[0].Path != tt.field {
+				t.Errorf("Expected %s with field error for %s, got %+v", tt.code, tt.field, p)
+			}
+		})
+	}
+}
+
+func TestValidator_Responses(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+		drift  bool
+	}{
+		{"empty list", "/v1", http.StatusOK, `[]`, false},
+		{"null instead of list", "/v1", http.StatusOK, `null`, true},
+		{"event without state", "/v1", http.StatusOK, `[{"id":"6650c2f1e4b0a1b2c3d4e5f6","type":"a","startedAt":"2024-01-01T00:00:00Z"}]`, true},
+		{"undocumented status", "/livez", http.StatusTeapot, `{"status":"up"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, drift := newValidatedRouter(t, ValidateAll, tt.status, tt.body)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("Response should reach the client unchanged, got %d %s", w.Code, w.Body.String())
+			}
+			if (len(*drift) > 0) != tt.drift {
+				t.Errorf("Expected drift %v, got %v", tt.drift, *drift)
+			}
+		})
+	}
+}
+
+func TestValidateMode(t *testing.T) {
+	for _, mode := range []string{ValidateNone, ValidateRequests, ValidateAll} {
+		if err := ValidateMode(mode); err != nil {
+			t.Errorf("Mode %s should be valid: %v", mode, err)
+		}
+	}
+	if err := ValidateMode("strict"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
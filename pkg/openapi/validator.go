@@ -0,0 +1,241 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"event-service/pkg/problem"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+)
+
+// Режимы проверки по спецификации (api.validation)
+const (
+	// ValidateNone — ничего не проверять
+	ValidateNone = "none"
+	// ValidateRequests — отклонять запросы, не соответствующие спецификации
+	ValidateRequests = "requests"
+	// ValidateAll — проверять и запросы, и ответы; расхождение ответа со спецификацией пишется в лог
+	ValidateAll = "all"
+)
+
+// ValidateMode проверяет название режима проверки
+func ValidateMode(mode string) error {
+	switch mode {
+	case ValidateNone, ValidateRequests, ValidateAll:
+		return nil
+	default:
+		return fmt.Errorf("ожидается %s, %s или %s, получено %q", ValidateNone, ValidateRequests, ValidateAll, mode)
+	}
+}
+
+var (
+	problemInvalidRequest   = problem.New(http.StatusBadRequest, "invalid_request")
+	problemInvalidParameter = problem.New(http.StatusBadRequest, "invalid_parameter")
+)
+
+// Validator проверяет запросы и ответы по встроенной спецификации
+// Запросы к путям, которых нет в спецификации (например, /metrics), не проверяются
+type Validator struct {
+	router    routers.Router
+	responses bool
+	// onResponseError вызывается, если ответ не соответствует спецификации
+	onResponseError func(c *gin.Context, err error)
+}
+
+// NewValidator создаёт проверку в режиме mode: ValidateRequests или ValidateAll
+func NewValidator(mode string) (*Validator, error) {
+	if err := ValidateMode(mode); err != nil {
+		return nil, err
+	}
+	if mode == ValidateNone {
+		return nil, fmt.Errorf("режим %s не требует проверки", ValidateNone)
+	}
+	doc, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{router: router, responses: mode == ValidateAll, onResponseError: logResponseError}, nil
+}
+
+// OnResponseError заменяет реакцию на ответ, не соответствующий спецификации
+// По умолчанию расхождение пишется в лог; тесты могут превращать его в ошибку теста
+func (v *Validator) OnResponseError(fn func(c *gin.Context, err error)) {
+	v.onResponseError = fn
+}
+
+// logResponseError пишет расхождение ответа со спецификацией в лог
+func logResponseError(c *gin.Context, err error) {
+	slog.ErrorContext(c.Request.Context(), "Ответ не соответствует спецификации OpenAPI",
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+		"status", c.Writer.Status(),
+		"error", err,
+	)
+}
+
+// Middleware проверяет запрос до обработчика и, в режиме ValidateAll, ответ после него
+// Некорректный запрос получает 400 invalid_request (тело) или invalid_parameter (параметры)
+// со списком ошибок полей. Тело проверяется, только если операция принимает JSON, —
+// потоки NDJSON и CSV при импорте обработчик читает и проверяет сам
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, params, err := v.router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: params,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				ExcludeRequestBody: !acceptsJSON(route.Operation),
+				MultiError:         true,
+				// Аутентификацию и права проверяют middleware пакета auth
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			problem.Abort(c, requestProblem(err))
+			return
+		}
+
+		if !v.responses {
+			c.Next()
+			return
+		}
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if err := v.validateResponse(c, input, recorder.body.Bytes()); err != nil {
+			v.onResponseError(c, err)
+		}
+	}
+}
+
+// validateResponse проверяет уже отправленный ответ: статус, заголовки и тело
+func (v *Validator) validateResponse(c *gin.Context, request *openapi3filter.RequestValidationInput, body []byte) error {
+	return openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: request,
+		Status:                 c.Writer.Status(),
+		Header:                 c.Writer.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(body)),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			MultiError:            true,
+		},
+	})
+}
+
+// acceptsJSON сообщает, принимает ли операция тело в формате JSON
+func acceptsJSON(operation *openapi3.Operation) bool {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return false
+	}
+	return operation.RequestBody.Value.Content.Get("application/json") != nil
+}
+
+// requestProblem превращает ошибки проверки запроса в ответ API с ошибками отдельных полей
+func requestProblem(err error) problem.Problem {
+	var fields []problem.FieldError
+	p := problemInvalidRequest
+	for _, e := range flatten(err) {
+		var requestErr *openapi3filter.RequestError
+		if !errors.As(e, &requestErr) {
+			fields = append(fields, problem.FieldError{Path: "/", Message: e.Error()})
+			continue
+		}
+		if requestErr.Parameter != nil {
+			p = problemInvalidParameter
+			fields = append(fields, problem.FieldError{Path: requestErr.Parameter.Name, Message: reason(requestErr)})
+			continue
+		}
+		schemaErrs := schemaErrors(requestErr.Err)
+		if len(schemaErrs) == 0 {
+			fields = append(fields, problem.FieldError{Path: "/", Message: reason(requestErr)})
+		}
+		for _, schemaErr := range schemaErrs {
+			fields = append(fields, problem.FieldError{
+				Path:    "/" + strings.Join(schemaErr.JSONPointer(), "/"),
+				Message: schemaErr.Reason,
+			})
+		}
+	}
+	return p.WithErrors(fields...)
+}
+
+// reason — причина ошибки запроса без повторения имени параметра
+func reason(err *openapi3filter.RequestError) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err.Err, &schemaErr) {
+		return schemaErr.Reason
+	}
+	if err.Reason != "" {
+		return err.Reason
+	}
+	if err.Err != nil {
+		return err.Err.Error()
+	}
+	return err.Error()
+}
+
+// schemaErrors находит ошибки схемы внутри err
+func schemaErrors(err error) []*openapi3.SchemaError {
+	var found []*openapi3.SchemaError
+	for _, e := range flatten(err) {
+		var schemaErr *openapi3.SchemaError
+		if errors.As(e, &schemaErr) {
+			found = append(found, schemaErr)
+		}
+	}
+	return found
+}
+
+// flatten раскладывает openapi3.MultiError (в том числе вложенные) на отдельные ошибки
+// Обёртки вроде RequestError не раскрываются — они нужны, чтобы понять, к чему относится ошибка
+func flatten(err error) []error {
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		if err == nil {
+			return nil
+		}
+		return []error{err}
+	}
+	var all []error
+	for _, e := range multi {
+		all = append(all, flatten(e)...)
+	}
+	return all
+}
+
+// bodyRecorder пропускает ответ клиенту и сохраняет копию тела для проверки
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *bodyRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
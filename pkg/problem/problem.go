@@ -0,0 +1,185 @@
+// Package problem описывает ответы API об ошибках в формате RFC 7807 (application/problem+json)
+//
+// Каждая ошибка получает стабильный машинный код (code), по которому клиенты различают ошибки,
+// не разбирая текст: title — краткое описание вида ошибки, detail — подробности конкретного случая,
+// instance — путь запроса, errors — ошибки отдельных полей при проверке данных.
+// Тексты предназначены для людей и могут меняться, код — нет. Они берутся из каталогов пакета i18n
+// на языке, выбранном по Accept-Language: заголовок — по коду, сообщения полей и detail — по ключу сообщения
+package problem
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"event-service/pkg/i18n"
+	"event-service/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType — тип содержимого ответов об ошибках
+const ContentType = "application/problem+json"
+
+// typePrefix — начало URI вида ошибки (type); к нему добавляется код
+const typePrefix = "urn:event-service:problem:"
+
+// codes — коды всех описанных видов ошибок (см. Codes)
+var codes sync.Map
+
+// FieldError описывает ошибку в конкретном поле запроса
+type FieldError struct {
+	// Path — JSON Pointer на поле тела запроса (например, "/type") или имя параметра query (например, "limit")
+	Path string `json:"path"`
+	// Message — что именно не так
+	Message string `json:"message"`
+
+	// key и args — ключ сообщения в каталоге и его параметры; пустой key — сообщение не переводится
+	// (например, текст валидатора JSON Schema)
+	key  string
+	args []any
+}
+
+// Field описывает ошибку в поле path сообщением key из каталога i18n с параметрами args
+// Message заполняется по-русски (для логов и текста ошибок), в ответе оно переводится на язык клиента
+func Field(path, key string, args ...any) FieldError {
+	return FieldError{Path: path, Message: i18n.Text(i18n.Russian, key, args...), key: key, args: args}
+}
+
+// Problem — ответ об ошибке (RFC 7807) с расширениями code, errors, report и requestId
+type Problem struct {
+	// Type — URI вида ошибки, urn:event-service:problem:<code>
+	Type string `json:"type"`
+	// Title — краткое описание вида ошибки, одинаковое для всех ошибок с этим кодом
+	Title string `json:"title"`
+	// Status — HTTP-статус ответа
+	Status int `json:"status"`
+	// Detail — подробности конкретного случая
+	Detail string `json:"detail,omitempty"`
+	// Instance — путь запроса, на который получена ошибка
+	Instance string `json:"instance,omitempty"`
+	// Code — стабильный машинный код ошибки, например "invalid_type_name"
+	Code string `json:"code"`
+	// Errors — ошибки отдельных полей, если они есть
+	Errors []FieldError `json:"errors,omitempty"`
+	// Report — что операция успела сделать до ошибки, например отчёт прерванного импорта
+	Report any `json:"report,omitempty"`
+	// RequestID — идентификатор запроса (X-Request-ID), по которому его можно найти в логах
+	RequestID string `json:"requestId,omitempty"`
+
+	// detailKey и detailArgs — ключ сообщения для Detail (см. WithDetailKey)
+	detailKey  string
+	detailArgs []any
+}
+
+// invalidParameter — ошибка в параметре query (см. InvalidParameter)
+var invalidParameter = New(http.StatusBadRequest, "invalid_parameter")
+
+// New описывает вид ошибки: HTTP-статус и код; заголовок — сообщение с ключом code из каталога i18n
+func New(status int, code string) Problem {
+	codes.Store(code, struct{}{})
+	return Problem{Type: typePrefix + code, Title: i18n.Text(i18n.Russian, code), Status: status, Code: code}
+}
+
+// Codes возвращает по алфавиту коды всех видов ошибок, описанных через New
+// Для каждого кода в каждом каталоге i18n должен быть заголовок
+func Codes() []string {
+	var list []string
+	codes.Range(func(code, _ any) bool {
+		list = append(list, code.(string))
+		return true
+	})
+	sort.Strings(list)
+	return list
+}
+
+// WithDetail возвращает копию с подробностями конкретного случая
+func (p Problem) WithDetail(detail string) Problem {
+	p.Detail = detail
+	return p
+}
+
+// WithDetailKey — как WithDetail, но подробности — сообщение key из каталога i18n с параметрами args
+func (p Problem) WithDetailKey(key string, args ...any) Problem {
+	p.Detail = i18n.Text(i18n.Russian, key, args...)
+	p.detailKey, p.detailArgs = key, args
+	return p
+}
+
+// WithErrors возвращает копию с ошибками отдельных полей
+func (p Problem) WithErrors(errors ...FieldError) Problem {
+	p.Errors = errors
+	return p
+}
+
+// WithReport возвращает копию с отчётом о том, что операция успела сделать до ошибки
+func (p Problem) WithReport(report any) Problem {
+	p.Report = report
+	return p
+}
+
+// Localize возвращает копию с текстами на языке lang: заголовком по коду,
+// а также detail и сообщениями полей, если они заданы ключом сообщения
+func (p Problem) Localize(lang string) Problem {
+	p.Title = i18n.Text(lang, p.Code)
+	if p.detailKey != "" {
+		p.Detail = i18n.Text(lang, p.detailKey, p.detailArgs...)
+	}
+	if len(p.Errors) > 0 {
+		errors := make([]FieldError, len(p.Errors))
+		for i, field := range p.Errors {
+			if field.key != "" {
+				field.Message = i18n.Text(lang, field.key, field.args...)
+			}
+			errors[i] = field
+		}
+		p.Errors = errors
+	}
+	return p
+}
+
+// InvalidParameter — ошибка в параметре query name; сообщение key из каталога i18n объясняет,
+// какое значение ожидается
+func InvalidParameter(name, key string, args ...any) Problem {
+	return invalidParameter.WithErrors(Field(name, key, args...))
+}
+
+// Respond отвечает документом problem+json на языке запроса (см. i18n.Middleware);
+// instance и requestId заполняются из запроса
+func Respond(c *gin.Context, p Problem) {
+	ctx := c.Request.Context()
+	lang := i18n.FromContext(ctx)
+	p = p.Localize(lang)
+	p.Instance = c.Request.URL.Path
+	p.RequestID = logging.RequestID(ctx)
+	// render.JSON не меняет уже заданный Content-Type
+	c.Header("Content-Type", ContentType)
+	c.Header("Content-Language", lang)
+	c.JSON(p.Status, p)
+}
+
+// Abort — как Respond, но ещё и прерывает цепочку обработчиков (для middleware)
+func Abort(c *gin.Context, p Problem) {
+	c.Abort()
+	Respond(c, p)
+}
+
+// RetryAfter ставит заголовок Retry-After: через сколько целых секунд (не меньше 1) повторить запрос
+func RetryAfter(c *gin.Context, d time.Duration) {
+	seconds := int64(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+// Internal пишет err в лог и отвечает p (обычно со статусом 500)
+// Клиент видит только описание p и идентификатор запроса — подробности сбоя остаются в логе
+func Internal(c *gin.Context, err error, p Problem) {
+	slog.ErrorContext(c.Request.Context(), p.Title, "code", p.Code, "error", err)
+	Respond(c, p)
+}
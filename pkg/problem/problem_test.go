@@ -0,0 +1,115 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"event-service/pkg/i18n"
+	"event-service/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(t *testing.T, handler gin.HandlerFunc) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(logging.RequestIDMiddleware())
+	router.GET("/v1/events/:id/tree", handler, func(c *gin.Context) {
+		t.Error("Handler chain should be aborted")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/events/42/tree", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Response is not a problem document: %s", w.Body.String())
+	}
+	return w, p
+}
+
+func TestAbort(t *testing.T) {
+	w, p := serve(t, func(c *gin.Context) {
+		Abort(c, New(http.StatusNotFound, "event_not_found").WithDetail("нет события 42"))
+	})
+
+	if w.Code != http.StatusNotFound || p.Status != http.StatusNotFound {
+		t.Errorf("Expected status 404 in response and body, got %d and %d", w.Code, p.Status)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Expected Content-Type %s, got %q", ContentType, ct)
+	}
+	want := Problem{
+		Type:      "urn:event-service:problem:event_not_found",
+		Title:     "Событие не найдено",
+		Status:    http.StatusNotFound,
+		Detail:    "нет события 42",
+		Instance:  "/v1/events/42/tree",
+		Code:      "event_not_found",
+		RequestID: "req-1",
+	}
+	if p.Type != want.Type || p.Title != want.Title || p.Detail != want.Detail || p.Instance != want.Instance || p.Code != want.Code || p.RequestID != want.RequestID {
+		t.Errorf("Unexpected problem:\n got %+v\nwant %+v", p, want)
+	}
+}
+
+func TestInvalidParameter(t *testing.T) {
+	w, p := serve(t, func(c *gin.Context) {
+		Abort(c, InvalidParameter("limit", "expected.range", 0, 100))
+	})
+
+	if w.Code != http.StatusBadRequest || p.Code != "invalid_parameter" {
+		t.Errorf("Expected 400 invalid_parameter, got %d %s", w.Code, p.Code)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Path != "limit" || p.Errors[0].Message != "ожидается число от 0 до 100" {
+		t.Errorf("Expected field error for limit, got %+v", p)
+	}
+}
+
+func TestInternal_HidesError(t *testing.T) {
+	w, p := serve(t, func(c *gin.Context) {
+		c.Abort()
+		Internal(c, errors.New("секрет подключения"), New(http.StatusInternalServerError, "start_failed"))
+	})
+
+	if w.Code != http.StatusInternalServerError || p.Code != "start_failed" {
+		t.Errorf("Expected 500 start_failed, got %d %s", w.Code, p.Code)
+	}
+	if p.Detail != "" {
+		t.Errorf("Internal error details must not reach the client, got %q", p.Detail)
+	}
+}
+
+func TestRespond_Localized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(i18n.Middleware(i18n.Russian))
+	router.GET("/v1/events", func(c *gin.Context) {
+		Respond(c, InvalidParameter("limit", "expected.range", 0, 100).WithDetailKey("header.required", "X-Tenant-ID"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/events", nil)
+	req.Header.Set("Accept-Language", "en-GB,en;q=0.9")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Response is not a problem document: %s", w.Body.String())
+	}
+	if p.Code != "invalid_parameter" || p.Title != "Invalid query parameter" || p.Detail != "Header X-Tenant-ID is required" {
+		t.Errorf("Expected English title and detail with the same code, got %+v", p)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Message != "expected a number from 0 to 100" {
+		t.Errorf("Expected English field message, got %+v", p.Errors)
+	}
+	if lang := w.Header().Get("Content-Language"); lang != i18n.English {
+		t.Errorf("Expected Content-Language en, got %q", lang)
+	}
+}
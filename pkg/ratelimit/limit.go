@@ -0,0 +1,162 @@
+// Package ratelimit ограничивает частоту запросов клиентов и суточные квоты арендаторов
+// Частота ограничивается алгоритмом token bucket: у каждого клиента на каждом маршруте
+// своё «ведро» токенов, которое равномерно пополняется со скоростью Rate
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit — параметры token bucket
+type Limit struct {
+	// Rate — сколько токенов добавляется в ведро за секунду
+	Rate float64
+	// Burst — вместимость ведра: сколько запросов можно сделать подряд
+	Burst int
+}
+
+// ParseLimit разбирает лимит вида "10/s", "600/m" или "1000/h:50"
+// Число перед "/" — сколько запросов разрешено за период; после ":" — вместимость ведра
+// Без ":" вместимость равна числу запросов за период
+func ParseLimit(s string) (Limit, error) {
+	spec, burstSpec, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	count, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("лимит %q должен иметь вид <число>/<s|m|h>", s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("лимит %q: число запросов должно быть положительным", s)
+	}
+	var period time.Duration
+	switch strings.TrimSpace(unit) {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("лимит %q: период должен быть s, m или h", s)
+	}
+
+	limit := Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+	if hasBurst {
+		burst, err := strconv.Atoi(strings.TrimSpace(burstSpec))
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("лимит %q: вместимость должна быть положительной", s)
+		}
+		limit.Burst = burst
+	}
+	return limit, nil
+}
+
+// refill возвращает число токенов в ведре спустя elapsed после последнего обращения
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+}
+
+// fillTime возвращает время, за которое пустое ведро наполняется целиком
+// Ведро, к которому не обращались дольше, можно забыть: оно всё равно полное
+func (l Limit) fillTime() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// result формирует ответ по числу токенов после попытки взять один
+func (l Limit) result(tokens float64, allowed bool) Result {
+	r := Result{Allowed: allowed, Limit: l.Burst, Remaining: int(math.Floor(tokens))}
+	if !allowed {
+		// Ждать, пока в ведре наберётся целый токен
+		r.RetryAfter = time.Duration((1 - tokens) / l.Rate * float64(time.Second))
+	}
+	return r
+}
+
+// Result — итог попытки взять токен
+type Result struct {
+	// Allowed — запрос можно выполнить
+	Allowed bool
+	// Limit — вместимость ведра
+	Limit int
+	// Remaining — сколько запросов ещё можно сделать сразу
+	Remaining int
+	// RetryAfter — через сколько появится следующий токен (только если Allowed=false)
+	RetryAfter time.Duration
+}
+
+// DefaultRoute — ключ правила, действующего для маршрутов без своего лимита
+const DefaultRoute = "*"
+
+// Rules — лимиты по маршрутам
+// Ключ — метод и шаблон пути gin, например "POST /v1/start", или DefaultRoute
+type Rules map[string]Limit
+
+// ParseRules разбирает правила вида "*=100/m, POST /v1/start=10/s:20, GET /v1=30/m"
+func ParseRules(s string) (Rules, error) {
+	rules := Rules{}
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		route, spec, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("правило %q должно иметь вид <маршрут>=<лимит>", strings.TrimSpace(item))
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	return rules, nil
+}
+
+// For возвращает лимит для маршрута
+// Маршрут без своего правила использует DefaultRoute; если нет и его — ok=false
+func (r Rules) For(method, path string) (Limit, bool) {
+	if limit, ok := r[method+" "+path]; ok {
+		return limit, true
+	}
+	limit, ok := r[DefaultRoute]
+	return limit, ok
+}
+
+// Quotas — суточные квоты на создание событий по арендаторам
+// Ключ — идентификатор арендатора или DefaultRoute для всех остальных; 0 — без ограничения
+type Quotas map[string]int64
+
+// ParseQuotas разбирает квоты вида "*=10000, acme=50000, internal=0"
+func ParseQuotas(s string) (Quotas, error) {
+	quotas := Quotas{}
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		tenantID, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("квота %q должна иметь вид <арендатор>=<число>", strings.TrimSpace(item))
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("квота %q: ожидалось неотрицательное число", strings.TrimSpace(item))
+		}
+		quotas[strings.TrimSpace(tenantID)] = n
+	}
+	return quotas, nil
+}
+
+// For возвращает суточную квоту арендатора (0 — без ограничения)
+func (q Quotas) For(tenantID string) int64 {
+	if n, ok := q[tenantID]; ok {
+		return n
+	}
+	return q[DefaultRoute]
+}
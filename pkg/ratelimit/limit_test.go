@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := map[string]Limit{
+		"10/s":      {Rate: 10, Burst: 10},
+		"600/m":     {Rate: 10, Burst: 600},
+		"3600/h:5":  {Rate: 1, Burst: 5},
+		" 2 / s:1 ": {Rate: 2, Burst: 1},
+	}
+	for spec, want := range tests {
+		got, err := ParseLimit(spec)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", spec, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: expected %+v, got %+v", spec, want, got)
+		}
+	}
+
+	for _, spec := range []string{"", "10", "10/d", "0/s", "-1/s", "ten/s", "10/s:0", "10/s:x"} {
+		if _, err := ParseLimit(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("*=100/m, POST  /v1/start=10/s:20,GET /v1=30/m,")
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %v", rules)
+	}
+
+	if limit, ok := rules.For("POST", "/v1/start"); !ok || limit.Burst != 20 {
+		t.Errorf("Unexpected rule for start: %+v %v", limit, ok)
+	}
+	if limit, ok := rules.For("POST", "/v1/finish"); !ok || limit.Burst != 100 {
+		t.Errorf("Expected the default rule for finish: %+v %v", limit, ok)
+	}
+	if _, ok := (Rules{}).For("GET", "/v1"); ok {
+		t.Error("Empty rules should not limit anything")
+	}
+
+	for _, spec := range []string{"POST /v1/start", "*=fast"} {
+		if _, err := ParseRules(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestParseQuotas(t *testing.T) {
+	quotas, err := ParseQuotas("*=1000, acme=50000, internal=0")
+	if err != nil {
+		t.Fatalf("ParseQuotas failed: %v", err)
+	}
+	if quotas.For("acme") != 50000 || quotas.For("globex") != 1000 || quotas.For("internal") != 0 {
+		t.Errorf("Unexpected quotas: %v", quotas)
+	}
+	if (Quotas{}).For("acme") != 0 {
+		t.Error("Empty quotas should not limit anything")
+	}
+
+	for _, spec := range []string{"acme", "acme=-1", "acme=many"} {
+		if _, err := ParseQuotas(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestLimit_RefillAndResult(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 4}
+	if got := limit.refill(0, time.Second); got != 2 {
+		t.Errorf("Expected 2 tokens after a second, got %v", got)
+	}
+	if got := limit.refill(3, time.Hour); got != 4 {
+		t.Errorf("Tokens should not exceed burst, got %v", got)
+	}
+	if got := limit.refill(1, -time.Second); got != 1 {
+		t.Errorf("Clock skew should not remove tokens, got %v", got)
+	}
+	if limit.fillTime() != 2*time.Second {
+		t.Errorf("Unexpected fill time %v", limit.fillTime())
+	}
+
+	r := limit.result(0.5, false)
+	if r.Allowed || r.Remaining != 0 || r.RetryAfter != 250*time.Millisecond {
+		t.Errorf("Unexpected result %+v", r)
+	}
+}
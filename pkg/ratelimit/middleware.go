@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-service/pkg/auth"
+	"event-service/pkg/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// problemRateLimited — ответ при превышении лимита запросов
+var problemRateLimited = problem.New(http.StatusTooManyRequests, "rate_limited")
+
+// Middleware ограничивает частоту запросов каждого клиента на каждом маршруте
+// Клиент определяется по API-ключу, subject токена или, без аутентификации, по IP-адресу
+// Лимит берётся из rules по методу и шаблону пути; при превышении — 429 с Retry-After
+// Если хранилище недоступно, запрос пропускается: лимиты не должны ронять сервис
+func Middleware(store Store, rules Rules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, ok := rules.For(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		// У каждого маршрута своё ведро, даже если лимит взят из правила по умолчанию
+		key := Key(c.Request.Method+" "+c.FullPath(), clientKey(c))
+		result, err := store.Take(c.Request.Context(), key, limit, time.Now())
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Не удалось проверить лимит запросов", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			tooManyRequests(c, result.RetryAfter, problemRateLimited)
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientKey определяет, чей это запрос
+func clientKey(c *gin.Context) string {
+	return Client(c.Request.Context(), c.ClientIP())
+}
+
+// Client возвращает ключ клиента для лимитов: API-ключ, subject токена или, без аутентификации, адрес ip
+// Ключ одинаков во всех API, поэтому клиент расходует одно и то же ведро маршрута и в HTTP, и в gRPC
+func Client(ctx context.Context, ip string) string {
+	if p := auth.FromContext(ctx); p != nil {
+		if p.KeyID != "" {
+			return "key:" + p.KeyID
+		}
+		return "sub:" + p.Tenant + "/" + p.Subject
+	}
+	return "ip:" + ip
+}
+
+// Key возвращает ключ ведра клиента client на маршруте route ("POST /v1/start")
+func Key(route, client string) string {
+	return "rate:" + route + "|" + client
+}
+
+// tooManyRequests отвечает 429 с заголовком Retry-After
+func tooManyRequests(c *gin.Context, retryAfter time.Duration, p problem.Problem) {
+	problem.RetryAfter(c, retryAfter)
+	problem.Abort(c, p)
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"event-service/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failingStore имитирует недоступное хранилище лимитов
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit, time.Time) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func (failingStore) Add(context.Context, string, int64, time.Time) (int64, error) {
+	return 0, errors.New("store is down")
+}
+
+// setupLimitedRouter создаёт роутер, где заголовок X-Test-Key задаёт API-ключ клиента
+func setupLimitedRouter(store Store, rules Rules) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: "key:" + key, KeyID: key})
+		}
+		c.Request = c.Request.WithContext(ctx)
+	})
+	router.Use(Middleware(store, rules))
+	router.GET("/v1", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/v1/start", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func doLimitedRequest(router *gin.Engine, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	rules := Rules{"GET /v1": {Rate: 1.0 / 60, Burst: 2}}
+	router := setupLimitedRouter(NewMemoryStore(), rules)
+
+	for i := 0; i < 2; i++ {
+		w := doLimitedRequest(router, http.MethodGet, "/v1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(1-i) {
+			t.Errorf("Request %d: unexpected headers %v", i+1, w.Header())
+		}
+	}
+
+	w := doLimitedRequest(router, http.MethodGet, "/v1", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", w.Code)
+	}
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry < 59 || retry > 60 {
+		t.Errorf("Expected Retry-After about 60s, got %q", w.Header().Get("Retry-After"))
+	}
+
+	// У клиента с API-ключом своё ведро
+	if w := doLimitedRequest(router, http.MethodGet, "/v1", map[string]string{"X-Test-Key": "k1"}); w.Code != http.StatusOK {
+		t.Errorf("Key client should not share the IP bucket, got %d", w.Code)
+	}
+
+	// Маршрут без правила не ограничен
+	for i := 0; i < 5; i++ {
+		if w := doLimitedRequest(router, http.MethodPost, "/v1/start", nil); w.Code != http.StatusOK {
+			t.Fatalf("Unlimited route returned %d", w.Code)
+		}
+	}
+}
+
+func TestMiddleware_PerRoute(t *testing.T) {
+	rules := Rules{DefaultRoute: {Rate: 1.0 / 60, Burst: 1}}
+	router := setupLimitedRouter(NewMemoryStore(), rules)
+
+	// Правило по умолчанию действует на каждый маршрут отдельно
+	if w := doLimitedRequest(router, http.MethodGet, "/v1", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if w := doLimitedRequest(router, http.MethodPost, "/v1/start", nil); w.Code != http.StatusOK {
+		t.Fatalf("Other route should have its own bucket, got %d", w.Code)
+	}
+	if w := doLimitedRequest(router, http.MethodGet, "/v1", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_StoreUnavailable(t *testing.T) {
+	router := setupLimitedRouter(failingStore{}, Rules{DefaultRoute: {Rate: 1, Burst: 1}})
+
+	for i := 0; i < 3; i++ {
+		if w := doLimitedRequest(router, http.MethodPost, "/v1/start", nil); w.Code != http.StatusOK {
+			t.Fatalf("Requests should pass when the store is down, got %d", w.Code)
+		}
+	}
+}
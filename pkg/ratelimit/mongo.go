@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore хранит лимиты в коллекции MongoDB, общей для всех реплик сервиса
+// Каждое ведро и счётчик — отдельный документ с _id = ключ; обновления атомарны,
+// поэтому реплики не могут вместе превысить лимит
+// Устаревшие документы удаляет TTL-индекс по полю expires_at
+type MongoStore struct {
+	// collection — коллекция с вёдрами и счётчиками
+	collection *mongo.Collection
+}
+
+// NewMongoStore создаёт хранилище лимитов в коллекции col
+func NewMongoStore(col *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: col}
+}
+
+// EnsureIndexes создаёт TTL-индекс, удаляющий устаревшие вёдра и счётчики
+// Вызывается один раз при старте приложения
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Take реализует Store
+// Пополнение и списание токена выполняются одним конвейером обновления на сервере MongoDB
+func (s *MongoStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	burst := float64(limit.Burst)
+	elapsedSeconds := bson.M{"$divide": bson.A{
+		bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}}}},
+		1000,
+	}}
+	pipeline := mongo.Pipeline{
+		// Пополняем ведро за прошедшее время (новое ведро — полное)
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$tokens", burst}},
+				bson.M{"$multiply": bson.A{elapsedSeconds, limit.Rate}},
+			}}}},
+			"updated_at": now,
+			"expires_at": now.Add(limit.fillTime()),
+		}}},
+		// Берём токен, если он есть; выражения стадии видят tokens до списания
+		{{Key: "$set", Value: bson.M{
+			"allowed": bson.M{"$gte": bson.A{"$tokens", 1}},
+			"tokens": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$tokens", 1}},
+				bson.M{"$subtract": bson.A{"$tokens", 1}},
+				"$tokens",
+			}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&doc)
+	if mongo.IsDuplicateKeyError(err) {
+		// Две реплики одновременно создали ведро — повторяем, теперь документ уже есть
+		err = s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&doc)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return limit.result(doc.Tokens, doc.Allowed), nil
+}
+
+// Add реализует Store
+func (s *MongoStore) Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error) {
+	update := bson.M{
+		"$inc":         bson.M{"count": delta},
+		"$setOnInsert": bson.M{"expires_at": expiresAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc struct {
+		Count int64 `bson:"count"`
+	}
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&doc)
+	if mongo.IsDuplicateKeyError(err) {
+		err = s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&doc)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return doc.Count, nil
+}
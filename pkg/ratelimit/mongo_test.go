@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"event-service/internal/db"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setupTestMongoStore создаёт хранилище лимитов на встроенном MongoDB
+func setupTestMongoStore(t *testing.T) (*MongoStore, func()) {
+	t.Helper()
+
+	mongoURI, cleanupMongo, err := db.StartEmbeddedMongo()
+	if err != nil {
+		t.Fatalf("Не удалось запустить встроенный MongoDB: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		cleanupMongo()
+		t.Fatalf("Не удалось подключиться к MongoDB: %v", err)
+	}
+
+	collection := client.Database("events_test_db").Collection("rate_limits")
+	collection.Drop(ctx)
+
+	store := NewMongoStore(collection)
+	if err := store.EnsureIndexes(ctx); err != nil {
+		client.Disconnect(ctx)
+		cleanupMongo()
+		t.Fatalf("Не удалось создать индексы: %v", err)
+	}
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client.Disconnect(ctx)
+		cleanupMongo()
+	}
+	return store, cleanup
+}
+
+func TestMongoStore_Take(t *testing.T) {
+	store, cleanup := setupTestMongoStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 2}
+	now := time.Now().Truncate(time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		r, err := store.Take(ctx, "a", limit, now)
+		if err != nil {
+			t.Fatalf("Take failed: %v", err)
+		}
+		if !r.Allowed || r.Remaining != 1-i {
+			t.Fatalf("Request %d: unexpected result %+v", i+1, r)
+		}
+	}
+	r, _ := store.Take(ctx, "a", limit, now)
+	if r.Allowed || r.RetryAfter != time.Second {
+		t.Errorf("Third request should be denied with 1s retry, got %+v", r)
+	}
+	if r, _ := store.Take(ctx, "a", limit, now.Add(time.Second)); !r.Allowed {
+		t.Errorf("Token should be refilled after a second, got %+v", r)
+	}
+}
+
+func TestMongoStore_TakeConcurrent(t *testing.T) {
+	store, cleanup := setupTestMongoStore(t)
+	defer cleanup()
+
+	// Параллельные запросы (как из разных реплик) не превышают вместимость ведра
+	limit := Limit{Rate: 1.0 / 3600, Burst: 5}
+	now := time.Now()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := store.Take(context.Background(), "shared", limit, now)
+			if err != nil {
+				t.Errorf("Take failed: %v", err)
+				return
+			}
+			if r.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 5 {
+		t.Errorf("Expected exactly 5 allowed requests, got %d", allowed)
+	}
+}
+
+func TestMongoStore_Add(t *testing.T) {
+	store, cleanup := setupTestMongoStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+	for want := int64(1); want <= 3; want++ {
+		got, err := store.Add(ctx, "q", 1, expiresAt)
+		if err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("Expected %d, got %d", want, got)
+		}
+	}
+	if got, _ := store.Add(ctx, "q", -1, expiresAt); got != 2 {
+		t.Errorf("Expected 2 after refund, got %d", got)
+	}
+}
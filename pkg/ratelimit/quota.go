@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"event-service/pkg/tenant"
+)
+
+// ErrQuotaExceeded возвращается, если суточная квота арендатора на создание событий исчерпана
+var ErrQuotaExceeded = errors.New("суточная квота арендатора на создание событий исчерпана")
+
+// QuotaError — исчерпанная квота арендатора; errors.Is(err, ErrQuotaExceeded) для неё верно
+type QuotaError struct {
+	Tenant string
+	Limit  int64
+	// ResetAt — когда квота сбросится (полночь UTC)
+	ResetAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: арендатор %s, не больше %d в сутки", ErrQuotaExceeded, e.Tenant, e.Limit)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// RetryAfter возвращает, через сколько квота сбросится
+func (e *QuotaError) RetryAfter() time.Duration {
+	return time.Until(e.ResetAt)
+}
+
+// Quota ограничивает число событий, создаваемых арендатором за сутки (UTC)
+// Квоту расходует сервис событий в тот момент, когда событие действительно создаётся,
+// поэтому она одинаково действует в HTTP, gRPC и GraphQL API, а запуск, вернувший уже активное событие,
+// её не тратит
+type Quota struct {
+	store  Store
+	quotas Quotas
+}
+
+// NewQuota создаёт квоты quotas с состоянием в store
+func NewQuota(store Store, quotas Quotas) *Quota {
+	return &Quota{store: store, quotas: quotas}
+}
+
+// Take расходует n единиц квоты арендатора из контекста
+// Если квоты не хватает, ничего не расходуется и возвращается *QuotaError
+// refund возвращает взятое, если события так и не были созданы
+// Если хранилище недоступно, квота не проверяется: лимиты не должны ронять сервис
+func (q *Quota) Take(ctx context.Context, n int64) (refund func(), err error) {
+	tenantID := tenant.ID(ctx)
+	quota := q.quotas.For(tenantID)
+	if quota == 0 {
+		return func() {}, nil
+	}
+
+	now := time.Now().UTC()
+	reset := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	key := "quota:" + tenantID + ":" + now.Format(time.DateOnly)
+	// Счётчик живёт чуть дольше суток, чтобы запоздавший возврат не создал его заново
+	expiresAt := reset.Add(time.Hour)
+
+	used, err := q.store.Add(ctx, key, n, expiresAt)
+	if err != nil {
+		slog.WarnContext(ctx, "Не удалось проверить квоту арендатора", "tenant", tenantID, "error", err)
+		return func() {}, nil
+	}
+	refund = func() {
+		if _, err := q.store.Add(context.WithoutCancel(ctx), key, -n, expiresAt); err != nil {
+			slog.WarnContext(ctx, "Не удалось вернуть квоту арендатора", "tenant", tenantID, "error", err)
+		}
+	}
+	if used > quota {
+		refund()
+		return nil, &QuotaError{Tenant: tenantID, Limit: quota, ResetAt: reset}
+	}
+	return refund, nil
+}
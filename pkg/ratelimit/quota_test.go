@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-service/pkg/tenant"
+)
+
+func TestQuota(t *testing.T) {
+	quota := NewQuota(NewMemoryStore(), Quotas{DefaultRoute: 2, "internal": 0})
+	acme := tenant.WithTenant(context.Background(), "acme")
+
+	// Возвращённая квота снова доступна
+	refund, err := quota.Take(acme, 1)
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	refund()
+	for i := 0; i < 2; i++ {
+		if _, err := quota.Take(acme, 1); err != nil {
+			t.Fatalf("Take %d failed: %v", i+1, err)
+		}
+	}
+
+	_, err = quota.Take(acme, 1)
+	var exceeded *QuotaError
+	if !errors.As(err, &exceeded) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected QuotaError, got %v", err)
+	}
+	// Квота сбрасывается в полночь UTC
+	if reset := exceeded.ResetAt; exceeded.Tenant != "acme" || exceeded.Limit != 2 ||
+		reset.Hour() != 0 || reset.Minute() != 0 || exceeded.RetryAfter() <= 0 || exceeded.RetryAfter() > 24*time.Hour {
+		t.Errorf("Unexpected error %+v", exceeded)
+	}
+	// Отказ квоту не расходует, но и не освобождает
+	if _, err := quota.Take(acme, 1); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected the quota to stay exhausted, got %v", err)
+	}
+
+	// Квота своя у каждого арендатора; 0 — без ограничения
+	if _, err := quota.Take(tenant.WithTenant(context.Background(), "globex"), 2); err != nil {
+		t.Errorf("Other tenant should have its own quota, got %v", err)
+	}
+	internal := tenant.WithTenant(context.Background(), "internal")
+	for i := 0; i < 5; i++ {
+		if _, err := quota.Take(internal, 1); err != nil {
+			t.Fatalf("Unlimited tenant failed: %v", err)
+		}
+	}
+}
+
+func TestQuota_StoreUnavailable(t *testing.T) {
+	quota := NewQuota(failingStore{}, Quotas{DefaultRoute: 1})
+	for i := 0; i < 3; i++ {
+		refund, err := quota.Take(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("Quota should not apply when the store is down, got %v", err)
+		}
+		refund()
+	}
+}
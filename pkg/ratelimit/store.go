@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store хранит состояние лимитов: вёдра токенов и счётчики квот
+// MemoryStore подходит для одного экземпляра сервиса, MongoStore — для нескольких реплик
+type Store interface {
+	// Take пытается взять один токен из ведра key с параметрами limit в момент now
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Add прибавляет delta к счётчику key и возвращает новое значение
+	// Счётчик создаётся с нуля и удаляется после expiresAt
+	Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error)
+}
+
+// sweepInterval — как часто MemoryStore удаляет устаревшие вёдра и счётчики
+const sweepInterval = time.Minute
+
+// MemoryStore хранит лимиты в памяти процесса
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+// bucket — ведро токенов одного клиента на одном маршруте
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// expiresAt — когда ведро гарантированно наполнится и его можно забыть
+	expiresAt time.Time
+}
+
+// counter — счётчик квоты
+type counter struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// NewMemoryStore создаёт хранилище лимитов в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, counters: map[string]*counter{}}
+}
+
+// Take реализует Store
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.tokens = limit.refill(b.tokens, now.Sub(b.updatedAt))
+	b.updatedAt = now
+	b.expiresAt = now.Add(limit.fillTime())
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return limit.result(b.tokens, allowed), nil
+}
+
+// Add реализует Store
+func (s *MemoryStore) Add(_ context.Context, key string, delta int64, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expiresAt) {
+		c = &counter{expiresAt: expiresAt}
+		s.counters[key] = c
+	}
+	c.value += delta
+	return c.value, nil
+}
+
+// sweep удаляет наполнившиеся вёдра и истёкшие счётчики; вызывается под s.mu
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.After(b.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, c := range s.counters {
+		if !now.Before(c.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
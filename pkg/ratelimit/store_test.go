@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 2}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if r, _ := store.Take(ctx, "a", limit, now); !r.Allowed {
+			t.Fatalf("Request %d should be allowed", i+1)
+		}
+	}
+	r, _ := store.Take(ctx, "a", limit, now)
+	if r.Allowed || r.RetryAfter != time.Second {
+		t.Errorf("Third request should be denied with 1s retry, got %+v", r)
+	}
+
+	// Другое ведро не затронуто
+	if r, _ := store.Take(ctx, "b", limit, now); !r.Allowed {
+		t.Error("Separate key should have its own bucket")
+	}
+
+	// Через секунду в ведре появляется токен
+	if r, _ := store.Take(ctx, "a", limit, now.Add(time.Second)); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("Token should be refilled after a second, got %+v", r)
+	}
+}
+
+func TestMemoryStore_AddAndSweep(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Hour)
+	for want := int64(1); want <= 3; want++ {
+		if got, _ := store.Add(ctx, "q", 1, expiresAt); got != want {
+			t.Errorf("Expected %d, got %d", want, got)
+		}
+	}
+	if got, _ := store.Add(ctx, "q", -1, expiresAt); got != 2 {
+		t.Errorf("Expected 2 after refund, got %d", got)
+	}
+
+	// Истёкший счётчик начинается с нуля
+	if got, _ := store.Add(ctx, "old", 5, time.Now().Add(-time.Second)); got != 5 {
+		t.Errorf("Expected 5, got %d", got)
+	}
+	if got, _ := store.Add(ctx, "old", 1, time.Now().Add(time.Hour)); got != 1 {
+		t.Errorf("Expired counter should restart, got %d", got)
+	}
+
+	// Наполнившиеся вёдра удаляются при очистке
+	store.Take(ctx, "bucket", Limit{Rate: 10, Burst: 1}, time.Now())
+	store.lastSweep = time.Time{}
+	store.sweep(time.Now().Add(time.Minute))
+	if _, ok := store.buckets["bucket"]; ok {
+		t.Error("Full bucket should be swept")
+	}
+}
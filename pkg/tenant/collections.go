@@ -0,0 +1,186 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Placement — способ размещения данных арендаторов в MongoDB
+type Placement string
+
+const (
+	// PlacementShared — все арендаторы в одной коллекции, различаются полем tenant_id
+	PlacementShared Placement = "shared"
+	// PlacementCollection — у каждого арендатора своя коллекция в общей базе
+	PlacementCollection Placement = "collection"
+	// PlacementDatabase — у каждого арендатора своя база данных
+	PlacementDatabase Placement = "database"
+)
+
+// ParsePlacement превращает строку из настроек в Placement
+// Пустая строка означает PlacementShared
+func ParsePlacement(s string) (Placement, error) {
+	switch Placement(s) {
+	case "", PlacementShared:
+		return PlacementShared, nil
+	case PlacementCollection, PlacementDatabase:
+		return Placement(s), nil
+	default:
+		return "", fmt.Errorf("неизвестный способ размещения арендаторов: %q (ожидается shared, collection или database)", s)
+	}
+}
+
+// Collections выбирает коллекцию MongoDB для арендатора из контекста
+// Поле tenant_id в документах используется при любом размещении — отдельные
+// коллекции и базы лишь добавляют ещё один уровень изоляции
+type Collections struct {
+	client     *mongo.Client
+	database   string
+	collection string
+	placement  Placement
+
+	// shared — готовая коллекция для PlacementShared
+	shared *mongo.Collection
+
+	// reserved — имена коллекций других назначений в той же базе (см. Reserve)
+	reserved []string
+}
+
+// NewCollections создаёт выбор коллекций для указанного размещения
+// database и collection — базовые имена; данные арендатора Default всегда лежат
+// именно в них, поэтому данные, сохранённые до мультиарендности, остаются на месте
+func NewCollections(client *mongo.Client, database, collection string, placement Placement) *Collections {
+	return &Collections{
+		client:     client,
+		database:   database,
+		collection: collection,
+		placement:  placement,
+		shared:     client.Database(database).Collection(collection),
+	}
+}
+
+// Shared возвращает выбор коллекций, в котором все арендаторы делят коллекцию col
+func Shared(col *mongo.Collection) *Collections {
+	return &Collections{
+		database:   col.Database().Name(),
+		collection: col.Name(),
+		placement:  PlacementShared,
+		shared:     col,
+	}
+}
+
+// Reserve запоминает имена коллекций других назначений в той же базе и возвращает c
+// При размещении collection коллекция вида <collection>_<суффикс> может оказаться не коллекцией
+// арендатора, а, например, архивом events_archive рядом с events. Tenants пропускает такие имена
+// и коллекции их арендаторов (events_archive_acme)
+func (c *Collections) Reserve(names ...string) *Collections {
+	for _, name := range names {
+		if name != c.collection {
+			c.reserved = append(c.reserved, name)
+		}
+	}
+	return c
+}
+
+// Placement возвращает способ размещения данных арендаторов
+func (c *Collections) Placement() Placement {
+	return c.placement
+}
+
+// For возвращает коллекцию и идентификатор арендатора из контекста
+func (c *Collections) For(ctx context.Context) (*mongo.Collection, string, error) {
+	id := ID(ctx)
+	if err := Validate(id); err != nil {
+		return nil, "", err
+	}
+	if id == Default || c.placement == PlacementShared {
+		return c.shared, id, nil
+	}
+
+	switch c.placement {
+	case PlacementCollection:
+		return c.client.Database(c.database).Collection(c.collection + "_" + id), id, nil
+	case PlacementDatabase:
+		return c.client.Database(c.database + "_" + id).Collection(c.collection), id, nil
+	default:
+		return nil, "", fmt.Errorf("неизвестный способ размещения арендаторов: %q", c.placement)
+	}
+}
+
+// All возвращает коллекции всех арендаторов, которые уже есть в базе, начиная с общей
+// Нужен для сводной статистики по всем арендаторам (например, метрик); обычные запросы используют For
+func (c *Collections) All(ctx context.Context) ([]*mongo.Collection, error) {
+	ids, err := c.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]*mongo.Collection, 0, len(ids))
+	for _, id := range ids {
+		col, _, err := c.For(WithTenant(ctx, id))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, col)
+	}
+	return all, nil
+}
+
+// Tenants возвращает арендаторов, чьи коллекции возвращает All, в том же порядке: первым — Default
+// с общей коллекцией, за ним — арендаторы, у которых уже есть отдельная коллекция или база
+// При размещении shared все арендаторы живут в общей коллекции, и список состоит из одного Default
+func (c *Collections) Tenants(ctx context.Context) ([]string, error) {
+	ids := []string{Default}
+	if c.client == nil || c.placement == PlacementShared {
+		return ids, nil
+	}
+
+	var names []string
+	var prefix string
+	var err error
+	switch c.placement {
+	case PlacementCollection:
+		prefix = c.collection + "_"
+		names, err = c.client.Database(c.database).ListCollectionNames(ctx, bson.M{"name": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}})
+	case PlacementDatabase:
+		prefix = c.database + "_"
+		names, err = c.client.ListDatabaseNames(ctx, bson.M{"name": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}})
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tenantNames(names, prefix) {
+		if c.placement == PlacementCollection && c.isReserved(name) {
+			continue
+		}
+		ids = append(ids, strings.TrimPrefix(name, prefix))
+	}
+	return ids, nil
+}
+
+// isReserved сообщает, принадлежит ли коллекция name другому назначению или его арендатору
+func (c *Collections) isReserved(name string) bool {
+	for _, reserved := range c.reserved {
+		if name == reserved || strings.HasPrefix(name, reserved+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantNames оставляет только имена вида prefix+<арендатор>, отбрасывая коллекции и базы
+// с похожим началом, окончание которых не может быть идентификатором арендатора
+func tenantNames(names []string, prefix string) []string {
+	var result []string
+	for _, name := range names {
+		id := strings.TrimPrefix(name, prefix)
+		if id != Default && Validate(id) == nil {
+			result = append(result, name)
+		}
+	}
+	return result
+}
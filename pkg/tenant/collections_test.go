@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newTestClient создаёт клиента MongoDB без подключения к серверу
+// Для выбора коллекций сервер не нужен: драйвер подключается лениво
+func newTestClient(t *testing.T) *mongo.Client {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:1"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	return client
+}
+
+func TestCollections_For(t *testing.T) {
+	client := newTestClient(t)
+	acme := WithTenant(context.Background(), "acme")
+
+	tests := []struct {
+		placement    Placement
+		ctx          context.Context
+		wantDatabase string
+		wantName     string
+	}{
+		{PlacementShared, acme, "events_db", "events"},
+		{PlacementCollection, acme, "events_db", "events_acme"},
+		{PlacementDatabase, acme, "events_db_acme", "events"},
+		// Арендатор по умолчанию всегда живёт в базовых коллекциях
+		{PlacementCollection, context.Background(), "events_db", "events"},
+		{PlacementDatabase, context.Background(), "events_db", "events"},
+	}
+
+	for _, tt := range tests {
+		collections := NewCollections(client, "events_db", "events", tt.placement)
+		col, id, err := collections.For(tt.ctx)
+		if err != nil {
+			t.Fatalf("For failed: %v", err)
+		}
+		if col.Database().Name() != tt.wantDatabase || col.Name() != tt.wantName {
+			t.Errorf("%s/%s: got %s.%s, expected %s.%s", tt.placement, id,
+				col.Database().Name(), col.Name(), tt.wantDatabase, tt.wantName)
+		}
+	}
+}
+
+func TestCollections_For_InvalidTenant(t *testing.T) {
+	collections := NewCollections(newTestClient(t), "events_db", "events", PlacementDatabase)
+
+	// Идентификатор из контекста попадает в имя базы, поэтому проверяется ещё раз
+	if _, _, err := collections.For(WithTenant(context.Background(), "../admin")); err == nil {
+		t.Error("Expected error for invalid tenant id")
+	}
+}
+
+func TestShared(t *testing.T) {
+	col := newTestClient(t).Database("events_db").Collection("events")
+	collections := Shared(col)
+
+	got, id, err := collections.For(WithTenant(context.Background(), "acme"))
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+	if got != col || id != "acme" || collections.Placement() != PlacementShared {
+		t.Errorf("Shared collections should always return the given collection")
+	}
+}
+
+func TestTenantNames(t *testing.T) {
+	names := tenantNames([]string{"events_acme", "events_default", "events_Bad Name", "events_globex"}, "events_")
+	if len(names) != 2 || names[0] != "events_acme" || names[1] != "events_globex" {
+		t.Errorf("Unexpected tenant names: %v", names)
+	}
+}
+
+func TestShared_All(t *testing.T) {
+	col := newTestClient(t).Database("events_db").Collection("events")
+	all, err := Shared(col).All(context.Background())
+	if err != nil || len(all) != 1 || all[0] != col {
+		t.Errorf("Shared collections should contain only the shared collection: %v %v", all, err)
+	}
+}
+
+func TestShared_Tenants(t *testing.T) {
+	col := newTestClient(t).Database("events_db").Collection("events")
+	ids, err := Shared(col).Tenants(context.Background())
+	if err != nil || len(ids) != 1 || ids[0] != Default {
+		t.Errorf("Shared collections should belong to the default tenant: %v %v", ids, err)
+	}
+}
+
+func TestCollections_Reserve(t *testing.T) {
+	collections := NewCollections(newTestClient(t), "events_db", "events", PlacementCollection).
+		Reserve("events", "events_archive", "audit_log")
+
+	for name, want := range map[string]bool{
+		"events_acme":         false,
+		"events_archive":      true,
+		"events_archive_acme": true,
+		"events_archived":     false,
+		"events":              false,
+	} {
+		if got := collections.isReserved(name); got != want {
+			t.Errorf("isReserved(%q) = %v, expected %v", name, got, want)
+		}
+	}
+}
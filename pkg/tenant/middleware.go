@@ -0,0 +1,78 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"event-service/pkg/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultHeader — заголовок, из которого по умолчанию берётся арендатор
+const DefaultHeader = "X-Tenant-ID"
+
+var (
+	// ErrForbidden — запрошен арендатор, отличный от арендатора учётных данных
+	ErrForbidden = errors.New("нет доступа к данным этого арендатора")
+	// ErrRequired — арендатор не указан, а без него запросы не принимаются (tenants.required)
+	ErrRequired = errors.New("арендатор не указан")
+)
+
+// Ответы об ошибках определения арендатора
+var (
+	problemForbidden = problem.New(http.StatusForbidden, "tenant_forbidden")
+	problemRequired  = problem.New(http.StatusBadRequest, "tenant_required")
+	problemInvalid   = problem.New(http.StatusBadRequest, "invalid_tenant")
+)
+
+// Middleware определяет арендатора запроса и кладёт его в контекст запроса
+//
+// Если арендатор уже привязан к контексту учётными данными (например, claim API-ключа),
+// заголовок может только совпадать с ним — иначе 403. Без учётных данных арендатор
+// берётся из заголовка header. Если заголовка нет, required=true отклоняет запрос
+// с 400, а required=false относит его к арендатору Default
+func Middleware(header string, required bool) gin.HandlerFunc {
+	if header == "" {
+		header = DefaultHeader
+	}
+	return func(c *gin.Context) {
+		ctx, err := Resolve(c.Request.Context(), c.GetHeader(header), required)
+		switch {
+		case errors.Is(err, ErrForbidden):
+			problem.Abort(c, problemForbidden)
+		case errors.Is(err, ErrRequired):
+			problem.Abort(c, problemRequired.WithDetailKey("header.required", header))
+		case err != nil:
+			problem.Abort(c, problemInvalid.WithDetail(err.Error()))
+		default:
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+		}
+	}
+}
+
+// Resolve определяет арендатора по запрошенному клиентом requested так же, как Middleware,
+// и возвращает контекст с арендатором
+// Ошибки: ErrForbidden — requested не совпадает с арендатором учётных данных,
+// ErrRequired — арендатор не указан при required=true, иначе — ошибка Validate
+func Resolve(ctx context.Context, requested string, required bool) (context.Context, error) {
+	if bound, ok := FromContext(ctx); ok {
+		if requested != "" && requested != bound {
+			return nil, ErrForbidden
+		}
+		return ctx, nil
+	}
+
+	if requested == "" {
+		if required {
+			return nil, ErrRequired
+		}
+		requested = Default
+	}
+	if err := Validate(requested); err != nil {
+		return nil, err
+	}
+	return WithTenant(ctx, requested), nil
+}
@@ -0,0 +1,67 @@
+package tenant
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTenantRouter создаёт роутер, который возвращает арендатора из контекста запроса
+// bound — арендатор, уже привязанный учётными данными (пустая строка — не привязан)
+func setupTenantRouter(required bool, bound string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if bound != "" {
+		router.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(WithTenant(c.Request.Context(), bound))
+		})
+	}
+	router.Use(Middleware("", required))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, ID(c.Request.Context()))
+	})
+	return router
+}
+
+func doTenantRequest(router *gin.Engine, header string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(DefaultHeader, header)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		required   bool
+		bound      string
+		header     string
+		wantStatus int
+		wantTenant string
+	}{
+		{"header", false, "", "acme", http.StatusOK, "acme"},
+		{"no header, optional", false, "", "", http.StatusOK, Default},
+		{"no header, required", true, "", "", http.StatusBadRequest, ""},
+		{"invalid header", false, "", "Acme Corp", http.StatusBadRequest, ""},
+		{"bound by credentials", true, "acme", "", http.StatusOK, "acme"},
+		{"bound and matching header", true, "acme", "acme", http.StatusOK, "acme"},
+		{"bound and other tenant in header", true, "acme", "globex", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doTenantRequest(setupTenantRouter(tt.required, tt.bound), tt.header)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != tt.wantTenant {
+				t.Errorf("Expected tenant %s, got %s", tt.wantTenant, w.Body.String())
+			}
+		})
+	}
+}
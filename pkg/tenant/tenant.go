@@ -0,0 +1,50 @@
+// Package tenant отвечает за изоляцию данных разных клиентов (арендаторов) сервиса
+// Арендатор определяется для каждого запроса и передаётся через context.Context,
+// а репозитории по нему выбирают коллекцию и фильтруют документы
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Default — арендатор, к которому относятся запросы без явного арендатора
+// и все данные, сохранённые до появления мультиарендности
+const Default = "default"
+
+// idPattern ограничивает идентификатор арендатора символами,
+// допустимыми в именах коллекций и баз данных MongoDB
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,47}$`)
+
+// Validate проверяет идентификатор арендатора
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("идентификатор арендатора %q должен состоять из строчных букв, цифр, '_' и '-' (до 48 символов)", id)
+	}
+	return nil
+}
+
+// contextKey — ключ для хранения арендатора в context.Context
+type contextKey struct{}
+
+// WithTenant возвращает контекст, привязанный к арендатору id
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext возвращает арендатора из контекста
+// Второе значение показывает, был ли арендатор задан явно
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	if !ok || id == "" {
+		return Default, false
+	}
+	return id, true
+}
+
+// ID возвращает арендатора из контекста или Default, если он не задан
+func ID(ctx context.Context) string {
+	id, _ := FromContext(ctx)
+	return id
+}
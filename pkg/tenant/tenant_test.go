@@ -0,0 +1,58 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []string{"default", "acme", "acme-corp", "team_42", "0"}
+	for _, id := range valid {
+		if err := Validate(id); err != nil {
+			t.Errorf("Validate(%q) unexpected error: %v", id, err)
+		}
+	}
+
+	invalid := []string{"", "Acme", "-acme", "acme.corp", "acme/corp", "acme corp", "$acme", strings.Repeat("a", 49)}
+	for _, id := range invalid {
+		if err := Validate(id); err == nil {
+			t.Errorf("Validate(%q) expected error", id)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	id, ok := FromContext(context.Background())
+	if id != Default || ok {
+		t.Errorf("Expected (%s, false) for empty context, got (%s, %v)", Default, id, ok)
+	}
+
+	ctx := WithTenant(context.Background(), "acme")
+	id, ok = FromContext(ctx)
+	if id != "acme" || !ok {
+		t.Errorf("Expected (acme, true), got (%s, %v)", id, ok)
+	}
+	if ID(ctx) != "acme" {
+		t.Errorf("ID() = %s, expected acme", ID(ctx))
+	}
+}
+
+func TestParsePlacement(t *testing.T) {
+	tests := map[string]Placement{
+		"":           PlacementShared,
+		"shared":     PlacementShared,
+		"collection": PlacementCollection,
+		"database":   PlacementDatabase,
+	}
+	for input, expected := range tests {
+		placement, err := ParsePlacement(input)
+		if err != nil || placement != expected {
+			t.Errorf("ParsePlacement(%q) = %q, %v; expected %q", input, placement, err, expected)
+		}
+	}
+
+	if _, err := ParsePlacement("cluster"); err == nil {
+		t.Error("Expected error for unknown placement")
+	}
+}
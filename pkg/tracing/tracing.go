@@ -0,0 +1,117 @@
+// Package tracing настраивает трассировку OpenTelemetry
+//
+// Спаны создаются в коде через глобальный TracerProvider (otel.Tracer), поэтому пакеты с бизнес-логикой
+// не зависят от того, куда и как спаны экспортируются. Setup выбирает экспорт: none — спаны не записываются,
+// stdout — печатаются в консоль (для отладки), otlp — отправляются в коллектор по OTLP/HTTP.
+// Контекст трассировки принимается и передаётся дальше в заголовках W3C (traceparent, tracestate, baggage)
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Способы экспорта спанов
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ValidateExporter проверяет название способа экспорта
+func ValidateExporter(name string) error {
+	switch name {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+		return nil
+	default:
+		return fmt.Errorf("неизвестный экспорт спанов %q (ожидается none, stdout или otlp)", name)
+	}
+}
+
+// Options — настройки трассировки
+type Options struct {
+	// Exporter — none, stdout или otlp
+	Exporter string
+	// Endpoint — адрес OTLP/HTTP-коллектора (host:port)
+	Endpoint string
+	// Insecure — подключаться к коллектору без TLS
+	Insecure bool
+	// ServiceName — имя сервиса в спанах (service.name)
+	ServiceName string
+	// Output — куда печатать спаны при экспорте stdout (nil — os.Stdout)
+	Output io.Writer
+}
+
+// Setup настраивает глобальный TracerProvider и передачу контекста в заголовках W3C
+// Возвращает функцию, которая при остановке сервиса отправляет накопленные спаны
+// Даже с экспортом none контекст из входящих заголовков сохраняется и передаётся дальше
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		out := opts.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+	case ExporterOTLP:
+		clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opts.Endpoint)}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, clientOpts...)
+	default:
+		err = ValidateExporter(opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать экспорт спанов: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", opts.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось описать сервис для трассировки: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Решение о записи принимает вызывающий сервис; без него записываются все запросы
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Middleware начинает серверный спан на каждый HTTP-запрос с именем по шаблону маршрута
+// Если в запросе есть заголовок traceparent, спан продолжает трассировку вызывающего сервиса
+func Middleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName)
+}
+
+// MongoMonitor возвращает монитор команд драйвера MongoDB, который создаёт спан на каждую команду
+// (find, insert, update, aggregate...) внутри спана вызвавшей её операции
+func MongoMonitor() *event.CommandMonitor {
+	return otelmongo.NewMonitor()
+}
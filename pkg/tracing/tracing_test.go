@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// restoreGlobals возвращает глобальные TracerProvider и propagator после теста
+func restoreGlobals(t *testing.T) {
+	t.Helper()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestSetup_Exporters(t *testing.T) {
+	restoreGlobals(t)
+	ctx := context.Background()
+
+	shutdown, err := Setup(ctx, Options{Exporter: ExporterNone, ServiceName: "test"})
+	if err != nil || shutdown(ctx) != nil {
+		t.Errorf("Setup with none should succeed: %v", err)
+	}
+
+	if _, err := Setup(ctx, Options{Exporter: "jaeger", ServiceName: "test"}); err == nil {
+		t.Error("Expected error for unknown exporter")
+	}
+
+	// Спаны печатаются в stdout при остановке (отправляются пачками)
+	var out bytes.Buffer
+	shutdown, err = Setup(ctx, Options{Exporter: ExporterStdout, ServiceName: "event-service-test", Output: &out})
+	if err != nil {
+		t.Fatalf("Setup with stdout failed: %v", err)
+	}
+	_, span := otel.Tracer("test").Start(ctx, "operation")
+	span.End()
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"Name":"operation"`) || !strings.Contains(out.String(), "event-service-test") {
+		t.Errorf("Expected span with service name in output:\n%s", out.String())
+	}
+}
+
+func TestValidateExporter(t *testing.T) {
+	for _, name := range []string{ExporterNone, ExporterStdout, ExporterOTLP} {
+		if err := ValidateExporter(name); err != nil {
+			t.Errorf("ValidateExporter(%q) = %v", name, err)
+		}
+	}
+	if ValidateExporter("") == nil {
+		t.Error("Empty exporter should be invalid")
+	}
+}
+
+func TestMiddleware_PropagatesTraceContext(t *testing.T) {
+	restoreGlobals(t)
+	if _, err := Setup(context.Background(), Options{Exporter: ExporterNone}); err != nil {
+		t.Fatal(err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware("event-service"))
+	router.GET("/v1/events/:id/tree", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/events/42/tree", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Span should continue the incoming trace, got %s", span.SpanContext.TraceID())
+	}
+	if span.Parent.SpanID().String() != "00f067aa0ba902b7" || !span.Parent.IsRemote() {
+		t.Errorf("Span parent should be the remote caller, got %s", span.Parent.SpanID())
+	}
+	if span.Name != "/v1/events/:id/tree" {
+		t.Errorf("Span should be named after the route template, got %q", span.Name)
+	}
+}